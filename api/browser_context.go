@@ -29,10 +29,12 @@ type BrowserContext interface {
 	AddCookies(cookies goja.Value)
 	AddInitScript(script goja.Value, arg goja.Value)
 	Browser() Browser
+	ClearBearerToken()
 	ClearCookies()
+	ClearGeolocation()
 	ClearPermissions()
 	Close()
-	Cookies() []goja.Object
+	Cookies(urls ...string) []*Cookie
 	ExposeBinding(name string, callback goja.Callable, opts goja.Value)
 	ExposeFunction(name string, callback goja.Callable)
 	GrantPermissions(permissions []string, opts goja.Value)
@@ -40,6 +42,7 @@ type BrowserContext interface {
 	NewPage() Page
 	Pages() []Page
 	Route(url goja.Value, handler goja.Callable)
+	SetBearerToken(token string)
 	SetDefaultNavigationTimeout(timeout int64)
 	SetDefaultTimeout(timeout int64)
 	SetExtraHTTPHeaders(headers map[string]string)
@@ -52,7 +55,7 @@ type BrowserContext interface {
 	// - https://github.com/microsoft/playwright/pull/2763
 	SetHTTPCredentials(httpCredentials goja.Value)
 	SetOffline(offline bool)
-	StorageState(opts goja.Value)
+	StorageState(opts goja.Value) *StorageState
 	Unroute(url goja.Value, handler goja.Callable)
 	WaitForEvent(event string, optsOrPredicate goja.Value) interface{}
 }
@@ -0,0 +1,37 @@
+/*
+ *
+ * xk6-browser - a browser automation extension for k6
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package api
+
+// ConsoleMessage is the interface of a message logged to the browser console.
+type ConsoleMessage interface {
+	Args() []JSHandle
+	Location() *ConsoleMessageLocation
+	Text() string
+	Type() string
+}
+
+// ConsoleMessageLocation is the location in the page source
+// where a console message was logged.
+type ConsoleMessageLocation struct {
+	URL          string `js:"url"`
+	LineNumber   int64  `js:"lineNumber"`
+	ColumnNumber int64  `js:"columnNumber"`
+}
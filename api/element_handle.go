@@ -54,6 +54,7 @@ type ElementHandle interface {
 	SelectOption(values goja.Value, opts goja.Value) []string
 	SelectText(opts goja.Value)
 	SetInputFiles(files goja.Value, opts goja.Value)
+	ShadowRoot() ElementHandle
 	Tap(opts goja.Value)
 	TextContent() string
 	Type(text string, opts goja.Value)
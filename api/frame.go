@@ -6,19 +6,33 @@ import "github.com/dop251/goja"
 type Frame interface {
 	AddScriptTag(opts goja.Value)
 	AddStyleTag(opts goja.Value)
+	AllInnerTexts(selector string) []string
+	AllTextContents(selector string) []string
 	Check(selector string, opts goja.Value)
 	ChildFrames() []Frame
+	ClearHighlight()
 	Click(selector string, opts goja.Value)
 	Content() string
+	Count(selector string) int64
 	Dblclick(selector string, opts goja.Value)
+	DOMSnapshot() *DOMSnapshotNode
 	DispatchEvent(selector string, typ string, eventInit goja.Value, opts goja.Value)
+	EvalOnSelector(selector string, pageFunc goja.Value, args ...goja.Value) interface{}
+	EvalOnSelectorAll(selector string, pageFunc goja.Value, args ...goja.Value) interface{}
 	Evaluate(pageFunc goja.Value, args ...goja.Value) interface{}
 	EvaluateHandle(pageFunc goja.Value, args ...goja.Value) JSHandle
+	// EvaluateTop evaluates the page function against the top window
+	// of a same-origin frame.
+	EvaluateTop(pageFunc goja.Value, args ...goja.Value) interface{}
 	Fill(selector string, value string, opts goja.Value)
 	Focus(selector string, opts goja.Value)
 	FrameElement() ElementHandle
 	GetAttribute(selector string, name string, opts goja.Value) goja.Value
+	// GetByRole creates and returns a new locator for the elements with
+	// the ARIA role, and optionally the accessible name.
+	GetByRole(role string, opts goja.Value) Locator
 	Goto(url string, opts goja.Value) Response
+	Highlight(selector string)
 	Hover(selector string, opts goja.Value)
 	InnerHTML(selector string, opts goja.Value) string
 	InnerText(selector string, opts goja.Value) string
@@ -37,10 +51,14 @@ type Frame interface {
 	Name() string
 	Query(selector string) ElementHandle
 	QueryAll(selector string) []ElementHandle
+	QuerySettled(selector string) ElementHandle
 	Page() Page
 	ParentFrame() Frame
 	Press(selector string, key string, opts goja.Value)
+	Screenshot(opts goja.Value) goja.ArrayBuffer
+	ResourceTransferSize(selector string) int64
 	SelectOption(selector string, values goja.Value, opts goja.Value) []string
+	SelectText(selector string, opts goja.Value)
 	SetContent(html string, opts goja.Value)
 	SetInputFiles(selector string, files goja.Value, opts goja.Value)
 	Tap(selector string, opts goja.Value)
@@ -49,9 +67,15 @@ type Frame interface {
 	Type(selector string, text string, opts goja.Value)
 	Uncheck(selector string, opts goja.Value)
 	URL() string
+	WaitForAnySelector(selectors []string, opts goja.Value) *SelectorMatch
+	WaitForCount(selector string, count int64, opts goja.Value)
+	WaitForExecutionContext(opts goja.Value) *goja.Promise
 	WaitForFunction(pageFunc, opts goja.Value, args ...goja.Value) *goja.Promise
+	WaitForImages(selector string, opts goja.Value) *goja.Promise
 	WaitForLoadState(state string, opts goja.Value)
 	WaitForNavigation(opts goja.Value) Response
+	WaitForRequest(urlOrPredicate, opts goja.Value) *goja.Promise
+	WaitForResponse(urlOrPredicate, opts goja.Value) *goja.Promise
 	WaitForSelector(selector string, opts goja.Value) ElementHandle
 	WaitForTimeout(timeout int64)
 }
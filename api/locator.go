@@ -10,6 +10,15 @@ import "github.com/dop251/goja"
 
 // Locator represents a way to find element(s) on a page at any moment.
 type Locator interface {
+	// All returns a locator for each of the elements that match the locator's
+	// selector. Each returned locator re-resolves its element on every action.
+	All() []Locator
+	// First returns a locator to the first matching element.
+	First() Locator
+	// Last returns a locator to the last matching element.
+	Last() Locator
+	// Nth returns a locator to the n-th matching element. It's zero based.
+	Nth(index int) Locator
 	// Click on an element using locator's selector with strict mode on.
 	Click(opts goja.Value)
 	// Dblclick double clicks on an element using locator's selector with strict mode on.
@@ -52,7 +61,8 @@ type Locator interface {
 	// the locator's selector with strict mode on.
 	TextContent(opts goja.Value) string
 	// InputValue returns the element's input value that matches
-	// the locator's selector with strict mode on.
+	// the locator's selector with strict mode on. The values of a
+	// multi-select are joined with commas.
 	InputValue(opts goja.Value) string
 	// SelectOption, filters option values of the first element that matches
 	// the locator's selector (with strict mode on), selects the
@@ -34,6 +34,7 @@ type Page interface {
 	Content() string
 	Context() BrowserContext
 	Dblclick(selector string, opts goja.Value)
+	DOMSnapshot() *DOMSnapshotNode
 	DispatchEvent(selector string, typ string, eventInit goja.Value, opts goja.Value)
 	DragAndDrop(source string, target string, opts goja.Value)
 	EmulateMedia(opts goja.Value)
@@ -47,6 +48,9 @@ type Page interface {
 	Frame(frameSelector goja.Value) Frame
 	Frames() []Frame
 	GetAttribute(selector string, name string, opts goja.Value) goja.Value
+	// GetByRole creates and returns a new locator for the elements with
+	// the ARIA role, and optionally the accessible name (main frame).
+	GetByRole(role string, opts goja.Value) Locator
 	GoBack(opts goja.Value) Response
 	GoForward(opts goja.Value) Response
 	Goto(url string, opts goja.Value) Response
@@ -64,6 +68,8 @@ type Page interface {
 	// Locator creates and returns a new locator for this page (main frame).
 	Locator(selector string, opts goja.Value) Locator
 	MainFrame() Frame
+	Off(event string, handler goja.Value)
+	On(event string, handler goja.Value)
 	Opener() Page
 	Pause()
 	Pdf(opts goja.Value) goja.ArrayBuffer
@@ -71,30 +77,34 @@ type Page interface {
 	Query(selector string) ElementHandle
 	QueryAll(selector string) []ElementHandle
 	Reload(opts goja.Value) Response
-	Route(url goja.Value, handler goja.Callable)
+	Route(url goja.Value, handler goja.Value)
 	Screenshot(opts goja.Value) goja.ArrayBuffer
 	SelectOption(selector string, values goja.Value, opts goja.Value) []string
 	SetContent(html string, opts goja.Value)
 	SetDefaultNavigationTimeout(timeout int64)
 	SetDefaultTimeout(timeout int64)
 	SetExtraHTTPHeaders(headers map[string]string)
+	SetGeolocation(geolocation goja.Value, opts goja.Value)
 	SetInputFiles(selector string, files goja.Value, opts goja.Value)
+	SetOrientation(orientation string)
 	SetViewportSize(viewportSize goja.Value)
 	Tap(selector string, opts goja.Value)
 	TextContent(selector string, opts goja.Value) string
 	Title() string
 	Type(selector string, text string, opts goja.Value)
 	Uncheck(selector string, opts goja.Value)
-	Unroute(url goja.Value, handler goja.Callable)
+	Unroute(url goja.Value, handler goja.Value)
 	URL() string
 	Video() Video
 	ViewportSize() map[string]float64
-	WaitForEvent(event string, optsOrPredicate goja.Value) interface{}
+	WaitForCount(selector string, count int64, opts goja.Value)
+	WaitForEvent(event string, optsOrPredicate goja.Value) *goja.Promise
 	WaitForFunction(fn, opts goja.Value, args ...goja.Value) *goja.Promise
 	WaitForLoadState(state string, opts goja.Value)
 	WaitForNavigation(opts goja.Value) Response
-	WaitForRequest(urlOrPredicate, opts goja.Value) Request
-	WaitForResponse(urlOrPredicate, opts goja.Value) Response
+	WaitForNetworkIdle(opts goja.Value)
+	WaitForRequest(urlOrPredicate, opts goja.Value) *goja.Promise
+	WaitForResponse(urlOrPredicate, opts goja.Value) *goja.Promise
 	WaitForSelector(selector string, opts goja.Value) ElementHandle
 	WaitForTimeout(timeout int64)
 	Workers() []Worker
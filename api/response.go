@@ -33,12 +33,15 @@ type Response interface {
 	Headers() map[string]string
 	HeadersArray() []HTTPHeader
 	JSON() goja.Value
+	NavigationTiming() goja.Value
 	Ok() bool
+	RedirectChain() []string
 	Request() Request
 	SecurityDetails() goja.Value
 	ServerAddr() goja.Value
 	Size() HTTPMessageSize
 	Status() int64
 	StatusText() string
+	Text() string
 	URL() string
 }
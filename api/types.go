@@ -20,6 +20,47 @@
 
 package api
 
+// DOMSnapshotNode is a node in a serialized snapshot of a DOM tree.
+// Element nodes have a tag, text nodes only have a text.
+type DOMSnapshotNode struct {
+	Tag        string             `json:"tag,omitempty" js:"tag"`
+	Attributes map[string]string  `json:"attributes,omitempty" js:"attributes"`
+	Text       string             `json:"text,omitempty" js:"text"`
+	Children   []*DOMSnapshotNode `json:"children,omitempty" js:"children"`
+}
+
+// Cookie is a browser cookie. URL is only used when adding a cookie, as
+// an alternative to setting its domain and path.
+type Cookie struct {
+	Name     string  `json:"name" js:"name"`
+	Value    string  `json:"value" js:"value"`
+	URL      string  `json:"url,omitempty" js:"url"`
+	Domain   string  `json:"domain" js:"domain"`
+	Path     string  `json:"path" js:"path"`
+	Expires  float64 `json:"expires" js:"expires"` // seconds since the UNIX epoch, or -1 for a session cookie
+	HTTPOnly bool    `json:"httpOnly" js:"httpOnly"`
+	Secure   bool    `json:"secure" js:"secure"`
+	SameSite string  `json:"sameSite,omitempty" js:"sameSite"`
+}
+
+// StorageState is the cookies and the local storage of a browser context.
+type StorageState struct {
+	Cookies []*Cookie        `json:"cookies" js:"cookies"`
+	Origins []*OriginStorage `json:"origins" js:"origins"`
+}
+
+// OriginStorage is the local storage of an origin.
+type OriginStorage struct {
+	Origin       string          `json:"origin" js:"origin"`
+	LocalStorage []*StorageEntry `json:"localStorage" js:"localStorage"`
+}
+
+// StorageEntry is an item of the local storage.
+type StorageEntry struct {
+	Name  string `json:"name" js:"name"`
+	Value string `json:"value" js:"value"`
+}
+
 // HTTPHeader is a single HTTP header.
 type HTTPHeader struct {
 	Name  string `json:"name"`
@@ -37,6 +78,20 @@ func (s HTTPMessageSize) Total() int64 {
 	return s.Headers + s.Body
 }
 
+// NavigationTiming is the timing of the navigation that resulted in a
+// response, from its start until its waitUntil lifecycle event fired.
+type NavigationTiming struct {
+	StartTime float64 `json:"startTime" js:"startTime"` // milliseconds since the UNIX epoch
+	EndTime   float64 `json:"endTime" js:"endTime"`     // milliseconds since the UNIX epoch
+	Duration  float64 `json:"duration" js:"duration"`   // milliseconds
+}
+
+// SelectorMatch is an element matched by one of several selectors.
+type SelectorMatch struct {
+	Handle   ElementHandle `js:"handle"`
+	Selector string        `js:"selector"`
+}
+
 type Rect struct {
 	X      float64 `js:"x"`
 	Y      float64 `js:"y"`
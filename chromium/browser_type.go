@@ -454,6 +454,9 @@ func makeLogger(ctx context.Context, launchOpts *common.LaunchOptions) (*log.Log
 	if _, ok := os.LookupEnv("XK6_BROWSER_CALLER"); ok {
 		logger.ReportCaller()
 	}
+	if _, ok := os.LookupEnv("XK6_BROWSER_CDP_TRACE"); ok {
+		logger.TraceCDP()
+	}
 
 	return logger, nil
 }
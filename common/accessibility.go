@@ -0,0 +1,379 @@
+/*
+ *
+ * xk6-browser - a browser automation extension for k6
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/xk6-browser/api"
+	"github.com/grafana/xk6-browser/k6ext"
+
+	"github.com/chromedp/cdproto/accessibility"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/dop251/goja"
+)
+
+// Ensure Accessibility implements the api.Accessibility interface.
+var _ api.Accessibility = &Accessibility{}
+
+// Accessibility reads the accessibility tree of a page.
+// Each Page has a publicly accessible Accessibility.
+type Accessibility struct {
+	ctx     context.Context
+	session session
+}
+
+// NewAccessibility returns a new Accessibility.
+func NewAccessibility(ctx context.Context, s session) *Accessibility {
+	return &Accessibility{
+		ctx:     ctx,
+		session: s,
+	}
+}
+
+// Snapshot returns the accessibility tree of the main frame as nested
+// objects with the role, name, value, and children of the nodes, or null
+// if the root element isn't in the tree. Like in Playwright, the nodes
+// that aren't interesting, e.g. generic containers, are left out unless
+// interestingOnly is false, and so is the root element, which makes the
+// snapshot null.
+func (a *Accessibility) Snapshot(opts goja.Value) goja.Value {
+	parsedOpts := NewAccessibilitySnapshotOptions()
+	if err := parsedOpts.Parse(a.ctx, opts); err != nil {
+		k6ext.Panic(a.ctx, "parsing accessibility snapshot options: %w", err)
+	}
+	snapshot, err := a.snapshot(parsedOpts)
+	if err != nil {
+		k6ext.Panic(a.ctx, "taking accessibility snapshot: %w", err)
+	}
+
+	rt := k6ext.Runtime(a.ctx)
+	if snapshot == nil {
+		return goja.Null()
+	}
+	return rt.ToValue(snapshot)
+}
+
+func (a *Accessibility) snapshot(opts *AccessibilitySnapshotOptions) (map[string]interface{}, error) {
+	nodes, err := accessibility.GetFullAXTree().Do(cdp.WithExecutor(a.ctx, a.session))
+	if err != nil {
+		return nil, fmt.Errorf("getting accessibility tree: %w", err)
+	}
+	tree := newAXTree(nodes)
+	if tree == nil {
+		return nil, nil
+	}
+	root := tree
+	if opts.Root != nil {
+		node, err := dom.DescribeNode().
+			WithObjectID(opts.Root.remoteObject.ObjectID).
+			Do(cdp.WithExecutor(a.ctx, opts.Root.session))
+		if err != nil {
+			return nil, fmt.Errorf("getting root node: %w", err)
+		}
+		if root = tree.find(node.BackendNodeID); root == nil {
+			return nil, nil
+		}
+	}
+
+	var interesting map[*axNode]bool
+	if opts.InterestingOnly {
+		// whether a node is interesting depends on its ancestors, e.g.
+		// the children of controls aren't, so it's collected from the
+		// whole tree.
+		interesting = make(map[*axNode]bool)
+		tree.collectInteresting(interesting, false)
+		if opts.Root != nil && !interesting[root] {
+			return nil, nil
+		}
+	}
+	serialized := root.serializeTree(interesting)
+	if len(serialized) == 0 {
+		return nil, nil
+	}
+	return serialized[0], nil
+}
+
+// axNode is a node of the accessibility tree with its children resolved.
+type axNode struct {
+	payload  *accessibility.Node
+	children []*axNode
+
+	role                    string
+	name                    string
+	focusable               bool
+	editable                string
+	hidden                  bool
+	cachedHasFocusableChild *bool
+}
+
+// newAXTree returns the root of the tree of the nodes, which CDP returns
+// in depth first order, starting with the root.
+func newAXTree(nodes []*accessibility.Node) *axNode {
+	if len(nodes) == 0 {
+		return nil
+	}
+	byID := make(map[accessibility.NodeID]*axNode, len(nodes))
+	for _, n := range nodes {
+		byID[n.NodeID] = newAXNode(n)
+	}
+	for _, n := range nodes {
+		parent := byID[n.NodeID]
+		for _, id := range n.ChildIDs {
+			if child, ok := byID[id]; ok {
+				parent.children = append(parent.children, child)
+			}
+		}
+	}
+	return byID[nodes[0].NodeID]
+}
+
+func newAXNode(n *accessibility.Node) *axNode {
+	node := &axNode{
+		payload: n,
+		role:    "Unknown",
+		name:    axValueString(n.Name),
+	}
+	if r := axValueString(n.Role); r != "" {
+		node.role = r
+	}
+	for _, p := range n.Properties {
+		switch p.Name {
+		case accessibility.PropertyNameFocusable:
+			node.focusable, _ = axValue(p.Value).(bool)
+		case accessibility.PropertyNameEditable:
+			node.editable, _ = axValue(p.Value).(string)
+		case accessibility.PropertyNameHidden:
+			node.hidden, _ = axValue(p.Value).(bool)
+		}
+	}
+	return node
+}
+
+// axValue returns the value of an accessibility value, or nil if it has
+// none.
+func axValue(v *accessibility.Value) interface{} {
+	if v == nil || len(v.Value) == 0 {
+		return nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(v.Value, &value); err != nil {
+		return nil
+	}
+	return value
+}
+
+// axValueString returns the value of an accessibility value as a string.
+func axValueString(v *accessibility.Value) string {
+	switch value := axValue(v).(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+func (n *axNode) find(id cdp.BackendNodeID) *axNode {
+	if n.payload.BackendDOMNodeID == id {
+		return n
+	}
+	for _, c := range n.children {
+		if found := c.find(id); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func (n *axNode) isPlainTextField() bool {
+	if n.editable == "richtext" {
+		return false
+	}
+	return n.editable != "" || n.role == "textbox" || n.role == "searchbox"
+}
+
+func (n *axNode) isTextOnlyObject() bool {
+	return n.role == "LineBreak" || n.role == "text" || n.role == "InlineTextBox" || n.role == "StaticText"
+}
+
+func (n *axNode) hasFocusableChild() bool {
+	if n.cachedHasFocusableChild == nil {
+		has := false
+		for _, c := range n.children {
+			if c.focusable || c.hasFocusableChild() {
+				has = true
+				break
+			}
+		}
+		n.cachedHasFocusableChild = &has
+	}
+	return *n.cachedHasFocusableChild
+}
+
+func (n *axNode) isLeafNode() bool {
+	if len(n.children) == 0 {
+		return true
+	}
+	// the children of text fields are their text, which is the value
+	// of the node.
+	if n.isPlainTextField() || n.isTextOnlyObject() {
+		return true
+	}
+	switch n.role {
+	case "doc-cover", "graphics-symbol", "img", "Meter", "scrollbar", "slider", "separator", "progressbar":
+		return true
+	}
+	if n.hasFocusableChild() {
+		return false
+	}
+	if n.focusable && n.name != "" {
+		return true
+	}
+	return n.role == "heading" && n.name != ""
+}
+
+func (n *axNode) isControl() bool {
+	switch n.role {
+	case "button", "checkbox", "ColorWell", "combobox", "DisclosureTriangle",
+		"listbox", "menu", "menubar", "menuitem", "menuitemcheckbox",
+		"menuitemradio", "radio", "scrollbar", "searchbox", "slider",
+		"spinbutton", "switch", "tab", "textbox", "tree", "treeitem":
+		return true
+	}
+	return false
+}
+
+func (n *axNode) isInteresting(insideControl bool) bool {
+	if n.payload.Ignored || n.hidden || n.role == "Ignored" {
+		return false
+	}
+	if n.focusable || n.editable == "richtext" {
+		return true
+	}
+	// a control is interesting even if it's not focusable.
+	if n.isControl() {
+		return true
+	}
+	// but the children of a control aren't.
+	if insideControl {
+		return false
+	}
+	return n.isLeafNode() && n.name != ""
+}
+
+func (n *axNode) collectInteresting(collection map[*axNode]bool, insideControl bool) {
+	if n.isInteresting(insideControl) {
+		collection[n] = true
+	}
+	if n.isLeafNode() {
+		return
+	}
+	insideControl = insideControl || n.isControl()
+	for _, c := range n.children {
+		c.collectInteresting(collection, insideControl)
+	}
+}
+
+// serializeTree returns the serialized node with its serialized children,
+// or only its children if the node isn't in interesting. A nil interesting
+// serializes all the nodes.
+func (n *axNode) serializeTree(interesting map[*axNode]bool) []map[string]interface{} {
+	var children []map[string]interface{}
+	for _, c := range n.children {
+		children = append(children, c.serializeTree(interesting)...)
+	}
+	if interesting != nil && !interesting[n] {
+		return children
+	}
+	node := n.serialize()
+	if len(children) > 0 {
+		node["children"] = children
+	}
+	return []map[string]interface{}{node}
+}
+
+func (n *axNode) serialize() map[string]interface{} {
+	properties := make(map[string]interface{})
+	for _, p := range n.payload.Properties {
+		properties[strings.ToLower(string(p.Name))] = axValue(p.Value)
+	}
+	if d := axValueString(n.payload.Description); d != "" {
+		properties["description"] = d
+	}
+
+	role := n.role
+	switch role {
+	case "RootWebArea":
+		role = "WebArea"
+	case "StaticText":
+		role = "text"
+	}
+	node := map[string]interface{}{
+		"role": role,
+		"name": n.name,
+	}
+	if v := axValue(n.payload.Value); v != nil {
+		node["value"] = v
+	}
+	for _, p := range []string{"description", "keyshortcuts", "roledescription", "valuetext"} {
+		if v, ok := properties[p].(string); ok && v != "" {
+			node[p] = v
+		}
+	}
+	for _, p := range []string{
+		"disabled", "expanded", "focused", "modal", "multiline",
+		"multiselectable", "readonly", "required", "selected",
+	} {
+		// the document is always focused.
+		if p == "focused" && role == "WebArea" {
+			continue
+		}
+		if v, ok := properties[p].(bool); ok && v {
+			node[p] = v
+		}
+	}
+	for _, p := range []string{"level", "valuemax", "valuemin"} {
+		if v, ok := properties[p].(float64); ok {
+			node[p] = v
+		}
+	}
+	for _, p := range []string{"autocomplete", "haspopup", "invalid", "orientation"} {
+		if v, ok := properties[p].(string); ok && v != "" && v != "false" {
+			node[p] = v
+		}
+	}
+	for _, p := range []string{"checked", "pressed"} {
+		switch v := properties[p]; v {
+		case nil:
+		case "mixed":
+			node[p] = "mixed"
+		default:
+			node[p] = v == true || v == "true"
+		}
+	}
+	return node
+}
@@ -134,8 +134,15 @@ func (b *Browser) connect() error {
 
 	b.conn = conn
 
-	// We don't need to lock this because `connect()` is called only in NewBrowser
-	b.defaultContext = NewBrowserContext(b.ctx, b, "", NewBrowserContextOptions(), b.logger)
+	// We don't need to lock this because `connect()` is called only in NewBrowser.
+	// The default context has no options to opt in to downloads, so it
+	// always accepts them.
+	opts := NewBrowserContextOptions()
+	opts.AcceptDownloads = true
+	b.defaultContext = NewBrowserContext(b.ctx, b, "", opts, b.logger)
+	if err := b.defaultContext.initDownloads(); err != nil {
+		return fmt.Errorf("initializing downloads of the default browser context: %w", err)
+	}
 
 	return b.initEvents()
 }
@@ -155,6 +162,23 @@ func (b *Browser) disposeContext(id cdp.BrowserContextID) error {
 	return nil
 }
 
+// flushHars writes the HARs of the browser contexts that record one, so that
+// the contexts that weren't closed, and the ones of a browser that lost its
+// connection, still export their requests.
+func (b *Browser) flushHars() {
+	b.contextsMu.RLock()
+	defer b.contextsMu.RUnlock()
+
+	for id, bctx := range b.contexts {
+		if bctx.har == nil {
+			continue
+		}
+		if err := bctx.har.flush(); err != nil {
+			b.logger.Errorf("Browser:flushHars", "bctxid:%v err:%v", id, err)
+		}
+	}
+}
+
 func (b *Browser) getPages() []*Page {
 	b.pagesMu.RLock()
 	defer b.pagesMu.RUnlock()
@@ -173,12 +197,15 @@ func (b *Browser) initEvents() error {
 	b.conn.on(cancelCtx, []string{
 		cdproto.EventTargetAttachedToTarget,
 		cdproto.EventTargetDetachedFromTarget,
+		cdproto.EventBrowserDownloadWillBegin,
+		cdproto.EventBrowserDownloadProgress,
 		EventConnectionClose,
 	}, chHandler)
 
 	go func() {
 		defer func() {
 			b.logger.Debugf("Browser:initEvents:defer", "ctx err: %v", cancelCtx.Err())
+			b.flushHars()
 			b.browserProc.didLoseConnection()
 			if b.cancelFn != nil {
 				b.cancelFn()
@@ -195,6 +222,10 @@ func (b *Browser) initEvents() error {
 				} else if ev, ok := event.data.(*target.EventDetachedFromTarget); ok {
 					b.logger.Debugf("Browser:initEvents:onDetachedFromTarget", "sid:%v", ev.SessionID)
 					b.onDetachedFromTarget(ev)
+				} else if ev, ok := event.data.(*cdpbrowser.EventDownloadWillBegin); ok {
+					b.onDownloadWillBegin(ev)
+				} else if ev, ok := event.data.(*cdpbrowser.EventDownloadProgress); ok {
+					b.onDownloadProgress(ev)
 				} else if event.typ == EventConnectionClose {
 					b.logger.Debugf("Browser:initEvents:EventConnectionClose", "")
 					return
@@ -321,6 +352,32 @@ func (b *Browser) onAttachedToTarget(ev *target.EventAttachedToTarget) {
 	}
 }
 
+// onDownloadWillBegin emits the download to the page whose frame started it.
+func (b *Browser) onDownloadWillBegin(ev *cdpbrowser.EventDownloadWillBegin) {
+	b.logger.Debugf("Browser:onDownloadWillBegin", "fid:%v guid:%s", ev.FrameID, ev.GUID)
+
+	for _, p := range b.getPages() {
+		if p.frameManager.getFrameByID(ev.FrameID) == nil {
+			continue
+		}
+		if d := p.browserCtx.onDownloadWillBegin(p, ev); d != nil {
+			p.emit(EventPageDownload, d)
+		}
+		return
+	}
+}
+
+func (b *Browser) onDownloadProgress(ev *cdpbrowser.EventDownloadProgress) {
+	b.contextsMu.RLock()
+	defer b.contextsMu.RUnlock()
+
+	for _, bctx := range b.contexts {
+		if bctx.onDownloadProgress(ev) {
+			return
+		}
+	}
+}
+
 // onDetachedFromTarget event can be issued multiple times per target if multiple
 // sessions have been attached to it. So we'll remove the page only once.
 func (b *Browser) onDetachedFromTarget(ev *target.EventDetachedFromTarget) {
@@ -417,6 +474,11 @@ func (b *Browser) Close() {
 
 	atomic.CompareAndSwapInt64(&b.state, b.state, BrowserStateClosed)
 
+	b.flushHars()
+	if err := b.defaultContext.cleanupDownloads(); err != nil {
+		b.logger.Errorf("Browser:Close", "%v", err)
+	}
+
 	action := cdpbrowser.Close()
 	if err := action.Do(cdp.WithExecutor(b.ctx, b.conn)); err != nil {
 		if _, ok := err.(*websocket.CloseError); !ok {
@@ -453,6 +515,13 @@ func (b *Browser) IsConnected() bool {
 
 // NewContext creates a new incognito-like browser context.
 func (b *Browser) NewContext(opts goja.Value) api.BrowserContext {
+	// the options are parsed first, so that the context isn't created
+	// for invalid options.
+	browserCtxOpts := NewBrowserContextOptions()
+	if err := browserCtxOpts.Parse(b.ctx, opts); err != nil {
+		k6ext.Panic(b.ctx, "parsing newContext options: %w", err)
+	}
+
 	action := target.CreateBrowserContext().WithDisposeOnDetach(true)
 	browserContextID, err := action.Do(cdp.WithExecutor(b.ctx, b.conn))
 	b.logger.Debugf("Browser:NewContext", "bctxid:%v", browserContextID)
@@ -460,14 +529,16 @@ func (b *Browser) NewContext(opts goja.Value) api.BrowserContext {
 		k6ext.Panic(b.ctx, "cannot create browser context (%s): %w", browserContextID, err)
 	}
 
-	browserCtxOpts := NewBrowserContextOptions()
-	if err := browserCtxOpts.Parse(b.ctx, opts); err != nil {
-		k6ext.Panic(b.ctx, "parsing newContext options: %w", err)
+	browserCtx := NewBrowserContext(b.ctx, b, browserContextID, browserCtxOpts, b.logger)
+	if err := browserCtx.initDownloads(); err != nil {
+		if derr := b.disposeContext(browserContextID); derr != nil {
+			b.logger.Errorf("Browser:NewContext", "%v", derr)
+		}
+		k6ext.Panic(b.ctx, "initializing downloads: %w", err)
 	}
 
 	b.contextsMu.Lock()
 	defer b.contextsMu.Unlock()
-	browserCtx := NewBrowserContext(b.ctx, b, browserContextID, browserCtxOpts, b.logger)
 	b.contexts[browserContextID] = browserCtx
 
 	return browserCtx
@@ -22,13 +22,18 @@ package common
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
 	"reflect"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/grafana/xk6-browser/api"
 	"github.com/grafana/xk6-browser/k6ext"
 	"github.com/grafana/xk6-browser/log"
+	xk6storage "github.com/grafana/xk6-browser/storage"
 
 	k6modules "go.k6.io/k6/js/modules"
 
@@ -59,6 +64,19 @@ type BrowserContext struct {
 	vu              k6modules.VU
 
 	evaluateOnNewDocumentSources []string
+	// storageStateOrigins is the local storage of the storageState option,
+	// by origin, that the pages haven't loaded yet.
+	storageStateMu      sync.Mutex
+	storageStateOrigins map[string][]*api.StorageEntry
+
+	// har records the requests of the context if the recordHar option is set.
+	har *harRecorder
+
+	// downloadsDir is where the downloads of the context are saved to if
+	// the acceptDownloads option is set.
+	downloadsDir *xk6storage.Dir
+	downloadsMu  sync.Mutex
+	downloads    map[string]*Download
 }
 
 // NewBrowserContext creates a new browser context.
@@ -79,12 +97,38 @@ func NewBrowserContext(
 	if opts != nil && len(opts.Permissions) > 0 {
 		b.GrantPermissions(opts.Permissions, nil)
 	}
+	if opts != nil && opts.RecordHar != "" {
+		b.har = newHarRecorder(opts.RecordHar)
+	}
+	if opts != nil && opts.StorageState != nil {
+		if err := b.setStorageState(opts.StorageState); err != nil {
+			// the context is unusable, so it's not left open in the browser.
+			if err := browser.disposeContext(id); err != nil {
+				logger.Debugf("NewBrowserContext", "bctxid:%v disposing: %v", id, err)
+			}
+			k6ext.Panic(ctx, "setting storage state: %w", err)
+		}
+	}
 
 	return &b
 }
 
+// AddCookies adds cookies into the browser context. All pages within
+// the browser context will share these cookies.
 func (b *BrowserContext) AddCookies(cookies goja.Value) {
-	k6ext.Panic(b.ctx, "BrowserContext.addCookies(cookies) has not been implemented yet")
+	b.logger.Debugf("BrowserContext:AddCookies", "bctxid:%v", b.id)
+
+	params, err := parseCookies(b.ctx, cookies)
+	if err != nil {
+		k6ext.Panic(b.ctx, "parsing cookies: %w", err)
+	}
+	if len(params) == 0 {
+		return
+	}
+	action := storage.SetCookies(params).WithBrowserContextID(b.id)
+	if err := action.Do(cdp.WithExecutor(b.ctx, b.browser.conn)); err != nil {
+		k6ext.Panic(b.ctx, "adding cookies: %w", err)
+	}
 }
 
 // AddInitScript adds a script that will be initialized on all new pages.
@@ -118,7 +162,7 @@ func (b *BrowserContext) AddInitScript(script goja.Value, arg goja.Value) {
 
 	b.evaluateOnNewDocumentSources = append(b.evaluateOnNewDocumentSources, source)
 
-	for _, p := range b.browser.getPages() {
+	for _, p := range b.getPages() {
 		p.evaluateOnNewDocument(source)
 	}
 }
@@ -133,17 +177,30 @@ func (b *BrowserContext) ClearCookies() {
 	b.logger.Debugf("BrowserContext:ClearCookies", "bctxid:%v", b.id)
 
 	action := storage.ClearCookies().WithBrowserContextID(b.id)
-	if err := action.Do(b.ctx); err != nil {
+	if err := action.Do(cdp.WithExecutor(b.ctx, b.browser.conn)); err != nil {
 		k6ext.Panic(b.ctx, "clearing cookies: %w", err)
 	}
 }
 
+// ClearGeolocation clears the geolocation override of all pages in
+// the browser context.
+func (b *BrowserContext) ClearGeolocation() {
+	b.logger.Debugf("BrowserContext:ClearGeolocation", "bctxid:%v", b.id)
+
+	b.opts.Geolocation = nil
+	for _, p := range b.getPages() {
+		if err := p.updateGeolocation(); err != nil {
+			k6ext.Panic(b.ctx, "clearing geo location in target ID %s: %w", p.targetID, err)
+		}
+	}
+}
+
 // ClearPermissions clears any permission overrides.
 func (b *BrowserContext) ClearPermissions() {
 	b.logger.Debugf("BrowserContext:ClearPermissions", "bctxid:%v", b.id)
 
 	action := cdpbrowser.ResetPermissions().WithBrowserContextID(b.id)
-	if err := action.Do(b.ctx); err != nil {
+	if err := action.Do(cdp.WithExecutor(b.ctx, b.browser.conn)); err != nil {
 		k6ext.Panic(b.ctx, "clearing permissions: %w", err)
 	}
 }
@@ -155,14 +212,39 @@ func (b *BrowserContext) Close() {
 	if b.id == "" {
 		k6ext.Panic(b.ctx, "default browser context can't be closed")
 	}
+	if err := b.cleanupDownloads(); err != nil {
+		k6ext.Panic(b.ctx, "cleaning up downloads: %w", err)
+	}
 	if err := b.browser.disposeContext(b.id); err != nil {
 		k6ext.Panic(b.ctx, "disposing browser context: %w", err)
 	}
+	if b.har != nil {
+		if err := b.har.flush(); err != nil {
+			k6ext.Panic(b.ctx, "exporting HAR: %w", err)
+		}
+	}
 }
 
-func (b *BrowserContext) Cookies() []goja.Object {
-	k6ext.Panic(b.ctx, "BrowserContext.cookies() has not been implemented yet")
-	return nil
+// Cookies returns the cookies of the browser context. If urls are given,
+// only the cookies that would be sent to any of them are returned.
+func (b *BrowserContext) Cookies(urls ...string) []*api.Cookie {
+	b.logger.Debugf("BrowserContext:Cookies", "bctxid:%v urls:%v", b.id, urls)
+
+	action := storage.GetCookies().WithBrowserContextID(b.id)
+	cookies, err := action.Do(cdp.WithExecutor(b.ctx, b.browser.conn))
+	if err != nil {
+		k6ext.Panic(b.ctx, "getting cookies: %w", err)
+	}
+	cookies, err = filterCookies(cookies, urls)
+	if err != nil {
+		k6ext.Panic(b.ctx, "filtering cookies: %w", err)
+	}
+
+	result := make([]*api.Cookie, 0, len(cookies))
+	for _, c := range cookies {
+		result = append(result, toAPICookie(c))
+	}
+	return result
 }
 
 func (b *BrowserContext) ExposeBinding(name string, callback goja.Callable, opts goja.Value) {
@@ -174,26 +256,11 @@ func (b *BrowserContext) ExposeFunction(name string, callback goja.Callable) {
 }
 
 // GrantPermissions enables the specified permissions, all others will be disabled.
+// The permissions apply to the origin given in the options, or to all
+// origins of the context if there's none.
 func (b *BrowserContext) GrantPermissions(permissions []string, opts goja.Value) {
 	b.logger.Debugf("BrowserContext:GrantPermissions", "bctxid:%v", b.id)
 
-	permsToProtocol := map[string]cdpbrowser.PermissionType{
-		"geolocation":          cdpbrowser.PermissionTypeGeolocation,
-		"midi":                 cdpbrowser.PermissionTypeMidi,
-		"midi-sysex":           cdpbrowser.PermissionTypeMidiSysex,
-		"notifications":        cdpbrowser.PermissionTypeNotifications,
-		"camera":               cdpbrowser.PermissionTypeVideoCapture,
-		"microphone":           cdpbrowser.PermissionTypeAudioCapture,
-		"background-sync":      cdpbrowser.PermissionTypeBackgroundSync,
-		"ambient-light-sensor": cdpbrowser.PermissionTypeSensors,
-		"accelerometer":        cdpbrowser.PermissionTypeSensors,
-		"gyroscope":            cdpbrowser.PermissionTypeSensors,
-		"magnetometer":         cdpbrowser.PermissionTypeSensors,
-		"accessibility-events": cdpbrowser.PermissionTypeAccessibilityEvents,
-		"clipboard-read":       cdpbrowser.PermissionTypeClipboardReadWrite,
-		"clipboard-write":      cdpbrowser.PermissionTypeClipboardSanitizedWrite,
-		"payment-handler":      cdpbrowser.PermissionTypePaymentHandler,
-	}
 	origin := ""
 
 	rt := b.vu.Runtime()
@@ -207,9 +274,9 @@ func (b *BrowserContext) GrantPermissions(permissions []string, opts goja.Value)
 		}
 	}
 
-	perms := make([]cdpbrowser.PermissionType, 0, len(permissions))
-	for _, p := range permissions {
-		perms = append(perms, permsToProtocol[p])
+	perms, err := parsePermissions(permissions)
+	if err != nil {
+		k6ext.Panic(b.ctx, "granting permissions: %w", err)
 	}
 
 	action := cdpbrowser.GrantPermissions(perms).WithOrigin(origin).WithBrowserContextID(b.id)
@@ -218,6 +285,40 @@ func (b *BrowserContext) GrantPermissions(permissions []string, opts goja.Value)
 	}
 }
 
+// permissionTypes maps the permission names scripts use to the permission
+// types of the protocol.
+var permissionTypes = map[string]cdpbrowser.PermissionType{
+	"geolocation":          cdpbrowser.PermissionTypeGeolocation,
+	"midi":                 cdpbrowser.PermissionTypeMidi,
+	"midi-sysex":           cdpbrowser.PermissionTypeMidiSysex,
+	"notifications":        cdpbrowser.PermissionTypeNotifications,
+	"camera":               cdpbrowser.PermissionTypeVideoCapture,
+	"microphone":           cdpbrowser.PermissionTypeAudioCapture,
+	"background-sync":      cdpbrowser.PermissionTypeBackgroundSync,
+	"ambient-light-sensor": cdpbrowser.PermissionTypeSensors,
+	"accelerometer":        cdpbrowser.PermissionTypeSensors,
+	"gyroscope":            cdpbrowser.PermissionTypeSensors,
+	"magnetometer":         cdpbrowser.PermissionTypeSensors,
+	"accessibility-events": cdpbrowser.PermissionTypeAccessibilityEvents,
+	"clipboard-read":       cdpbrowser.PermissionTypeClipboardReadWrite,
+	"clipboard-write":      cdpbrowser.PermissionTypeClipboardSanitizedWrite,
+	"payment-handler":      cdpbrowser.PermissionTypePaymentHandler,
+}
+
+// parsePermissions returns the protocol permission types of the permission
+// names. It returns an error for unknown permission names.
+func parsePermissions(permissions []string) ([]cdpbrowser.PermissionType, error) {
+	perms := make([]cdpbrowser.PermissionType, 0, len(permissions))
+	for _, p := range permissions {
+		perm, ok := permissionTypes[p]
+		if !ok {
+			return nil, fmt.Errorf("unknown permission %q", p)
+		}
+		perms = append(perms, perm)
+	}
+	return perms, nil
+}
+
 // NewCDPSession returns a new CDP session attached to this target.
 func (b *BrowserContext) NewCDPSession() api.CDPSession {
 	k6ext.Panic(b.ctx, "BrowserContext.newCDPSession() has not been implemented yet")
@@ -250,13 +351,24 @@ func (b *BrowserContext) NewPage() api.Page {
 
 // Pages returns a list of pages inside this browser context.
 func (b *BrowserContext) Pages() []api.Page {
-	pages := make([]api.Page, 1)
-	for _, p := range b.browser.getPages() {
+	pages := make([]api.Page, 0)
+	for _, p := range b.getPages() {
 		pages = append(pages, p)
 	}
 	return pages
 }
 
+// getPages returns the pages that belong to this browser context.
+func (b *BrowserContext) getPages() []*Page {
+	var pages []*Page
+	for _, p := range b.browser.getPages() {
+		if p.browserCtx == b {
+			pages = append(pages, p)
+		}
+	}
+	return pages
+}
+
 func (b *BrowserContext) Route(url goja.Value, handler goja.Callable) {
 	k6ext.Panic(b.ctx, "BrowserContext.route(url, handler) has not been implemented yet")
 }
@@ -279,17 +391,57 @@ func (b *BrowserContext) SetExtraHTTPHeaders(headers map[string]string) {
 	k6ext.Panic(b.ctx, "BrowserContext.setExtraHTTPHeaders(headers) has not been implemented yet")
 }
 
+// SetBearerToken sets the Authorization header of every request of
+// the pages in the browser context to the given bearer token.
+func (b *BrowserContext) SetBearerToken(token string) {
+	b.logger.Debugf("BrowserContext:SetBearerToken", "bctxid:%v", b.id)
+
+	if token == "" {
+		k6ext.Panic(b.ctx, "setting bearer token: token is empty")
+	}
+	b.deleteExtraHTTPHeader("Authorization")
+	b.opts.ExtraHTTPHeaders["Authorization"] = "Bearer " + token
+	b.updateExtraHTTPHeaders()
+}
+
+// ClearBearerToken removes the Authorization header set by SetBearerToken.
+func (b *BrowserContext) ClearBearerToken() {
+	b.logger.Debugf("BrowserContext:ClearBearerToken", "bctxid:%v", b.id)
+
+	b.deleteExtraHTTPHeader("Authorization")
+	b.updateExtraHTTPHeaders()
+}
+
+// deleteExtraHTTPHeader deletes the extra HTTP header regardless
+// of the case it was set with.
+func (b *BrowserContext) deleteExtraHTTPHeader(name string) {
+	if b.opts.ExtraHTTPHeaders == nil {
+		b.opts.ExtraHTTPHeaders = make(map[string]string)
+	}
+	for k := range b.opts.ExtraHTTPHeaders {
+		if strings.EqualFold(k, name) {
+			delete(b.opts.ExtraHTTPHeaders, k)
+		}
+	}
+}
+
+func (b *BrowserContext) updateExtraHTTPHeaders() {
+	for _, p := range b.getPages() {
+		p.updateExtraHTTPHeaders()
+	}
+}
+
 // SetGeolocation overrides the geo location of the user.
 func (b *BrowserContext) SetGeolocation(geolocation goja.Value) {
 	b.logger.Debugf("BrowserContext:SetGeolocation", "bctxid:%v", b.id)
 
 	g := NewGeolocation()
 	if err := g.Parse(b.ctx, geolocation); err != nil {
-		k6ext.Panic(b.ctx, "parsing geo location: %v", err)
+		k6ext.Panic(b.ctx, "parsing geo location: %w", err)
 	}
 
 	b.opts.Geolocation = g
-	for _, p := range b.browser.getPages() {
+	for _, p := range b.getPages() {
 		if err := p.updateGeolocation(); err != nil {
 			k6ext.Panic(b.ctx, "updating geo location in target ID %s: %w", p.targetID, err)
 		}
@@ -312,8 +464,15 @@ func (b *BrowserContext) SetHTTPCredentials(httpCredentials goja.Value) {
 		k6ext.Panic(b.ctx, "setting HTTP credentials: %w", err)
 	}
 
-	b.opts.HttpCredentials = c
-	for _, p := range b.browser.getPages() {
+	// the credentials are copied since the network managers of the pages
+	// may be reading the current ones.
+	credentials := make(HTTPCredentials, len(b.opts.HttpCredentials)+1)
+	for origin, cr := range b.opts.HttpCredentials {
+		credentials[origin] = cr
+	}
+	credentials[c.Origin] = c
+	b.opts.HttpCredentials = credentials
+	for _, p := range b.getPages() {
 		p.updateHttpCredentials()
 	}
 }
@@ -323,13 +482,34 @@ func (b *BrowserContext) SetOffline(offline bool) {
 	b.logger.Debugf("BrowserContext:SetOffline", "bctxid:%v offline:%t", b.id, offline)
 
 	b.opts.Offline = offline
-	for _, p := range b.browser.getPages() {
+	for _, p := range b.getPages() {
 		p.updateOffline()
 	}
 }
 
-func (b *BrowserContext) StorageState(opts goja.Value) {
-	k6ext.Panic(b.ctx, "BrowserContext.storageState(opts) has not been implemented yet")
+// StorageState returns the cookies of the browser context, and the local
+// storage of the origins of its open frames. If the path option is set,
+// the state is also saved to the file as JSON, to be used by the
+// storageState option of new contexts.
+func (b *BrowserContext) StorageState(opts goja.Value) *api.StorageState {
+	b.logger.Debugf("BrowserContext:StorageState", "bctxid:%v", b.id)
+
+	var path string
+	if gojaValueExists(opts) {
+		if v := opts.ToObject(b.vu.Runtime()).Get("path"); gojaValueExists(v) {
+			path = v.String()
+		}
+	}
+	state := &api.StorageState{
+		Cookies: b.Cookies(),
+		Origins: b.originStorages(),
+	}
+	if path != "" {
+		if err := saveStorageState(state, path); err != nil {
+			k6ext.Panic(b.ctx, "saving storage state: %w", err)
+		}
+	}
+	return state
 }
 
 func (b *BrowserContext) Unroute(url goja.Value, handler goja.Callable) {
@@ -429,3 +609,98 @@ func (b *BrowserContext) WaitForEvent(event string, optsOrPredicate goja.Value)
 func (b *BrowserContext) getSession(id target.SessionID) *Session {
 	return b.browser.conn.getSession(id)
 }
+
+// initDownloads sets where the browser saves the downloads started in the
+// context, and enables the download events. It does nothing unless the
+// acceptDownloads option is set.
+func (b *BrowserContext) initDownloads() error {
+	if !b.opts.AcceptDownloads {
+		return nil
+	}
+
+	var dir xk6storage.Dir
+	if err := dir.Make("", b.opts.DownloadsPath); err != nil {
+		return fmt.Errorf("making downloads directory: %w", err)
+	}
+	if err := os.MkdirAll(dir.Dir, 0o755); err != nil { //nolint:gomnd
+		return fmt.Errorf("making downloads directory %q: %w", dir.Dir, err)
+	}
+	b.downloadsDir = &dir
+	b.downloads = make(map[string]*Download)
+
+	action := cdpbrowser.SetDownloadBehavior(cdpbrowser.SetDownloadBehaviorBehaviorAllowAndName).
+		WithBrowserContextID(b.id).
+		WithDownloadPath(dir.Dir).
+		WithEventsEnabled(true)
+	if err := action.Do(cdp.WithExecutor(b.ctx, b.browser.conn)); err != nil {
+		return fmt.Errorf("allowing downloads: %w", err)
+	}
+	return nil
+}
+
+// onDownloadWillBegin starts tracking a download started by the page.
+// It returns nil if the context doesn't accept downloads.
+func (b *BrowserContext) onDownloadWillBegin(p *Page, ev *cdpbrowser.EventDownloadWillBegin) *Download {
+	b.logger.Debugf("BrowserContext:onDownloadWillBegin", "bctxid:%v guid:%s url:%q", b.id, ev.GUID, ev.URL)
+
+	b.downloadsMu.Lock()
+	defer b.downloadsMu.Unlock()
+
+	if b.downloadsDir == nil {
+		return nil
+	}
+	d := NewDownload(b.ctx, p, ev.GUID, ev.URL, ev.SuggestedFilename, b.downloadsDir.Dir, b.logger)
+	b.downloads[ev.GUID] = d
+
+	return d
+}
+
+// onDownloadProgress finishes the download when it completes or is canceled.
+// It returns false if the download isn't started in this context.
+func (b *BrowserContext) onDownloadProgress(ev *cdpbrowser.EventDownloadProgress) bool {
+	b.downloadsMu.Lock()
+	d, ok := b.downloads[ev.GUID]
+	b.downloadsMu.Unlock()
+	if !ok {
+		return false
+	}
+
+	switch ev.State {
+	case cdpbrowser.DownloadProgressStateCompleted:
+		d.finish(nil)
+	case cdpbrowser.DownloadProgressStateCanceled:
+		d.finish(errDownloadCanceled)
+	case cdpbrowser.DownloadProgressStateInProgress:
+	}
+	return true
+}
+
+// cleanupDownloads cancels the unfinished downloads of the context, and
+// removes their partial files. The downloads directory is removed if it's
+// a temporary one.
+func (b *BrowserContext) cleanupDownloads() error {
+	b.downloadsMu.Lock()
+	defer b.downloadsMu.Unlock()
+
+	if b.downloadsDir == nil {
+		return nil
+	}
+	var rerr error
+	for guid, d := range b.downloads {
+		if d.finished() {
+			continue
+		}
+		action := cdpbrowser.CancelDownload(guid).WithBrowserContextID(b.id)
+		if err := action.Do(cdp.WithExecutor(b.ctx, b.browser.conn)); err != nil {
+			b.logger.Debugf("BrowserContext:cleanupDownloads", "bctxid:%v guid:%s canceling: %v", b.id, guid, err)
+		}
+		d.finish(errDownloadContextClosed)
+		if err := os.Remove(d.path); err != nil && !errors.Is(err, os.ErrNotExist) && rerr == nil {
+			rerr = fmt.Errorf("removing partial download %q: %w", d.path, err)
+		}
+	}
+	if err := b.downloadsDir.Cleanup(); err != nil && rerr == nil {
+		rerr = fmt.Errorf("removing downloads directory: %w", err)
+	}
+	return rerr
+}
@@ -22,41 +22,78 @@ package common
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/grafana/xk6-browser/api"
 	"github.com/grafana/xk6-browser/k6ext"
 
 	"github.com/dop251/goja"
 )
 
+// browserMetricNames are the names of the metrics that the browser emits
+// automatically for the pages of a browser context.
+var browserMetricNames = []string{
+	"browser_dom_content_loaded",
+	"browser_first_paint",
+	"browser_cumulative_layout_shift",
+	"browser_first_contentful_paint",
+	"browser_first_meaningful_paint",
+	"browser_loaded",
+	"browser_document_response_end",
+	"browser_long_task",
+	"browser_largest_contentful_paint",
+	"browser_time_to_first_byte",
+}
+
+// Ways to handle the beforeunload dialogs automatically.
+const (
+	BeforeUnloadDialogAccept  = "accept"
+	BeforeUnloadDialogDismiss = "dismiss"
+)
+
 // BrowserContextOptions stores browser context options.
 type BrowserContextOptions struct {
-	AcceptDownloads   bool              `js:"acceptDownloads"`
-	BypassCSP         bool              `js:"bypassCSP"`
-	ColorScheme       ColorScheme       `js:"colorScheme"`
-	DeviceScaleFactor float64           `js:"deviceScaleFactor"`
-	ExtraHTTPHeaders  map[string]string `js:"extraHTTPHeaders"`
-	Geolocation       *Geolocation      `js:"geolocation"`
-	HasTouch          bool              `js:"hasTouch"`
-	HttpCredentials   *Credentials      `js:"httpCredentials"`
-	IgnoreHTTPSErrors bool              `js:"ignoreHTTPSErrors"`
-	IsMobile          bool              `js:"isMobile"`
-	JavaScriptEnabled bool              `js:"javaScriptEnabled"`
-	Locale            string            `js:"locale"`
-	Offline           bool              `js:"offline"`
-	Permissions       []string          `js:"permissions"`
-	ReducedMotion     ReducedMotion     `js:"reducedMotion"`
-	Screen            *Screen           `js:"screen"`
-	TimezoneID        string            `js:"timezoneID"`
-	UserAgent         string            `js:"userAgent"`
-	VideosPath        string            `js:"videosPath"`
-	Viewport          *Viewport         `js:"viewport"`
+	AcceptDownloads    bool              `js:"acceptDownloads"`
+	AcceptLanguage     string            `js:"acceptLanguage"`
+	BeforeUnloadDialog string            `js:"beforeUnloadDialog"`
+	BrowserMetrics     []string          `js:"browserMetrics"`
+	BypassCSP          bool              `js:"bypassCSP"`
+	ColorScheme        ColorScheme       `js:"colorScheme"`
+	Contrast           Contrast          `js:"contrast"`
+	DeviceScaleFactor  float64           `js:"deviceScaleFactor"`
+	DownloadsPath      string            `js:"downloadsPath"`
+	ExtraHTTPHeaders   map[string]string `js:"extraHTTPHeaders"`
+	ForcedColors       ForcedColors      `js:"forcedColors"`
+	Geolocation        *Geolocation      `js:"geolocation"`
+	HasTouch           bool              `js:"hasTouch"`
+	HttpCredentials    HTTPCredentials   `js:"httpCredentials"`
+	IgnoreHTTPSErrors  bool              `js:"ignoreHTTPSErrors"`
+	IsMobile           bool              `js:"isMobile"`
+	JavaScriptEnabled  bool              `js:"javaScriptEnabled"`
+	Locale             string            `js:"locale"`
+	NetworkIdleTimeout time.Duration     `js:"networkIdleTimeout"`
+	Offline            bool              `js:"offline"`
+	Orientation        ScreenOrientation `js:"orientation"`
+	Permissions        []string          `js:"permissions"`
+	RecordHar          string            `js:"recordHar"`
+	ReducedMotion      ReducedMotion     `js:"reducedMotion"`
+	Screen             *Screen           `js:"screen"`
+	StorageState       *api.StorageState `js:"storageState"`
+	TimezoneID         string            `js:"timezoneID"`
+	UserAgent          string            `js:"userAgent"`
+	VideosPath         string            `js:"videosPath"`
+	Viewport           *Viewport         `js:"viewport"`
 }
 
 // NewBrowserContextOptions creates a default set of browser context options.
 func NewBrowserContextOptions() *BrowserContextOptions {
 	return &BrowserContextOptions{
+		BrowserMetrics:    append([]string{}, browserMetricNames...),
 		ColorScheme:       ColorSchemeLight,
+		Contrast:          ContrastNoPreference,
 		DeviceScaleFactor: 1.0,
 		ExtraHTTPHeaders:  make(map[string]string),
 		JavaScriptEnabled: true,
@@ -76,6 +113,22 @@ func (b *BrowserContextOptions) Parse(ctx context.Context, opts goja.Value) erro
 			switch k {
 			case "acceptDownloads":
 				b.AcceptDownloads = opts.Get(k).ToBoolean()
+			case "acceptLanguage":
+				b.AcceptLanguage = opts.Get(k).String()
+			case "beforeUnloadDialog":
+				switch d := opts.Get(k).String(); d {
+				case BeforeUnloadDialogAccept, BeforeUnloadDialogDismiss:
+					b.BeforeUnloadDialog = d
+				default:
+					return fmt.Errorf("beforeUnloadDialog must be either %q or %q, got %q",
+						BeforeUnloadDialogAccept, BeforeUnloadDialogDismiss, d)
+				}
+			case "browserMetrics":
+				ms, err := parseBrowserMetrics(opts.Get(k))
+				if err != nil {
+					return err
+				}
+				b.BrowserMetrics = ms
 			case "bypassCSP":
 				b.BypassCSP = opts.Get(k).ToBoolean()
 			case "colorScheme":
@@ -87,13 +140,33 @@ func (b *BrowserContextOptions) Parse(ctx context.Context, opts goja.Value) erro
 				default:
 					b.ColorScheme = ColorSchemeNoPreference
 				}
+			case "contrast":
+				switch Contrast(opts.Get(k).String()) {
+				case "more":
+					b.Contrast = ContrastMore
+				case "less":
+					b.Contrast = ContrastLess
+				case "custom":
+					b.Contrast = ContrastCustom
+				default:
+					b.Contrast = ContrastNoPreference
+				}
 			case "deviceScaleFactor":
 				b.DeviceScaleFactor = opts.Get(k).ToFloat()
+			case "downloadsPath":
+				b.DownloadsPath = opts.Get(k).String()
 			case "extraHTTPHeaders":
 				headers := opts.Get(k).ToObject(rt)
 				for _, k := range headers.Keys() {
 					b.ExtraHTTPHeaders[k] = headers.Get(k).String()
 				}
+			case "forcedColors":
+				switch f := ForcedColors(opts.Get(k).String()); f {
+				case ForcedColorsActive, ForcedColorsNone:
+					b.ForcedColors = f
+				default:
+					b.ForcedColors = ""
+				}
 			case "geolocation":
 				geolocation := NewGeolocation()
 				if err := geolocation.Parse(ctx, opts.Get(k).ToObject(rt)); err != nil {
@@ -103,10 +176,15 @@ func (b *BrowserContextOptions) Parse(ctx context.Context, opts goja.Value) erro
 			case "hasTouch":
 				b.HasTouch = opts.Get(k).ToBoolean()
 			case "httpCredentials":
-				credentials := NewCredentials()
-				if err := credentials.Parse(ctx, opts.Get(k).ToObject(rt)); err != nil {
+				credentials := NewHTTPCredentials()
+				if err := credentials.Parse(ctx, opts.Get(k)); err != nil {
 					return err
 				}
+				// no credentials, e.g. an explicit null, are left unset
+				// so that requests aren't intercepted for nothing.
+				if len(credentials) == 0 {
+					credentials = nil
+				}
 				b.HttpCredentials = credentials
 			case "ignoreHTTPSErrors":
 				b.IgnoreHTTPSErrors = opts.Get(k).ToBoolean()
@@ -116,14 +194,36 @@ func (b *BrowserContextOptions) Parse(ctx context.Context, opts goja.Value) erro
 				b.JavaScriptEnabled = opts.Get(k).ToBoolean()
 			case "locale":
 				b.Locale = opts.Get(k).String()
+			case "networkIdleTimeout":
+				d, err := parseNetworkIdleTimeout(opts.Get(k))
+				if err != nil {
+					return err
+				}
+				b.NetworkIdleTimeout = d
 			case "offline":
 				b.Offline = opts.Get(k).ToBoolean()
+			case "orientation":
+				o, err := parseScreenOrientation(opts.Get(k).String())
+				if err != nil {
+					return err
+				}
+				b.Orientation = o
 			case "permissions":
 				if ps, ok := opts.Get(k).Export().([]interface{}); ok {
 					for _, p := range ps {
 						b.Permissions = append(b.Permissions, fmt.Sprintf("%v", p))
 					}
 				}
+			case "recordHar":
+				// accept both a path and a {path} object.
+				v := opts.Get(k)
+				if obj, ok := v.(*goja.Object); ok {
+					v = obj.Get("path")
+				}
+				if !gojaValueExists(v) || v.String() == "" {
+					return errors.New("recordHar requires a path")
+				}
+				b.RecordHar = v.String()
 			case "reducedMotion":
 				switch ReducedMotion(opts.Get(k).String()) {
 				case "reduce":
@@ -137,6 +237,12 @@ func (b *BrowserContextOptions) Parse(ctx context.Context, opts goja.Value) erro
 					return err
 				}
 				b.Screen = screen
+			case "storageState":
+				state, err := parseStorageState(opts.Get(k))
+				if err != nil {
+					return fmt.Errorf("parsing storageState: %w", err)
+				}
+				b.StorageState = state
 			case "timezoneID":
 				b.TimezoneID = opts.Get(k).String()
 			case "userAgent":
@@ -152,3 +258,35 @@ func (b *BrowserContextOptions) Parse(ctx context.Context, opts goja.Value) erro
 	}
 	return nil
 }
+
+// parseBrowserMetrics parses the browser metrics to emit. It can be either
+// true or false to enable or disable all of them, or the names of the
+// metrics to enable.
+func parseBrowserMetrics(v goja.Value) ([]string, error) {
+	switch e := v.Export().(type) {
+	case bool:
+		if e {
+			return append([]string{}, browserMetricNames...), nil
+		}
+		return []string{}, nil
+	case []interface{}:
+		ms := make([]string, 0, len(e))
+		for _, m := range e {
+			name := fmt.Sprintf("%v", m)
+			if !stringSliceContains(browserMetricNames, name) {
+				return nil, fmt.Errorf("unknown browser metric %q; must be one of: %s",
+					name, strings.Join(browserMetricNames, ", "))
+			}
+			ms = append(ms, name)
+		}
+		return ms, nil
+	default:
+		return nil, fmt.Errorf("browserMetrics must be a boolean or an array of metric names, got %T", e)
+	}
+}
+
+// isBrowserMetricEnabled returns true if the browser should emit
+// the metric with the given name.
+func (b *BrowserContextOptions) isBrowserMetricEnabled(name string) bool {
+	return stringSliceContains(b.BrowserMetrics, name)
+}
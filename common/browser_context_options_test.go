@@ -2,10 +2,12 @@ package common
 
 import (
 	"testing"
+	"time"
 
 	"github.com/grafana/xk6-browser/k6ext/k6test"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestBrowserContextOptionsPermissions(t *testing.T) {
@@ -21,3 +23,114 @@ func TestBrowserContextOptionsPermissions(t *testing.T) {
 	assert.Len(t, opts.Permissions, 2)
 	assert.Equal(t, opts.Permissions, []string{"camera", "microphone"})
 }
+
+func TestBrowserContextOptionsNetworkIdleTimeout(t *testing.T) {
+	t.Parallel()
+
+	vu := k6test.NewVU(t)
+	opts := NewBrowserContextOptions()
+	assert.Zero(t, opts.NetworkIdleTimeout)
+
+	err := opts.Parse(vu.Context(), vu.ToGojaValue((map[string]interface{}{
+		"networkIdleTimeout": 250,
+	})))
+	require.NoError(t, err)
+	assert.Equal(t, 250*time.Millisecond, opts.NetworkIdleTimeout)
+
+	err = opts.Parse(vu.Context(), vu.ToGojaValue((map[string]interface{}{
+		"networkIdleTimeout": -250,
+	})))
+	assert.Error(t, err)
+}
+
+func TestBrowserContextOptionsBrowserMetrics(t *testing.T) {
+	t.Parallel()
+
+	vu := k6test.NewVU(t)
+	opts := NewBrowserContextOptions()
+	assert.True(t, opts.isBrowserMetricEnabled("browser_loaded"))
+	assert.True(t, opts.isBrowserMetricEnabled("browser_first_paint"))
+
+	err := opts.Parse(vu.Context(), vu.ToGojaValue((map[string]interface{}{
+		"browserMetrics": false,
+	})))
+	require.NoError(t, err)
+	assert.False(t, opts.isBrowserMetricEnabled("browser_loaded"))
+
+	err = opts.Parse(vu.Context(), vu.ToGojaValue((map[string]interface{}{
+		"browserMetrics": []interface{}{"browser_loaded"},
+	})))
+	require.NoError(t, err)
+	assert.True(t, opts.isBrowserMetricEnabled("browser_loaded"))
+	assert.False(t, opts.isBrowserMetricEnabled("browser_first_paint"))
+
+	err = opts.Parse(vu.Context(), vu.ToGojaValue((map[string]interface{}{
+		"browserMetrics": []interface{}{"browser_unknown"},
+	})))
+	assert.Error(t, err)
+}
+
+func TestBrowserContextOptionsHTTPCredentialsNull(t *testing.T) {
+	t.Parallel()
+
+	vu := k6test.NewVU(t)
+	opts := NewBrowserContextOptions()
+	err := opts.Parse(vu.Context(), vu.ToGojaValue((map[string]interface{}{
+		"httpCredentials": nil,
+	})))
+	require.NoError(t, err)
+	assert.Nil(t, opts.HttpCredentials)
+}
+
+func TestBrowserContextOptionsBeforeUnloadDialog(t *testing.T) {
+	t.Parallel()
+
+	vu := k6test.NewVU(t)
+	opts := NewBrowserContextOptions()
+	assert.Empty(t, opts.BeforeUnloadDialog)
+
+	err := opts.Parse(vu.Context(), vu.ToGojaValue((map[string]interface{}{
+		"beforeUnloadDialog": "dismiss",
+	})))
+	require.NoError(t, err)
+	assert.Equal(t, BeforeUnloadDialogDismiss, opts.BeforeUnloadDialog)
+
+	err = opts.Parse(vu.Context(), vu.ToGojaValue((map[string]interface{}{
+		"beforeUnloadDialog": "ignore",
+	})))
+	assert.Error(t, err)
+}
+
+func TestBrowserContextOptionsForcedColors(t *testing.T) {
+	t.Parallel()
+
+	vu := k6test.NewVU(t)
+	opts := NewBrowserContextOptions()
+	assert.Empty(t, opts.ForcedColors, "should fall back to the system setting")
+
+	err := opts.Parse(vu.Context(), vu.ToGojaValue((map[string]interface{}{
+		"forcedColors": "active",
+	})))
+	require.NoError(t, err)
+	assert.Equal(t, ForcedColorsActive, opts.ForcedColors)
+}
+
+func TestBrowserContextOptionsOrientation(t *testing.T) {
+	t.Parallel()
+
+	vu := k6test.NewVU(t)
+	opts := NewBrowserContextOptions()
+	assert.Empty(t, opts.Orientation, "should be derived from the viewport")
+
+	err := opts.Parse(vu.Context(), vu.ToGojaValue((map[string]interface{}{
+		"orientation": "landscape-secondary",
+	})))
+	require.NoError(t, err)
+	assert.Equal(t, ScreenOrientationLandscapeSecondary, opts.Orientation)
+
+	err = opts.Parse(vu.Context(), vu.ToGojaValue((map[string]interface{}{
+		"orientation": "sideways",
+	})))
+	assert.EqualError(t, err, `invalid screen orientation "sideways", must be one of: `+
+		`portrait-primary, portrait-secondary, landscape-primary, landscape-secondary`)
+}
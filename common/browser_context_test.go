@@ -0,0 +1,49 @@
+package common
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cdpbrowser "github.com/chromedp/cdproto/browser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBrowserContextInitDownloadsWithoutAcceptDownloads(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "downloads")
+	// the context has no browser, so any CDP call would panic.
+	bctx := &BrowserContext{
+		ctx: context.Background(),
+		opts: &BrowserContextOptions{
+			AcceptDownloads: false,
+			DownloadsPath:   dir,
+		},
+	}
+	require.NoError(t, bctx.initDownloads())
+	assert.Nil(t, bctx.downloadsDir)
+
+	_, err := os.Stat(dir)
+	assert.True(t, os.IsNotExist(err), "should not create the downloads directory")
+
+	require.NoError(t, bctx.cleanupDownloads())
+}
+
+func TestParsePermissions(t *testing.T) {
+	t.Parallel()
+
+	perms, err := parsePermissions([]string{"geolocation", "notifications", "accelerometer"})
+	require.NoError(t, err)
+	assert.Equal(t, []cdpbrowser.PermissionType{
+		cdpbrowser.PermissionTypeGeolocation,
+		cdpbrowser.PermissionTypeNotifications,
+		cdpbrowser.PermissionTypeSensors,
+	}, perms)
+
+	_, err = parsePermissions([]string{"geolocation", "teleport"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown permission "teleport"`)
+}
@@ -0,0 +1,119 @@
+/*
+ *
+ * xk6-browser - a browser automation extension for k6
+ * Copyright (C) 2022 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package common
+
+import (
+	"strings"
+
+	"github.com/grafana/xk6-browser/api"
+
+	cdpruntime "github.com/chromedp/cdproto/runtime"
+)
+
+// Ensure ConsoleMessage implements the api.ConsoleMessage interface.
+var _ api.ConsoleMessage = &ConsoleMessage{}
+
+// ConsoleMessage is a message logged to the browser console.
+type ConsoleMessage struct {
+	typ      string
+	text     string
+	args     []api.JSHandle
+	location *api.ConsoleMessageLocation
+
+	// argsUsed is set once the arguments are handed out, after which
+	// they're no longer released when the handlers return.
+	argsUsed bool
+}
+
+// NewConsoleMessage creates a new console message from a console API call
+// whose arguments are the args handles.
+func NewConsoleMessage(event *cdpruntime.EventConsoleAPICalled, args []api.JSHandle) *ConsoleMessage {
+	m := ConsoleMessage{
+		typ:      event.Type.String(),
+		text:     consoleMessageText(event.Args),
+		args:     args,
+		location: &api.ConsoleMessageLocation{},
+	}
+	if st := event.StackTrace; st != nil && len(st.CallFrames) > 0 {
+		cf := st.CallFrames[0]
+		m.location.URL = cf.URL
+		m.location.LineNumber = cf.LineNumber
+		m.location.ColumnNumber = cf.ColumnNumber
+	}
+	return &m
+}
+
+// Args returns the handles of the arguments passed to the console API call.
+func (m *ConsoleMessage) Args() []api.JSHandle {
+	m.argsUsed = true
+	return m.args
+}
+
+// argsAccessed returns true if the arguments were handed out.
+// It must only be called from the VU goroutine, like Args.
+func (m *ConsoleMessage) argsAccessed() bool {
+	return m.argsUsed
+}
+
+// Location returns where in the page source the message was logged.
+func (m *ConsoleMessage) Location() *api.ConsoleMessageLocation {
+	return m.location
+}
+
+// Text returns the text of the message.
+func (m *ConsoleMessage) Text() string {
+	return m.text
+}
+
+// Type returns the type of the console API call, e.g. log, warning or error.
+func (m *ConsoleMessage) Type() string {
+	return m.typ
+}
+
+// consoleMessageText joins the text representations of the console
+// API call arguments with spaces, like the browser console does.
+func consoleMessageText(args []*cdpruntime.RemoteObject) string {
+	texts := make([]string, 0, len(args))
+	for _, arg := range args {
+		texts = append(texts, remoteObjectText(arg))
+	}
+	return strings.Join(texts, " ")
+}
+
+func remoteObjectText(obj *cdpruntime.RemoteObject) string {
+	switch {
+	case obj.Type == cdpruntime.TypeString:
+		if v, err := parseRemoteObject(obj); err == nil {
+			if s, ok := v.(string); ok {
+				return s
+			}
+		}
+		return string(obj.Value)
+	case obj.UnserializableValue != "":
+		return obj.UnserializableValue.String()
+	case len(obj.Value) > 0:
+		return string(obj.Value)
+	case obj.Description != "":
+		return obj.Description
+	default:
+		return obj.Type.String()
+	}
+}
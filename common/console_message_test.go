@@ -0,0 +1,51 @@
+/*
+ *
+ * xk6-browser - a browser automation extension for k6
+ * Copyright (C) 2022 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package common
+
+import (
+	"testing"
+
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsoleMessageText(t *testing.T) {
+	t.Parallel()
+
+	args := []*runtime.RemoteObject{
+		{Type: runtime.TypeString, Value: []byte(`"hello"`)},
+		{Type: runtime.TypeNumber, Value: []byte(`42`)},
+		{Type: runtime.TypeNumber, UnserializableValue: "NaN"},
+		{Type: runtime.TypeObject, ObjectID: "1", Description: "Object"},
+		{Type: runtime.TypeUndefined},
+	}
+	assert.Equal(t, "hello 42 NaN Object undefined", consoleMessageText(args))
+}
+
+func TestConsoleMessageArgsAccessed(t *testing.T) {
+	t.Parallel()
+
+	m := NewConsoleMessage(&runtime.EventConsoleAPICalled{Type: runtime.APITypeLog}, nil)
+	assert.False(t, m.argsAccessed())
+
+	m.Args()
+	assert.True(t, m.argsAccessed())
+}
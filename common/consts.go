@@ -33,4 +33,13 @@ const (
 	// Life-cycle consts
 
 	LifeCycleNetworkIdleTimeout time.Duration = 500 * time.Millisecond
+
+	// Soft navigation consts
+
+	// SoftNavigationSettleWindow is how long after a same-document
+	// navigation QuerySettled waits for the DOM to settle.
+	SoftNavigationSettleWindow time.Duration = time.Second
+	// SoftNavigationSettleQuiet is how long the DOM must go without changes
+	// to be considered settled.
+	SoftNavigationSettleQuiet time.Duration = 100 * time.Millisecond
 )
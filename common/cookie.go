@@ -0,0 +1,209 @@
+/*
+ *
+ * xk6-browser - a browser automation extension for k6
+ * Copyright (C) 2022 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/grafana/xk6-browser/api"
+	"github.com/grafana/xk6-browser/k6ext"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/dop251/goja"
+)
+
+var errCookieNoName = errors.New("cookie should have a name")
+
+// parseCookies parses an array of cookies to add to a browser context.
+// Each cookie should have either a url, or a domain and a path.
+func parseCookies(ctx context.Context, cookies goja.Value) ([]*network.CookieParam, error) {
+	if cookies == nil || goja.IsUndefined(cookies) || goja.IsNull(cookies) {
+		return nil, nil
+	}
+	rt := k6ext.Runtime(ctx)
+
+	var items []goja.Value
+	if err := rt.ExportTo(cookies, &items); err != nil {
+		return nil, fmt.Errorf("cookies should be an array: %w", err)
+	}
+	params := make([]*network.CookieParam, 0, len(items))
+	for i, item := range items {
+		if item == nil || goja.IsUndefined(item) || goja.IsNull(item) {
+			return nil, fmt.Errorf("cookies[%d]: expected object, got %v", i, item)
+		}
+		c, err := parseCookie(rt, item.ToObject(rt))
+		if err != nil {
+			return nil, fmt.Errorf("cookies[%d]: %w", i, err)
+		}
+		params = append(params, c)
+	}
+	return params, nil
+}
+
+func parseCookie(rt *goja.Runtime, cookie *goja.Object) (*network.CookieParam, error) {
+	c := network.CookieParam{}
+	for _, k := range cookie.Keys() {
+		v := cookie.Get(k)
+		switch k {
+		case "name":
+			c.Name = v.String()
+		case "value":
+			c.Value = v.String()
+		case "url":
+			c.URL = v.String()
+		case "domain":
+			c.Domain = v.String()
+		case "path":
+			c.Path = v.String()
+		case "expires":
+			// a negative expiry means a session cookie.
+			if e := v.ToFloat(); e >= 0 {
+				c.Expires = cookieExpires(e)
+			}
+		case "httpOnly":
+			c.HTTPOnly = v.ToBoolean()
+		case "secure":
+			c.Secure = v.ToBoolean()
+		case "sameSite":
+			switch ss := network.CookieSameSite(v.String()); ss {
+			case network.CookieSameSiteStrict, network.CookieSameSiteLax, network.CookieSameSiteNone:
+				c.SameSite = ss
+			default:
+				return nil, fmt.Errorf("invalid sameSite %q: must be one of: Strict, Lax, None", ss)
+			}
+		}
+	}
+
+	if c.Name == "" {
+		return nil, errCookieNoName
+	}
+	switch {
+	case c.URL != "" && (c.Domain != "" || c.Path != ""):
+		return nil, fmt.Errorf("cookie %q should have either a url or a domain and path, not both", c.Name)
+	case c.URL == "" && (c.Domain == "" || c.Path == ""):
+		return nil, fmt.Errorf("cookie %q should have either a url or a domain and path", c.Name)
+	case c.URL != "":
+		u, err := url.Parse(c.URL)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("cookie %q has an invalid url %q", c.Name, c.URL)
+		}
+	}
+	return &c, nil
+}
+
+// cookieExpires converts the expiry of a cookie in seconds since the UNIX
+// epoch to a CDP time.
+func cookieExpires(e float64) *cdp.TimeSinceEpoch {
+	sec, frac := math.Modf(e)
+	t := cdp.TimeSinceEpoch(time.Unix(int64(sec), int64(frac*float64(time.Second))))
+	return &t
+}
+
+// filterCookies returns the cookies that would be sent to any of the urls.
+// It returns all the cookies if there are no urls.
+func filterCookies(cookies []*network.Cookie, urls []string) ([]*network.Cookie, error) {
+	if len(urls) == 0 {
+		return cookies, nil
+	}
+	parsed := make([]*url.URL, 0, len(urls))
+	for _, s := range urls {
+		u, err := url.Parse(s)
+		if err != nil {
+			return nil, fmt.Errorf("parsing url %q: %w", s, err)
+		}
+		parsed = append(parsed, u)
+	}
+
+	var filtered []*network.Cookie
+	for _, c := range cookies {
+		for _, u := range parsed {
+			if cookieMatchesURL(c, u) {
+				filtered = append(filtered, c)
+				break
+			}
+		}
+	}
+	return filtered, nil
+}
+
+func cookieMatchesURL(c *network.Cookie, u *url.URL) bool {
+	// a domain without a leading dot means a host-only cookie.
+	if strings.HasPrefix(c.Domain, ".") {
+		if !strings.HasSuffix("."+u.Hostname(), c.Domain) {
+			return false
+		}
+	} else if u.Hostname() != c.Domain {
+		return false
+	}
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	if !cookiePathMatches(c.Path, path) {
+		return false
+	}
+	if c.Secure && u.Scheme != "https" && !isLoopbackHost(u.Hostname()) {
+		return false
+	}
+	return true
+}
+
+// cookiePathMatches returns true if the request path path-matches the
+// cookie path as defined in RFC 6265 section 5.1.4.
+func cookiePathMatches(cookiePath, path string) bool {
+	if !strings.HasPrefix(path, cookiePath) {
+		return false
+	}
+	return len(path) == len(cookiePath) ||
+		strings.HasSuffix(cookiePath, "/") ||
+		path[len(cookiePath)] == '/'
+}
+
+// isLoopbackHost returns true if the host is a loopback host, which the
+// browser considers a secure origin even without https.
+func isLoopbackHost(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
+
+// toAPICookie converts a CDP cookie to the cookie returned to scripts.
+func toAPICookie(c *network.Cookie) *api.Cookie {
+	expires := c.Expires
+	if c.Session {
+		expires = -1
+	}
+	return &api.Cookie{
+		Name:     c.Name,
+		Value:    c.Value,
+		Domain:   c.Domain,
+		Path:     c.Path,
+		Expires:  expires,
+		HTTPOnly: c.HTTPOnly,
+		Secure:   c.Secure,
+		SameSite: c.SameSite.String(),
+	}
+}
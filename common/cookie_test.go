@@ -0,0 +1,132 @@
+/*
+ *
+ * xk6-browser - a browser automation extension for k6
+ * Copyright (C) 2022 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package common
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/grafana/xk6-browser/k6ext/k6test"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCookies(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ok", func(t *testing.T) {
+		t.Parallel()
+
+		vu := k6test.NewVU(t)
+		cookies, err := parseCookies(vu.Context(), vu.ToGojaValue([]map[string]interface{}{
+			{"name": "a", "value": "1", "url": "https://example.com/"},
+			{"name": "b", "value": "2", "domain": ".example.com", "path": "/", "expires": 1.5, "sameSite": "Lax"},
+			{"name": "c", "value": "3", "domain": "example.com", "path": "/", "expires": -1},
+		}))
+		require.NoError(t, err)
+		require.Len(t, cookies, 3)
+
+		assert.Equal(t, "https://example.com/", cookies[0].URL)
+		assert.Equal(t, ".example.com", cookies[1].Domain)
+		assert.Equal(t, network.CookieSameSiteLax, cookies[1].SameSite)
+		require.NotNil(t, cookies[1].Expires)
+		assert.Equal(t, int64(1500), cookies[1].Expires.Time().UnixMilli())
+		assert.Nil(t, cookies[2].Expires, "should be a session cookie")
+	})
+
+	for name, cookie := range map[string]map[string]interface{}{
+		"no_name":        {"value": "1", "url": "https://example.com"},
+		"no_url_domain":  {"name": "a", "value": "1"},
+		"no_path":        {"name": "a", "value": "1", "domain": "example.com"},
+		"url_and_domain": {"name": "a", "value": "1", "url": "https://example.com", "domain": "example.com"},
+		"invalid_url":    {"name": "a", "value": "1", "url": "example"},
+		"invalid_same":   {"name": "a", "value": "1", "url": "https://example.com", "sameSite": "Sometimes"},
+	} {
+		cookie := cookie
+		t.Run("err/"+name, func(t *testing.T) {
+			t.Parallel()
+
+			vu := k6test.NewVU(t)
+			_, err := parseCookies(vu.Context(), vu.ToGojaValue([]map[string]interface{}{cookie}))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestFilterCookies(t *testing.T) {
+	t.Parallel()
+
+	cookies := []*network.Cookie{
+		{Name: "host", Domain: "example.com", Path: "/"},
+		{Name: "sub", Domain: ".example.com", Path: "/"},
+		{Name: "path", Domain: "example.com", Path: "/admin"},
+		{Name: "secure", Domain: "example.com", Path: "/", Secure: true},
+		{Name: "other", Domain: "other.com", Path: "/"},
+	}
+	names := func(urls ...string) []string {
+		filtered, err := filterCookies(cookies, urls)
+		require.NoError(t, err)
+		var names []string
+		for _, c := range filtered {
+			names = append(names, c.Name)
+		}
+		return names
+	}
+
+	assert.Len(t, names(), len(cookies))
+	assert.Equal(t, []string{"host", "sub"}, names("http://example.com"))
+	assert.Equal(t, []string{"host", "sub", "path", "secure"}, names("https://example.com/admin/users"))
+	assert.Equal(t, []string{"sub"}, names("http://www.example.com/"))
+	assert.Equal(t, []string{"sub", "other"}, names("http://www.example.com/", "http://other.com"))
+}
+
+func TestCookieMatchesURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		cookie network.Cookie
+		url    string
+		want   bool
+	}{
+		{"exact_path", network.Cookie{Domain: "example.com", Path: "/admin"}, "http://example.com/admin", true},
+		{"sub_path", network.Cookie{Domain: "example.com", Path: "/admin"}, "http://example.com/admin/users", true},
+		{"path_prefix", network.Cookie{Domain: "example.com", Path: "/admin"}, "http://example.com/administrator", false},
+		{"path_slash", network.Cookie{Domain: "example.com", Path: "/admin/"}, "http://example.com/admin/users", true},
+		{"secure_https", network.Cookie{Domain: "example.com", Path: "/", Secure: true}, "https://example.com", true},
+		{"secure_http", network.Cookie{Domain: "example.com", Path: "/", Secure: true}, "http://example.com", false},
+		{"secure_localhost", network.Cookie{Domain: "localhost", Path: "/", Secure: true}, "http://localhost", true},
+		{"secure_ipv4_loopback", network.Cookie{Domain: "127.0.0.1", Path: "/", Secure: true}, "http://127.0.0.1:8080", true},
+		{"secure_ipv6_loopback", network.Cookie{Domain: "::1", Path: "/", Secure: true}, "http://[::1]:8080", true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			u, err := url.Parse(tt.url)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cookieMatchesURL(&tt.cookie, u))
+		})
+	}
+}
@@ -0,0 +1,206 @@
+/*
+ *
+ * xk6-browser - a browser automation extension for k6
+ * Copyright (C) 2022 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/grafana/xk6-browser/api"
+	"github.com/grafana/xk6-browser/k6ext"
+	"github.com/grafana/xk6-browser/log"
+
+	"github.com/dop251/goja"
+)
+
+// Ensure Download implements the api.Download interface.
+var _ api.Download = &Download{}
+
+var (
+	errDownloadCanceled      = errors.New("download canceled")
+	errDownloadContextClosed = errors.New("browser context closed before the download finished")
+)
+
+// Download is a file download started by a page. The browser saves the
+// file to the downloads directory of the browser context, named after
+// the GUID of the download.
+type Download struct {
+	ctx               context.Context
+	logger            *log.Logger
+	page              *Page
+	guid              string
+	url               string
+	suggestedFilename string
+	path              string
+
+	done     chan struct{}
+	doneOnce sync.Once
+	err      error
+}
+
+// NewDownload creates a new download that is saved to the dir.
+func NewDownload(
+	ctx context.Context, p *Page, guid, url, suggestedFilename, dir string, l *log.Logger,
+) *Download {
+	return &Download{
+		ctx:               ctx,
+		logger:            l,
+		page:              p,
+		guid:              guid,
+		url:               url,
+		suggestedFilename: suggestedFilename,
+		path:              filepath.Join(dir, guid),
+		done:              make(chan struct{}),
+	}
+}
+
+// Delete deletes the downloaded file after waiting for the download to finish.
+func (d *Download) Delete(opts goja.Value) {
+	d.logger.Debugf("Download:Delete", "guid:%s", d.guid)
+
+	if err := d.wait(opts); err != nil {
+		if errors.Is(err, ErrTimedOut) {
+			k6ext.Panic(d.ctx, "download %q: %w", d.suggestedFilename, err)
+		}
+		return
+	}
+	if err := os.Remove(d.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		k6ext.Panic(d.ctx, "deleting download %q: %w", d.suggestedFilename, err)
+	}
+}
+
+// Failure waits for the download to finish and returns the reason it
+// failed, or an empty string if it succeeded.
+func (d *Download) Failure(opts goja.Value) string {
+	if err := d.wait(opts); err != nil {
+		if errors.Is(err, ErrTimedOut) {
+			k6ext.Panic(d.ctx, "download %q: %w", d.suggestedFilename, err)
+		}
+		return err.Error()
+	}
+	return ""
+}
+
+// Page returns the page that started the download.
+func (d *Download) Page() api.Page {
+	return d.page
+}
+
+// Path waits for the download to finish and returns the path of the downloaded file.
+func (d *Download) Path(opts goja.Value) string {
+	d.logger.Debugf("Download:Path", "guid:%s", d.guid)
+
+	if err := d.wait(opts); err != nil {
+		k6ext.Panic(d.ctx, "download %q: %w", d.suggestedFilename, err)
+	}
+	return d.path
+}
+
+// SaveAs waits for the download to finish and copies the downloaded file to the path.
+func (d *Download) SaveAs(path string, opts goja.Value) {
+	d.logger.Debugf("Download:SaveAs", "guid:%s path:%q", d.guid, path)
+
+	if err := d.wait(opts); err != nil {
+		k6ext.Panic(d.ctx, "download %q: %w", d.suggestedFilename, err)
+	}
+	if err := copyFile(d.path, path); err != nil {
+		k6ext.Panic(d.ctx, "saving download %q: %w", d.suggestedFilename, err)
+	}
+}
+
+// SuggestedFilename returns the file name suggested by the browser for the download.
+func (d *Download) SuggestedFilename() string {
+	return d.suggestedFilename
+}
+
+// URL returns the URL of the downloaded resource.
+func (d *Download) URL() string {
+	return d.url
+}
+
+// finish marks the download as finished. The err is nil if the download
+// completed successfully. Only the first call has an effect.
+func (d *Download) finish(err error) {
+	d.doneOnce.Do(func() {
+		d.err = err
+		close(d.done)
+	})
+}
+
+func (d *Download) finished() bool {
+	select {
+	case <-d.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// wait waits for the download to finish, for at most the timeout in opts.
+// It defaults to the default timeout of the page.
+func (d *Download) wait(opts goja.Value) error {
+	wopts := NewDownloadWaitOptions(d.page.defaultTimeout())
+	if err := wopts.Parse(d.ctx, opts); err != nil {
+		k6ext.Panic(d.ctx, "parsing download options: %w", err)
+	}
+
+	var timeout <-chan time.Time
+	if wopts.Timeout > 0 {
+		t := time.NewTimer(wopts.Timeout)
+		defer t.Stop()
+		timeout = t.C
+	}
+	select {
+	case <-d.done:
+		return d.err
+	case <-d.ctx.Done():
+		return d.ctx.Err()
+	case <-timeout:
+		return fmt.Errorf("waiting for the download to finish: %w after %s", ErrTimedOut, wopts.Timeout)
+	}
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src) //nolint:gosec
+	if err != nil {
+		return fmt.Errorf("opening %q: %w", src, err)
+	}
+	defer func() { _ = in.Close() }()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil { //nolint:gomnd
+		return fmt.Errorf("creating the directory of %q: %w", dst, err)
+	}
+	out, err := os.Create(dst) //nolint:gosec
+	if err != nil {
+		return fmt.Errorf("creating %q: %w", dst, err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return fmt.Errorf("copying to %q: %w", dst, err)
+	}
+	return out.Close()
+}
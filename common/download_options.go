@@ -0,0 +1,59 @@
+/*
+ *
+ * xk6-browser - a browser automation extension for k6
+ * Copyright (C) 2022 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package common
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/xk6-browser/k6ext"
+
+	"github.com/dop251/goja"
+)
+
+// DownloadWaitOptions are the options of the Download methods that wait
+// for the download to finish.
+type DownloadWaitOptions struct {
+	// Timeout is the maximum time to wait for the download to finish.
+	// A zero timeout disables the timeout.
+	Timeout time.Duration `json:"timeout"`
+}
+
+// NewDownloadWaitOptions returns the default DownloadWaitOptions.
+func NewDownloadWaitOptions(defaultTimeout time.Duration) *DownloadWaitOptions {
+	return &DownloadWaitOptions{
+		Timeout: defaultTimeout,
+	}
+}
+
+// Parse parses the DownloadWaitOptions from a goja value.
+func (o *DownloadWaitOptions) Parse(ctx context.Context, opts goja.Value) error {
+	rt := k6ext.Runtime(ctx)
+	if opts != nil && !goja.IsUndefined(opts) && !goja.IsNull(opts) {
+		opts := opts.ToObject(rt)
+		for _, k := range opts.Keys() {
+			if k == "timeout" {
+				o.Timeout = time.Duration(opts.Get(k).ToInteger()) * time.Millisecond
+			}
+		}
+	}
+	return nil
+}
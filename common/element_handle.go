@@ -6,8 +6,10 @@ import (
 	"fmt"
 	"math"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf16"
 
 	"github.com/grafana/xk6-browser/api"
 	"github.com/grafana/xk6-browser/common/js"
@@ -117,7 +119,7 @@ func (h *ElementHandle) checkHitTargetAt(apiCtx context.Context, point Position)
 	return true, nil
 }
 
-func (h *ElementHandle) checkElementState(_ context.Context, state string) (*bool, error) {
+func (h *ElementHandle) checkElementState(apiCtx context.Context, state string) (*bool, error) {
 	fn := `
 		(node, injected, state) => {
 			return injected.checkElementState(node, state);
@@ -127,7 +129,7 @@ func (h *ElementHandle) checkElementState(_ context.Context, state string) (*boo
 		forceCallable: true,
 		returnByValue: true,
 	}
-	result, err := h.evalWithScript(h.ctx, opts, fn, state)
+	result, err := h.evalWithScript(apiCtx, opts, fn, state)
 	if err != nil {
 		return nil, err
 	}
@@ -288,6 +290,34 @@ func (h *ElementHandle) fill(_ context.Context, value string) error {
 	return nil
 }
 
+func (h *ElementHandle) setInputFiles(apiCtx context.Context, files []*InputFile) error {
+	fn := `
+		(node, injected, files) => {
+			return injected.setInputFiles(node, files);
+		}
+	`
+	opts := evalOptions{
+		forceCallable: true,
+		returnByValue: true,
+	}
+	if files == nil {
+		files = []*InputFile{}
+	}
+	result, err := h.evalWithScript(apiCtx, opts, fn, files)
+	if err != nil {
+		return err
+	}
+	v, ok := result.(goja.Value)
+	if !ok {
+		return fmt.Errorf("unexpected type %T", result)
+	}
+	if s := v.String(); s != resultDone {
+		return errorFromDOMError(s)
+	}
+
+	return nil
+}
+
 func (h *ElementHandle) focus(apiCtx context.Context, resetSelectionIfNotFocused bool) error {
 	fn := `
 		(node, injected, resetSelectionIfNotFocused) => {
@@ -354,13 +384,46 @@ func (h *ElementHandle) innerText(apiCtx context.Context) (interface{}, error) {
 	return h.eval(apiCtx, opts, js)
 }
 
+// scopedInnerText returns the inner text of the elements matching the
+// selector within the element, or of the element itself if the selector
+// is nil. A positive maxDepth leaves out the text of the elements nested
+// deeper than that below them.
+func (h *ElementHandle) scopedInnerText(apiCtx context.Context, selector *Selector, maxDepth int64) (interface{}, error) {
+	fn := `
+		(node, injected, selector, maxDepth) => {
+			return injected.innerText(node, selector, maxDepth);
+		}
+	`
+	opts := evalOptions{
+		forceCallable: true,
+		returnByValue: true,
+	}
+	return h.evalWithScript(apiCtx, opts, fn, selector, maxDepth)
+}
+
+// inputValue returns the value of an <input>, <textarea> or <select>
+// element, the values of the selected options of a multi-select joined
+// with commas, or the text content of a contenteditable element.
+//
+// Since InputValue returns a string, the joined values of a multi-select
+// are ambiguous when an option value contains a comma. Use evaluate on the
+// selectedOptions of the element to read such values.
 func (h *ElementHandle) inputValue(apiCtx context.Context) (interface{}, error) {
 	js := `
 		(element) => {
-			if (element.nodeType !== Node.ELEMENT_NODE || (element.nodeName !== 'INPUT' && element.nodeName !== 'TEXTAREA' && element.nodeName !== 'SELECT')) {
-        			throw Error('Node is not an <input>, <textarea> or <select> element');
+			if (element.nodeType !== Node.ELEMENT_NODE) {
+				throw Error('Node is not an <input>, <textarea>, <select> or contenteditable element');
+			}
+			if (element.nodeName === 'SELECT' && element.multiple) {
+				return Array.from(element.selectedOptions).map(o => o.value).join(',');
+			}
+			if (element.nodeName === 'INPUT' || element.nodeName === 'TEXTAREA' || element.nodeName === 'SELECT') {
+				return element.value;
 			}
-			return element.value;
+			if (element.isContentEditable) {
+				return element.textContent;
+			}
+			throw Error('Node is not an <input>, <textarea>, <select> or contenteditable element');
 		}
 	`
 	opts := evalOptions{
@@ -370,28 +433,38 @@ func (h *ElementHandle) inputValue(apiCtx context.Context) (interface{}, error)
 	return h.eval(apiCtx, opts, js)
 }
 
-func (h *ElementHandle) isChecked(apiCtx context.Context, timeout time.Duration) (bool, error) {
-	return h.waitForElementState(apiCtx, []string{"checked"}, timeout)
+// isChecked and the other element state helpers check the state of the
+// element once, without waiting for it.
+func (h *ElementHandle) isChecked(apiCtx context.Context) (bool, error) {
+	return h.isElementState(apiCtx, "checked")
+}
+
+func (h *ElementHandle) isDisabled(apiCtx context.Context) (bool, error) {
+	return h.isElementState(apiCtx, "disabled")
 }
 
-func (h *ElementHandle) isDisabled(apiCtx context.Context, timeout time.Duration) (bool, error) {
-	return h.waitForElementState(apiCtx, []string{"disabled"}, timeout)
+func (h *ElementHandle) isEditable(apiCtx context.Context) (bool, error) {
+	return h.isElementState(apiCtx, "editable")
 }
 
-func (h *ElementHandle) isEditable(apiCtx context.Context, timeout time.Duration) (bool, error) {
-	return h.waitForElementState(apiCtx, []string{"editable"}, timeout)
+func (h *ElementHandle) isEnabled(apiCtx context.Context) (bool, error) {
+	return h.isElementState(apiCtx, "enabled")
 }
 
-func (h *ElementHandle) isEnabled(apiCtx context.Context, timeout time.Duration) (bool, error) {
-	return h.waitForElementState(apiCtx, []string{"enabled"}, timeout)
+func (h *ElementHandle) isHidden(apiCtx context.Context) (bool, error) {
+	return h.isElementState(apiCtx, "hidden")
 }
 
-func (h *ElementHandle) isHidden(apiCtx context.Context, timeout time.Duration) (bool, error) {
-	return h.waitForElementState(apiCtx, []string{"hidden"}, timeout)
+func (h *ElementHandle) isVisible(apiCtx context.Context) (bool, error) {
+	return h.isElementState(apiCtx, "visible")
 }
 
-func (h *ElementHandle) isVisible(apiCtx context.Context, timeout time.Duration) (bool, error) {
-	return h.waitForElementState(apiCtx, []string{"visible"}, timeout)
+func (h *ElementHandle) isElementState(apiCtx context.Context, state string) (bool, error) {
+	v, err := h.checkElementState(apiCtx, state)
+	if err != nil {
+		return false, err
+	}
+	return *v, nil
 }
 
 func (h *ElementHandle) offsetPosition(apiCtx context.Context, offset *Position) (*Position, error) {
@@ -621,11 +694,19 @@ func (h *ElementHandle) textContent(apiCtx context.Context) (interface{}, error)
 	return h.eval(apiCtx, opts, js)
 }
 
-func (h *ElementHandle) typ(apiCtx context.Context, text string, opts *KeyboardOptions) error {
+// typ focuses the element and types the text into it. With respectMaxLength,
+// the text is cut at the number of characters the maxlength of the element
+// still allows, so that no key is pressed past it.
+func (h *ElementHandle) typ(apiCtx context.Context, text string, opts *KeyboardOptions, respectMaxLength bool) error {
 	err := h.focus(apiCtx, true)
 	if err != nil {
 		return err
 	}
+	if respectMaxLength {
+		if text, err = h.truncateToMaxLength(apiCtx, text); err != nil {
+			return err
+		}
+	}
 	err = h.frame.page.Keyboard.typ(text, opts)
 	if err != nil {
 		return err
@@ -633,6 +714,48 @@ func (h *ElementHandle) typ(apiCtx context.Context, text string, opts *KeyboardO
 	return nil
 }
 
+// truncateToMaxLength returns the beginning of the text that fits in the
+// room the maxlength of the focused input or textarea leaves, counting the
+// selected text that typing replaces. The text is returned as is if the
+// element has no maxlength.
+func (h *ElementHandle) truncateToMaxLength(apiCtx context.Context, text string) (string, error) {
+	fn := `
+		(element) => {
+			if (!(element instanceof HTMLInputElement || element instanceof HTMLTextAreaElement) ||
+				element.maxLength < 0) {
+				return -1;
+			}
+			// the selection is null for the input types that don't support it.
+			const selected = (element.selectionEnd || 0) - (element.selectionStart || 0);
+			return Math.max(0, element.maxLength - element.value.length + selected);
+		}
+	`
+	opts := evalOptions{
+		forceCallable: true,
+		returnByValue: true,
+	}
+	result, err := h.eval(apiCtx, opts, fn)
+	if err != nil {
+		return "", fmt.Errorf("getting maxlength: %w", err)
+	}
+	v, ok := result.(goja.Value)
+	if !ok {
+		return "", fmt.Errorf("unexpected type %T", result)
+	}
+	room := v.ToInteger()
+	if room < 0 {
+		return text, nil
+	}
+	// maxlength counts UTF-16 code units, like the length of a JS string.
+	var n int64
+	for i, r := range text {
+		if n += int64(utf16.RuneLen(r)); n > room {
+			return text[:i], nil
+		}
+	}
+	return text, nil
+}
+
 func (h *ElementHandle) waitAndScrollIntoViewIfNeeded(apiCtx context.Context, force, noWaitAfter bool, timeout time.Duration) error {
 	fn := func(apiCtx context.Context, handle *ElementHandle) (interface{}, error) {
 		fn := `
@@ -667,7 +790,7 @@ func (h *ElementHandle) waitForElementState(
 		forceCallable: true,
 		returnByValue: true,
 	}
-	result, err := h.evalWithScript(apiCtx, opts, fn, states, timeout.Milliseconds())
+	result, err := h.evalWithScript(apiCtx, opts, fn, states, injectedTimeout(timeout))
 	if err != nil {
 		return false, errorFromDOMError(err.Error())
 	}
@@ -696,20 +819,27 @@ func (h *ElementHandle) waitForSelector(apiCtx context.Context, selector string,
 		return nil, err
 	}
 	fn := `
-		(node, injected, selector, strict, state, timeout, ...args) => {
-			return injected.waitForSelector(selector, node, strict, state, 'raf', timeout, ...args);
+		(node, injected, selector, strict, state, timeout, waitID) => {
+			return injected.waitForSelector(selector, node, strict, state, 'raf', timeout, waitID);
 		}
 	`
 	eopts := evalOptions{
 		forceCallable: true,
 		returnByValue: false,
 	}
+	waitID := h.execCtx.nextWaitID()
 	result, err := h.evalWithScript(
 		apiCtx,
 		eopts, fn, parsedSelector,
-		opts.Strict, opts.State.String(), opts.Timeout.Milliseconds(),
+		opts.Strict, opts.stateArg(), injectedTimeout(opts.Timeout), waitID,
 	)
 	if err != nil {
+		if apiCtx.Err() != nil {
+			h.cancelWait(waitID)
+		}
+		if strings.HasPrefix(err.Error(), "error:") {
+			return nil, errorFromDOMError(err.Error())
+		}
 		return nil, err
 	}
 	switch r := result.(type) {
@@ -720,6 +850,120 @@ func (h *ElementHandle) waitForSelector(apiCtx context.Context, selector string,
 	}
 }
 
+// waitForCount waits until the selector matches count elements within the
+// element.
+func (h *ElementHandle) waitForCount(
+	apiCtx context.Context, selector string, count int64, timeout time.Duration,
+) error {
+	parsedSelector, err := NewSelector(selector)
+	if err != nil {
+		return err
+	}
+	fn := `
+		(node, injected, selector, count, timeout, waitID) => {
+			return injected.waitForCount(selector, node, count, 'raf', timeout, waitID);
+		}
+	`
+	eopts := evalOptions{
+		forceCallable: true,
+		returnByValue: true,
+	}
+	waitID := h.execCtx.nextWaitID()
+	result, err := h.evalWithScript(apiCtx, eopts, fn, parsedSelector, count, injectedTimeout(timeout), waitID)
+	if err != nil {
+		if apiCtx.Err() != nil {
+			h.cancelWait(waitID)
+		}
+		if strings.HasPrefix(err.Error(), "error:") {
+			return errorFromDOMError(err.Error())
+		}
+		return err
+	}
+	if s, ok := result.(string); ok { // An error happened (returned as "error:..." from JS)
+		return errorFromDOMError(s)
+	}
+	return nil
+}
+
+// waitForAnySelector waits for the first of the selectors to reach the state
+// in opts, and returns the matching element and the index of the selector.
+func (h *ElementHandle) waitForAnySelector(
+	apiCtx context.Context, selectors []string, opts *FrameWaitForSelectorOptions,
+) (*ElementHandle, int, error) {
+	parsedSelectors := make([]*Selector, 0, len(selectors))
+	for _, selector := range selectors {
+		parsedSelector, err := NewSelector(selector)
+		if err != nil {
+			return nil, -1, err
+		}
+		parsedSelectors = append(parsedSelectors, parsedSelector)
+	}
+	fn := `
+		(node, injected, selectors, strict, state, timeout, waitID) => {
+			return injected.waitForAnySelector(selectors, node, strict, state, 'raf', timeout, waitID);
+		}
+	`
+	eopts := evalOptions{
+		forceCallable: true,
+		returnByValue: false,
+	}
+	waitID := h.execCtx.nextWaitID()
+	result, err := h.evalWithScript(
+		apiCtx,
+		eopts, fn, parsedSelectors,
+		opts.Strict, opts.stateArg(), injectedTimeout(opts.Timeout), waitID,
+	)
+	if err != nil {
+		if apiCtx.Err() != nil {
+			h.cancelWait(waitID)
+		}
+		if strings.HasPrefix(err.Error(), "error:") {
+			return nil, -1, errorFromDOMError(err.Error())
+		}
+		return nil, -1, err
+	}
+	match, ok := result.(jsHandle)
+	if !ok {
+		return nil, -1, fmt.Errorf("unexpected type %T", result)
+	}
+	defer func() { _ = match.dispose() }()
+
+	props, err := match.getProperties()
+	if err != nil {
+		return nil, -1, err
+	}
+	index, ok := props["index"]
+	if !ok {
+		return nil, -1, errors.New("missing the index of the matching selector")
+	}
+	i := int(index.JSONValue().ToInteger())
+	_ = index.dispose()
+
+	handle, _ := props["element"].(*ElementHandle)
+	if handle == nil && props["element"] != nil {
+		_ = props["element"].dispose()
+	}
+
+	return handle, i, nil
+}
+
+// cancelWait stops the in-page wait with the waitID. It's used to stop the
+// polling in the page when the wait is abandoned before it finishes.
+func (h *ElementHandle) cancelWait(waitID int64) {
+	fn := `
+		(node, injected, waitID) => {
+			injected.cancelWait(waitID);
+		}
+	`
+	opts := evalOptions{
+		forceCallable: true,
+		returnByValue: true,
+	}
+	if _, err := h.evalWithScript(h.ctx, opts, fn, waitID); err != nil {
+		h.logger.Debugf("ElementHandle:cancelWait", "waitID:%d err:%v", waitID, err)
+	}
+}
+
 // AsElement returns this element handle.
 func (h *ElementHandle) AsElement() api.ElementHandle {
 	return h
@@ -913,8 +1157,8 @@ func (h *ElementHandle) InputValue(opts goja.Value) string {
 
 // IsChecked checks if a checkbox or radio is checked.
 func (h *ElementHandle) IsChecked() bool {
-	result, err := h.isChecked(h.ctx, 0)
-	if err != nil && err != ErrTimedOut { // We don't care anout timeout errors here!
+	result, err := h.isChecked(h.ctx)
+	if err != nil {
 		k6ext.Panic(h.ctx, "element isChecked: %w", err)
 	}
 	return result
@@ -922,8 +1166,8 @@ func (h *ElementHandle) IsChecked() bool {
 
 // IsDisabled checks if the element is disabled.
 func (h *ElementHandle) IsDisabled() bool {
-	result, err := h.isDisabled(h.ctx, 0)
-	if err != nil && err != ErrTimedOut { // We don't care anout timeout errors here!
+	result, err := h.isDisabled(h.ctx)
+	if err != nil {
 		k6ext.Panic(h.ctx, "element isDisabled: %w", err)
 	}
 	return result
@@ -931,8 +1175,8 @@ func (h *ElementHandle) IsDisabled() bool {
 
 // IsEditable checks if the element is editable.
 func (h *ElementHandle) IsEditable() bool {
-	result, err := h.isEditable(h.ctx, 0)
-	if err != nil && err != ErrTimedOut { // We don't care anout timeout errors here!
+	result, err := h.isEditable(h.ctx)
+	if err != nil {
 		k6ext.Panic(h.ctx, "element isEditable: %w", err)
 	}
 	return result
@@ -940,8 +1184,8 @@ func (h *ElementHandle) IsEditable() bool {
 
 // IsEnabled checks if the element is enabled.
 func (h *ElementHandle) IsEnabled() bool {
-	result, err := h.isEnabled(h.ctx, 0)
-	if err != nil && err != ErrTimedOut { // We don't care anout timeout errors here!
+	result, err := h.isEnabled(h.ctx)
+	if err != nil {
 		k6ext.Panic(h.ctx, "element isEnabled: %w", err)
 	}
 	return result
@@ -949,8 +1193,8 @@ func (h *ElementHandle) IsEnabled() bool {
 
 // IsHidden checks if the element is hidden.
 func (h *ElementHandle) IsHidden() bool {
-	result, err := h.isHidden(h.ctx, 0)
-	if err != nil && err != ErrTimedOut { // We don't care anout timeout errors here!
+	result, err := h.isHidden(h.ctx)
+	if err != nil {
 		k6ext.Panic(h.ctx, "element isHidden: %w", err)
 	}
 	return result
@@ -958,8 +1202,8 @@ func (h *ElementHandle) IsHidden() bool {
 
 // IsVisible checks if the element is visible.
 func (h *ElementHandle) IsVisible() bool {
-	result, err := h.isVisible(h.ctx, 0)
-	if err != nil && err != ErrTimedOut { // We don't care anout timeout errors here!
+	result, err := h.isVisible(h.ctx)
+	if err != nil {
 		k6ext.Panic(h.ctx, "element isVisible: %w", err)
 	}
 	return result
@@ -1021,9 +1265,23 @@ func (h *ElementHandle) Press(key string, opts goja.Value) {
 // Query runs "element.querySelector" within the page. If no element matches the selector,
 // the return value resolves to "null".
 func (h *ElementHandle) Query(selector string) api.ElementHandle {
+	element, err := h.query(h.ctx, selector)
+	if err != nil {
+		k6ext.Panic(h.ctx, "%w", err)
+	}
+	applySlowMo(h.ctx)
+	if element == nil {
+		return nil
+	}
+	return element
+}
+
+// query is like Query but returns an error instead of throwing,
+// and doesn't apply slow motion. It returns nil if no element matches.
+func (h *ElementHandle) query(apiCtx context.Context, selector string) (*ElementHandle, error) {
 	parsedSelector, err := NewSelector(selector)
 	if err != nil {
-		k6ext.Panic(h.ctx, "parsing selector %q: %w", selector, err)
+		return nil, fmt.Errorf("parsing selector %q: %w", selector, err)
 	}
 	fn := `
 		(node, injected, selector) => {
@@ -1034,24 +1292,23 @@ func (h *ElementHandle) Query(selector string) api.ElementHandle {
 		forceCallable: true,
 		returnByValue: false,
 	}
-	result, err := h.evalWithScript(h.ctx, opts, fn, parsedSelector)
+	result, err := h.evalWithScript(apiCtx, opts, fn, parsedSelector)
 	if err != nil {
-		k6ext.Panic(h.ctx, "querying selector %q: %w", selector, err)
+		return nil, fmt.Errorf("querying selector %q: %w", selector, err)
 	}
 	if result == nil {
-		return nil
+		return nil, nil
 	}
 
-	var (
-		handle  = result.(api.JSHandle)
-		element = handle.AsElement()
-	)
-	applySlowMo(h.ctx)
-	if element != nil {
-		return element
+	handle, ok := result.(jsHandle)
+	if !ok {
+		return nil, fmt.Errorf("querying selector %q: %w", selector, ErrJSHandleInvalid)
+	}
+	if element, ok := handle.AsElement().(*ElementHandle); ok && element != nil {
+		return element, nil
 	}
 	handle.Dispose()
-	return nil
+	return nil, nil
 }
 
 // QueryAll queries element subtree for matching elements.
@@ -1137,6 +1394,10 @@ func (h *ElementHandle) Check(opts goja.Value) {
 	h.SetChecked(true, opts)
 }
 
+// checkedStatePollInterval is how often setChecked checks whether the
+// element reached the checked state after clicking it.
+const checkedStatePollInterval = 50 * time.Millisecond
+
 func (h *ElementHandle) setChecked(apiCtx context.Context, checked bool, p *Position) error {
 	state, err := h.checkElementState(apiCtx, "checked")
 	if err != nil {
@@ -1151,15 +1412,22 @@ func (h *ElementHandle) setChecked(apiCtx context.Context, checked bool, p *Posi
 		return err
 	}
 
-	state, err = h.checkElementState(apiCtx, "checked")
-	if err != nil {
-		return err
-	}
-	if checked != *state {
-		return errors.New("clicking the checkbox did not change its state")
+	// custom components may update their state asynchronously after the
+	// click, so the state is checked until it changes or the action times out.
+	for {
+		state, err = h.checkElementState(apiCtx, "checked")
+		if err != nil {
+			return err
+		}
+		if checked == *state {
+			return nil
+		}
+		select {
+		case <-apiCtx.Done():
+			return errors.New("clicking the checkbox did not change its state")
+		case <-time.After(checkedStatePollInterval):
+		}
 	}
-
-	return nil
 }
 
 func (h *ElementHandle) Screenshot(opts goja.Value) goja.ArrayBuffer {
@@ -1229,9 +1497,56 @@ func (h *ElementHandle) SelectText(opts goja.Value) {
 	applySlowMo(h.ctx)
 }
 
+// SetInputFiles sets the files of the file input element. The files are
+// either paths, or objects with the name, mimeType and buffer of the file.
 func (h *ElementHandle) SetInputFiles(files goja.Value, opts goja.Value) {
-	// TODO: implement
-	k6ext.Panic(h.ctx, "ElementHandle.setInputFiles() has not been implemented yet")
+	actionOpts := NewElementHandleBaseOptions(h.defaultTimeout())
+	if err := actionOpts.Parse(h.ctx, opts); err != nil {
+		k6ext.Panic(h.ctx, "parsing setInputFiles options: %w", err)
+	}
+	parsedFiles, err := parseInputFiles(h.ctx, files)
+	if err != nil {
+		k6ext.Panic(h.ctx, "parsing setInputFiles files: %w", err)
+	}
+	fn := func(apiCtx context.Context, handle *ElementHandle) (interface{}, error) {
+		return nil, handle.setInputFiles(apiCtx, parsedFiles)
+	}
+	actFn := h.newAction([]string{}, fn, actionOpts.Force, actionOpts.NoWaitAfter, actionOpts.Timeout)
+	if _, err := callApiWithTimeout(h.ctx, actFn, actionOpts.Timeout); err != nil {
+		k6ext.Panic(h.ctx, "setting input files: %w", err)
+	}
+	applySlowMo(h.ctx)
+}
+
+// ShadowRoot returns a handle to the element's open shadow root, or nil
+// if the element has no shadow root or it's closed.
+// The returned handle can be used to query elements within the shadow root.
+func (h *ElementHandle) ShadowRoot() api.ElementHandle {
+	fn := `
+		(node) => {
+			return node.shadowRoot;
+		}
+	`
+	opts := evalOptions{
+		forceCallable: true,
+		returnByValue: false,
+	}
+	result, err := h.eval(h.ctx, opts, fn)
+	if err != nil {
+		k6ext.Panic(h.ctx, "getting shadow root: %w", err)
+	}
+	if result == nil {
+		return nil
+	}
+	handle, ok := result.(api.JSHandle)
+	if !ok {
+		k6ext.Panic(h.ctx, "unexpected type %T", result)
+	}
+	if element := handle.AsElement(); element != nil {
+		return element
+	}
+	handle.Dispose()
+	return nil
 }
 
 func (h *ElementHandle) Tap(opts goja.Value) {
@@ -1240,6 +1555,9 @@ func (h *ElementHandle) Tap(opts goja.Value) {
 	if err != nil {
 		k6ext.Panic(h.ctx, "parsing tap options: %w", err)
 	}
+	if !h.frame.page.hasTouch() {
+		k6ext.Panic(h.ctx, "tapping element: %w", ErrTouchDisabled)
+	}
 
 	fn := func(apiCtx context.Context, handle *ElementHandle, p *Position) (interface{}, error) {
 		return nil, handle.tap(apiCtx, p)
@@ -1274,7 +1592,7 @@ func (h *ElementHandle) Type(text string, opts goja.Value) {
 		k6ext.Panic(h.ctx, "parsing type options: %v", err)
 	}
 	fn := func(apiCtx context.Context, handle *ElementHandle) (interface{}, error) {
-		return nil, handle.typ(apiCtx, text, NewKeyboardOptions())
+		return nil, handle.typ(apiCtx, text, NewKeyboardOptions(), parsedOpts.RespectMaxLength)
 	}
 	actFn := h.newAction([]string{}, fn, false, parsedOpts.NoWaitAfter, parsedOpts.Timeout)
 	_, err := callApiWithTimeout(h.ctx, actFn, parsedOpts.Timeout)
@@ -1349,7 +1667,7 @@ func (h *ElementHandle) newAction(
 	actionFn := func(apiCtx context.Context) (interface{}, error) {
 		// Check if we should run actionability checks
 		if !force {
-			if _, err := h.waitForElementState(apiCtx, states, timeout); err != nil {
+			if _, err := h.waitForElementState(apiCtx, states, remainingTimeout(apiCtx, timeout)); err != nil {
 				return nil, err
 			}
 		}
@@ -1395,7 +1713,7 @@ func (h *ElementHandle) newPointerAction(
 		// Check if we should run actionability checks
 		if !opts.Force {
 			states := []string{"visible", "stable", "enabled"}
-			if _, err = h.waitForElementState(apiCtx, states, opts.Timeout); err != nil {
+			if _, err = h.waitForElementState(apiCtx, states, remainingTimeout(apiCtx, opts.Timeout)); err != nil {
 				return nil, fmt.Errorf("waiting for element state: %w", err)
 			}
 		}
@@ -1461,9 +1779,15 @@ func (h *ElementHandle) newPointerAction(
 	}
 
 	return func(apiCtx context.Context, resultCh chan interface{}, errCh chan error) {
-		if res, err := retryPointerAction(apiCtx, pointerFn, opts); err != nil {
+		res, err := retryPointerAction(apiCtx, pointerFn, opts)
+		switch {
+		case err != nil && opts.AllowTargetClose && isTargetClosedError(err):
+			// the action most likely navigated away or closed the page,
+			// which the caller expects.
+			resultCh <- nil
+		case err != nil:
 			errCh <- err
-		} else {
+		default:
 			resultCh <- res
 		}
 	}
@@ -1497,11 +1821,26 @@ func errorFromDOMError(derr string) error {
 	if strings.Contains(derr, "timed out") {
 		return ErrTimedOut
 	}
+	if isTargetClosedError(errors.New(derr)) {
+		return newTargetClosedError(derr)
+	}
 	if s := "error:expectednode:"; strings.HasPrefix(derr, s) {
 		return fmt.Errorf("expected node but got %s", strings.TrimPrefix(derr, s))
 	}
+	if s := "error:strictmodeviolation:"; strings.HasPrefix(derr, s) {
+		// the error is in the form of "error:strictmodeviolation:count:selector".
+		parts := strings.SplitN(strings.TrimPrefix(derr, s), ":", 2)
+		if count, err := strconv.Atoi(parts[0]); err == nil && len(parts) == 2 {
+			return StrictModeViolationError{Selector: parts[1], Count: count}
+		}
+	}
+	switch derr {
+	case "error:notconnected":
+		return ErrElementNotAttached
+	case "error:intercept":
+		return ErrElementIntercepted
+	}
 	errs := map[string]string{
-		"error:notconnected":           "element is not attached to the DOM",
 		"error:notelement":             "node is not an element",
 		"error:nothtmlelement":         "not an HTMLElement",
 		"error:notfillableelement":     "element is not an <input>, <textarea> or [contenteditable] element",
@@ -1509,14 +1848,13 @@ func errorFromDOMError(derr string) error {
 		"error:notfillablenumberinput": "cannot type text into input[type=number]",
 		"error:notvaliddate":           "malformed value",
 		"error:notinput":               "node is not an HTMLInputElement",
+		"error:notfileinput":           "node is not an input[type=file] element",
 		"error:hasnovalue":             "node is not an HTMLInputElement or HTMLTextAreaElement or HTMLSelectElement",
 		"error:notselect":              "element is not a <select> element",
 		"error:notcheckbox":            "not a checkbox or radio button",
 		"error:notmultiplefileinput":   "non-multiple file input can only accept single file",
-		"error:strictmodeviolation":    "strict mode violation, multiple elements returned for selector query",
 		"error:notqueryablenode":       "node is not queryable",
 		"error:nthnocapture":           "can't query n-th element in a chained selector with capture",
-		"error:intercept":              "another element is intercepting with pointer action",
 	}
 	if err, ok := errs[derr]; ok {
 		return errors.New(err)
@@ -31,15 +31,27 @@ import (
 )
 
 type ElementHandleBaseOptions struct {
-	Force       bool          `json:"force"`
-	NoWaitAfter bool          `json:"noWaitAfter"`
-	Timeout     time.Duration `json:"timeout"`
+	Force       bool `json:"force"`
+	NoWaitAfter bool `json:"noWaitAfter"`
+	// Timeout is the maximum time to wait for the action.
+	// A zero timeout disables the timeout.
+	// The deadline option shortens it to the time left before the
+	// deadline, so that a sequence of actions can share one budget.
+	Timeout time.Duration `json:"timeout"`
+	// Retries is how many times a frame action resolves the selector
+	// and runs again after a recoverable failure, such as the element
+	// getting detached. All the attempts share the timeout.
+	Retries int64 `json:"retries"`
 }
 
 type ElementHandleBasePointerOptions struct {
 	ElementHandleBaseOptions
 	Position *Position `json:"position"`
 	Trial    bool      `json:"trial"`
+	// AllowTargetClose makes a pointer action succeed, instead of failing
+	// with a TargetClosedError, when the page closes while it runs, e.g.
+	// when the action is expected to navigate away or close a popup.
+	AllowTargetClose bool `json:"allowTargetClose"`
 }
 
 // ScrollPosition is a parameter for scrolling an element.
@@ -98,14 +110,18 @@ type ElementHandlePressOptions struct {
 	Delay       int64         `json:"delay"`
 	NoWaitAfter bool          `json:"noWaitAfter"`
 	Timeout     time.Duration `json:"timeout"`
+	// Retries is only used by the frame action, see ElementHandleBaseOptions.
+	Retries int64 `json:"retries"`
 }
 
 type ElementHandleScreenshotOptions struct {
-	Path           string        `json:"path"`
-	Format         ImageFormat   `json:"format"`
-	OmitBackground bool          `json:"omitBackground"`
-	Quality        int64         `json:"quality"`
-	Timeout        time.Duration `json:"timeout"`
+	Path              string        `json:"path"`
+	Format            ImageFormat   `json:"format"`
+	OmitBackground    bool          `json:"omitBackground"`
+	Quality           int64         `json:"quality"`
+	Timeout           time.Duration `json:"timeout"`
+	WaitForFonts      bool          `json:"waitForFonts"`
+	DisableAnimations bool          `json:"disableAnimations"`
 }
 
 type ElementHandleSetCheckedOptions struct {
@@ -122,6 +138,11 @@ type ElementHandleTypeOptions struct {
 	Delay       int64         `json:"delay"`
 	NoWaitAfter bool          `json:"noWaitAfter"`
 	Timeout     time.Duration `json:"timeout"`
+	// RespectMaxLength stops typing at the maxlength of the element,
+	// instead of pressing the keys that the browser ignores.
+	RespectMaxLength bool `json:"respectMaxLength"`
+	// Retries is only used by the frame action, see ElementHandleBaseOptions.
+	Retries int64 `json:"retries"`
 }
 
 type ElementHandleWaitForElementStateOptions struct {
@@ -140,17 +161,29 @@ func (o *ElementHandleBaseOptions) Parse(ctx context.Context, opts goja.Value) e
 	if !gojaValueExists(opts) {
 		return nil
 	}
+	var deadline time.Time
 	gopts := opts.ToObject(k6ext.Runtime(ctx))
 	for _, k := range gopts.Keys() {
 		switch k {
+		case "deadline":
+			var err error
+			if deadline, err = parseDeadline(gopts.Get(k)); err != nil {
+				return err
+			}
 		case "force":
 			o.Force = gopts.Get(k).ToBoolean()
 		case "noWaitAfter": //nolint:goconst
 			o.NoWaitAfter = gopts.Get(k).ToBoolean()
+		case "retries":
+			var err error
+			if o.Retries, err = parseRetries(gopts.Get(k)); err != nil {
+				return err
+			}
 		case "timeout":
 			o.Timeout = time.Duration(gopts.Get(k).ToInteger()) * time.Millisecond
 		}
 	}
+	o.Timeout = timeoutUntil(deadline, o.Timeout)
 
 	return nil
 }
@@ -172,6 +205,8 @@ func (o *ElementHandleBasePointerOptions) Parse(ctx context.Context, opts goja.V
 		opts := opts.ToObject(rt)
 		for _, k := range opts.Keys() {
 			switch k {
+			case "allowTargetClose":
+				o.AllowTargetClose = opts.Get(k).ToBoolean()
 			case "position":
 				var p map[string]float64
 				o.Position = &Position{}
@@ -330,6 +365,11 @@ func (o *ElementHandlePressOptions) Parse(ctx context.Context, opts goja.Value)
 				o.Delay = opts.Get(k).ToInteger()
 			case "noWaitAfter":
 				o.NoWaitAfter = opts.Get(k).ToBoolean()
+			case "retries":
+				var err error
+				if o.Retries, err = parseRetries(opts.Get(k)); err != nil {
+					return err
+				}
 			case "timeout":
 				o.Timeout = time.Duration(opts.Get(k).ToInteger()) * time.Millisecond
 			}
@@ -363,6 +403,8 @@ func (o *ElementHandleScreenshotOptions) Parse(ctx context.Context, opts goja.Va
 		opts := opts.ToObject(rt)
 		for _, k := range opts.Keys() {
 			switch k {
+			case "disableAnimations":
+				o.DisableAnimations = opts.Get(k).ToBoolean()
 			case "omitBackground":
 				o.OmitBackground = opts.Get(k).ToBoolean()
 			case "path":
@@ -376,6 +418,8 @@ func (o *ElementHandleScreenshotOptions) Parse(ctx context.Context, opts goja.Va
 				}
 			case "timeout":
 				o.Timeout = time.Duration(opts.Get(k).ToInteger()) * time.Millisecond
+			case "waitForFonts":
+				o.WaitForFonts = opts.Get(k).ToBoolean()
 			}
 		}
 
@@ -461,6 +505,13 @@ func (o *ElementHandleTypeOptions) Parse(ctx context.Context, opts goja.Value) e
 				o.Delay = opts.Get(k).ToInteger()
 			case "noWaitAfter":
 				o.NoWaitAfter = opts.Get(k).ToBoolean()
+			case "respectMaxLength":
+				o.RespectMaxLength = opts.Get(k).ToBoolean()
+			case "retries":
+				var err error
+				if o.Retries, err = parseRetries(opts.Get(k)); err != nil {
+					return err
+				}
 			case "timeout":
 				o.Timeout = time.Duration(opts.Get(k).ToInteger()) * time.Millisecond
 			}
@@ -19,8 +19,17 @@ func TestErrorFromDOMError(t *testing.T) {
 		want     error
 	}{
 		{in: "timed out", want: ErrTimedOut, sentinel: true},
-		{in: "error:notconnected", want: errors.New("element is not attached to the DOM")},
+		{in: "error:notconnected", want: ErrElementNotAttached, sentinel: true},
+		{in: "error:intercept", want: ErrElementIntercepted, sentinel: true},
 		{in: "error:expectednode:anything", want: errors.New("expected node but got anything")},
+		{
+			in:   "error:strictmodeviolation:3:div >> text=a:b",
+			want: errors.New(`strict mode violation: "div >> text=a:b" resolved to 3 elements`),
+		},
+		{
+			in:   "evaluating pointer action: Target closed",
+			want: errors.New("target closed during the action: evaluating pointer action: Target closed"),
+		},
 		{in: "nonexistent error", want: errors.New("nonexistent error")},
 	} {
 		got := errorFromDOMError(tc.in)
@@ -21,7 +21,9 @@
 package common
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/chromedp/cdproto/runtime"
 )
@@ -38,14 +40,96 @@ func (e Error) Error() string {
 const (
 	ErrUnexpectedRemoteObjectWithID Error = "cannot extract value when remote object ID is given"
 	ErrChannelClosed                Error = "channel closed"
+	ErrElementIntercepted           Error = "another element is intercepting with pointer action"
+	ErrElementNotAttached           Error = "element is not attached to the DOM"
 	ErrFrameDetached                Error = "frame detached"
 	ErrJSHandleDisposed             Error = "JS handle is disposed"
 	ErrJSHandleInvalid              Error = "JS handle is invalid"
+	ErrResponseBodyUnavailable      Error = "response body is no longer available, it may have been evicted from the browser's buffer"
 	ErrTargetCrashed                Error = "Target has crashed"
 	ErrTimedOut                     Error = "timed out"
+	ErrTouchDisabled                Error = "the page does not support tap, set hasTouch: true in the browser context options to enable touch emulation"
 	ErrWrongExecutionContext        Error = "JS handles can be evaluated only in the context they were created"
 )
 
+// StrictModeViolationError is returned when a strict selector
+// resolves to more than one element.
+type StrictModeViolationError struct {
+	Selector string
+	Count    int
+}
+
+// Error satisfies the builtin error interface.
+func (e StrictModeViolationError) Error() string {
+	return fmt.Sprintf("strict mode violation: %q resolved to %d elements", e.Selector, e.Count)
+}
+
+// TargetClosedError is returned when the page, or the browser session
+// driving it, goes away while an action or an evaluation is running. This
+// is usually caused by a navigation or by the page being closed, e.g. a
+// click on a link that closes its own popup.
+type TargetClosedError struct {
+	err error
+}
+
+const targetClosedErrorPrefix = "target closed during the action"
+
+// Error satisfies the builtin error interface.
+func (e TargetClosedError) Error() string {
+	if e.err == nil {
+		return targetClosedErrorPrefix
+	}
+	return fmt.Sprintf("%s: %v", targetClosedErrorPrefix, e.err)
+}
+
+// Is satisfies the builtin error Is interface.
+func (e TargetClosedError) Is(target error) bool {
+	switch target.(type) {
+	case TargetClosedError:
+		return true
+	}
+	return false
+}
+
+// Unwrap satisfies the builtin error Unwrap interface.
+func (e TargetClosedError) Unwrap() error {
+	return e.err
+}
+
+// isTargetClosedError returns true if err is caused by the target or its
+// session closing, as reported by the connection or by the browser.
+func isTargetClosedError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, TargetClosedError{}) || errors.Is(err, ErrChannelClosed) {
+		return true
+	}
+	// the errors are turned to strings at some of the boundaries.
+	msg := err.Error()
+	for _, s := range []string{
+		targetClosedErrorPrefix,
+		ErrChannelClosed.Error(),
+		"Target closed",
+		"Session closed",
+		"Session with given id not found",
+		"No target with given id",
+		"Inspected target navigated or closed",
+	} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// newTargetClosedError returns a TargetClosedError caused by the error
+// message derr, dropping the message of a TargetClosedError it wraps.
+func newTargetClosedError(derr string) TargetClosedError {
+	derr = strings.Replace(derr, targetClosedErrorPrefix+": ", "", 1)
+	return TargetClosedError{err: errors.New(derr)}
+}
+
 type BigIntParseError struct {
 	err error
 }
@@ -77,3 +161,64 @@ type UnserializableValueError struct {
 func (e UnserializableValueError) Error() string {
 	return fmt.Sprintf("unsupported unserializable value: %s", e.UnserializableValue)
 }
+
+// NonSerializableValueError is returned when a page function returns a value
+// that cannot be transferred by value from the browser, such as the window
+// object, a DOM node or a function.
+type NonSerializableValueError struct {
+	// Type describes the returned value, e.g. "function" or "HTMLDivElement".
+	Type string
+}
+
+// Error satisfies the builtin error interface.
+func (e NonSerializableValueError) Error() string {
+	return fmt.Sprintf(
+		"page function returned a non-serializable value of type %s; "+
+			"return a serializable value or use evaluateHandle instead", e.Type)
+}
+
+// Is satisfies the builtin error Is interface.
+func (e NonSerializableValueError) Is(target error) bool {
+	switch target.(type) {
+	case NonSerializableValueError:
+		return true
+	}
+	return false
+}
+
+// NavigationError is returned when a navigation fails with a network error,
+// e.g. net::ERR_NAME_NOT_RESOLVED.
+type NavigationError struct {
+	// Code is the machine-readable reason of the failure without the net::
+	// prefix, e.g. ERR_NAME_NOT_RESOLVED, ERR_CONNECTION_REFUSED or ERR_ABORTED.
+	Code string `js:"code"`
+	// URL is the URL of the failed navigation.
+	URL string `js:"url"`
+	// Text is the error text as reported by the browser.
+	Text string `js:"text"`
+}
+
+func newNavigationError(url, errorText string) NavigationError {
+	code := strings.TrimPrefix(errorText, "net::")
+	if i := strings.IndexFunc(code, func(r rune) bool {
+		return !(r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_')
+	}); i != -1 {
+		code = code[:i]
+	}
+	return NavigationError{Code: code, URL: url, Text: errorText}
+}
+
+// Error satisfies the builtin error interface.
+func (e NavigationError) Error() string {
+	return fmt.Sprintf("%s at %q", e.Text, e.URL)
+}
+
+// Is satisfies the builtin error Is interface.
+// A target without a code matches any navigation error.
+func (e NavigationError) Is(target error) bool {
+	t, ok := target.(NavigationError)
+	if !ok {
+		return false
+	}
+	return t.Code == "" || t.Code == e.Code
+}
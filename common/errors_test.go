@@ -0,0 +1,87 @@
+/*
+ *
+ * xk6-browser - a browser automation extension for k6
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package common
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNavigationError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		errorText, wantCode string
+	}{
+		{"net::ERR_NAME_NOT_RESOLVED", "ERR_NAME_NOT_RESOLVED"},
+		{"net::ERR_CONNECTION_REFUSED", "ERR_CONNECTION_REFUSED"},
+		{"net::ERR_ABORTED; maybe frame was detached?", "ERR_ABORTED"},
+		{"ERR_HTTP2_PROTOCOL_ERROR", "ERR_HTTP2_PROTOCOL_ERROR"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.wantCode, func(t *testing.T) {
+			t.Parallel()
+
+			err := newNavigationError("https://test.k6.io", tt.errorText)
+			assert.Equal(t, tt.wantCode, err.Code)
+			assert.Equal(t, fmt.Sprintf("%s at %q", tt.errorText, "https://test.k6.io"), err.Error())
+
+			wrapped := fmt.Errorf("navigating: %w", err)
+			assert.True(t, errors.Is(wrapped, NavigationError{}))
+			assert.True(t, errors.Is(wrapped, NavigationError{Code: tt.wantCode}))
+			assert.False(t, errors.Is(wrapped, NavigationError{Code: "ERR_UNKNOWN"}))
+		})
+	}
+}
+
+func TestTargetClosedError(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		err  error
+		want bool
+	}{
+		{ErrChannelClosed, true},
+		{fmt.Errorf("evaluating pointer action: %w", ErrChannelClosed), true},
+		{errors.New("Target closed"), true},
+		{errors.New("Inspected target navigated or closed"), true},
+		{errors.New("Session with given id not found: 1A2B"), true},
+		{TargetClosedError{err: errors.New("any")}, true},
+		{ErrTimedOut, false},
+		{errors.New("Execution context was destroyed"), false},
+		{nil, false},
+	} {
+		assert.Equalf(t, tt.want, isTargetClosedError(tt.err), "%v", tt.err)
+	}
+
+	err := TargetClosedError{err: ErrChannelClosed}
+	assert.Equal(t, "target closed during the action: channel closed", err.Error())
+	assert.True(t, errors.Is(fmt.Errorf("clicking: %w", err), TargetClosedError{}))
+	assert.True(t, errors.Is(err, ErrChannelClosed))
+
+	// wrapping the message of a target closed error doesn't repeat it.
+	got := newTargetClosedError("evaluating pointer action: " + err.Error())
+	assert.Equal(t, "target closed during the action: evaluating pointer action: channel closed", got.Error())
+}
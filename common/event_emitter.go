@@ -60,14 +60,17 @@ const (
 	EventPageFrameDetached    string = "framedetached"
 	EventPageFrameNavigated   string = "framenavigated"
 	EventPageLoad             string = "load"
+	EventPageLongTask         string = "longtask"
 	EventPageError            string = "pageerror"
 	EventPagePopup            string = "popup"
 	EventPageRequest          string = "request"
 	EventPageRequestFailed    string = "requestfailed"
 	EventPageRequestFinished  string = "requestfinished"
 	EventPageResponse         string = "response"
+	EventPageSecurity         string = "security"
 	EventPageWebSocket        string = "websocket"
 	EventPageWorker           string = "worker"
+	EventPageWorkerClose      string = "workerclose"
 
 	// Session
 
@@ -21,11 +21,15 @@
 package common
 
 import (
+	"bytes"
 	"context"
 	_ "embed"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"regexp"
+	"strings"
+	"sync/atomic"
 
 	"github.com/grafana/xk6-browser/api"
 	"github.com/grafana/xk6-browser/k6ext"
@@ -45,6 +49,60 @@ const evaluationScriptURL = "__xk6_browser_evaluation_script__"
 
 var sourceURLRegex = regexp.MustCompile(`^(?s)[\040\t]*//[@#] sourceURL=\s*(\S*?)\s*$`)
 
+// largeStringChunkSize is the number of characters of a large string
+// result that are retrieved at a time, so that big results, such as the
+// data URLs of canvases, don't have to fit in a single CDP message.
+const largeStringChunkSize = 1 << 20
+
+// largeStringKey is the key of the map in which the large string results
+// are kept until they're retrieved, and the marker of the result that
+// points to them.
+const largeStringKey = "__xk6_browser_large_strings__"
+
+// largeStringWrapperJS wraps a page function so that it keeps the string
+// results longer than a chunk in the page and returns a reference instead.
+const largeStringWrapperJS = `async function(...args) {
+	const result = await (
+%s
+	).apply(this, args);
+	if (typeof result !== "string" || result.length <= %d) {
+		return result;
+	}
+	const key = Symbol.for(%q);
+	if (!globalThis[key]) {
+		Object.defineProperty(globalThis, key, { value: { nextID: 0, strings: new Map() } });
+	}
+	const store = globalThis[key];
+	const id = ++store.nextID;
+	store.strings.set(id, result);
+	return { [%[3]q]: id };
+}`
+
+// largeStringReleaseJS releases a large string result that isn't read to
+// the end.
+const largeStringReleaseJS = `function(id) {
+	const store = globalThis[Symbol.for(%q)];
+	if (store) {
+		store.strings.delete(id);
+	}
+}`
+
+// largeStringChunkJS returns a chunk of a large string result, without
+// splitting a surrogate pair, and the position of the next chunk. It
+// releases the string once the last chunk is read.
+const largeStringChunkJS = `function(id, start, size) {
+	const store = globalThis[Symbol.for(%q)];
+	const s = store.strings.get(id);
+	let end = Math.min(start + size, s.length);
+	if (end < s.length && /[\uD800-\uDBFF]/.test(s[end - 1])) {
+		end--;
+	}
+	if (end === s.length) {
+		store.strings.delete(id);
+	}
+	return { chunk: s.slice(start, end), end, done: end === s.length };
+}`
+
 type executionWorld string
 
 const (
@@ -58,10 +116,15 @@ func (ew executionWorld) valid() bool {
 
 type evalOptions struct {
 	forceCallable, returnByValue bool
+	// largeStrings retrieves the string results longer than a chunk in
+	// chunks. It's for the evaluations of the scripts, whose results can be
+	// large, e.g. the data URLs of canvases, and needs returnByValue.
+	largeStrings bool
 }
 
 func (ea evalOptions) String() string {
-	return fmt.Sprintf("forceCallable:%t returnByValue:%t", ea.forceCallable, ea.returnByValue)
+	return fmt.Sprintf("forceCallable:%t returnByValue:%t largeStrings:%t",
+		ea.forceCallable, ea.returnByValue, ea.largeStrings)
 }
 
 // ExecutionContext represents a JS execution context.
@@ -74,6 +137,9 @@ type ExecutionContext struct {
 	injectedScript api.JSHandle
 	vu             k6modules.VU
 
+	// lastWaitID is the ID of the last in-page wait started in this context.
+	lastWaitID int64
+
 	// Used for logging
 	sid  target.SessionID // Session ID
 	stid cdp.FrameID      // Session TargetID
@@ -110,6 +176,12 @@ func NewExecutionContext(
 	return e
 }
 
+// nextWaitID returns a new ID for an in-page wait, which can be used to
+// cancel the wait in the injected script.
+func (e *ExecutionContext) nextWaitID() int64 {
+	return atomic.AddInt64(&e.lastWaitID, 1)
+}
+
 // Adopts specified backend node into this execution context from another execution context.
 func (e *ExecutionContext) adoptBackendNodeID(backendNodeID cdp.BackendNodeID) (*ElementHandle, error) {
 	e.logger.Debugf(
@@ -117,6 +189,12 @@ func (e *ExecutionContext) adoptBackendNodeID(backendNodeID cdp.BackendNodeID) (
 		"sid:%s stid:%s fid:%s ectxid:%d furl:%q bnid:%d",
 		e.sid, e.stid, e.fid, e.id, e.furl, backendNodeID)
 
+	// the execution contexts of the workers don't have a frame, and can't
+	// own element handles.
+	if e.frame == nil {
+		return nil, fmt.Errorf("adopting backend node %d: execution context %d has no frame", backendNodeID, e.id)
+	}
+
 	var (
 		remoteObj *runtime.RemoteObject
 		err       error
@@ -185,6 +263,13 @@ func (e *ExecutionContext) eval(
 		Do(context.Context) (*runtime.RemoteObject, *runtime.ExceptionDetails, error)
 	}
 
+	largeStrings := opts.returnByValue && opts.largeStrings
+	if largeStrings && !opts.forceCallable {
+		// the large strings are kept by the wrapper of a page function, so
+		// the expression is evaluated as the result of one.
+		js = "() => (\n" + js + "\n)"
+		opts.forceCallable = true
+	}
 	if !opts.forceCallable {
 		if !sourceURLRegex.Match([]byte(js)) {
 			js += "\n" + suffix
@@ -202,11 +287,14 @@ func (e *ExecutionContext) eval(
 			if err != nil {
 				return nil, fmt.Errorf("converting argument %q "+
 					"in execution context ID %d and frame ID %v: %w",
-					arg, e.id, e.Frame().ID(), err)
+					arg, e.id, e.fid, err)
 			}
 			arguments = append(arguments, result)
 		}
 
+		if largeStrings {
+			js = fmt.Sprintf(largeStringWrapperJS, js, largeStringChunkSize, largeStringKey)
+		}
 		js += "\n" + suffix + "\n"
 		action = runtime.CallFunctionOn(js).
 			WithArguments(arguments).
@@ -222,9 +310,17 @@ func (e *ExecutionContext) eval(
 		err              error
 	)
 	if remoteObject, exceptionDetails, err = action.Do(cdp.WithExecutor(apiCtx, e.session)); err != nil {
+		if isTargetClosedError(err) {
+			return nil, TargetClosedError{err: err}
+		}
 		var cdpe *cdproto.Error
 		if errors.As(err, &cdpe) && cdpe.Code == -32000 {
-			err = fmt.Errorf("execution context with ID %d not found", e.id)
+			switch {
+			case opts.returnByValue && isNonSerializableCDPError(cdpe):
+				err = NonSerializableValueError{Type: "object (cyclic or too deeply nested)"}
+			default:
+				err = fmt.Errorf("execution context with ID %d not found", e.id)
+			}
 		}
 		return nil, err
 	}
@@ -241,6 +337,16 @@ func (e *ExecutionContext) eval(
 	}
 
 	if opts.returnByValue {
+		if typ, ok := nonSerializableValueType(remoteObject); ok {
+			return nil, NonSerializableValueError{Type: typ}
+		}
+		if id, ok := largeStringID(remoteObject); largeStrings && ok {
+			s, err := e.readLargeString(apiCtx, id)
+			if err != nil {
+				return nil, fmt.Errorf("reading large string result: %w", err)
+			}
+			return k6ext.Runtime(apiCtx).ToValue(s), nil
+		}
 		res, err = valueFromRemoteObject(apiCtx, remoteObject)
 		if err != nil {
 			return nil, fmt.Errorf(
@@ -255,6 +361,94 @@ func (e *ExecutionContext) eval(
 	return res, nil
 }
 
+// largeStringID returns the ID of the large string that the result points
+// to, if the result is a reference to a large string.
+func largeStringID(robj *runtime.RemoteObject) (int64, bool) {
+	if robj.Type != runtime.TypeObject || !bytes.Contains(robj.Value, []byte(largeStringKey)) {
+		return 0, false
+	}
+	var ref map[string]int64
+	if err := json.Unmarshal(robj.Value, &ref); err != nil || len(ref) != 1 {
+		return 0, false
+	}
+	id, ok := ref[largeStringKey]
+	return id, ok
+}
+
+// readLargeString retrieves a large string result in chunks. The string
+// is released in the page even if it can't be read to the end.
+func (e *ExecutionContext) readLargeString(apiCtx context.Context, id int64) (_ string, err error) {
+	defer func() {
+		if err != nil {
+			e.releaseLargeString(id)
+		}
+	}()
+
+	var (
+		sb    strings.Builder
+		start int64
+		js    = fmt.Sprintf(largeStringChunkJS, largeStringKey)
+	)
+	for {
+		var arguments []*runtime.CallArgument
+		for _, arg := range []interface{}{id, start, int64(largeStringChunkSize)} {
+			a, err := convertArgument(apiCtx, e, arg)
+			if err != nil {
+				return "", err
+			}
+			arguments = append(arguments, a)
+		}
+		action := runtime.CallFunctionOn(js).
+			WithArguments(arguments).
+			WithExecutionContextID(e.id).
+			WithReturnByValue(true)
+		remoteObject, exceptionDetails, err := action.Do(cdp.WithExecutor(apiCtx, e.session))
+		if err != nil {
+			return "", fmt.Errorf("reading chunk at %d: %w", start, err)
+		}
+		if exceptionDetails != nil {
+			return "", fmt.Errorf("reading chunk at %d: %s", start, parseExceptionDetails(exceptionDetails))
+		}
+		var c struct {
+			Chunk string `json:"chunk"`
+			End   int64  `json:"end"`
+			Done  bool   `json:"done"`
+		}
+		if err := json.Unmarshal(remoteObject.Value, &c); err != nil {
+			return "", fmt.Errorf("parsing chunk at %d: %w", start, err)
+		}
+		sb.WriteString(c.Chunk)
+		if c.Done {
+			return sb.String(), nil
+		}
+		start = c.End
+	}
+}
+
+// releaseLargeString releases the large string with the id in the page. It
+// doesn't use the context of the evaluation, which may be done.
+func (e *ExecutionContext) releaseLargeString(id int64) {
+	arg, err := convertArgument(e.ctx, e, id)
+	if err == nil {
+		action := runtime.CallFunctionOn(fmt.Sprintf(largeStringReleaseJS, largeStringKey)).
+			WithArguments([]*runtime.CallArgument{arg}).
+			WithExecutionContextID(e.id)
+		_, _, err = action.Do(cdp.WithExecutor(e.ctx, e.session))
+	}
+	if err != nil {
+		e.logger.Debugf("ExecutionContext:releaseLargeString",
+			"sid:%s stid:%s fid:%s ectxid:%d furl:%q id:%d err:%v",
+			e.sid, e.stid, e.fid, e.id, e.furl, id, err)
+	}
+}
+
+// isNonSerializableCDPError returns true if the browser couldn't return the
+// evaluation result by value, e.g. when a page function returns the window.
+func isNonSerializableCDPError(err *cdproto.Error) bool {
+	return strings.Contains(err.Message, "Object couldn't be returned by value") ||
+		strings.Contains(err.Message, "Object reference chain is too long")
+}
+
 // Based on: https://github.com/microsoft/playwright/blob/master/src/server/injected/injectedScript.ts
 //go:embed js/injected_script.js
 var injectedScriptSource string
@@ -307,6 +501,7 @@ func (e *ExecutionContext) Eval(
 	opts := evalOptions{
 		forceCallable: true,
 		returnByValue: true,
+		largeStrings:  true,
 	}
 	evalArgs := make([]interface{}, 0, len(args))
 	for _, a := range args {
@@ -0,0 +1,43 @@
+/*
+ *
+ * xk6-browser - a browser automation extension for k6
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/xk6-browser/log"
+
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutionContextWithoutFrame(t *testing.T) {
+	t.Parallel()
+
+	ec := NewExecutionContext(context.Background(), nil, nil, runtime.ExecutionContextID(1), log.NewNullLogger())
+	assert.Nil(t, ec.Frame())
+
+	h, err := ec.adoptBackendNodeID(1)
+	require.EqualError(t, err, "adopting backend node 1: execution context 1 has no frame")
+	assert.Nil(t, h)
+}
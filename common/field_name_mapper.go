@@ -32,8 +32,11 @@ type FieldNameMapper struct {
 }
 
 var methodNameExceptions = map[string]string{
-	"Query":    "$",
-	"QueryAll": "$$",
+	"DOMSnapshot":       "domSnapshot",
+	"EvalOnSelector":    "$eval",
+	"EvalOnSelectorAll": "$$eval",
+	"Query":             "$",
+	"QueryAll":          "$$",
 }
 
 // NewFieldNameMapper creates a new field name mapper to add some method name
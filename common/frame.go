@@ -2,12 +2,16 @@ package common
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
 	"github.com/grafana/xk6-browser/api"
+	"github.com/grafana/xk6-browser/common/js"
 	"github.com/grafana/xk6-browser/k6ext"
 	"github.com/grafana/xk6-browser/log"
 
@@ -16,6 +20,7 @@ import (
 
 	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/cdproto/network"
+	cdppage "github.com/chromedp/cdproto/page"
 	"github.com/chromedp/cdproto/runtime"
 	"github.com/dop251/goja"
 )
@@ -51,6 +56,9 @@ type Frame struct {
 	detached     bool
 	vu           k6modules.VU
 	initTime     time.Time
+	// navigatedWithinDocumentTime is the time of the last same-document
+	// navigation, e.g. a route change of a single page app.
+	navigatedWithinDocumentTime time.Time
 
 	// A life cycle event is only considered triggered for a frame if the entire
 	// frame subtree has also had the life cycle event triggered.
@@ -62,14 +70,38 @@ type Frame struct {
 
 	executionContextMu sync.RWMutex
 	executionContexts  map[executionWorld]frameExecutionContext
+	// executionContextReady channels are closed when the execution
+	// context of their world is set, and removed when it's destroyed.
+	executionContextReady map[executionWorld]chan struct{}
 
 	loadingStartedTime time.Time
 
-	networkIdleCh chan struct{}
+	// networkIdleCancel cancels the only active network idle timer of the
+	// frame. networkIdleGen is incremented whenever the timer is stopped
+	// or restarted, so that a stale timer doesn't fire.
+	networkIdleMu     sync.Mutex
+	networkIdleCancel context.CancelFunc
+	networkIdleGen    uint64
+	// gotoNetworkIdleTimeout overrides the network idle timeout of the
+	// browser context during a navigation, see networkIdleTimeout.
+	gotoNetworkIdleTimeoutMu sync.RWMutex
+	gotoNetworkIdleTimeout   time.Duration
 
 	inflightRequestsMu sync.RWMutex
 	inflightRequests   map[network.RequestID]bool
 
+	// largestContentfulPaint is the latest LCP of the current document,
+	// which is only final once the frame leaves the document.
+	largestContentfulPaintMu sync.Mutex
+	largestContentfulPaint   *float64
+
+	// layoutShift is the CLS of the current document so far, which is the
+	// largest sum of the layout shifts of a session window. layoutShiftWindow
+	// is the session window of the latest layout shifts.
+	layoutShiftMu     sync.Mutex
+	layoutShift       float64
+	layoutShiftWindow layoutShiftWindow
+
 	currentDocument *DocumentInfo
 	pendingDocument *DocumentInfo
 
@@ -105,8 +137,8 @@ func NewFrame(
 		subtreeLifecycleEvents: make(map[LifecycleEvent]bool),
 		inflightRequests:       make(map[network.RequestID]bool),
 		executionContexts:      make(map[executionWorld]frameExecutionContext),
+		executionContextReady:  make(map[executionWorld]chan struct{}),
 		currentDocument:        &DocumentInfo{},
-		networkIdleCh:          make(chan struct{}),
 		log:                    log,
 	}
 }
@@ -186,9 +218,45 @@ func (f *Frame) clearLifecycle() {
 	}
 }
 
+// recalculateLifecycle recalculates the lifecycle events that fired for
+// the entire subtree of the frame. The frames are collected first, and then
+// recalculated bottom-up, so that the children are always up to date before
+// their parent, without recursion and without holding the locks of more
+// than one frame at a time.
 func (f *Frame) recalculateLifecycle() {
 	f.log.Debugf("Frame:recalculateLifecycle", "fid:%s furl:%q", f.ID(), f.URL())
 
+	frames := f.subtreeFrames()
+	for i := len(frames) - 1; i >= 0; i-- {
+		frames[i].recalculateSubtreeLifecycle()
+	}
+}
+
+// subtreeFrames returns the frame and its descendants in breadth-first
+// order, so that every frame comes before its children.
+func (f *Frame) subtreeFrames() []*Frame {
+	var (
+		frames = []*Frame{f}
+		seen   = map[*Frame]bool{f: true}
+	)
+	for i := 0; i < len(frames); i++ {
+		for _, child := range frames[i].ChildFrames() {
+			cf := child.(*Frame)
+			// a precaution for preventing a cycle in the frame tree
+			if seen[cf] {
+				continue
+			}
+			seen[cf] = true
+			frames = append(frames, cf)
+		}
+	}
+	return frames
+}
+
+// recalculateSubtreeLifecycle recalculates the lifecycle events that fired
+// for the subtree of the frame from its own events and the subtree events
+// of its children, which must be recalculated beforehand.
+func (f *Frame) recalculateSubtreeLifecycle() {
 	// Start with triggered events.
 	events := make(map[LifecycleEvent]bool)
 	f.lifecycleEventsMu.RLock()
@@ -200,23 +268,17 @@ func (f *Frame) recalculateLifecycle() {
 	f.lifecycleEventsMu.RUnlock()
 
 	// Only consider a life cycle event as fired if it has triggered for all of subtree.
-	f.childFramesMu.RLock()
-	{
-		for child := range f.childFrames {
-			cf := child.(*Frame)
-			// a precaution for preventing a deadlock in *Frame.childFramesMu
-			if cf == f {
-				continue
-			}
-			cf.recalculateLifecycle()
-			for k := range events {
-				if !cf.hasSubtreeLifecycleEventFired(k) {
-					delete(events, k)
-				}
+	for _, child := range f.ChildFrames() {
+		cf := child.(*Frame)
+		if cf == f {
+			continue
+		}
+		for k := range events {
+			if !cf.hasSubtreeLifecycleEventFired(k) {
+				delete(events, k)
 			}
 		}
 	}
-	f.childFramesMu.RUnlock()
 
 	// Check if any of the fired events should be considered fired when looking at the entire subtree.
 	mainFrame := f.manager.MainFrame()
@@ -261,12 +323,25 @@ func (f *Frame) recalculateLifecycle() {
 func (f *Frame) stopNetworkIdleTimer() {
 	f.log.Debugf("Frame:stopNetworkIdleTimer", "fid:%s furl:%q", f.ID(), f.URL())
 
-	select {
-	case f.networkIdleCh <- struct{}{}:
-	default:
+	f.networkIdleMu.Lock()
+	defer f.networkIdleMu.Unlock()
+
+	f.stopNetworkIdleTimerLocked()
+}
+
+// stopNetworkIdleTimerLocked cancels the active network idle timer, if any,
+// and invalidates it in case it's about to fire. It must be called with
+// networkIdleMu held.
+func (f *Frame) stopNetworkIdleTimerLocked() {
+	if f.networkIdleCancel != nil {
+		f.networkIdleCancel()
+		f.networkIdleCancel = nil
 	}
+	f.networkIdleGen++
 }
 
+// startNetworkIdleTimer replaces the active network idle timer of the frame,
+// so that there is only one timer at a time.
 func (f *Frame) startNetworkIdleTimer() {
 	f.log.Debugf("Frame:startNetworkIdleTimer", "fid:%s furl:%q", f.ID(), f.URL())
 
@@ -274,27 +349,78 @@ func (f *Frame) startNetworkIdleTimer() {
 		return
 	}
 
-	f.stopNetworkIdleTimer()
+	f.networkIdleMu.Lock()
+	f.stopNetworkIdleTimerLocked()
+	ctx, cancel := context.WithCancel(f.ctx)
+	f.networkIdleCancel = cancel
+	gen := f.networkIdleGen
+	f.networkIdleMu.Unlock()
 
 	go func() {
+		// the timeout is looked up here, since the frame manager lock
+		// it needs might be held by the caller.
+		t := time.NewTimer(f.networkIdleTimeout())
+		defer t.Stop()
+
 		select {
-		case <-f.ctx.Done():
-		case <-f.networkIdleCh:
-		case <-time.After(LifeCycleNetworkIdleTimeout):
-			f.manager.frameLifecycleEvent(cdp.FrameID(f.ID()), LifecycleEventNetworkIdle)
+		case <-ctx.Done():
+			return
+		case <-t.C:
 		}
+
+		f.networkIdleMu.Lock()
+		stale := gen != f.networkIdleGen
+		if !stale {
+			f.networkIdleCancel = nil
+		}
+		f.networkIdleMu.Unlock()
+		// the timer might have been stopped or restarted after it fired.
+		if stale {
+			return
+		}
+		cancel()
+		f.manager.frameLifecycleEvent(cdp.FrameID(f.ID()), LifecycleEventNetworkIdle)
 	}()
 }
 
+// networkIdleTimeout returns how long the frame has to be without network
+// requests to reach the networkidle lifecycle state. It's the timeout of the
+// ongoing navigation of the frame, or of its closest parent navigating with
+// one, otherwise the frame manager's.
+//
+// It reads the parent frames under the frame manager lock, so it must not
+// be called with the lock held.
+func (f *Frame) networkIdleTimeout() time.Duration {
+	f.manager.framesMu.RLock()
+	defer f.manager.framesMu.RUnlock()
+
+	for fr := f; fr != nil; fr = fr.parentFrame {
+		fr.gotoNetworkIdleTimeoutMu.RLock()
+		d := fr.gotoNetworkIdleTimeout
+		fr.gotoNetworkIdleTimeoutMu.RUnlock()
+		if d > 0 {
+			return d
+		}
+	}
+	return f.manager.networkIdleTimeout()
+}
+
+func (f *Frame) setGotoNetworkIdleTimeout(d time.Duration) {
+	f.gotoNetworkIdleTimeoutMu.Lock()
+	defer f.gotoNetworkIdleTimeoutMu.Unlock()
+
+	f.gotoNetworkIdleTimeout = d
+}
+
 func (f *Frame) detach() {
 	f.log.Debugf("Frame:detach", "fid:%s furl:%q", f.ID(), f.URL())
 
 	f.stopNetworkIdleTimer()
+	f.flushLargestContentfulPaint()
 	f.setDetached(true)
 	if f.parentFrame != nil {
 		f.parentFrame.removeChildFrame(f)
 	}
-	f.parentFrame = nil
 	// detach() is called by the same frame Goroutine that manages execution
 	// context switches. so this should be safe.
 	// we don't need to protect the following with executionContextMu.
@@ -353,7 +479,21 @@ func (f *Frame) emitMetric(m *k6metrics.Metric, t time.Time) {
 		return
 	}
 
+	f.pushMetric(m, value)
+}
+
+// pushMetric pushes a sample with the value for the metric, tagged with
+// the frame's URL.
+func (f *Frame) pushMetric(m *k6metrics.Metric, value float64) {
+	if !f.page.browserCtx.opts.isBrowserMetricEnabled(m.Name) {
+		return
+	}
+
 	state := f.vu.State()
+	if state == nil {
+		// the iteration has ended.
+		return
+	}
 	tags := state.CloneTags()
 	if state.Options.SystemTags.Has(k6metrics.TagURL) {
 		tags["url"] = f.URL()
@@ -371,6 +511,125 @@ func (f *Frame) emitMetric(m *k6metrics.Metric, t time.Time) {
 	})
 }
 
+// setLargestContentfulPaint records the latest LCP of the current document.
+// It's pushed as a metric once the frame leaves the document.
+func (f *Frame) setLargestContentfulPaint(value float64) {
+	f.largestContentfulPaintMu.Lock()
+	defer f.largestContentfulPaintMu.Unlock()
+
+	f.largestContentfulPaint = &value
+}
+
+// flushLargestContentfulPaint pushes the LCP of the current document, if
+// the document has one. It pushes it only once per document, and must be
+// called before the frame URL changes to the next document.
+func (f *Frame) flushLargestContentfulPaint() {
+	f.largestContentfulPaintMu.Lock()
+	lcp := f.largestContentfulPaint
+	f.largestContentfulPaint = nil
+	f.largestContentfulPaintMu.Unlock()
+
+	if lcp == nil {
+		return
+	}
+	f.pushMetric(k6ext.GetCustomMetrics(f.ctx).BrowserLargestContentfulPaint, *lcp)
+}
+
+// emitDocumentResponseEnd pushes the time from the frame's init to the end
+// of the response of the current document. Together with the load metric,
+// it tells apart the time spent on the network and on rendering.
+func (f *Frame) emitDocumentResponseEnd() {
+	var end time.Time
+	f.manager.framesMu.RLock()
+	if req := f.currentDocument.request; req != nil {
+		end = req.responseEndTime
+	}
+	f.manager.framesMu.RUnlock()
+
+	if end.IsZero() {
+		return
+	}
+	if f.initTime.After(end) {
+		// the document finished downloading before it was committed.
+		f.pushMetric(k6ext.GetCustomMetrics(f.ctx).BrowserDocumentResponseEnd, 0)
+		return
+	}
+	f.emitMetric(k6ext.GetCustomMetrics(f.ctx).BrowserDocumentResponseEnd, end)
+}
+
+// emitTimeToFirstByte pushes the time from sending the request of the
+// document to receiving the first byte of its response. It skips the
+// documents without a request, and the ones served from the cache. The
+// caller must hold the lock of the frame manager, which guards the document.
+func (f *Frame) emitTimeToFirstByte(doc *DocumentInfo) {
+	req := doc.request
+	if req == nil {
+		return
+	}
+	resp := req.response
+	if resp == nil || resp.timing == nil {
+		return
+	}
+	if req.fromMemoryCache || resp.fromDiskCache || resp.fromPrefetchCache {
+		return
+	}
+	ttfb := resp.timing.ReceiveHeadersEnd - resp.timing.SendStart
+	f.pushMetric(k6ext.GetCustomMetrics(f.ctx).BrowserTimeToFirstByte, ttfb)
+}
+
+// Like in web-vitals, the layout shifts are grouped in session windows of
+// the shifts that are less than a second apart, and that span at most five
+// seconds.
+const (
+	layoutShiftWindowGap = 1000 // ms
+	layoutShiftWindowMax = 5000 // ms
+)
+
+// layoutShiftWindow is a session window of layout shifts. The times are the
+// start times of the first and last shifts in the window.
+type layoutShiftWindow struct {
+	value       float64
+	first, last float64
+}
+
+// addLayoutShift adds a layout shift that started at startTime, in ms, to
+// the CLS of the current document.
+func (f *Frame) addLayoutShift(value, startTime float64) {
+	f.layoutShiftMu.Lock()
+	defer f.layoutShiftMu.Unlock()
+
+	w := &f.layoutShiftWindow
+	if w.value > 0 &&
+		startTime-w.last < layoutShiftWindowGap &&
+		startTime-w.first < layoutShiftWindowMax {
+		w.value += value
+		w.last = startTime
+	} else {
+		*w = layoutShiftWindow{value: value, first: startTime, last: startTime}
+	}
+	if w.value > f.layoutShift {
+		f.layoutShift = w.value
+	}
+}
+
+// cumulativeLayoutShift returns the CLS of the current document so far.
+func (f *Frame) cumulativeLayoutShift() float64 {
+	f.layoutShiftMu.Lock()
+	defer f.layoutShiftMu.Unlock()
+
+	return f.layoutShift
+}
+
+// resetLayoutShift starts the CLS over for a new document or, in single
+// page apps, for a new route within the same document.
+func (f *Frame) resetLayoutShift() {
+	f.layoutShiftMu.Lock()
+	defer f.layoutShiftMu.Unlock()
+
+	f.layoutShift = 0
+	f.layoutShiftWindow = layoutShiftWindow{}
+}
+
 func (f *Frame) newDocumentHandle() (*ElementHandle, error) {
 	result, err := f.evaluate(
 		f.ctx,
@@ -419,6 +678,10 @@ func (f *Frame) hasSubtreeLifecycleEventFired(event LifecycleEvent) bool {
 func (f *Frame) navigated(name string, url string, loaderID string) {
 	f.log.Debugf("Frame:navigated", "fid:%s furl:%q lid:%s name:%q url:%q", f.ID(), f.URL(), loaderID, name, url)
 
+	// the LCP of the previous document is final now.
+	f.flushLargestContentfulPaint()
+	f.resetLayoutShift()
+
 	f.propertiesMu.Lock()
 	defer f.propertiesMu.Unlock()
 	f.name = name
@@ -435,11 +698,13 @@ func (f *Frame) nullContext(execCtxID runtime.ExecutionContextID) {
 
 	if ec := f.executionContexts[mainWorld]; ec != nil && ec.ID() == execCtxID {
 		f.executionContexts[mainWorld] = nil
+		delete(f.executionContextReady, mainWorld)
 		f.documentHandle = nil
 		return
 	}
 	if ec := f.executionContexts[utilityWorld]; ec != nil && ec.ID() == execCtxID {
 		f.executionContexts[utilityWorld] = nil
+		delete(f.executionContextReady, utilityWorld)
 	}
 }
 
@@ -496,11 +761,16 @@ func (f *Frame) removeChildFrame(child *Frame) {
 }
 
 func (f *Frame) requestByID(reqID network.RequestID) *Request {
+	return f.networkManager().requestFromID(reqID)
+}
+
+// networkManager returns the network manager of the session of the frame.
+func (f *Frame) networkManager() *NetworkManager {
 	frameSession := f.page.getFrameSession(cdp.FrameID(f.ID()))
 	if frameSession == nil {
 		frameSession = f.page.mainFrameSession
 	}
-	return frameSession.networkManager.requestFromID(reqID)
+	return frameSession.networkManager
 }
 
 func (f *Frame) setContext(world executionWorld, execCtx frameExecutionContext) {
@@ -522,6 +792,9 @@ func (f *Frame) setContext(world executionWorld, execCtx frameExecutionContext)
 	}
 
 	f.executionContexts[world] = execCtx
+	if ready, ok := f.executionContextReady[world]; ok {
+		close(ready)
+	}
 	f.log.Debugf("Frame:setContext", "fid:%s furl:%q ectxid:%d world:%s, world set",
 		f.ID(), f.URL(), execCtx.ID(), world)
 }
@@ -537,17 +810,31 @@ func (f *Frame) waitForExecutionContext(world executionWorld) {
 	f.log.Debugf("Frame:waitForExecutionContext", "fid:%s furl:%q world:%s",
 		f.ID(), f.URL(), world)
 
-	t := time.NewTimer(50 * time.Millisecond)
-	defer t.Stop()
-	for {
-		select {
-		case <-t.C:
-			if f.hasContext(world) {
-				return
-			}
-		case <-f.ctx.Done():
-			return
+	_ = f.executionContextReadyWait(f.ctx, world)
+}
+
+// executionContextReadyWait waits until the frame has an execution context
+// in the given world, or the context is done.
+func (f *Frame) executionContextReadyWait(ctx context.Context, world executionWorld) error {
+	f.executionContextMu.Lock()
+	ready, ok := f.executionContextReady[world]
+	if !ok {
+		if f.executionContextReady == nil {
+			f.executionContextReady = make(map[executionWorld]chan struct{})
+		}
+		ready = make(chan struct{})
+		if f.executionContexts[world] != nil {
+			close(ready)
 		}
+		f.executionContextReady[world] = ready
+	}
+	f.executionContextMu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
 
@@ -574,9 +861,30 @@ func (f *Frame) waitForFunction(
 		return nil, fmt.Errorf("getting injected script: %w", err)
 	}
 
+	// the last value the predicate returned is added to the timeout error,
+	// to tell why the wait didn't finish.
 	pageFn := `
 		(injected, predicate, polling, timeout, ...args) => {
-			return injected.waitForPredicateFunction(predicate, polling, timeout, ...args);
+			let last;
+			const tracked = (...args) => {
+				last = { value: predicate(...args) };
+				return last.value;
+			};
+			const describe = (v) => {
+				if (typeof v === "function") return "a function";
+				let s;
+				try {
+					s = JSON.stringify(v);
+				} catch (e) {}
+				if (s === undefined) s = String(v);
+				return s.length > 100 ? s.substring(0, 100) + "..." : s;
+			};
+			return injected.waitForPredicateFunction(tracked, polling, timeout, ...args).catch((e) => {
+				if (last !== undefined && typeof e === "string" && e.startsWith("timed out")) {
+					throw e + ", the predicate last returned: " + describe(last.value);
+				}
+				throw e;
+			});
 		}
 	`
 
@@ -604,7 +912,7 @@ func (f *Frame) waitForFunction(
 				injected,
 				handle,
 				polling,
-				timeout.Milliseconds(), // The JS value is in ms integers
+				injectedTimeout(timeout), // The JS value is in ms integers
 			}, args...)...)
 		if err != nil {
 			cb(func() error {
@@ -626,7 +934,7 @@ func (f *Frame) waitForSelectorRetry(
 	selector string, opts *FrameWaitForSelectorOptions, retry int,
 ) (h *ElementHandle, err error) {
 	for ; retry >= 0; retry-- {
-		if h, err = f.waitForSelector(selector, opts); err == nil {
+		if h, err = f.waitForSelector(f.ctx, selector, opts); err == nil {
 			return h, nil
 		}
 	}
@@ -634,7 +942,11 @@ func (f *Frame) waitForSelectorRetry(
 	return nil, err
 }
 
-func (f *Frame) waitForSelector(selector string, opts *FrameWaitForSelectorOptions) (*ElementHandle, error) {
+// waitForSelector waits for the selector to reach the state in opts.
+// The wait in the page is cancelled when apiCtx is done.
+func (f *Frame) waitForSelector(
+	apiCtx context.Context, selector string, opts *FrameWaitForSelectorOptions,
+) (*ElementHandle, error) {
 	f.log.Debugf("Frame:waitForSelector", "fid:%s furl:%q sel:%q", f.ID(), f.URL(), selector)
 
 	document, err := f.document()
@@ -642,7 +954,7 @@ func (f *Frame) waitForSelector(selector string, opts *FrameWaitForSelectorOptio
 		return nil, err
 	}
 
-	handle, err := document.waitForSelector(f.ctx, selector, opts)
+	handle, err := document.waitForSelector(apiCtx, selector, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -650,36 +962,175 @@ func (f *Frame) waitForSelector(selector string, opts *FrameWaitForSelectorOptio
 		return nil, fmt.Errorf("wait for selector %q did not result in any nodes", selector)
 	}
 
-	// We always return ElementHandles in the main execution context (aka "DOM world")
+	if handle, err = f.adoptToMainWorld(handle); err != nil {
+		return nil, fmt.Errorf("wait for selector %q %w", selector, err)
+	}
+
+	return handle, nil
+}
+
+func (f *Frame) waitForAnySelector(
+	apiCtx context.Context, selectors []string, opts *FrameWaitForSelectorOptions,
+) (*ElementHandle, string, error) {
+	f.log.Debugf("Frame:waitForAnySelector", "fid:%s furl:%q sels:%q", f.ID(), f.URL(), selectors)
+
+	if len(selectors) == 0 {
+		return nil, "", errors.New("no selectors to wait for")
+	}
+
+	document, err := f.document()
+	if err != nil {
+		return nil, "", err
+	}
+
+	handle, i, err := document.waitForAnySelector(apiCtx, selectors, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	if i < 0 || i >= len(selectors) {
+		return nil, "", fmt.Errorf("unexpected index %d of the matching selector", i)
+	}
+	selector := selectors[i]
+	if handle == nil {
+		// the detached and hidden states don't resolve to an element.
+		return nil, selector, nil
+	}
+
+	if handle, err = f.adoptToMainWorld(handle); err != nil {
+		return nil, "", fmt.Errorf("wait for selector %q %w", selector, err)
+	}
+
+	return handle, selector, nil
+}
+
+// adoptToMainWorld returns the handle in the main execution context (aka "DOM world"),
+// adopting it from another execution context if needed.
+func (f *Frame) adoptToMainWorld(handle *ElementHandle) (*ElementHandle, error) {
 	f.executionContextMu.RLock()
 	defer f.executionContextMu.RUnlock()
 
 	ec := f.executionContexts[mainWorld]
 	if ec == nil {
-		return nil, fmt.Errorf("wait for selector %q cannot find execution context: %q", selector, mainWorld)
+		return nil, fmt.Errorf("cannot find execution context: %q", mainWorld)
 	}
 	// an element should belong to the current execution context.
 	// otherwise, we should adopt it to this execution context.
-	if ec != handle.execCtx {
-		defer handle.Dispose()
-		if handle, err = ec.adoptElementHandle(handle); err != nil {
-			return nil, fmt.Errorf("wait for selector %q cannot adopt element handle: %w", selector, err)
-		}
+	if ec == handle.execCtx {
+		return handle, nil
+	}
+	defer handle.Dispose()
+	adopted, err := ec.adoptElementHandle(handle)
+	if err != nil {
+		return nil, fmt.Errorf("cannot adopt element handle: %w", err)
 	}
 
-	return handle, nil
+	return adopted, nil
 }
 
+// AddScriptTag adds a <script> tag with the given URL, or with the content
+// of the given file or string, to the frame. It returns once the script
+// is loaded.
 func (f *Frame) AddScriptTag(opts goja.Value) {
-	k6ext.Panic(f.ctx, "Frame.AddScriptTag() has not been implemented yet")
+	f.log.Debugf("Frame:AddScriptTag", "fid:%s furl:%q", f.ID(), f.URL())
+
+	popts := NewFrameAddScriptTagOptions()
+	if err := popts.Parse(f.ctx, opts); err != nil {
+		k6ext.Panic(f.ctx, "parsing addScriptTag options: %w", err)
+	}
+	if err := f.addScriptTag(popts); err != nil {
+		k6ext.Panic(f.ctx, "adding script tag: %w", err)
+	}
+
 	applySlowMo(f.ctx)
 }
 
+func (f *Frame) addScriptTag(opts *FrameAddScriptTagOptions) error {
+	content := opts.Content
+	if opts.Path != "" {
+		b, err := os.ReadFile(opts.Path)
+		if err != nil {
+			return fmt.Errorf("reading script file: %w", err)
+		}
+		content = string(b) + "\n//# sourceURL=" + filepath.ToSlash(opts.Path)
+	}
+
+	js := `async (opts) => {
+		const script = document.createElement('script');
+		script.type = opts.type || 'text/javascript';
+		if (opts.url) {
+			script.src = opts.url;
+			const loaded = new Promise((resolve, reject) => {
+				script.onload = resolve;
+				script.onerror = () => reject(new Error('loading script ' + opts.url));
+			});
+			document.head.appendChild(script);
+			await loaded;
+			return;
+		}
+		script.text = opts.content;
+		document.head.appendChild(script);
+	}`
+	args := map[string]string{"url": opts.URL, "content": content, "type": opts.Type}
+
+	return f.addTag(js, args)
+}
+
+// AddStyleTag adds a <link rel="stylesheet"> tag with the given URL, or a
+// <style> tag with the content of the given file or string, to the frame.
+// It returns once the stylesheet is loaded.
 func (f *Frame) AddStyleTag(opts goja.Value) {
-	k6ext.Panic(f.ctx, "Frame.AddStyleTag() has not been implemented yet")
+	f.log.Debugf("Frame:AddStyleTag", "fid:%s furl:%q", f.ID(), f.URL())
+
+	popts := NewFrameAddStyleTagOptions()
+	if err := popts.Parse(f.ctx, opts); err != nil {
+		k6ext.Panic(f.ctx, "parsing addStyleTag options: %w", err)
+	}
+	if err := f.addStyleTag(popts); err != nil {
+		k6ext.Panic(f.ctx, "adding style tag: %w", err)
+	}
+
 	applySlowMo(f.ctx)
 }
 
+func (f *Frame) addStyleTag(opts *FrameAddStyleTagOptions) error {
+	content := opts.Content
+	if opts.Path != "" {
+		b, err := os.ReadFile(opts.Path)
+		if err != nil {
+			return fmt.Errorf("reading style file: %w", err)
+		}
+		content = string(b) + "\n/*# sourceURL=" + filepath.ToSlash(opts.Path) + "*/"
+	}
+
+	js := `async (opts) => {
+		let style;
+		if (opts.url) {
+			style = document.createElement('link');
+			style.rel = 'stylesheet';
+			style.href = opts.url;
+		} else {
+			style = document.createElement('style');
+			style.appendChild(document.createTextNode(opts.content));
+		}
+		const loaded = new Promise((resolve, reject) => {
+			style.onload = resolve;
+			style.onerror = () => reject(new Error('loading stylesheet ' + (opts.url || '')));
+		});
+		document.head.appendChild(style);
+		await loaded;
+	}`
+	args := map[string]string{"url": opts.URL, "content": content}
+
+	return f.addTag(js, args)
+}
+
+// addTag evaluates the js function that adds a tag to the frame in the
+// main world, so that the page scripts can see the result.
+func (f *Frame) addTag(js string, args map[string]string) error {
+	_, err := f.evaluateWithTimeout(0, js, args)
+	return err
+}
+
 // ChildFrames returns a list of child frames.
 func (f *Frame) ChildFrames() []api.Frame {
 	f.childFramesMu.RLock()
@@ -692,6 +1143,18 @@ func (f *Frame) ChildFrames() []api.Frame {
 	return l
 }
 
+// ClearHighlight removes the highlight added by Highlight, if any.
+func (f *Frame) ClearHighlight() {
+	f.log.Debugf("Frame:ClearHighlight", "fid:%s furl:%q", f.ID(), f.URL())
+
+	if f.page.browserCtx.browser.launchOpts.Headless {
+		return
+	}
+	if err := f.highlight(nil); err != nil {
+		k6ext.Panic(f.ctx, "clearing highlight: %w", err)
+	}
+}
+
 // Click clicks the first element found that matches selector.
 func (f *Frame) Click(selector string, opts goja.Value) {
 	f.log.Debugf("Frame:Click", "fid:%s furl:%q sel:%q", f.ID(), f.URL(), selector)
@@ -720,6 +1183,77 @@ func (f *Frame) click(selector string, opts *FrameClickOptions) error {
 	return nil
 }
 
+// AllInnerTexts returns the inner texts of all the elements that match the
+// selector, in document order.
+func (f *Frame) AllInnerTexts(selector string) []string {
+	f.log.Debugf("Frame:AllInnerTexts", "fid:%s furl:%q sel:%q", f.ID(), f.URL(), selector)
+
+	texts, err := f.allTexts(selector, "innerText")
+	if err != nil {
+		k6ext.Panic(f.ctx, "allInnerTexts of %q: %w", selector, err)
+	}
+	return texts
+}
+
+// AllTextContents returns the text contents of all the elements that match
+// the selector, in document order.
+func (f *Frame) AllTextContents(selector string) []string {
+	f.log.Debugf("Frame:AllTextContents", "fid:%s furl:%q sel:%q", f.ID(), f.URL(), selector)
+
+	texts, err := f.allTexts(selector, "textContent")
+	if err != nil {
+		k6ext.Panic(f.ctx, "allTextContents of %q: %w", selector, err)
+	}
+	return texts
+}
+
+// allTexts returns the property, either innerText or textContent, of all
+// the elements that match the selector in a single evaluation.
+func (f *Frame) allTexts(selector, property string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(f.ctx, f.defaultTimeout())
+	defer cancel()
+
+	parsedSelector, err := NewSelector(selector)
+	if err != nil {
+		return nil, fmt.Errorf("parsing selector: %w", err)
+	}
+	document, err := f.document()
+	if err != nil {
+		return nil, fmt.Errorf("getting document: %w", err)
+	}
+	fn := `(node, injected, selector, property) => {
+		const elements = injected.querySelectorAll(selector, node);
+		if (typeof elements === "string") {
+			return elements;
+		}
+		return elements.map(e => {
+			// only the HTML elements have an innerText.
+			const text = property in e ? e[property] : e.textContent;
+			return text || "";
+		});
+	}`
+	opts := evalOptions{forceCallable: true, returnByValue: true}
+	result, err := document.evalWithScript(ctx, opts, fn, parsedSelector, property)
+	if err != nil {
+		return nil, timeoutError(err, f.defaultTimeout())
+	}
+	gv, ok := result.(goja.Value)
+	if !ok || !gojaValueExists(gv) {
+		return []string{}, nil
+	}
+	switch r := gv.Export().(type) {
+	case string: // an error happened (returned as "error:..." from JS)
+		return nil, errorFromDOMError(r)
+	case []interface{}:
+		texts := make([]string, 0, len(r))
+		for _, t := range r {
+			texts = append(texts, fmt.Sprintf("%v", t))
+		}
+		return texts, nil
+	}
+	return []string{}, nil
+}
+
 // Check clicks the first element found that matches selector.
 func (f *Frame) Check(selector string, opts goja.Value) {
 	f.log.Debugf("Frame:Check", "fid:%s furl:%q sel:%q", f.ID(), f.URL(), selector)
@@ -795,14 +1329,10 @@ func (f *Frame) IsChecked(selector string, opts goja.Value) bool {
 
 func (f *Frame) isChecked(selector string, opts *FrameIsCheckedOptions) (bool, error) {
 	isChecked := func(apiCtx context.Context, handle *ElementHandle) (interface{}, error) {
-		v, err := handle.isChecked(apiCtx, 0) // Zero timeout when checking state
-		if errors.Is(err, ErrTimedOut) {      // We don't care about timeout errors here!
-			return v, nil
-		}
-		return v, err
+		return handle.isChecked(apiCtx)
 	}
 	act := f.newAction(
-		selector, DOMElementStateAttached, opts.Strict, isChecked, []string{}, false, true, opts.Timeout,
+		selector, DOMElementStateAttached, opts.Strict, isChecked, []string{}, false, true, opts.Timeout, 0,
 	)
 	v, err := callApiWithTimeout(f.ctx, act, opts.Timeout)
 	if err != nil {
@@ -836,6 +1366,115 @@ func (f *Frame) Content() string {
 	return gojaValueToString(f.ctx, f.Evaluate(rt.ToValue(js)))
 }
 
+// Count returns the number of elements that match the selector, without
+// creating a handle for each of them.
+func (f *Frame) Count(selector string) int64 {
+	f.log.Debugf("Frame:Count", "fid:%s furl:%q sel:%q", f.ID(), f.URL(), selector)
+
+	n, err := f.count(selector)
+	if err != nil {
+		k6ext.Panic(f.ctx, "counting %q: %w", selector, err)
+	}
+	return n
+}
+
+func (f *Frame) count(selector string) (int64, error) {
+	ctx, cancel := context.WithTimeout(f.ctx, f.defaultTimeout())
+	defer cancel()
+
+	parsedSelector, err := NewSelector(selector)
+	if err != nil {
+		return 0, fmt.Errorf("parsing selector: %w", err)
+	}
+	document, err := f.document()
+	if err != nil {
+		return 0, fmt.Errorf("getting document: %w", err)
+	}
+	fn := `(node, injected, selector) => {
+		const elements = injected.querySelectorAll(selector, node);
+		if (typeof elements === "string") {
+			return elements;
+		}
+		return elements.length;
+	}`
+	opts := evalOptions{forceCallable: true, returnByValue: true}
+	result, err := document.evalWithScript(ctx, opts, fn, parsedSelector)
+	if err != nil {
+		err = timeoutError(err, f.defaultTimeout())
+		return 0, err
+	}
+	gv, ok := result.(goja.Value)
+	if !ok || !gojaValueExists(gv) {
+		return 0, nil
+	}
+	if s, ok := gv.Export().(string); ok { // an error happened (returned as "error:..." from JS)
+		return 0, errorFromDOMError(s)
+	}
+	return gv.ToInteger(), nil
+}
+
+// DOMSnapshot returns a serialized snapshot of the DOM tree of the frame.
+func (f *Frame) DOMSnapshot() *api.DOMSnapshotNode {
+	f.log.Debugf("Frame:DOMSnapshot", "fid:%s furl:%q", f.ID(), f.URL())
+
+	snapshot, err := f.domSnapshot()
+	if err != nil {
+		k6ext.Panic(f.ctx, "capturing DOM snapshot: %w", err)
+	}
+	return snapshot
+}
+
+func (f *Frame) domSnapshot() (*api.DOMSnapshotNode, error) {
+	// the tree is serialized to JSON in the page so that deep
+	// trees don't hit the limits of returning objects by value.
+	js := `() => {
+		const serialize = (node) => {
+			if (node.nodeType === Node.TEXT_NODE) {
+				const text = node.textContent.trim();
+				return text ? { text } : null;
+			}
+			if (node.nodeType !== Node.ELEMENT_NODE) {
+				return null;
+			}
+			const result = { tag: node.localName };
+			if (node.attributes.length > 0) {
+				result.attributes = {};
+				for (const attr of node.attributes) {
+					result.attributes[attr.name] = attr.value;
+				}
+			}
+			const children = [];
+			for (const child of node.childNodes) {
+				const c = serialize(child);
+				if (c) {
+					children.push(c);
+				}
+			}
+			if (children.length > 0) {
+				result.children = children;
+			}
+			return result;
+		};
+		return JSON.stringify(document.documentElement ? serialize(document.documentElement) : null);
+	}`
+
+	f.waitForExecutionContext(mainWorld)
+
+	opts := evalOptions{
+		forceCallable: true,
+		returnByValue: true,
+	}
+	result, err := f.evaluate(f.ctx, mainWorld, opts, f.vu.Runtime().ToValue(js))
+	if err != nil {
+		return nil, err
+	}
+	var snapshot *api.DOMSnapshotNode
+	if err := json.Unmarshal([]byte(gojaValueToString(f.ctx, result)), &snapshot); err != nil {
+		return nil, fmt.Errorf("unmarshaling DOM snapshot: %w", err)
+	}
+	return snapshot, nil
+}
+
 // Dblclick double clicks an element matching provided selector.
 func (f *Frame) Dblclick(selector string, opts goja.Value) {
 	f.log.Debugf("Frame:DblClick", "fid:%s furl:%q sel:%q", f.ID(), f.URL(), selector)
@@ -867,6 +1506,11 @@ func (f *Frame) dblclick(selector string, opts *FrameDblclickOptions) error {
 }
 
 // DispatchEvent dispatches an event for the first element matching the selector.
+// The event bubbles, is cancelable and composed by default. eventInit can turn
+// these off with its bubbles, cancelable and composed properties.
+// The event is created with the constructor of its type, e.g. a MouseEvent
+// for click or a DragEvent for drop, and a plain Event for an unknown type.
+// The dataTransfer of a drag event is an object of the data of its formats.
 func (f *Frame) DispatchEvent(selector, typ string, eventInit, opts goja.Value) {
 	f.log.Debugf("Frame:DispatchEvent", "fid:%s furl:%q sel:%q typ:%q", f.ID(), f.URL(), selector, typ)
 
@@ -892,7 +1536,7 @@ func (f *Frame) dispatchEvent(selector, typ string, eventInit goja.Value, opts *
 	)
 	act := f.newAction(
 		selector, DOMElementStateAttached, opts.Strict, dispatchEvent, []string{},
-		force, noWaitAfter, opts.Timeout,
+		force, noWaitAfter, opts.Timeout, opts.Retries,
 	)
 	if _, err := callApiWithTimeout(f.ctx, act, opts.Timeout); err != nil {
 		return errorFromDOMError(err.Error())
@@ -902,6 +1546,8 @@ func (f *Frame) dispatchEvent(selector, typ string, eventInit goja.Value, opts *
 }
 
 // Evaluate will evaluate provided page function within an execution context.
+// A returned BigInt is converted to a string of its decimal digits, so that
+// it doesn't lose precision.
 func (f *Frame) Evaluate(pageFunc goja.Value, args ...goja.Value) interface{} {
 	f.log.Debugf("Frame:Evaluate", "fid:%s furl:%q", f.ID(), f.URL())
 
@@ -910,15 +1556,165 @@ func (f *Frame) Evaluate(pageFunc goja.Value, args ...goja.Value) interface{} {
 	opts := evalOptions{
 		forceCallable: true,
 		returnByValue: true,
+		largeStrings:  true,
 	}
 	result, err := f.evaluate(f.ctx, mainWorld, opts, pageFunc, args...)
 	if err != nil {
 		k6ext.Panic(f.ctx, "evaluating JS: %v", err)
 	}
 
-	applySlowMo(f.ctx)
+	applySlowMo(f.ctx)
+
+	return result
+}
+
+// EvaluateTop evaluates the page function like Evaluate, but against the top
+// window of the frame, i.e. window.top, in the main execution context of the
+// top frame of the page. It can be used to read the state that same-origin
+// iframes share through window.top. It throws if the frame isn't same-origin
+// with the top frame, since the frame can't access window.top either.
+func (f *Frame) EvaluateTop(pageFunc goja.Value, args ...goja.Value) interface{} {
+	f.log.Debugf("Frame:EvaluateTop", "fid:%s furl:%q", f.ID(), f.URL())
+
+	result, err := f.evaluateTop(pageFunc, args...)
+	if err != nil {
+		k6ext.Panic(f.ctx, "evaluating JS in the top frame: %v", err)
+	}
+
+	applySlowMo(f.ctx)
+
+	return result
+}
+
+func (f *Frame) evaluateTop(pageFunc goja.Value, args ...goja.Value) (interface{}, error) {
+	opts := evalOptions{
+		forceCallable: true,
+		returnByValue: true,
+	}
+	top := f.topFrame()
+	if top != f {
+		// accessing the location of a cross-origin window throws.
+		sameOriginFn := f.vu.Runtime().ToValue(`() => {
+			try {
+				return window.top.location.origin === window.location.origin;
+			} catch (e) {
+				return false;
+			}
+		}`)
+		f.waitForExecutionContext(mainWorld)
+		v, err := f.evaluate(f.ctx, mainWorld, opts, sameOriginFn)
+		if err != nil {
+			return nil, fmt.Errorf("checking the origin of the top frame: %w", err)
+		}
+		if gv, ok := v.(goja.Value); !ok || !gv.ToBoolean() {
+			return nil, fmt.Errorf("frame %q is not same-origin with the top frame %q", f.URL(), top.URL())
+		}
+	}
+
+	top.waitForExecutionContext(mainWorld)
+	opts.largeStrings = true
+	return top.evaluate(f.ctx, mainWorld, opts, pageFunc, args...)
+}
+
+// topFrame returns the root of the frame tree that the frame belongs to.
+func (f *Frame) topFrame() *Frame {
+	top := f
+	for top.parentFrame != nil {
+		top = top.parentFrame
+	}
+	return top
+}
+
+// EvalOnSelector finds the first element matching the selector and calls
+// pageFunc with it as the first argument, followed by args. It returns the
+// serialized result of pageFunc. It throws if no element matches.
+func (f *Frame) EvalOnSelector(selector string, pageFunc goja.Value, args ...goja.Value) interface{} {
+	f.log.Debugf("Frame:EvalOnSelector", "fid:%s furl:%q sel:%q", f.ID(), f.URL(), selector)
+
+	v, err := f.evalOnSelector(selector, pageFunc, args...)
+	if err != nil {
+		k6ext.Panic(f.ctx, "evaluating on selector %q: %w", selector, err)
+	}
+
+	return v
+}
+
+func (f *Frame) evalOnSelector(selector string, pageFunc goja.Value, args ...goja.Value) (interface{}, error) {
+	ctx, cancel := context.WithTimeout(f.ctx, f.defaultTimeout())
+	defer cancel()
+
+	document, err := f.document()
+	if err != nil {
+		return nil, fmt.Errorf("getting document: %w", err)
+	}
+	handle, err := document.query(ctx, selector)
+	if err != nil {
+		return nil, err
+	}
+	if handle == nil {
+		return nil, errors.New("no element matches the selector")
+	}
+	defer func() {
+		if err := handle.dispose(); err != nil {
+			f.log.Debugf("Frame:evalOnSelector", "fid:%s disposing handle: %v", f.ID(), err)
+		}
+	}()
+
+	rt := f.vu.Runtime()
+	args = append([]goja.Value{rt.ToValue(handle)}, args...)
+
+	return handle.execCtx.Eval(ctx, pageFunc, args...)
+}
+
+// EvalOnSelectorAll finds all the elements matching the selector and calls
+// pageFunc with an array of them as the first argument, followed by args.
+// It returns the serialized result of pageFunc. If no element matches,
+// pageFunc is called with an empty array.
+func (f *Frame) EvalOnSelectorAll(selector string, pageFunc goja.Value, args ...goja.Value) interface{} {
+	f.log.Debugf("Frame:EvalOnSelectorAll", "fid:%s furl:%q sel:%q", f.ID(), f.URL(), selector)
+
+	v, err := f.evalOnSelectorAll(selector, pageFunc, args...)
+	if err != nil {
+		k6ext.Panic(f.ctx, "evaluating on all elements of selector %q: %w", selector, err)
+	}
+
+	return v
+}
+
+func (f *Frame) evalOnSelectorAll(selector string, pageFunc goja.Value, args ...goja.Value) (interface{}, error) {
+	ctx, cancel := context.WithTimeout(f.ctx, f.defaultTimeout())
+	defer cancel()
+
+	parsedSelector, err := NewSelector(selector)
+	if err != nil {
+		return nil, fmt.Errorf("parsing selector: %w", err)
+	}
+	document, err := f.document()
+	if err != nil {
+		return nil, fmt.Errorf("getting document: %w", err)
+	}
+	opts := evalOptions{
+		forceCallable: true,
+		returnByValue: false,
+	}
+	result, err := document.evalWithScript(ctx, opts, js.QueryAll, parsedSelector)
+	if err != nil {
+		return nil, fmt.Errorf("querying selector: %w", err)
+	}
+	handles, ok := result.(jsHandle)
+	if !ok {
+		return nil, fmt.Errorf("getting elements array handle: %w", ErrJSHandleInvalid)
+	}
+	defer func() {
+		if err := handles.dispose(); err != nil {
+			f.log.Debugf("Frame:evalOnSelectorAll", "fid:%s disposing handle: %v", f.ID(), err)
+		}
+	}()
+
+	rt := f.vu.Runtime()
+	args = append([]goja.Value{rt.ToValue(handles)}, args...)
 
-	return result
+	return document.execCtx.Eval(ctx, pageFunc, args...)
 }
 
 // EvaluateHandle will evaluate provided page function within an execution context.
@@ -964,9 +1760,9 @@ func (f *Frame) fill(selector, value string, opts *FrameFillOptions) error {
 		return nil, handle.fill(apiCtx, value)
 	}
 	act := f.newAction(
-		selector, DOMElementStateAttached, opts.Strict,
+		selector, DOMElementStateEditable, opts.Strict,
 		fill, []string{"visible", "enabled", "editable"},
-		opts.Force, opts.NoWaitAfter, opts.Timeout,
+		opts.Force, opts.NoWaitAfter, opts.Timeout, opts.Retries,
 	)
 	if _, err := callApiWithTimeout(f.ctx, act, opts.Timeout); err != nil {
 		return errorFromDOMError(err.Error())
@@ -995,7 +1791,7 @@ func (f *Frame) focus(selector string, opts *FrameBaseOptions) error {
 	}
 	act := f.newAction(
 		selector, DOMElementStateAttached, opts.Strict, focus,
-		[]string{}, false, true, opts.Timeout,
+		[]string{}, false, true, opts.Timeout, 0,
 	)
 	if _, err := callApiWithTimeout(f.ctx, act, opts.Timeout); err != nil {
 		return errorFromDOMError(err.Error())
@@ -1038,7 +1834,7 @@ func (f *Frame) getAttribute(selector, name string, opts *FrameBaseOptions) (goj
 	}
 	act := f.newAction(
 		selector, DOMElementStateAttached, opts.Strict, getAttribute,
-		[]string{}, false, true, opts.Timeout,
+		[]string{}, false, true, opts.Timeout, 0,
 	)
 	v, err := callApiWithTimeout(f.ctx, act, opts.Timeout)
 	if err != nil {
@@ -1052,13 +1848,96 @@ func (f *Frame) getAttribute(selector, name string, opts *FrameBaseOptions) (goj
 	return gv, nil
 }
 
+// GetByRole creates and returns a new locator for the elements with the
+// ARIA role that match the options.
+func (f *Frame) GetByRole(role string, opts goja.Value) api.Locator {
+	f.log.Debugf("Frame:GetByRole", "fid:%s furl:%q role:%q", f.ID(), f.URL(), role)
+
+	if !reRole.MatchString(role) {
+		k6ext.Panic(f.ctx, "getByRole: invalid role %q", role)
+	}
+	popts := NewFrameGetByRoleOptions()
+	if err := popts.Parse(f.ctx, opts); err != nil {
+		k6ext.Panic(f.ctx, "parsing getByRole options: %w", err)
+	}
+
+	return NewLocator(f.ctx, popts.selector(role), f, f.log)
+}
+
 // Goto will navigate the frame to the specified URL and return a HTTP response object.
+// It returns once the frame and its subframes reach the lifecycle event of the
+// waitUntil option: load (the default), domcontentloaded or networkidle.
 func (f *Frame) Goto(url string, opts goja.Value) api.Response {
 	resp := f.manager.NavigateFrame(f, url, opts)
 	applySlowMo(f.ctx)
 	return resp
 }
 
+// Highlight outlines all the elements that match the selector, to see what
+// a selector matches while debugging. It replaces the previous highlight,
+// and it's only shown in headful mode. Since the outlines are absolutely
+// positioned over the elements, they don't change the layout of the page.
+func (f *Frame) Highlight(selector string) {
+	f.log.Debugf("Frame:Highlight", "fid:%s furl:%q sel:%q", f.ID(), f.URL(), selector)
+
+	if f.page.browserCtx.browser.launchOpts.Headless {
+		f.log.Debugf("Frame:Highlight:return", "fid:%s furl:%q sel:%q headless", f.ID(), f.URL(), selector)
+		return
+	}
+	parsedSelector, err := NewSelector(selector)
+	if err != nil {
+		k6ext.Panic(f.ctx, "parsing selector %q: %w", selector, err)
+	}
+	if err := f.highlight(parsedSelector); err != nil {
+		k6ext.Panic(f.ctx, "highlighting %q: %w", selector, err)
+	}
+}
+
+// highlight outlines the elements that match the selector, after removing
+// the previous highlight. A nil selector only removes the highlight.
+func (f *Frame) highlight(selector *Selector) error {
+	ctx, cancel := context.WithTimeout(f.ctx, f.defaultTimeout())
+	defer cancel()
+
+	document, err := f.document()
+	if err != nil {
+		return fmt.Errorf("getting document: %w", err)
+	}
+	fn := `(node, injected, selector) => {
+		const doc = node.ownerDocument || node;
+		for (const overlay of doc.querySelectorAll("[data-k6-browser-highlight]")) {
+			overlay.remove();
+		}
+		if (!selector) {
+			return;
+		}
+		const elements = injected.querySelectorAll(selector, node);
+		if (typeof elements === "string" || elements.length === 0) {
+			return;
+		}
+		const overlay = doc.createElement("div");
+		overlay.setAttribute("data-k6-browser-highlight", "");
+		overlay.style.cssText = "position: absolute; top: 0; left: 0; width: 0; height: 0; " +
+			"pointer-events: none; z-index: 2147483647;";
+		const view = doc.defaultView;
+		for (const element of elements) {
+			const rect = element.getBoundingClientRect();
+			const box = doc.createElement("div");
+			box.style.cssText = "position: absolute; box-sizing: border-box; pointer-events: none; " +
+				"outline: 2px solid rgb(255, 0, 255); background: rgba(255, 0, 255, 0.2); " +
+				"left: " + (rect.left + view.scrollX) + "px; top: " + (rect.top + view.scrollY) + "px; " +
+				"width: " + rect.width + "px; height: " + rect.height + "px;";
+			overlay.appendChild(box);
+		}
+		doc.documentElement.appendChild(overlay);
+	}`
+	opts := evalOptions{forceCallable: true, returnByValue: true}
+	if _, err := document.evalWithScript(ctx, opts, fn, selector); err != nil {
+		return timeoutError(err, f.defaultTimeout())
+	}
+	return nil
+}
+
 // Hover moves the pointer over the first element that matches the selector.
 func (f *Frame) Hover(selector string, opts goja.Value) {
 	f.log.Debugf("Frame:Hover", "fid:%s furl:%q sel:%q", f.ID(), f.URL(), selector)
@@ -1113,7 +1992,7 @@ func (f *Frame) innerHTML(selector string, opts *FrameInnerHTMLOptions) (string,
 	}
 	act := f.newAction(
 		selector, DOMElementStateAttached, opts.Strict, innerHTML,
-		[]string{}, false, true, opts.Timeout,
+		[]string{}, false, true, opts.Timeout, 0,
 	)
 	v, err := callApiWithTimeout(f.ctx, act, opts.Timeout)
 	if err != nil {
@@ -1150,12 +2029,22 @@ func (f *Frame) InnerText(selector string, opts goja.Value) string {
 }
 
 func (f *Frame) innerText(selector string, opts *FrameInnerTextOptions) (string, error) {
+	var within *Selector
+	if opts.Within != "" {
+		var err error
+		if within, err = NewSelector(opts.Within); err != nil {
+			return "", fmt.Errorf("parsing selector %q: %w", opts.Within, err)
+		}
+	}
 	innerText := func(apiCtx context.Context, handle *ElementHandle) (interface{}, error) {
-		return handle.innerText(apiCtx)
+		if within == nil && opts.MaxDepth == 0 {
+			return handle.innerText(apiCtx)
+		}
+		return handle.scopedInnerText(apiCtx, within, opts.MaxDepth)
 	}
 	act := f.newAction(
 		selector, DOMElementStateAttached, opts.Strict, innerText,
-		[]string{}, false, true, opts.Timeout,
+		[]string{}, false, true, opts.Timeout, 0,
 	)
 	v, err := callApiWithTimeout(f.ctx, act, opts.Timeout)
 	if err != nil {
@@ -1173,7 +2062,8 @@ func (f *Frame) innerText(selector string, opts *FrameInnerTextOptions) (string,
 }
 
 // InputValue returns the input value of the first element found
-// that matches the selector.
+// that matches the selector. The values of a multi-select are joined
+// with commas.
 func (f *Frame) InputValue(selector string, opts goja.Value) string {
 	f.log.Debugf("Frame:InputValue", "fid:%s furl:%q sel:%q", f.ID(), f.URL(), selector)
 
@@ -1195,7 +2085,7 @@ func (f *Frame) inputValue(selector string, opts *FrameInputValueOptions) (strin
 	}
 	act := f.newAction(
 		selector, DOMElementStateAttached, opts.Strict, inputValue,
-		[]string{}, false, true, opts.Timeout,
+		[]string{}, false, true, opts.Timeout, 0,
 	)
 	v, err := callApiWithTimeout(f.ctx, act, opts.Timeout)
 	if err != nil {
@@ -1244,14 +2134,10 @@ func (f *Frame) IsEditable(selector string, opts goja.Value) bool {
 
 func (f *Frame) isEditable(selector string, opts *FrameIsEditableOptions) (bool, error) {
 	isEditable := func(apiCtx context.Context, handle *ElementHandle) (interface{}, error) {
-		v, err := handle.isEditable(apiCtx, 0) // Zero timeout when checking state
-		if errors.Is(err, ErrTimedOut) {       // We don't care about timeout errors here!
-			return v, nil
-		}
-		return v, err
+		return handle.isEditable(apiCtx)
 	}
 	act := f.newAction(
-		selector, DOMElementStateAttached, opts.Strict, isEditable, []string{}, false, true, opts.Timeout,
+		selector, DOMElementStateAttached, opts.Strict, isEditable, []string{}, false, true, opts.Timeout, 0,
 	)
 	v, err := callApiWithTimeout(f.ctx, act, opts.Timeout)
 	if err != nil {
@@ -1285,14 +2171,10 @@ func (f *Frame) IsEnabled(selector string, opts goja.Value) bool {
 
 func (f *Frame) isEnabled(selector string, opts *FrameIsEnabledOptions) (bool, error) {
 	isEnabled := func(apiCtx context.Context, handle *ElementHandle) (interface{}, error) {
-		v, err := handle.isEnabled(apiCtx, 0) // Zero timeout when checking state
-		if errors.Is(err, ErrTimedOut) {      // We don't care about timeout errors here!
-			return v, nil
-		}
-		return v, err
+		return handle.isEnabled(apiCtx)
 	}
 	act := f.newAction(
-		selector, DOMElementStateAttached, opts.Strict, isEnabled, []string{}, false, true, opts.Timeout,
+		selector, DOMElementStateAttached, opts.Strict, isEnabled, []string{}, false, true, opts.Timeout, 0,
 	)
 	v, err := callApiWithTimeout(f.ctx, act, opts.Timeout)
 	if err != nil {
@@ -1326,14 +2208,10 @@ func (f *Frame) IsDisabled(selector string, opts goja.Value) bool {
 
 func (f *Frame) isDisabled(selector string, opts *FrameIsDisabledOptions) (bool, error) {
 	isDisabled := func(apiCtx context.Context, handle *ElementHandle) (interface{}, error) {
-		v, err := handle.isDisabled(apiCtx, 0) // Zero timeout when checking state
-		if errors.Is(err, ErrTimedOut) {       // We don't care about timeout errors here!
-			return v, nil
-		}
-		return v, err
+		return handle.isDisabled(apiCtx)
 	}
 	act := f.newAction(
-		selector, DOMElementStateAttached, opts.Strict, isDisabled, []string{}, false, true, opts.Timeout,
+		selector, DOMElementStateAttached, opts.Strict, isDisabled, []string{}, false, true, opts.Timeout, 0,
 	)
 	v, err := callApiWithTimeout(f.ctx, act, opts.Timeout)
 	if err != nil {
@@ -1367,14 +2245,10 @@ func (f *Frame) IsHidden(selector string, opts goja.Value) bool {
 
 func (f *Frame) isHidden(selector string, opts *FrameIsHiddenOptions) (bool, error) {
 	isHidden := func(apiCtx context.Context, handle *ElementHandle) (interface{}, error) {
-		v, err := handle.isHidden(apiCtx, 0) // Zero timeout when checking state
-		if errors.Is(err, ErrTimedOut) {     // We don't care about timeout errors here!
-			return v, nil
-		}
-		return v, err
+		return handle.isHidden(apiCtx)
 	}
 	act := f.newAction(
-		selector, DOMElementStateAttached, opts.Strict, isHidden, []string{}, false, true, opts.Timeout,
+		selector, DOMElementStateAttached, opts.Strict, isHidden, []string{}, false, true, opts.Timeout, 0,
 	)
 	v, err := callApiWithTimeout(f.ctx, act, opts.Timeout)
 	if err != nil {
@@ -1408,14 +2282,10 @@ func (f *Frame) IsVisible(selector string, opts goja.Value) bool {
 
 func (f *Frame) isVisible(selector string, opts *FrameIsVisibleOptions) (bool, error) {
 	isVisible := func(apiCtx context.Context, handle *ElementHandle) (interface{}, error) {
-		v, err := handle.isVisible(apiCtx, 0) // Zero timeout when checking state
-		if errors.Is(err, ErrTimedOut) {      // We don't care about timeout errors here!
-			return v, nil
-		}
-		return v, err
+		return handle.isVisible(apiCtx)
 	}
 	act := f.newAction(
-		selector, DOMElementStateAttached, opts.Strict, isVisible, []string{}, false, true, opts.Timeout,
+		selector, DOMElementStateAttached, opts.Strict, isVisible, []string{}, false, true, opts.Timeout, 0,
 	)
 	v, err := callApiWithTimeout(f.ctx, act, opts.Timeout)
 	if err != nil {
@@ -1477,6 +2347,55 @@ func (f *Frame) Query(selector string) api.ElementHandle {
 	return nil
 }
 
+// QuerySettled is like Query, except that right after a same-document
+// navigation, such as a client-side route change, it first waits for the
+// DOM to stop changing, so that it doesn't miss the elements of the new
+// route that aren't mounted yet.
+func (f *Frame) QuerySettled(selector string) api.ElementHandle {
+	f.log.Debugf("Frame:QuerySettled", "fid:%s furl:%q sel:%q", f.ID(), f.URL(), selector)
+
+	f.propertiesMu.RLock()
+	navigatedAt := f.navigatedWithinDocumentTime
+	f.propertiesMu.RUnlock()
+
+	if !navigatedAt.IsZero() {
+		if wait := SoftNavigationSettleWindow - time.Since(navigatedAt); wait > 0 {
+			if err := f.waitForDOMSettle(SoftNavigationSettleQuiet, wait); err != nil {
+				k6ext.Panic(f.ctx, "waiting for the DOM to settle: %w", err)
+			}
+		}
+	}
+
+	return f.Query(selector)
+}
+
+// waitForDOMSettle waits until the DOM goes without changes for the quiet
+// period, or for at most the timeout. It's not an error if the DOM keeps
+// changing until the timeout.
+func (f *Frame) waitForDOMSettle(quiet, timeout time.Duration) error {
+	pageFn := `(quiet, timeout) => new Promise(resolve => {
+		let timer;
+		const observer = new MutationObserver(() => {
+			clearTimeout(timer);
+			timer = setTimeout(done, quiet);
+		});
+		const deadline = setTimeout(() => done(), timeout);
+		function done() {
+			observer.disconnect();
+			clearTimeout(timer);
+			clearTimeout(deadline);
+			resolve();
+		}
+		observer.observe(document, {
+			attributes: true, characterData: true, childList: true, subtree: true,
+		});
+		timer = setTimeout(done, quiet);
+	})`
+	// leave room for the evaluation on top of the wait in the page.
+	_, err := f.evaluateWithTimeout(timeout+f.defaultTimeout(), pageFn, quiet.Milliseconds(), timeout.Milliseconds())
+	return err
+}
+
 func (f *Frame) QueryAll(selector string) []api.ElementHandle {
 	f.log.Debugf("Frame:QueryAll", "fid:%s furl:%q sel:%q", f.ID(), f.URL(), selector)
 
@@ -1522,7 +2441,7 @@ func (f *Frame) press(selector, key string, opts *FramePressOptions) error {
 	}
 	act := f.newAction(
 		selector, DOMElementStateAttached, opts.Strict, press,
-		[]string{}, false, opts.NoWaitAfter, opts.Timeout,
+		[]string{}, false, opts.NoWaitAfter, opts.Timeout, opts.Retries,
 	)
 	if _, err := callApiWithTimeout(f.ctx, act, opts.Timeout); err != nil {
 		return errorFromDOMError(err.Error())
@@ -1531,6 +2450,87 @@ func (f *Frame) press(selector, key string, opts *FramePressOptions) error {
 	return nil
 }
 
+// Screenshot captures a screenshot of the frame's region in the page viewport.
+// The clip and fullPage options are ignored, since the clip is the frame itself.
+func (f *Frame) Screenshot(opts goja.Value) goja.ArrayBuffer {
+	f.log.Debugf("Frame:Screenshot", "fid:%s furl:%q", f.ID(), f.URL())
+
+	parsedOpts := NewPageScreenshotOptions()
+	if err := parsedOpts.Parse(f.ctx, opts); err != nil {
+		k6ext.Panic(f.ctx, "parsing frame screenshot options: %w", err)
+	}
+	buf, err := f.screenshot(parsedOpts)
+	if err != nil {
+		k6ext.Panic(f.ctx, "capturing frame screenshot: %w", err)
+	}
+	rt := f.vu.Runtime()
+	return rt.NewArrayBuffer(*buf)
+}
+
+func (f *Frame) screenshot(opts *PageScreenshotOptions) (*[]byte, error) {
+	if f.IsDetached() {
+		return nil, ErrFrameDetached
+	}
+	opts.FullPage = false
+	opts.Clip = nil
+
+	// the main frame covers the whole viewport.
+	if f.parentFrame != nil {
+		element, err := f.page.getFrameElement(f)
+		if err != nil {
+			return nil, fmt.Errorf("getting frame element: %w", err)
+		}
+		box, err := element.boundingBox()
+		if err != nil {
+			return nil, fmt.Errorf("getting frame bounding box: %w", err)
+		}
+		if box.Width <= 0 || box.Height <= 0 {
+			return nil, fmt.Errorf("frame has a zero size: %vx%v", box.Width, box.Height)
+		}
+		opts.Clip = &cdppage.Viewport{
+			X:      box.X,
+			Y:      box.Y,
+			Width:  box.Width,
+			Height: box.Height,
+			Scale:  1,
+		}
+	}
+
+	return newScreenshotter(f.ctx).screenshotPage(f.page, opts)
+}
+
+// ResourceTransferSize returns the bytes transferred over the network for
+// the resource loaded by the element matching the selector, e.g. an <img>,
+// <video> or <script>. It returns -1 if the element has no resource, or if
+// the resource hasn't finished loading in the frame.
+func (f *Frame) ResourceTransferSize(selector string) int64 {
+	f.log.Debugf("Frame:ResourceTransferSize", "fid:%s furl:%q sel:%q", f.ID(), f.URL(), selector)
+
+	size, err := f.resourceTransferSize(selector)
+	if err != nil {
+		k6ext.Panic(f.ctx, "getting transfer size of the resource of %q: %w", selector, err)
+	}
+	return size
+}
+
+func (f *Frame) resourceTransferSize(selector string) (int64, error) {
+	rt := f.vu.Runtime()
+	v, err := f.evalOnSelector(selector, rt.ToValue(`el => el.currentSrc || el.src || el.href || ""`))
+	if err != nil {
+		return 0, err
+	}
+	url := gojaValueToString(f.ctx, v)
+	if url == "" {
+		return -1, nil
+	}
+	size, ok := f.networkManager().transferSize(f.ID(), url)
+	if !ok {
+		return -1, nil
+	}
+
+	return size, nil
+}
+
 // SelectOption selects the given options and returns the array of
 // option values of the first element found that matches the selector.
 func (f *Frame) SelectOption(selector string, values goja.Value, opts goja.Value) []string {
@@ -1556,7 +2556,7 @@ func (f *Frame) selectOption(selector string, values goja.Value, opts *FrameSele
 	}
 	act := f.newAction(
 		selector, DOMElementStateAttached, opts.Strict, selectOption,
-		[]string{}, opts.Force, opts.NoWaitAfter, opts.Timeout,
+		[]string{}, opts.Force, opts.NoWaitAfter, opts.Timeout, opts.Retries,
 	)
 	v, err := callApiWithTimeout(f.ctx, act, opts.Timeout)
 	if err != nil {
@@ -1586,6 +2586,37 @@ func (f *Frame) selectOption(selector string, values goja.Value, opts *FrameSele
 	return vals, nil
 }
 
+// SelectText selects the text of the first element found that matches the
+// selector. The value of the input and textarea elements is selected, and
+// the contents of the other elements.
+func (f *Frame) SelectText(selector string, opts goja.Value) {
+	f.log.Debugf("Frame:SelectText", "fid:%s furl:%q sel:%q", f.ID(), f.URL(), selector)
+
+	popts := NewFrameSelectTextOptions(f.defaultTimeout())
+	if err := popts.Parse(f.ctx, opts); err != nil {
+		k6ext.Panic(f.ctx, "parsing selectText options: %w", err)
+	}
+	if err := f.selectText(selector, popts); err != nil {
+		k6ext.Panic(f.ctx, "selectText on %q: %w", selector, err)
+	}
+	applySlowMo(f.ctx)
+}
+
+func (f *Frame) selectText(selector string, opts *FrameSelectTextOptions) error {
+	selectText := func(apiCtx context.Context, handle *ElementHandle) (interface{}, error) {
+		return nil, handle.selectText(apiCtx)
+	}
+	act := f.newAction(
+		selector, DOMElementStateAttached, opts.Strict, selectText,
+		[]string{}, opts.Force, opts.NoWaitAfter, opts.Timeout, opts.Retries,
+	)
+	if _, err := callApiWithTimeout(f.ctx, act, opts.Timeout); err != nil {
+		return errorFromDOMError(err.Error())
+	}
+
+	return nil
+}
+
 // SetContent replaces the entire HTML document content.
 func (f *Frame) SetContent(html string, opts goja.Value) {
 	f.log.Debugf("Frame:SetContent", "fid:%s furl:%q", f.ID(), f.URL())
@@ -1616,12 +2647,43 @@ func (f *Frame) SetContent(html string, opts goja.Value) {
 	applySlowMo(f.ctx)
 }
 
+// SetInputFiles sets the files of the first file input element that matches
+// the selector. The files are either paths, or objects with the name,
+// mimeType and base64 encoded buffer of the file.
 func (f *Frame) SetInputFiles(selector string, files goja.Value, opts goja.Value) {
-	k6ext.Panic(f.ctx, "Frame.setInputFiles(selector, files, opts) has not been implemented yet")
-	// TODO: needs slowMo
+	f.log.Debugf("Frame:SetInputFiles", "fid:%s furl:%q sel:%q", f.ID(), f.URL(), selector)
+
+	popts := NewFrameSetInputFilesOptions(f.defaultTimeout())
+	if err := popts.Parse(f.ctx, opts); err != nil {
+		k6ext.Panic(f.ctx, "parse: %w", err)
+	}
+	parsedFiles, err := parseInputFiles(f.ctx, files)
+	if err != nil {
+		k6ext.Panic(f.ctx, "parsing files: %w", err)
+	}
+	if err := f.setInputFiles(selector, parsedFiles, popts); err != nil {
+		k6ext.Panic(f.ctx, "setInputFiles on %q: %w", selector, err)
+	}
+	applySlowMo(f.ctx)
+}
+
+func (f *Frame) setInputFiles(selector string, files []*InputFile, opts *FrameSetInputFilesOptions) error {
+	setInputFiles := func(apiCtx context.Context, handle *ElementHandle) (interface{}, error) {
+		return nil, handle.setInputFiles(apiCtx, files)
+	}
+	act := f.newAction(
+		selector, DOMElementStateAttached, opts.Strict, setInputFiles,
+		[]string{}, opts.Force, opts.NoWaitAfter, opts.Timeout, opts.Retries,
+	)
+	if _, err := callApiWithTimeout(f.ctx, act, opts.Timeout); err != nil {
+		return errorFromDOMError(err.Error())
+	}
+
+	return nil
 }
 
-// Tap the first element that matches the selector.
+// Tap the first element that matches the selector. It requires the browser
+// context to be created with hasTouch: true.
 func (f *Frame) Tap(selector string, opts goja.Value) {
 	f.log.Debugf("Frame:Tap", "fid:%s furl:%q sel:%q", f.ID(), f.URL(), selector)
 
@@ -1637,6 +2699,9 @@ func (f *Frame) Tap(selector string, opts goja.Value) {
 }
 
 func (f *Frame) tap(selector string, opts *FrameTapOptions) error {
+	if !f.page.hasTouch() {
+		return ErrTouchDisabled
+	}
 	tap := func(apiCtx context.Context, handle *ElementHandle, p *Position) (interface{}, error) {
 		return nil, handle.tap(apiCtx, p)
 	}
@@ -1675,7 +2740,7 @@ func (f *Frame) textContent(selector string, opts *FrameTextContentOptions) (str
 	}
 	act := f.newAction(
 		selector, DOMElementStateAttached, opts.Strict, TextContent,
-		[]string{}, false, true, opts.Timeout,
+		[]string{}, false, true, opts.Timeout, 0,
 	)
 	v, err := callApiWithTimeout(f.ctx, act, opts.Timeout)
 	if err != nil {
@@ -1716,11 +2781,11 @@ func (f *Frame) Type(selector, text string, opts goja.Value) {
 
 func (f *Frame) typ(selector, text string, opts *FrameTypeOptions) error {
 	typeText := func(apiCtx context.Context, handle *ElementHandle) (interface{}, error) {
-		return nil, handle.typ(apiCtx, text, opts.ToKeyboardOptions())
+		return nil, handle.typ(apiCtx, text, opts.ToKeyboardOptions(), opts.RespectMaxLength)
 	}
 	act := f.newAction(
 		selector, DOMElementStateAttached, opts.Strict, typeText,
-		[]string{}, false, opts.NoWaitAfter, opts.Timeout,
+		[]string{}, false, opts.NoWaitAfter, opts.Timeout, opts.Retries,
 	)
 	if _, err := callApiWithTimeout(f.ctx, act, opts.Timeout); err != nil {
 		return errorFromDOMError(err.Error())
@@ -1741,6 +2806,14 @@ func (f *Frame) URL() string {
 	return f.url
 }
 
+// setNavigatedWithinDocument records the time of a same-document navigation.
+func (f *Frame) setNavigatedWithinDocument() {
+	f.propertiesMu.Lock()
+	defer f.propertiesMu.Unlock()
+
+	f.navigatedWithinDocumentTime = time.Now()
+}
+
 // URL set the frame URL.
 func (f *Frame) setURL(url string) {
 	defer f.propertiesMu.Unlock()
@@ -1773,13 +2846,8 @@ func (f *Frame) WaitForFunction(fn goja.Value, opts goja.Value, jsArgs ...goja.V
 		args = append(args, a.Export())
 	}
 
-	var polling interface{} = parsedOpts.Polling
-	if parsedOpts.Polling == PollingInterval {
-		polling = parsedOpts.Interval
-	}
-
 	promise, err := f.waitForFunction(f.ctx, mainWorld, js,
-		polling, parsedOpts.Timeout, args...)
+		parsedOpts.pollingValue(), parsedOpts.Timeout, args...)
 	if err != nil {
 		k6ext.Panic(f.ctx, "%w", err)
 	}
@@ -1787,6 +2855,92 @@ func (f *Frame) WaitForFunction(fn goja.Value, opts goja.Value, jsArgs ...goja.V
 	return promise
 }
 
+// WaitForExecutionContext returns a promise that resolves when the frame
+// has a usable main world execution context, e.g. to evaluate in a frame
+// that was just attached.
+func (f *Frame) WaitForExecutionContext(opts goja.Value) *goja.Promise {
+	f.log.Debugf("Frame:WaitForExecutionContext", "fid:%s furl:%q", f.ID(), f.URL())
+
+	parsedOpts := NewFrameWaitForExecutionContextOptions(f.defaultTimeout())
+	if err := parsedOpts.Parse(f.ctx, opts); err != nil {
+		k6ext.Panic(f.ctx, "parsing waitForExecutionContext options: %w", err)
+	}
+
+	cb := f.vu.RegisterCallback()
+	rt := f.vu.Runtime()
+	promise, resolve, reject := rt.NewPromise()
+
+	go func() {
+		var (
+			ctx    context.Context
+			cancel context.CancelFunc
+		)
+		if parsedOpts.Timeout > 0 {
+			ctx, cancel = context.WithTimeout(f.ctx, parsedOpts.Timeout)
+		} else {
+			ctx, cancel = context.WithCancel(f.ctx)
+		}
+		defer cancel()
+
+		err := timeoutError(f.executionContextReadyWait(ctx, mainWorld), parsedOpts.Timeout)
+		cb(func() error {
+			if err != nil {
+				reject(fmt.Errorf("waiting for execution context: %w", err))
+				return nil
+			}
+			resolve(f)
+			return nil
+		})
+	}()
+
+	return promise
+}
+
+// WaitForImages returns a promise that resolves once all the images within
+// the element matching the selector, or the element itself if it's an
+// image, have loaded and decoded. Unlike the networkidle load state, it
+// isn't fooled by lazy-loaded images or placeholders swapped in later.
+func (f *Frame) WaitForImages(selector string, opts goja.Value) *goja.Promise {
+	f.log.Debugf("Frame:WaitForImages", "fid:%s furl:%q sel:%q", f.ID(), f.URL(), selector)
+
+	parsedOpts := NewFrameWaitForImagesOptions(f.defaultTimeout())
+	if err := parsedOpts.Parse(f.ctx, opts); err != nil {
+		k6ext.Panic(f.ctx, "parsing waitForImages %q options: %w", selector, err)
+	}
+	parsedSelector, err := NewSelector(selector)
+	if err != nil {
+		k6ext.Panic(f.ctx, "parsing selector %q: %w", selector, err)
+	}
+
+	f.waitForExecutionContext(mainWorld)
+	f.executionContextMu.RLock()
+	execCtx := f.executionContexts[mainWorld]
+	f.executionContextMu.RUnlock()
+	if execCtx == nil {
+		k6ext.Panic(f.ctx, "waitForImages %q: execution context %q not found", selector, mainWorld)
+	}
+	injected, err := execCtx.getInjectedScript(f.ctx)
+	if err != nil {
+		k6ext.Panic(f.ctx, "waitForImages %q: getting injected script: %w", selector, err)
+	}
+
+	predicate := `(injected, selector) => {
+		const root = injected.querySelector(selector, document, false);
+		if (!root || typeof root === "string") {
+			return false;
+		}
+		const images = root.tagName === "IMG" ? [root] : [...root.querySelectorAll("img")];
+		return images.every(img => img.complete && img.naturalWidth > 0);
+	}`
+	promise, err := f.waitForFunction(f.ctx, mainWorld, predicate,
+		PollingRaf, parsedOpts.Timeout, injected, parsedSelector)
+	if err != nil {
+		k6ext.Panic(f.ctx, "waitForImages %q: %w", selector, err)
+	}
+
+	return promise
+}
+
 // WaitForLoadState waits for the given load state to be reached.
 func (f *Frame) WaitForLoadState(state string, opts goja.Value) {
 	f.log.Debugf("Frame:WaitForLoadState", "fid:%s furl:%q state:%s", f.ID(), f.URL(), state)
@@ -1805,16 +2959,30 @@ func (f *Frame) WaitForLoadState(state string, opts goja.Value) {
 		}
 	}
 
-	if f.hasLifecycleEventFired(waitUntil) {
-		return
+	if err = f.waitForLoadState(waitUntil, parsedOpts); err != nil {
+		k6ext.Panic(f.ctx, "waitForLoadState %q: %v", state, err)
 	}
+}
 
-	_, err = waitForEvent(f.ctx, f, []string{EventFrameAddLifecycle}, func(data interface{}) bool {
-		return data.(LifecycleEvent) == waitUntil
-	}, parsedOpts.Timeout)
-	if err != nil {
-		k6ext.Panic(f.ctx, "waitForLoadState %q: %v", state, err)
+// waitForLoadState waits for the frame to reach the lifecycle state. With
+// the includeSubframes option, it waits for the state to be reached by all
+// the frames of its subtree, e.g. for the network of every iframe of the
+// page to become idle.
+func (f *Frame) waitForLoadState(waitUntil LifecycleEvent, opts *FrameWaitForLoadStateOptions) error {
+	fired := f.hasLifecycleEventFired
+	if opts.IncludeSubframes {
+		fired = f.hasSubtreeLifecycleEventFired
+	}
+	if fired(waitUntil) {
+		return nil
 	}
+
+	// the event is emitted once the whole subtree reaches the state.
+	_, err := waitForEvent(f.ctx, f, []string{EventFrameAddLifecycle}, func(data interface{}) bool {
+		return data.(LifecycleEvent) == waitUntil
+	}, opts.Timeout)
+
+	return err
 }
 
 // WaitForNavigation waits for the given navigation lifecycle event to happen.
@@ -1822,8 +2990,50 @@ func (f *Frame) WaitForNavigation(opts goja.Value) api.Response {
 	return f.manager.WaitForFrameNavigation(f, opts)
 }
 
+// WaitForRequest returns a promise that resolves to the first request made
+// within the frame whose URL matches urlOrPredicate. It can be a glob
+// pattern, a RegExp or a function that is called with the request URL.
+// The requests are matched from the time of the call, so it captures the
+// requests of an action that runs before the promise is awaited.
+func (f *Frame) WaitForRequest(urlOrPredicate, opts goja.Value) *goja.Promise {
+	f.log.Debugf("Frame:WaitForRequest", "fid:%s furl:%q url:%v", f.ID(), f.URL(), urlOrPredicate)
+
+	parsedOpts := NewFrameWaitForRequestOptions(f.defaultTimeout())
+	if err := parsedOpts.Parse(f.ctx, opts); err != nil {
+		k6ext.Panic(f.ctx, "parsing waitForRequest options: %w", err)
+	}
+
+	if f.page == nil {
+		k6ext.Panic(f.ctx, "waitForRequest: frame has no page")
+	}
+
+	return f.page.waitForNetworkEvent(f, "waitForRequest", EventPageRequest, urlOrPredicate, parsedOpts.Timeout)
+}
+
+// WaitForResponse returns a promise that resolves to the first response
+// received within the frame whose URL matches urlOrPredicate. It can be a
+// glob pattern, a RegExp or a function that is called with the response URL.
+// The responses are matched from the time of the call, so it captures the
+// responses of an action that runs before the promise is awaited.
+func (f *Frame) WaitForResponse(urlOrPredicate, opts goja.Value) *goja.Promise {
+	f.log.Debugf("Frame:WaitForResponse", "fid:%s furl:%q url:%v", f.ID(), f.URL(), urlOrPredicate)
+
+	parsedOpts := NewFrameWaitForResponseOptions(f.defaultTimeout())
+	if err := parsedOpts.Parse(f.ctx, opts); err != nil {
+		k6ext.Panic(f.ctx, "parsing waitForResponse options: %w", err)
+	}
+
+	if f.page == nil {
+		k6ext.Panic(f.ctx, "waitForResponse: frame has no page")
+	}
+
+	return f.page.waitForNetworkEvent(f, "waitForResponse", EventPageResponse, urlOrPredicate, parsedOpts.Timeout)
+}
+
 // WaitForSelector waits for the given selector to match the waiting criteria.
 func (f *Frame) WaitForSelector(selector string, opts goja.Value) api.ElementHandle {
+	f.log.Debugf("Frame:WaitForSelector", "fid:%s furl:%q sel:%q", f.ID(), f.URL(), selector)
+
 	parsedOpts := NewFrameWaitForSelectorOptions(f.defaultTimeout())
 	if err := parsedOpts.Parse(f.ctx, opts); err != nil {
 		k6ext.Panic(f.ctx, "parsing waitForSelector %q options: %w", selector, err)
@@ -1835,6 +3045,53 @@ func (f *Frame) WaitForSelector(selector string, opts goja.Value) api.ElementHan
 	return handle
 }
 
+// WaitForAnySelector waits for the first of the given selectors to satisfy
+// the state option, and returns the matching element with its selector.
+func (f *Frame) WaitForAnySelector(selectors []string, opts goja.Value) *api.SelectorMatch {
+	f.log.Debugf("Frame:WaitForAnySelector", "fid:%s furl:%q sels:%q", f.ID(), f.URL(), selectors)
+
+	parsedOpts := NewFrameWaitForSelectorOptions(f.defaultTimeout())
+	if err := parsedOpts.Parse(f.ctx, opts); err != nil {
+		k6ext.Panic(f.ctx, "parsing waitForAnySelector %q options: %w", selectors, err)
+	}
+	handle, selector, err := f.waitForAnySelector(f.ctx, selectors, parsedOpts)
+	if err != nil {
+		k6ext.Panic(f.ctx, "waitForAnySelector %q: %w", selectors, err)
+	}
+	match := api.SelectorMatch{Selector: selector}
+	if handle != nil {
+		match.Handle = handle
+	}
+	return &match
+}
+
+// WaitForCount waits until the selector matches count elements, such as
+// zero for the loading spinners to vanish. Unlike the detached state of
+// WaitForSelector, it waits for all the matching elements at once.
+func (f *Frame) WaitForCount(selector string, count int64, opts goja.Value) {
+	f.log.Debugf("Frame:WaitForCount", "fid:%s furl:%q sel:%q count:%d", f.ID(), f.URL(), selector, count)
+
+	parsedOpts := NewFrameWaitForCountOptions(f.defaultTimeout())
+	if err := parsedOpts.Parse(f.ctx, opts); err != nil {
+		k6ext.Panic(f.ctx, "parsing waitForCount %q options: %w", selector, err)
+	}
+	if count < 0 {
+		k6ext.Panic(f.ctx, "waitForCount %q: count must be a non-negative integer, got: %d", selector, count)
+	}
+	if err := f.waitForCount(f.ctx, selector, count, parsedOpts.Timeout); err != nil {
+		k6ext.Panic(f.ctx, "waitForCount %q: %w", selector, err)
+	}
+}
+
+func (f *Frame) waitForCount(apiCtx context.Context, selector string, count int64, timeout time.Duration) error {
+	document, err := f.document()
+	if err != nil {
+		return err
+	}
+
+	return document.waitForCount(apiCtx, selector, count, timeout)
+}
+
 // WaitForTimeout waits the specified amount of milliseconds.
 func (f *Frame) WaitForTimeout(timeout int64) {
 	to := time.Duration(timeout) * time.Millisecond
@@ -1861,6 +3118,32 @@ func (f *Frame) adoptBackendNodeID(world executionWorld, id cdp.BackendNodeID) (
 	return ec.adoptBackendNodeID(id)
 }
 
+// evaluateWithTimeout waits for the main world of the frame, and evaluates
+// the js function in it with the args, returning the result by value. Both
+// steps share the timeout, and a zero timeout uses the default timeout.
+func (f *Frame) evaluateWithTimeout(timeout time.Duration, js string, args ...interface{}) (interface{}, error) {
+	if timeout == 0 {
+		timeout = f.defaultTimeout()
+	}
+	ctx, cancel := context.WithTimeout(f.ctx, timeout)
+	defer cancel()
+
+	if err := f.executionContextReadyWait(ctx, mainWorld); err != nil {
+		return nil, fmt.Errorf("waiting for execution context: %w", timeoutError(err, timeout))
+	}
+	rt := f.vu.Runtime()
+	gargs := make([]goja.Value, 0, len(args))
+	for _, a := range args {
+		gargs = append(gargs, rt.ToValue(a))
+	}
+	opts := evalOptions{forceCallable: true, returnByValue: true}
+	result, err := f.evaluate(ctx, mainWorld, opts, rt.ToValue(js), gargs...)
+	if err != nil {
+		return nil, timeoutError(err, timeout)
+	}
+	return result, nil
+}
+
 func (f *Frame) evaluate(
 	apiCtx context.Context,
 	world executionWorld,
@@ -1926,17 +3209,19 @@ type frameExecutionContext interface {
 //nolint:unparam
 func (f *Frame) newAction(
 	selector string, state DOMElementState, strict bool, fn elementHandleActionFunc, states []string,
-	force, noWaitAfter bool, timeout time.Duration,
+	force, noWaitAfter bool, timeout time.Duration, retries int64,
 ) func(apiCtx context.Context, resultCh chan interface{}, errCh chan error) {
 	// We execute a frame action in the following steps:
 	// 1. Find element matching specified selector
 	// 2. Wait for it to reach specified DOM state
 	// 3. Run element handle action (incl. actionability checks)
-	return func(apiCtx context.Context, resultCh chan interface{}, errCh chan error) {
+	// 4. Retry from step 1 on a recoverable failure
+	return retryAction(retries, func(apiCtx context.Context, resultCh chan interface{}, errCh chan error) {
 		waitOpts := NewFrameWaitForSelectorOptions(f.defaultTimeout())
 		waitOpts.State = state
 		waitOpts.Strict = strict
-		handle, err := f.waitForSelector(selector, waitOpts)
+		waitOpts.Timeout = remainingTimeout(apiCtx, timeout)
+		handle, err := f.waitForSelector(apiCtx, selector, waitOpts)
 		if err != nil {
 			errCh <- err
 			return
@@ -1947,7 +3232,7 @@ func (f *Frame) newAction(
 		}
 		f := handle.newAction(states, fn, false, false, timeout)
 		f(apiCtx, resultCh, errCh)
-	}
+	})
 }
 
 //nolint:unparam
@@ -1959,11 +3244,13 @@ func (f *Frame) newPointerAction(
 	// 1. Find element matching specified selector
 	// 2. Wait for it to reach specified DOM state
 	// 3. Run element handle action (incl. actionability checks)
-	return func(apiCtx context.Context, resultCh chan interface{}, errCh chan error) {
+	// 4. Retry from step 1 on a recoverable failure
+	return retryAction(opts.Retries, func(apiCtx context.Context, resultCh chan interface{}, errCh chan error) {
 		waitOpts := NewFrameWaitForSelectorOptions(f.defaultTimeout())
 		waitOpts.State = state
 		waitOpts.Strict = strict
-		handle, err := f.waitForSelector(selector, waitOpts)
+		waitOpts.Timeout = remainingTimeout(apiCtx, opts.Timeout)
+		handle, err := f.waitForSelector(apiCtx, selector, waitOpts)
 		if err != nil {
 			errCh <- err
 			return
@@ -1974,5 +3261,81 @@ func (f *Frame) newPointerAction(
 		}
 		f := handle.newPointerAction(fn, opts)
 		f(apiCtx, resultCh, errCh)
+	})
+}
+
+// retryActionDelays are the delays before the attempts of an action that
+// is retried. The last one is used for the rest of the attempts.
+var retryActionDelays = []time.Duration{
+	20 * time.Millisecond, 100 * time.Millisecond, 100 * time.Millisecond, 500 * time.Millisecond,
+}
+
+// retryAction returns an action that runs act again, up to retries times,
+// when it fails with a recoverable error, after a short delay that gives
+// the page time to settle. Since act resolves the selector from scratch,
+// this handles the elements that are replaced while a page re-renders,
+// unlike the actionability checks that wait on one element. All the
+// attempts share the deadline of apiCtx.
+func retryAction(
+	retries int64, act func(apiCtx context.Context, resultCh chan interface{}, errCh chan error),
+) func(apiCtx context.Context, resultCh chan interface{}, errCh chan error) {
+	if retries <= 0 {
+		return act
+	}
+	return func(apiCtx context.Context, resultCh chan interface{}, errCh chan error) {
+		for attempt := int64(0); ; attempt++ {
+			attemptResultCh := make(chan interface{}, 1)
+			attemptErrCh := make(chan error, 1)
+			act(apiCtx, attemptResultCh, attemptErrCh)
+
+			select {
+			case res := <-attemptResultCh:
+				resultCh <- res
+				return
+			case err := <-attemptErrCh:
+				if attempt >= retries || !isRecoverableActionError(err) {
+					errCh <- err
+					return
+				}
+			case <-apiCtx.Done():
+				return
+			}
+
+			delay := retryActionDelays[len(retryActionDelays)-1]
+			if attempt < int64(len(retryActionDelays)) {
+				delay = retryActionDelays[attempt]
+			}
+			select {
+			case <-time.After(delay):
+			case <-apiCtx.Done():
+				return
+			}
+		}
+	}
+}
+
+// isRecoverableActionError returns true if err is caused by the element
+// being detached or covered, or by its node or execution context going
+// away, which another attempt of the action can succeed after.
+func isRecoverableActionError(err error) bool {
+	if errors.Is(err, ErrElementNotAttached) || errors.Is(err, ErrElementIntercepted) {
+		return true
+	}
+	// the errors are turned to strings at some of the boundaries.
+	msg := err.Error()
+	for _, s := range []string{
+		ErrElementNotAttached.Error(),
+		ErrElementIntercepted.Error(),
+		"error:notconnected",
+		"error:intercept",
+		"Node is detached",
+		"No node with given id",
+		"Cannot find context with specified id",
+		"Execution context was destroyed",
+	} {
+		if strings.Contains(msg, s) {
+			return true
+		}
 	}
+	return false
 }
@@ -152,11 +152,15 @@ func (m *FrameManager) frameAbortedNavigation(frameID cdp.FrameID, errorText, do
 		"fmid:%d fid:%v err:%s docid:%s fname:%s furl:%s",
 		m.ID(), frameID, errorText, documentID, frame.Name(), frame.URL())
 
+	url := frame.URL()
+	if req := frame.pendingDocument.request; req != nil {
+		url = req.URL()
+	}
 	ne := &NavigationEvent{
 		url:         frame.URL(),
 		name:        frame.Name(),
 		newDocument: frame.pendingDocument,
-		err:         errors.New(errorText),
+		err:         newNavigationError(url, errorText),
 	}
 	frame.pendingDocument = nil
 	frame.emit(EventFrameNavigation, ne)
@@ -327,6 +331,7 @@ func (m *FrameManager) frameNavigated(frameID cdp.FrameID, parentFrameID cdp.Fra
 		m.ID(), frameID, parentFrameID, documentID, name, url, initial, documentID)
 
 	frame.clearLifecycle()
+	frame.emitTimeToFirstByte(frame.currentDocument)
 	frame.emit(EventFrameNavigation, &NavigationEvent{url: url, name: name, newDocument: frame.currentDocument})
 
 	// TODO: when we add API support for storage we need to track origins
@@ -358,7 +363,9 @@ func (m *FrameManager) frameNavigatedWithinDocument(frameID cdp.FrameID, url str
 	m.logger.Debugf("FrameManager:frameNavigatedWithinDocument",
 		"fmid:%d fid:%v furl:%s url:%s", m.ID(), frameID, frame.URL(), url)
 
+	frame.setNavigatedWithinDocument()
 	frame.setURL(url)
+	frame.resetLayoutShift()
 	frame.emit(EventFrameNavigation, &NavigationEvent{url: url, name: frame.Name()})
 }
 
@@ -425,6 +432,7 @@ func (m *FrameManager) removeFramesRecursively(frame *Frame) {
 		m.removeFramesRecursively(child.(*Frame))
 	}
 
+	frame.networkManager().deleteTransferSizes(frame.ID())
 	frame.detach()
 
 	m.framesMu.Lock()
@@ -433,6 +441,9 @@ func (m *FrameManager) removeFramesRecursively(frame *Frame) {
 		m.ID(), frame.ID(), frame.Name(), frame.URL())
 
 	delete(m.frames, cdp.FrameID(frame.ID()))
+	// the parent is unset under the lock, since networkIdleTimeout
+	// reads it from the network idle timers.
+	frame.parentFrame = nil
 	m.framesMu.Unlock()
 
 	if !m.page.IsClosed() {
@@ -539,6 +550,19 @@ func (m *FrameManager) requestStarted(req *Request) {
 	m.logger.Debugf("FrameManager:requestStarted", "fmid:%d rurl:%s pdoc:nil", m.ID(), req.URL())
 }
 
+// inflightRequestsLen returns the number of inflight requests of all the
+// frames on the page.
+func (m *FrameManager) inflightRequestsLen() int {
+	m.framesMu.RLock()
+	defer m.framesMu.RUnlock()
+
+	var n int
+	for _, f := range m.frames {
+		n += f.inflightRequestsLen()
+	}
+	return n
+}
+
 // Frames returns a list of frames on the page.
 func (m *FrameManager) Frames() []api.Frame {
 	m.framesMu.RLock()
@@ -590,6 +614,12 @@ func (m *FrameManager) NavigateFrame(frame *Frame, url string, opts goja.Value)
 		k6ext.Panic(m.ctx, "parsing frame navigation options to %q: %v", url, err)
 	}
 
+	if parsedOpts.NetworkIdleTimeout > 0 {
+		frame.setGotoNetworkIdleTimeout(parsedOpts.NetworkIdleTimeout)
+		defer frame.setGotoNetworkIdleTimeout(0)
+	}
+
+	start := time.Now()
 	timeoutCtx, timeoutCancelFn := context.WithTimeout(m.ctx, parsedOpts.Timeout)
 	defer timeoutCancelFn()
 
@@ -614,18 +644,72 @@ func (m *FrameManager) NavigateFrame(frame *Frame, url string, opts goja.Value)
 		// main frame's session.
 		fs = frame.page.mainFrameSession
 	}
-	newDocumentID, err := fs.navigateFrame(frame, url, parsedOpts.Referer)
+	m.framesMu.RLock()
+	prevDocument := frame.currentDocument
+	m.framesMu.RUnlock()
+
+	// Navigate in the background, since the VU goroutine might need to
+	// handle the navigation request if it's intercepted by a route.
+	type navigateResult struct {
+		documentID string
+		err        error
+	}
+	navCh := make(chan interface{}, 1)
+	navigate := func() (string, error) {
+		return fs.navigateFrame(frame, url, parsedOpts.Referer)
+	}
+	retryable := func(err error) bool {
+		if !isTransientNavigationError(err) {
+			return false
+		}
+		// a navigation aborted by another one that is committed or
+		// pending is handled as a redirect below instead.
+		m.framesMu.RLock()
+		replaced := frame.currentDocument != prevDocument || frame.pendingDocument != nil
+		m.framesMu.RUnlock()
+		if !replaced {
+			m.logger.Debugf("FrameManager:NavigateFrame:retry",
+				"fmid:%d fid:%v furl:%s url:%s err:%v", fmid, fid, furl, url, err)
+		}
+		return !replaced
+	}
+	go func() {
+		documentID, err := retryNavigation(timeoutCtx, parsedOpts.Retries, parsedOpts.RetryDelay, navigate, retryable)
+		navCh <- navigateResult{documentID, err}
+	}()
+	v, err := m.page.waitRouted(timeoutCtx, navCh, parsedOpts.Timeout)
 	if err != nil {
-		k6ext.Panic(m.ctx, "navigating to %q: %v", url, err)
+		k6ext.Panic(m.ctx, "navigating to %q: %w", url, err)
 	}
+	if v == nil {
+		return nil
+	}
+	res, _ := v.(navigateResult)
+	newDocumentID, err := res.documentID, res.err
 
 	var event *NavigationEvent
-	if newDocumentID != "" {
+	if err != nil {
+		var nerr NavigationError
+		if !errors.As(err, &nerr) {
+			k6ext.Panic(m.ctx, "navigating to %q: %v", url, err)
+		}
+		event, err = m.redirectedNavigation(timeoutCtx, frame, prevDocument, nerr, parsedOpts.Timeout)
+		if err != nil {
+			k6ext.Panic(m.ctx, "navigating to %q: %w", url, err)
+		}
+		if event == nil {
+			// throw the typed error as is so that scripts can check its code.
+			k6common.Throw(rt, nerr)
+		}
+		if event.err != nil {
+			k6common.Throw(rt, event.err)
+		}
+	} else if newDocumentID != "" {
 		m.logger.Debugf("FrameManager:NavigateFrame",
 			"fmid:%d fid:%v furl:%s url:%s newDocID:%s",
 			fmid, fid, furl, url, newDocumentID)
 
-		data, err := waitForEvent(m.ctx, frame, []string{EventFrameNavigation}, func(data interface{}) bool {
+		chNav, evCancelFn3 := createWaitForEventHandler(m.ctx, frame, []string{EventFrameNavigation}, func(data interface{}) bool {
 			ev := data.(*NavigationEvent)
 
 			// We are interested either in this specific document, or any other document that
@@ -634,12 +718,28 @@ func (m *FrameManager) NavigateFrame(frame *Frame, url string, opts goja.Value)
 				return true
 			}
 			return false
-		}, parsedOpts.Timeout)
+		})
+		defer evCancelFn3() // Remove event handler
+
+		data, err := m.page.waitRouted(timeoutCtx, chNav, parsedOpts.Timeout)
 		if err != nil {
 			k6ext.Panic(m.ctx, "navigating to %q: %v", url, err)
 		}
+		if data == nil {
+			return nil
+		}
 
 		event = data.(*NavigationEvent)
+		if event.err != nil {
+			// the navigation might have been aborted by a redirect.
+			redirected, err := m.redirectedNavigation(timeoutCtx, frame, prevDocument, event.err, parsedOpts.Timeout)
+			if err != nil {
+				k6ext.Panic(m.ctx, "navigating to %q: %w", url, err)
+			}
+			if redirected != nil {
+				event = redirected
+			}
+		}
 		if event.newDocument.documentID != newDocumentID {
 			m.logger.Debugf("FrameManager:NavigateFrame:interrupted",
 				"fmid:%d fid:%v furl:%s url:%s docID:%s newDocID:%s",
@@ -655,14 +755,14 @@ func (m *FrameManager) NavigateFrame(frame *Frame, url string, opts goja.Value)
 			"fmid:%d fid:%v furl:%s url:%s newDocID:0",
 			fmid, fid, furl, url)
 
-		select {
-		case <-timeoutCtx.Done():
-			if timeoutCtx.Err() == context.DeadlineExceeded {
-				k6ext.Panic(m.ctx, "navigating to %q: %s after %s", url, ErrTimedOut, parsedOpts.Timeout)
-			}
-		case data := <-chSameDoc:
-			event = data.(*NavigationEvent)
+		data, err := m.page.waitRouted(timeoutCtx, chSameDoc, parsedOpts.Timeout)
+		if err != nil {
+			k6ext.Panic(m.ctx, "navigating to %q: %w", url, err)
+		}
+		if data == nil {
+			return nil
 		}
+		event = data.(*NavigationEvent)
 	}
 
 	if !frame.hasSubtreeLifecycleEventFired(parsedOpts.WaitUntil) {
@@ -670,12 +770,8 @@ func (m *FrameManager) NavigateFrame(frame *Frame, url string, opts goja.Value)
 			"fmid:%d fid:%v furl:%s url:%s hasSubtreeLifecycleEventFired:false",
 			fmid, fid, furl, url)
 
-		select {
-		case <-timeoutCtx.Done():
-			if timeoutCtx.Err() == context.DeadlineExceeded {
-				k6ext.Panic(m.ctx, "navigating to %q: %s after %s", url, ErrTimedOut, parsedOpts.Timeout)
-			}
-		case <-chWaitUntilCh:
+		if _, err := m.page.waitRouted(timeoutCtx, chWaitUntilCh, parsedOpts.Timeout); err != nil {
+			k6ext.Panic(m.ctx, "navigating to %q: %w", url, err)
 		}
 	}
 
@@ -684,11 +780,112 @@ func (m *FrameManager) NavigateFrame(frame *Frame, url string, opts goja.Value)
 		req := event.newDocument.request
 		if req != nil && req.response != nil {
 			resp = req.response
+			resp.setNavigationTiming(start, time.Now())
 		}
 	}
 	return resp
 }
 
+// maxNavigationRetryDelay caps the doubling delay between the attempts of
+// a navigation.
+const maxNavigationRetryDelay = 5 * time.Second
+
+// transientNavigationErrorCodes are the network errors of a navigation
+// that another attempt can succeed after. The errors of a misconfigured
+// target, like ERR_NAME_NOT_RESOLVED or ERR_CONNECTION_REFUSED, aren't
+// retried.
+var transientNavigationErrorCodes = map[string]bool{
+	"ERR_ABORTED":              true,
+	"ERR_CONNECTION_CLOSED":    true,
+	"ERR_CONNECTION_RESET":     true,
+	"ERR_EMPTY_RESPONSE":       true,
+	"ERR_NETWORK_CHANGED":      true,
+	"ERR_NETWORK_IO_SUSPENDED": true,
+}
+
+// isTransientNavigationError returns true if err is a navigation error
+// with one of the transient network error codes.
+func isTransientNavigationError(err error) bool {
+	var nerr NavigationError
+	return errors.As(err, &nerr) && transientNavigationErrorCodes[nerr.Code]
+}
+
+// retryNavigation runs navigate again, up to retries times, while it fails
+// with an error that retryable accepts. The delay before a retry doubles
+// after each attempt. It returns the result of the last attempt, which has
+// the URL of the navigation in its error.
+func retryNavigation(
+	ctx context.Context, retries int64, delay time.Duration,
+	navigate func() (string, error), retryable func(error) bool,
+) (string, error) {
+	for attempt := int64(0); ; attempt++ {
+		documentID, err := navigate()
+		if err == nil || attempt >= retries || !retryable(err) {
+			return documentID, err
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return "", err
+		}
+		if delay *= 2; delay > maxNavigationRetryDelay {
+			delay = maxNavigationRetryDelay
+		}
+	}
+}
+
+// redirectedNavigation returns the navigation that replaced a navigation
+// aborted with net::ERR_ABORTED, e.g. when the server or the page redirects
+// right away. It returns nil if the navigation wasn't replaced by another one.
+func (m *FrameManager) redirectedNavigation(
+	ctx context.Context, frame *Frame, prevDocument *DocumentInfo, navErr error, timeout time.Duration,
+) (*NavigationEvent, error) {
+	if !errors.Is(navErr, NavigationError{Code: "ERR_ABORTED"}) {
+		return nil, nil
+	}
+
+	// subscribe before checking the frame state so that
+	// we don't miss a navigation committed in between.
+	ch, evCancelFn := createWaitForEventHandler(ctx, frame, []string{EventFrameNavigation}, func(data interface{}) bool {
+		return data.(*NavigationEvent).newDocument != nil
+	})
+	defer evCancelFn() // Remove event handler
+
+	m.framesMu.RLock()
+	current, pending := frame.currentDocument, frame.pendingDocument
+	m.framesMu.RUnlock()
+
+	m.logger.Debugf("FrameManager:redirectedNavigation",
+		"fmid:%d fid:%v furl:%s committed:%t pending:%t",
+		m.ID(), frame.ID(), frame.URL(), current != prevDocument, pending != nil)
+
+	switch {
+	case current != prevDocument:
+		return &NavigationEvent{url: frame.URL(), name: frame.Name(), newDocument: current}, nil
+	case pending == nil:
+		return nil, nil
+	}
+
+	data, err := m.page.waitRouted(ctx, ch, timeout)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return data.(*NavigationEvent), nil
+}
+
+// networkIdleTimeout returns how long the frames have to be without network
+// requests to reach the networkidle lifecycle state. It's the browser
+// context's timeout, or the default if it isn't set.
+// See Frame.networkIdleTimeout for the timeout of a navigation.
+func (m *FrameManager) networkIdleTimeout() time.Duration {
+	if m.page != nil && m.page.browserCtx != nil && m.page.browserCtx.opts != nil {
+		if d := m.page.browserCtx.opts.NetworkIdleTimeout; d > 0 {
+			return d
+		}
+	}
+	return LifeCycleNetworkIdleTimeout
+}
+
 // Page returns the page that this frame manager belongs to.
 func (m *FrameManager) Page() api.Page {
 	if m.page != nil {
@@ -717,19 +914,18 @@ func (m *FrameManager) WaitForFrameNavigation(frame *Frame, opts goja.Value) api
 		})
 	defer evCancelFn() // Remove event handler
 
-	var event *NavigationEvent
-	select {
-	case <-m.ctx.Done():
+	data, err := m.page.waitRouted(m.ctx, ch, parsedOpts.Timeout)
+	if err != nil {
+		k6ext.Panic(m.ctx, "waitForFrameNavigation: %w", err)
+	}
+	if data == nil {
 		// ignore: the extension is shutting down
 		m.logger.Warnf("FrameManager:WaitForFrameNavigation:<-ctx.Done",
 			"fmid:%d furl:%s err:%v",
 			m.ID(), frame.URL(), m.ctx.Err())
 		return nil
-	case <-time.After(parsedOpts.Timeout):
-		k6ext.Panic(m.ctx, "waitForFrameNavigation timed out after %s", parsedOpts.Timeout)
-	case data := <-ch:
-		event = data.(*NavigationEvent)
 	}
+	event := data.(*NavigationEvent)
 
 	if event.newDocument == nil {
 		// In case of navigation within the same document (e.g. via an anchor
@@ -0,0 +1,94 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsTransientNavigationError(t *testing.T) {
+	t.Parallel()
+
+	for text, want := range map[string]bool{
+		"net::ERR_ABORTED":            true,
+		"net::ERR_CONNECTION_RESET":   true,
+		"net::ERR_NETWORK_CHANGED":    true,
+		"net::ERR_NAME_NOT_RESOLVED":  false,
+		"net::ERR_CONNECTION_REFUSED": false,
+	} {
+		err := newNavigationError("https://test.k6.io", text)
+		assert.Equalf(t, want, isTransientNavigationError(err), "%s", text)
+	}
+	assert.False(t, isTransientNavigationError(errors.New("net::ERR_ABORTED")))
+}
+
+func TestRetryNavigation(t *testing.T) {
+	t.Parallel()
+
+	aborted := newNavigationError("https://test.k6.io", "net::ERR_ABORTED")
+	refused := newNavigationError("https://test.k6.io", "net::ERR_CONNECTION_REFUSED")
+
+	navigateErrs := func(errs ...error) (func() (string, error), *int) {
+		var calls int
+		return func() (string, error) {
+			calls++
+			if calls <= len(errs) {
+				return "", errs[calls-1]
+			}
+			return "doc", nil
+		}, &calls
+	}
+
+	t.Run("succeeds_after_transient", func(t *testing.T) {
+		t.Parallel()
+
+		navigate, calls := navigateErrs(aborted, aborted)
+		id, err := retryNavigation(context.Background(), 3, time.Millisecond, navigate, isTransientNavigationError)
+		require.NoError(t, err)
+		assert.Equal(t, "doc", id)
+		assert.Equal(t, 3, *calls)
+	})
+
+	t.Run("exhausted", func(t *testing.T) {
+		t.Parallel()
+
+		navigate, calls := navigateErrs(aborted, aborted, aborted)
+		_, err := retryNavigation(context.Background(), 2, time.Millisecond, navigate, isTransientNavigationError)
+		require.ErrorIs(t, err, NavigationError{Code: "ERR_ABORTED"})
+		assert.Contains(t, err.Error(), "https://test.k6.io")
+		assert.Equal(t, 3, *calls)
+	})
+
+	t.Run("non_transient", func(t *testing.T) {
+		t.Parallel()
+
+		navigate, calls := navigateErrs(refused)
+		_, err := retryNavigation(context.Background(), 3, time.Millisecond, navigate, isTransientNavigationError)
+		require.ErrorIs(t, err, NavigationError{Code: "ERR_CONNECTION_REFUSED"})
+		assert.Equal(t, 1, *calls)
+	})
+
+	t.Run("no_retries", func(t *testing.T) {
+		t.Parallel()
+
+		navigate, calls := navigateErrs(aborted)
+		_, err := retryNavigation(context.Background(), 0, time.Millisecond, navigate, isTransientNavigationError)
+		require.ErrorIs(t, err, NavigationError{Code: "ERR_ABORTED"})
+		assert.Equal(t, 1, *calls)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		navigate, calls := navigateErrs(aborted)
+		_, err := retryNavigation(ctx, 3, time.Hour, navigate, isTransientNavigationError)
+		require.ErrorIs(t, err, NavigationError{Code: "ERR_ABORTED"})
+		assert.Equal(t, 1, *calls)
+	})
+}
@@ -22,8 +22,13 @@ package common
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"net/url"
 	"reflect"
+	"strings"
 	"time"
 
 	"github.com/dop251/goja"
@@ -31,7 +36,24 @@ import (
 	"github.com/grafana/xk6-browser/k6ext"
 )
 
+// FrameAddScriptTagOptions are options for Frame.addScriptTag.
+type FrameAddScriptTagOptions struct {
+	URL     string `json:"url"`
+	Path    string `json:"path"`
+	Content string `json:"content"`
+	Type    string `json:"type"`
+}
+
+// FrameAddStyleTagOptions are options for Frame.addStyleTag.
+type FrameAddStyleTagOptions struct {
+	URL     string `json:"url"`
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
 type FrameBaseOptions struct {
+	// Timeout is the maximum time to wait for the action.
+	// A zero timeout disables the timeout.
 	Timeout time.Duration `json:"timeout"`
 	Strict  bool          `json:"strict"`
 }
@@ -56,10 +78,34 @@ type FrameFillOptions struct {
 	Strict bool `json:"strict"`
 }
 
+// FrameGetByRoleOptions are options for Frame.getByRole.
+type FrameGetByRoleOptions struct {
+	// Name matches the accessible name of the elements. It's either
+	// a JSON quoted string or a regular expression literal.
+	Name string `json:"name"`
+	// Exact matches the whole name case-sensitively, instead of
+	// a substring of it case-insensitively. It's ignored for
+	// regular expressions.
+	Exact bool `json:"exact"`
+	// Checked, Pressed and Expanded match the ARIA states of the
+	// elements. Nil matches any state.
+	Checked  *bool `json:"checked"`
+	Pressed  *bool `json:"pressed"`
+	Expanded *bool `json:"expanded"`
+}
+
 type FrameGotoOptions struct {
-	Referer   string         `json:"referer"`
-	Timeout   time.Duration  `json:"timeout"`
-	WaitUntil LifecycleEvent `json:"waitUntil"`
+	Referer            string         `json:"referer"`
+	Timeout            time.Duration  `json:"timeout"`
+	WaitUntil          LifecycleEvent `json:"waitUntil"`
+	NetworkIdleTimeout time.Duration  `json:"networkIdleTimeout"`
+	// Retries is how many times the navigation is started again after
+	// it fails with a transient network error, such as being aborted by
+	// a competing navigation. All the attempts share the timeout.
+	Retries int64 `json:"retries"`
+	// RetryDelay is the delay before the first retry, which doubles
+	// before each of the next ones, up to 5 seconds.
+	RetryDelay time.Duration `json:"retryDelay"`
 }
 
 type FrameHoverOptions struct {
@@ -73,6 +119,12 @@ type FrameInnerHTMLOptions struct {
 
 type FrameInnerTextOptions struct {
 	FrameBaseOptions
+	// Within is a selector that scopes the text to the elements
+	// matching it within the element.
+	Within string `json:"within"`
+	// MaxDepth leaves out the text of the elements nested more than
+	// MaxDepth levels below the element. Zero includes all of them.
+	MaxDepth int64 `json:"maxDepth"`
 }
 
 type FrameInputValueOptions struct {
@@ -113,6 +165,16 @@ type FrameSelectOptionOptions struct {
 	Strict bool `json:"strict"`
 }
 
+type FrameSelectTextOptions struct {
+	ElementHandleBaseOptions
+	Strict bool `json:"strict"`
+}
+
+type FrameSetInputFilesOptions struct {
+	ElementHandleBaseOptions
+	Strict bool `json:"strict"`
+}
+
 type FrameSetContentOptions struct {
 	Timeout   time.Duration  `json:"timeout"`
 	WaitUntil LifecycleEvent `json:"waitUntil"`
@@ -139,25 +201,111 @@ type FrameUncheckOptions struct {
 }
 
 type FrameWaitForFunctionOptions struct {
-	Polling  PollingType   `json:"polling"`
+	Polling PollingType `json:"polling"`
+	// Interval is the time between the calls of the predicate in
+	// milliseconds. It's only used with the interval polling.
 	Interval int64         `json:"interval"`
 	Timeout  time.Duration `json:"timeout"`
 }
 
-type FrameWaitForLoadStateOptions struct {
+type FrameWaitForCountOptions struct {
 	Timeout time.Duration `json:"timeout"`
 }
 
+type FrameWaitForExecutionContextOptions struct {
+	Timeout time.Duration `json:"timeout"`
+}
+
+type FrameWaitForImagesOptions struct {
+	Timeout time.Duration `json:"timeout"`
+}
+
+type FrameWaitForLoadStateOptions struct {
+	// IncludeSubframes waits for the state to be reached by the whole
+	// frame tree below the frame, instead of only by the frame itself.
+	IncludeSubframes bool          `json:"includeSubframes"`
+	Timeout          time.Duration `json:"timeout"`
+}
+
 type FrameWaitForNavigationOptions struct {
 	URL       string         `json:"url"`
 	WaitUntil LifecycleEvent `json:"waitUntil"`
 	Timeout   time.Duration  `json:"timeout"`
 }
 
+type FrameWaitForRequestOptions struct {
+	Timeout time.Duration `json:"timeout"`
+}
+
+type FrameWaitForResponseOptions struct {
+	Timeout time.Duration `json:"timeout"`
+}
+
 type FrameWaitForSelectorOptions struct {
-	State   DOMElementState `json:"state"`
-	Strict  bool            `json:"strict"`
-	Timeout time.Duration   `json:"timeout"`
+	State DOMElementState `json:"state"`
+	// States are the states that the element must be in at the same time.
+	// It is set when the state option is an array, and overrides State.
+	States  []DOMElementState `json:"states"`
+	Strict  bool              `json:"strict"`
+	Timeout time.Duration     `json:"timeout"`
+}
+
+// NewFrameAddScriptTagOptions returns a new FrameAddScriptTagOptions.
+func NewFrameAddScriptTagOptions() *FrameAddScriptTagOptions {
+	return &FrameAddScriptTagOptions{}
+}
+
+// Parse parses the addScriptTag options. One of the url, path or content
+// options is required.
+func (o *FrameAddScriptTagOptions) Parse(ctx context.Context, opts goja.Value) error {
+	rt := k6ext.Runtime(ctx)
+	if opts != nil && !goja.IsUndefined(opts) && !goja.IsNull(opts) {
+		opts := opts.ToObject(rt)
+		for _, k := range opts.Keys() {
+			switch k {
+			case "url":
+				o.URL = opts.Get(k).String()
+			case "path":
+				o.Path = opts.Get(k).String()
+			case "content":
+				o.Content = opts.Get(k).String()
+			case "type":
+				o.Type = opts.Get(k).String()
+			}
+		}
+	}
+	if o.URL == "" && o.Path == "" && o.Content == "" {
+		return errors.New("one of url, path or content is required")
+	}
+	return nil
+}
+
+// NewFrameAddStyleTagOptions returns a new FrameAddStyleTagOptions.
+func NewFrameAddStyleTagOptions() *FrameAddStyleTagOptions {
+	return &FrameAddStyleTagOptions{}
+}
+
+// Parse parses the addStyleTag options. One of the url, path or content
+// options is required.
+func (o *FrameAddStyleTagOptions) Parse(ctx context.Context, opts goja.Value) error {
+	rt := k6ext.Runtime(ctx)
+	if opts != nil && !goja.IsUndefined(opts) && !goja.IsNull(opts) {
+		opts := opts.ToObject(rt)
+		for _, k := range opts.Keys() {
+			switch k {
+			case "url":
+				o.URL = opts.Get(k).String()
+			case "path":
+				o.Path = opts.Get(k).String()
+			case "content":
+				o.Content = opts.Get(k).String()
+			}
+		}
+	}
+	if o.URL == "" && o.Path == "" && o.Content == "" {
+		return errors.New("one of url, path or content is required")
+	}
+	return nil
 }
 
 func NewFrameBaseOptions(defaultTimeout time.Duration) *FrameBaseOptions {
@@ -170,15 +318,22 @@ func NewFrameBaseOptions(defaultTimeout time.Duration) *FrameBaseOptions {
 func (o *FrameBaseOptions) Parse(ctx context.Context, opts goja.Value) error {
 	rt := k6ext.Runtime(ctx)
 	if opts != nil && !goja.IsUndefined(opts) && !goja.IsNull(opts) {
+		var deadline time.Time
 		opts := opts.ToObject(rt)
 		for _, k := range opts.Keys() {
 			switch k {
+			case "deadline":
+				var err error
+				if deadline, err = parseDeadline(opts.Get(k)); err != nil {
+					return err
+				}
 			case "strict":
 				o.Strict = opts.Get(k).ToBoolean()
 			case "timeout":
 				o.Timeout = time.Duration(opts.Get(k).ToInteger()) * time.Millisecond
 			}
 		}
+		o.Timeout = timeoutUntil(deadline, o.Timeout)
 	}
 	return nil
 }
@@ -279,11 +434,121 @@ func (o *FrameFillOptions) Parse(ctx context.Context, opts goja.Value) error {
 	return nil
 }
 
+// NewFrameGetByRoleOptions returns the default getByRole options.
+func NewFrameGetByRoleOptions() *FrameGetByRoleOptions {
+	return &FrameGetByRoleOptions{}
+}
+
+// Parse parses the getByRole options. The name option is either a string or
+// a RegExp, and the checked, pressed and expanded options are booleans.
+func (o *FrameGetByRoleOptions) Parse(ctx context.Context, opts goja.Value) error {
+	rt := k6ext.Runtime(ctx)
+	if opts != nil && !goja.IsUndefined(opts) && !goja.IsNull(opts) {
+		opts := opts.ToObject(rt)
+		for _, k := range opts.Keys() {
+			switch k {
+			case "checked", "expanded", "pressed":
+				v := opts.Get(k)
+				if !gojaValueExists(v) {
+					continue
+				}
+				b := v.ToBoolean()
+				switch k {
+				case "checked":
+					o.Checked = &b
+				case "expanded":
+					o.Expanded = &b
+				case "pressed":
+					o.Pressed = &b
+				}
+			case "exact":
+				o.Exact = opts.Get(k).ToBoolean()
+			case "name":
+				v := opts.Get(k)
+				if !gojaValueExists(v) {
+					continue
+				}
+				if re, ok := v.(*goja.Object); ok && re.ClassName() == "RegExp" {
+					o.Name = fmt.Sprintf("/%s/%s", escapeRoleNameRegExp(re.Get("source").String()), re.Get("flags").String())
+					continue
+				}
+				b, err := json.Marshal(v.String())
+				if err != nil {
+					return fmt.Errorf("parsing name: %w", err)
+				}
+				o.Name = string(b)
+			}
+		}
+	}
+	return nil
+}
+
+// selector returns the role selector of the elements with the role that
+// match the options.
+func (o *FrameGetByRoleOptions) selector(role string) string {
+	var sb strings.Builder
+	sb.WriteString("role=" + role)
+	if o.Name != "" {
+		var flag string
+		if o.Exact && !strings.HasPrefix(o.Name, "/") {
+			flag = " s"
+		}
+		fmt.Fprintf(&sb, "[name=%s%s]", o.Name, flag)
+	}
+	for _, s := range []struct {
+		name  string
+		value *bool
+	}{
+		{"checked", o.Checked},
+		{"pressed", o.Pressed},
+		{"expanded", o.Expanded},
+	} {
+		if s.value != nil {
+			fmt.Fprintf(&sb, "[%s=%t]", s.name, *s.value)
+		}
+	}
+	return sb.String()
+}
+
+// escapeRoleNameRegExp escapes the characters of a RegExp source that would
+// otherwise end the name attribute of a role selector early or split the
+// selector on quotes and >>. Escaped characters are kept as they are, and a
+// ] only closes a character class.
+func escapeRoleNameRegExp(source string) string {
+	var (
+		sb      strings.Builder
+		inClass bool
+	)
+	for i := 0; i < len(source); i++ {
+		c := source[i]
+		switch {
+		case c == '\\' && i+1 < len(source):
+			sb.WriteByte(c)
+			i++
+			sb.WriteByte(source[i])
+		case c == '"', c == '\'', c == '`', c == '>':
+			fmt.Fprintf(&sb, `\x%02x`, c)
+		case c == '[':
+			inClass = true
+			sb.WriteByte(c)
+		case c == ']' && !inClass:
+			sb.WriteString(`\]`)
+		case c == ']':
+			inClass = false
+			sb.WriteByte(c)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
 func NewFrameGotoOptions(defaultReferer string, defaultTimeout time.Duration) *FrameGotoOptions {
 	return &FrameGotoOptions{
-		Referer:   defaultReferer,
-		Timeout:   defaultTimeout,
-		WaitUntil: LifecycleEventLoad,
+		Referer:    defaultReferer,
+		Timeout:    defaultTimeout,
+		WaitUntil:  LifecycleEventLoad,
+		RetryDelay: 100 * time.Millisecond,
 	}
 }
 
@@ -293,8 +558,29 @@ func (o *FrameGotoOptions) Parse(ctx context.Context, opts goja.Value) error {
 		opts := opts.ToObject(rt)
 		for _, k := range opts.Keys() {
 			switch k {
+			case "networkIdleTimeout":
+				d, err := parseNetworkIdleTimeout(opts.Get(k))
+				if err != nil {
+					return fmt.Errorf("parsing goto options: %w", err)
+				}
+				o.NetworkIdleTimeout = d
 			case "referer":
-				o.Referer = opts.Get(k).String()
+				referer, err := parseReferer(opts.Get(k).String())
+				if err != nil {
+					return fmt.Errorf("parsing goto options: %w", err)
+				}
+				o.Referer = referer
+			case "retries":
+				o.Retries = opts.Get(k).ToInteger()
+				if o.Retries < 0 {
+					return fmt.Errorf("parsing goto options: retries must be a non-negative integer, got: %d", o.Retries)
+				}
+			case "retryDelay":
+				ms := opts.Get(k).ToInteger()
+				if ms < 0 {
+					return fmt.Errorf("parsing goto options: retryDelay must be a non-negative number of milliseconds, got: %d", ms)
+				}
+				o.RetryDelay = time.Duration(ms) * time.Millisecond
 			case "timeout":
 				o.Timeout = time.Duration(opts.Get(k).ToInteger()) * time.Millisecond
 			case "waitUntil":
@@ -308,6 +594,29 @@ func (o *FrameGotoOptions) Parse(ctx context.Context, opts goja.Value) error {
 	return nil
 }
 
+// parseReferer validates the referer of a navigation, which must be an
+// absolute URL. An empty referer sends no Referer header.
+func parseReferer(referer string) (string, error) {
+	if referer == "" {
+		return "", nil
+	}
+	u, err := url.Parse(referer)
+	if err != nil || !u.IsAbs() || u.Host == "" {
+		return "", fmt.Errorf("referer must be an absolute URL, got: %q", referer)
+	}
+	return referer, nil
+}
+
+// parseNetworkIdleTimeout parses the quiet period in milliseconds
+// after which a frame without network requests is considered idle.
+func parseNetworkIdleTimeout(v goja.Value) (time.Duration, error) {
+	ms := v.ToFloat()
+	if ms <= 0 || math.IsNaN(ms) {
+		return 0, fmt.Errorf("networkIdleTimeout must be a positive number of milliseconds, got: %v", v)
+	}
+	return time.Duration(ms * float64(time.Millisecond)), nil
+}
+
 func NewFrameHoverOptions(defaultTimeout time.Duration) *FrameHoverOptions {
 	return &FrameHoverOptions{
 		ElementHandleHoverOptions: *NewElementHandleHoverOptions(defaultTimeout),
@@ -355,6 +664,21 @@ func (o *FrameInnerTextOptions) Parse(ctx context.Context, opts goja.Value) erro
 	if err := o.FrameBaseOptions.Parse(ctx, opts); err != nil {
 		return err
 	}
+	if !gojaValueExists(opts) {
+		return nil
+	}
+	gopts := opts.ToObject(k6ext.Runtime(ctx))
+	for _, k := range gopts.Keys() {
+		switch k {
+		case "within":
+			o.Within = gopts.Get(k).String()
+		case "maxDepth":
+			o.MaxDepth = gopts.Get(k).ToInteger()
+			if o.MaxDepth < 0 {
+				return fmt.Errorf("maxDepth must be a non-negative integer, got: %d", o.MaxDepth)
+			}
+		}
+	}
 	return nil
 }
 
@@ -486,6 +810,54 @@ func (o *FrameSelectOptionOptions) Parse(ctx context.Context, opts goja.Value) e
 	return nil
 }
 
+func NewFrameSelectTextOptions(defaultTimeout time.Duration) *FrameSelectTextOptions {
+	return &FrameSelectTextOptions{
+		ElementHandleBaseOptions: *NewElementHandleBaseOptions(defaultTimeout),
+		Strict:                   false,
+	}
+}
+
+func (o *FrameSelectTextOptions) Parse(ctx context.Context, opts goja.Value) error {
+	rt := k6ext.Runtime(ctx)
+	if err := o.ElementHandleBaseOptions.Parse(ctx, opts); err != nil {
+		return err
+	}
+	if opts != nil && !goja.IsUndefined(opts) && !goja.IsNull(opts) {
+		opts := opts.ToObject(rt)
+		for _, k := range opts.Keys() {
+			switch k {
+			case "strict":
+				o.Strict = opts.Get(k).ToBoolean()
+			}
+		}
+	}
+	return nil
+}
+
+func NewFrameSetInputFilesOptions(defaultTimeout time.Duration) *FrameSetInputFilesOptions {
+	return &FrameSetInputFilesOptions{
+		ElementHandleBaseOptions: *NewElementHandleBaseOptions(defaultTimeout),
+		Strict:                   false,
+	}
+}
+
+func (o *FrameSetInputFilesOptions) Parse(ctx context.Context, opts goja.Value) error {
+	rt := k6ext.Runtime(ctx)
+	if err := o.ElementHandleBaseOptions.Parse(ctx, opts); err != nil {
+		return err
+	}
+	if opts != nil && !goja.IsUndefined(opts) && !goja.IsNull(opts) {
+		opts := opts.ToObject(rt)
+		for _, k := range opts.Keys() {
+			switch k {
+			case "strict":
+				o.Strict = opts.Get(k).ToBoolean()
+			}
+		}
+	}
+	return nil
+}
+
 func NewFrameSetContentOptions(defaultTimeout time.Duration) *FrameSetContentOptions {
 	return &FrameSetContentOptions{
 		Timeout:   defaultTimeout,
@@ -614,11 +986,13 @@ func (o *FrameWaitForFunctionOptions) Parse(ctx context.Context, opts goja.Value
 			switch k {
 			case "timeout":
 				o.Timeout = time.Duration(v.ToInteger()) * time.Millisecond
+			case "interval":
+				o.Interval = int64(math.Round(v.ToFloat()))
 			case "polling":
 				switch v.ExportType().Kind() { //nolint: exhaustive
-				case reflect.Int64:
+				case reflect.Int64, reflect.Float64:
 					o.Polling = PollingInterval
-					o.Interval = v.ToInteger()
+					o.Interval = int64(math.Round(v.ToFloat()))
 				case reflect.String:
 					if p, ok := pollingTypeToID[v.ToString().String()]; ok {
 						o.Polling = p
@@ -632,10 +1006,83 @@ func (o *FrameWaitForFunctionOptions) Parse(ctx context.Context, opts goja.Value
 			}
 		}
 	}
+	if o.Polling == PollingInterval && o.Interval <= 0 {
+		return fmt.Errorf("polling interval must be a positive number of milliseconds, got: %d", o.Interval)
+	}
 
 	return nil
 }
 
+// pollingValue returns the polling of the injected predicate runner: the
+// interval in milliseconds with the interval polling, or the name of the
+// polling otherwise, in which case the interval is ignored.
+func (o *FrameWaitForFunctionOptions) pollingValue() interface{} {
+	if o.Polling == PollingInterval {
+		return o.Interval
+	}
+	return o.Polling
+}
+
+func NewFrameWaitForCountOptions(defaultTimeout time.Duration) *FrameWaitForCountOptions {
+	return &FrameWaitForCountOptions{
+		Timeout: defaultTimeout,
+	}
+}
+
+func (o *FrameWaitForCountOptions) Parse(ctx context.Context, opts goja.Value) error {
+	rt := k6ext.Runtime(ctx)
+	if opts != nil && !goja.IsUndefined(opts) && !goja.IsNull(opts) {
+		opts := opts.ToObject(rt)
+		for _, k := range opts.Keys() {
+			switch k {
+			case "timeout":
+				o.Timeout = time.Duration(opts.Get(k).ToInteger()) * time.Millisecond
+			}
+		}
+	}
+	return nil
+}
+
+func NewFrameWaitForExecutionContextOptions(defaultTimeout time.Duration) *FrameWaitForExecutionContextOptions {
+	return &FrameWaitForExecutionContextOptions{
+		Timeout: defaultTimeout,
+	}
+}
+
+func (o *FrameWaitForExecutionContextOptions) Parse(ctx context.Context, opts goja.Value) error {
+	rt := k6ext.Runtime(ctx)
+	if opts != nil && !goja.IsUndefined(opts) && !goja.IsNull(opts) {
+		opts := opts.ToObject(rt)
+		for _, k := range opts.Keys() {
+			switch k {
+			case "timeout":
+				o.Timeout = time.Duration(opts.Get(k).ToInteger()) * time.Millisecond
+			}
+		}
+	}
+	return nil
+}
+
+func NewFrameWaitForImagesOptions(defaultTimeout time.Duration) *FrameWaitForImagesOptions {
+	return &FrameWaitForImagesOptions{
+		Timeout: defaultTimeout,
+	}
+}
+
+func (o *FrameWaitForImagesOptions) Parse(ctx context.Context, opts goja.Value) error {
+	rt := k6ext.Runtime(ctx)
+	if opts != nil && !goja.IsUndefined(opts) && !goja.IsNull(opts) {
+		opts := opts.ToObject(rt)
+		for _, k := range opts.Keys() {
+			switch k {
+			case "timeout":
+				o.Timeout = time.Duration(opts.Get(k).ToInteger()) * time.Millisecond
+			}
+		}
+	}
+	return nil
+}
+
 func NewFrameWaitForLoadStateOptions(defaultTimeout time.Duration) *FrameWaitForLoadStateOptions {
 	return &FrameWaitForLoadStateOptions{
 		Timeout: defaultTimeout,
@@ -648,6 +1095,8 @@ func (o *FrameWaitForLoadStateOptions) Parse(ctx context.Context, opts goja.Valu
 		opts := opts.ToObject(rt)
 		for _, k := range opts.Keys() {
 			switch k {
+			case "includeSubframes":
+				o.IncludeSubframes = opts.Get(k).ToBoolean()
 			case "timeout":
 				o.Timeout = time.Duration(opts.Get(k).ToInteger()) * time.Millisecond
 			}
@@ -685,6 +1134,46 @@ func (o *FrameWaitForNavigationOptions) Parse(ctx context.Context, opts goja.Val
 	return nil
 }
 
+func NewFrameWaitForRequestOptions(defaultTimeout time.Duration) *FrameWaitForRequestOptions {
+	return &FrameWaitForRequestOptions{
+		Timeout: defaultTimeout,
+	}
+}
+
+func (o *FrameWaitForRequestOptions) Parse(ctx context.Context, opts goja.Value) error {
+	rt := k6ext.Runtime(ctx)
+	if opts != nil && !goja.IsUndefined(opts) && !goja.IsNull(opts) {
+		opts := opts.ToObject(rt)
+		for _, k := range opts.Keys() {
+			switch k {
+			case "timeout":
+				o.Timeout = time.Duration(opts.Get(k).ToInteger()) * time.Millisecond
+			}
+		}
+	}
+	return nil
+}
+
+func NewFrameWaitForResponseOptions(defaultTimeout time.Duration) *FrameWaitForResponseOptions {
+	return &FrameWaitForResponseOptions{
+		Timeout: defaultTimeout,
+	}
+}
+
+func (o *FrameWaitForResponseOptions) Parse(ctx context.Context, opts goja.Value) error {
+	rt := k6ext.Runtime(ctx)
+	if opts != nil && !goja.IsUndefined(opts) && !goja.IsNull(opts) {
+		opts := opts.ToObject(rt)
+		for _, k := range opts.Keys() {
+			switch k {
+			case "timeout":
+				o.Timeout = time.Duration(opts.Get(k).ToInteger()) * time.Millisecond
+			}
+		}
+	}
+	return nil
+}
+
 func NewFrameWaitForSelectorOptions(defaultTimeout time.Duration) *FrameWaitForSelectorOptions {
 	return &FrameWaitForSelectorOptions{
 		State:   DOMElementStateVisible,
@@ -697,11 +1186,28 @@ func (o *FrameWaitForSelectorOptions) Parse(ctx context.Context, opts goja.Value
 	rt := k6ext.Runtime(ctx)
 
 	if opts != nil && !goja.IsUndefined(opts) && !goja.IsNull(opts) {
+		var deadline time.Time
 		opts := opts.ToObject(rt)
 		for _, k := range opts.Keys() {
 			switch k {
+			case "deadline":
+				var err error
+				if deadline, err = parseDeadline(opts.Get(k)); err != nil {
+					return err
+				}
 			case "state":
-				state := opts.Get(k).String()
+				v := opts.Get(k)
+				if t := v.ExportType(); t != nil && t.Kind() == reflect.Slice {
+					var states []string
+					if err := rt.ExportTo(v, &states); err != nil {
+						return fmt.Errorf("parsing DOM states: %w", err)
+					}
+					if err := o.parseStates(states); err != nil {
+						return err
+					}
+					continue
+				}
+				state := v.String()
 				if s, ok := domElementStateToID[state]; ok {
 					o.State = s
 				} else {
@@ -713,14 +1219,52 @@ func (o *FrameWaitForSelectorOptions) Parse(ctx context.Context, opts goja.Value
 				o.Timeout = time.Duration(opts.Get(k).ToInteger()) * time.Millisecond
 			}
 		}
+		o.Timeout = timeoutUntil(deadline, o.Timeout)
 	}
 
 	return nil
 }
 
+// parseStates parses a combination of states that an element must be in at
+// the same time. The detached and hidden states can't be combined since
+// they may not have an element.
+func (o *FrameWaitForSelectorOptions) parseStates(states []string) error {
+	if len(states) == 0 {
+		return errors.New("DOM states cannot be empty")
+	}
+	o.States = make([]DOMElementState, 0, len(states))
+	for _, state := range states {
+		s, ok := domElementStateToID[state]
+		if !ok {
+			return fmt.Errorf("%q is not a valid DOM state", state)
+		}
+		if s == DOMElementStateDetached || s == DOMElementStateHidden {
+			return fmt.Errorf("%q DOM state cannot be combined with other states", state)
+		}
+		o.States = append(o.States, s)
+	}
+	return nil
+}
+
+// stateArg returns the state, or the combination of states, to pass to
+// the injected script.
+func (o *FrameWaitForSelectorOptions) stateArg() interface{} {
+	if len(o.States) == 0 {
+		return o.State.String()
+	}
+	states := make([]string, 0, len(o.States))
+	for _, s := range o.States {
+		states = append(states, s.String())
+	}
+	return states
+}
+
 // FrameDispatchEventOptions are options for Frame.dispatchEvent.
 type FrameDispatchEventOptions struct {
 	*FrameBaseOptions
+	// Retries is how many times the action is retried, see
+	// ElementHandleBaseOptions.
+	Retries int64 `json:"retries"`
 }
 
 // NewFrameDispatchEventOptions returns a new FrameDispatchEventOptions.
@@ -729,3 +1273,20 @@ func NewFrameDispatchEventOptions(defaultTimeout time.Duration) *FrameDispatchEv
 		FrameBaseOptions: NewFrameBaseOptions(defaultTimeout),
 	}
 }
+
+// Parse parses the frame dispatch event options.
+func (o *FrameDispatchEventOptions) Parse(ctx context.Context, opts goja.Value) error {
+	if err := o.FrameBaseOptions.Parse(ctx, opts); err != nil {
+		return err
+	}
+	if !gojaValueExists(opts) {
+		return nil
+	}
+	if v := opts.ToObject(k6ext.Runtime(ctx)).Get("retries"); gojaValueExists(v) {
+		var err error
+		if o.Retries, err = parseRetries(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package common
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -47,6 +48,112 @@ func TestFrameGotoOptionsParse(t *testing.T) {
 	})
 }
 
+func TestFrameGotoOptionsParseReferer(t *testing.T) {
+	t.Parallel()
+
+	for referer, want := range map[string]string{
+		"https://example.com/from": "https://example.com/from",
+		"":                         "",
+	} {
+		vu := k6test.NewVU(t)
+		opts := vu.ToGojaValue(map[string]interface{}{"referer": referer})
+		gotoOpts := NewFrameGotoOptions("https://default.example.com/", 0)
+		require.NoError(t, gotoOpts.Parse(vu.Context(), opts))
+		assert.Equal(t, want, gotoOpts.Referer)
+	}
+
+	for _, referer := range []string{"example.com/from", "/from", "https://", "http://a b.com/"} {
+		vu := k6test.NewVU(t)
+		opts := vu.ToGojaValue(map[string]interface{}{"referer": referer})
+		err := NewFrameGotoOptions("", 0).Parse(vu.Context(), opts)
+		require.Errorf(t, err, "%q", referer)
+		assert.Contains(t, err.Error(), "referer must be an absolute URL")
+	}
+}
+
+func TestFrameGotoOptionsParseRetries(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ok", func(t *testing.T) {
+		t.Parallel()
+
+		vu := k6test.NewVU(t)
+		opts := vu.ToGojaValue(map[string]interface{}{
+			"retries":    3,
+			"retryDelay": 250,
+		})
+		gotoOpts := NewFrameGotoOptions("", 0)
+		require.NoError(t, gotoOpts.Parse(vu.Context(), opts))
+		assert.Equal(t, int64(3), gotoOpts.Retries)
+		assert.Equal(t, 250*time.Millisecond, gotoOpts.RetryDelay)
+	})
+
+	t.Run("default", func(t *testing.T) {
+		t.Parallel()
+
+		gotoOpts := NewFrameGotoOptions("", 0)
+		assert.Zero(t, gotoOpts.Retries)
+		assert.Equal(t, 100*time.Millisecond, gotoOpts.RetryDelay)
+	})
+
+	for k, want := range map[string]string{
+		"retries":    "retries must be a non-negative integer",
+		"retryDelay": "retryDelay must be a non-negative number of milliseconds",
+	} {
+		k, want := k, want
+		t.Run("err/"+k, func(t *testing.T) {
+			t.Parallel()
+
+			vu := k6test.NewVU(t)
+			opts := vu.ToGojaValue(map[string]interface{}{k: -1})
+			err := NewFrameGotoOptions("", 0).Parse(vu.Context(), opts)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), want)
+		})
+	}
+}
+
+func TestFrameGotoOptionsParseNetworkIdleTimeout(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ok", func(t *testing.T) {
+		t.Parallel()
+
+		vu := k6test.NewVU(t)
+		opts := vu.ToGojaValue(map[string]interface{}{
+			"networkIdleTimeout": 1500,
+		})
+		gotoOpts := NewFrameGotoOptions("", 0)
+		require.NoError(t, gotoOpts.Parse(vu.Context(), opts))
+		assert.Equal(t, 1500*time.Millisecond, gotoOpts.NetworkIdleTimeout)
+	})
+
+	t.Run("unset", func(t *testing.T) {
+		t.Parallel()
+
+		vu := k6test.NewVU(t)
+		gotoOpts := NewFrameGotoOptions("", 0)
+		require.NoError(t, gotoOpts.Parse(vu.Context(), vu.ToGojaValue(map[string]interface{}{})))
+		assert.Zero(t, gotoOpts.NetworkIdleTimeout)
+	})
+
+	for _, v := range []interface{}{0, -1, "abc"} {
+		v := v
+		t.Run(fmt.Sprintf("err/%v", v), func(t *testing.T) {
+			t.Parallel()
+
+			vu := k6test.NewVU(t)
+			opts := vu.ToGojaValue(map[string]interface{}{
+				"networkIdleTimeout": v,
+			})
+			gotoOpts := NewFrameGotoOptions("", 0)
+			err := gotoOpts.Parse(vu.Context(), opts)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "networkIdleTimeout must be a positive number")
+		})
+	}
+}
+
 func TestFrameSetContentOptionsParse(t *testing.T) {
 	t.Parallel()
 
@@ -119,3 +226,165 @@ func TestFrameWaitForNavigationOptionsParse(t *testing.T) {
 				`load, domcontentloaded, networkidle`)
 	})
 }
+
+func TestFrameWaitForSelectorOptionsParseStates(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ok", func(t *testing.T) {
+		t.Parallel()
+
+		vu := k6test.NewVU(t)
+		opts := NewFrameWaitForSelectorOptions(0)
+		err := opts.Parse(vu.Context(), vu.ToGojaValue(map[string]interface{}{
+			"state": []string{"visible", "enabled", "stable"},
+		}))
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			[]DOMElementState{DOMElementStateVisible, DOMElementStateEnabled, DOMElementStateStable},
+			opts.States)
+		assert.Equal(t, []string{"visible", "enabled", "stable"}, opts.stateArg())
+	})
+
+	t.Run("single", func(t *testing.T) {
+		t.Parallel()
+
+		vu := k6test.NewVU(t)
+		opts := NewFrameWaitForSelectorOptions(0)
+		err := opts.Parse(vu.Context(), vu.ToGojaValue(map[string]interface{}{
+			"state": "enabled",
+		}))
+		require.NoError(t, err)
+
+		assert.Empty(t, opts.States)
+		assert.Equal(t, "enabled", opts.stateArg())
+	})
+
+	for name, states := range map[string][]string{
+		"empty":    {},
+		"invalid":  {"visible", "clickable"},
+		"detached": {"visible", "detached"},
+		"hidden":   {"hidden"},
+	} {
+		states := states
+		t.Run("err/"+name, func(t *testing.T) {
+			t.Parallel()
+
+			vu := k6test.NewVU(t)
+			opts := NewFrameWaitForSelectorOptions(0)
+			err := opts.Parse(vu.Context(), vu.ToGojaValue(map[string]interface{}{
+				"state": states,
+			}))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestFrameGetByRoleOptionsSelector(t *testing.T) {
+	t.Parallel()
+
+	vu := k6test.NewVU(t)
+	re, err := vu.Runtime().RunString(`/Messages \(\d+\)/i`)
+	require.NoError(t, err)
+	reQuoted, err := vu.Runtime().RunString(`/say "hi" > 'x'] >> [>\]]/`)
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		name string
+		opts map[string]interface{}
+		want string
+	}{
+		{name: "no_name", want: `role=button`},
+		{name: "name", opts: map[string]interface{}{"name": `Say "hi"`}, want: `role=button[name="Say \"hi\""]`},
+		{name: "exact", opts: map[string]interface{}{"name": "Send", "exact": true}, want: `role=button[name="Send" s]`},
+		{name: "regexp", opts: map[string]interface{}{"name": re}, want: `role=button[name=/Messages \(\d+\)/i]`},
+		{name: "regexp_exact", opts: map[string]interface{}{"name": re, "exact": true}, want: `role=button[name=/Messages \(\d+\)/i]`},
+		{
+			name: "regexp_escaped",
+			opts: map[string]interface{}{"name": reQuoted},
+			want: `role=button[name=/say \x22hi\x22 \x3e \x27x\x27\] \x3e\x3e [\x3e\]]/]`,
+		},
+		{name: "pressed", opts: map[string]interface{}{"pressed": true}, want: `role=button[pressed=true]`},
+		{
+			name: "name_and_states",
+			opts: map[string]interface{}{"name": "Menu", "checked": false, "pressed": true, "expanded": false},
+			want: `role=button[name="Menu"][checked=false][pressed=true][expanded=false]`,
+		},
+		{name: "null_state", opts: map[string]interface{}{"expanded": nil}, want: `role=button`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := NewFrameGetByRoleOptions()
+			require.NoError(t, opts.Parse(vu.Context(), vu.ToGojaValue(tt.opts)))
+			assert.Equal(t, tt.want, opts.selector("button"))
+
+			s, err := NewSelector(opts.selector("button"))
+			require.NoError(t, err)
+			assert.Len(t, s.Parts, 1)
+		})
+	}
+}
+
+func TestFrameWaitForFunctionOptionsParse(t *testing.T) {
+	t.Parallel()
+
+	for name, tt := range map[string]struct {
+		opts        map[string]interface{}
+		wantPolling interface{}
+		wantErr     string
+	}{
+		"default":              {opts: map[string]interface{}{}, wantPolling: PollingRaf},
+		"interval_number":      {opts: map[string]interface{}{"polling": 100}, wantPolling: int64(100)},
+		"interval_float":       {opts: map[string]interface{}{"polling": 99.6}, wantPolling: int64(100)},
+		"interval_option":      {opts: map[string]interface{}{"polling": "interval", "interval": 50}, wantPolling: int64(50)},
+		"raf_ignores_interval": {opts: map[string]interface{}{"polling": "raf", "interval": 50}, wantPolling: PollingRaf},
+		"mutation_ignores_interval": {
+			opts:        map[string]interface{}{"polling": "mutation", "interval": 50},
+			wantPolling: PollingMutation,
+		},
+		"err_interval_missing": {
+			opts:    map[string]interface{}{"polling": "interval"},
+			wantErr: "polling interval must be a positive number of milliseconds, got: 0",
+		},
+		"err_interval_zero": {
+			opts:    map[string]interface{}{"polling": 0},
+			wantErr: "polling interval must be a positive number of milliseconds, got: 0",
+		},
+	} {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			vu := k6test.NewVU(t)
+			opts := NewFrameWaitForFunctionOptions(0)
+			err := opts.Parse(vu.Context(), vu.ToGojaValue(tt.opts))
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPolling, opts.pollingValue())
+		})
+	}
+}
+
+func TestFrameDispatchEventOptionsParse(t *testing.T) {
+	t.Parallel()
+
+	vu := k6test.NewVU(t)
+	opts := NewFrameDispatchEventOptions(time.Second)
+	err := opts.Parse(vu.Context(), vu.ToGojaValue(map[string]interface{}{
+		"strict":  true,
+		"timeout": 500,
+		// dblclick options don't apply to dispatchEvent.
+		"clickCount": 2,
+		"delay":      100,
+		"button":     "right",
+		"force":      true,
+	}))
+	require.NoError(t, err)
+
+	want := NewFrameDispatchEventOptions(time.Second)
+	want.Strict = true
+	want.Timeout = 500 * time.Millisecond
+	assert.Equal(t, want, opts)
+}
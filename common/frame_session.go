@@ -28,6 +28,8 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/grafana/xk6-browser/api"
 	"github.com/grafana/xk6-browser/k6ext"
@@ -52,6 +54,78 @@ import (
 
 const utilityWorldName = "__k6_browser_utility_world__"
 
+// longTaskBindingName is the name of the binding that the long task
+// observer reports the long tasks of a document to.
+const longTaskBindingName = "__k6_browser_long_task__"
+
+// longTaskObserverScript observes the long tasks of a document and reports
+// each one to the long task binding.
+const longTaskObserverScript = `(() => {
+	const report = window["` + longTaskBindingName + `"];
+	if (typeof report !== "function" ||
+		typeof PerformanceObserver === "undefined" ||
+		!(PerformanceObserver.supportedEntryTypes || []).includes("longtask")) {
+		return;
+	}
+	new PerformanceObserver((list) => {
+		for (const entry of list.getEntries()) {
+			report(JSON.stringify({
+				name: entry.name,
+				startTime: entry.startTime,
+				duration: entry.duration,
+			}));
+		}
+	}).observe({ type: "longtask", buffered: true });
+})();`
+
+// lcpBindingName is the name of the binding that the largest contentful
+// paint observer reports the LCP of the top-level document to.
+const lcpBindingName = "__k6_browser_lcp__"
+
+// lcpObserverScript observes the largest contentful paint of the top-level
+// document and reports each new candidate to the LCP binding. The last
+// candidate before the page leaves the document is the final LCP.
+const lcpObserverScript = `(() => {
+	const report = window["` + lcpBindingName + `"];
+	if (window.top !== window ||
+		typeof report !== "function" ||
+		typeof PerformanceObserver === "undefined" ||
+		!(PerformanceObserver.supportedEntryTypes || []).includes("largest-contentful-paint")) {
+		return;
+	}
+	new PerformanceObserver((list) => {
+		const entries = list.getEntries();
+		const last = entries[entries.length - 1];
+		if (last) {
+			report(JSON.stringify({ startTime: last.startTime }));
+		}
+	}).observe({ type: "largest-contentful-paint", buffered: true });
+})();`
+
+// clsBindingName is the name of the binding that the cumulative layout
+// shift observer reports the layout shifts of the top-level document to.
+const clsBindingName = "__k6_browser_cls__"
+
+// clsObserverScript observes the layout shifts of the top-level document
+// and reports the ones that aren't caused by recent user input to the CLS
+// binding. It runs in the utility world, out of reach of the page scripts.
+const clsObserverScript = `(() => {
+	const report = window["` + clsBindingName + `"];
+	if (window.top !== window ||
+		typeof report !== "function" ||
+		typeof PerformanceObserver === "undefined" ||
+		!(PerformanceObserver.supportedEntryTypes || []).includes("layout-shift")) {
+		return;
+	}
+	new PerformanceObserver((list) => {
+		for (const entry of list.getEntries()) {
+			if (!entry.hadRecentInput) {
+				report(JSON.stringify({ value: entry.value, startTime: entry.startTime }));
+			}
+		}
+	}).observe({ type: "layout-shift", buffered: true });
+})();`
+
 /*
    FrameSession is used for managing a frame's life-cycle, or in other words its full session.
    It manages all the event listening while deferring the state storage to the Frame and FrameManager
@@ -82,6 +156,10 @@ type FrameSession struct {
 	childSessions map[cdp.FrameID]*FrameSession
 	vu            k6modules.VU
 
+	// swappedIn is set to 1 when the out-of-process frame this session is
+	// attached to moves back into the process of its parent session.
+	swappedIn int64
+
 	logger *log.Logger
 	// logger that will properly serialize RemoteObject instances
 	serializer *log.Logger
@@ -158,6 +236,30 @@ func NewFrameSession(
 
 		return nil, err
 	}
+	if err = fs.initLongTaskObserver(); err != nil {
+		l.Debugf(
+			"NewFrameSession:initLongTaskObserver",
+			"sid:%v tid:%v err:%v",
+			s.ID(), tid, err)
+
+		return nil, err
+	}
+	if err = fs.initLCPObserver(); err != nil {
+		l.Debugf(
+			"NewFrameSession:initLCPObserver",
+			"sid:%v tid:%v err:%v",
+			s.ID(), tid, err)
+
+		return nil, err
+	}
+	if err = fs.initCLSObserver(); err != nil {
+		l.Debugf(
+			"NewFrameSession:initCLSObserver",
+			"sid:%v tid:%v err:%v",
+			s.ID(), tid, err)
+
+		return nil, err
+	}
 	if err = fs.initDomains(); err != nil {
 		l.Debugf(
 			"NewFrameSession:initDomains",
@@ -260,10 +362,14 @@ func (fs *FrameSession) initEvents() {
 					fs.onFrameStartedLoading(ev.FrameID)
 				case *cdppage.EventFrameStoppedLoading:
 					fs.onFrameStoppedLoading(ev.FrameID)
+				case *cdppage.EventJavascriptDialogOpening:
+					fs.onJavascriptDialogOpening(ev)
 				case *cdppage.EventLifecycleEvent:
 					fs.onPageLifecycle(ev)
 				case *cdppage.EventNavigatedWithinDocument:
 					fs.onPageNavigatedWithinDocument(ev)
+				case *cdpruntime.EventBindingCalled:
+					fs.onBindingCalled(ev)
 				case *cdpruntime.EventConsoleAPICalled:
 					fs.onConsoleAPICalled(ev)
 				case *cdpruntime.EventExceptionThrown:
@@ -293,18 +399,11 @@ func (fs *FrameSession) initFrameTree() error {
 		return fmt.Errorf("enabling page domain: %w", err)
 	}
 
-	var frameTree *cdppage.FrameTree
-	var err error
-
 	// Recursively enumerate all existing frames in page to create initial in-memory structures
 	// used for access and manipulation from JS.
-	action2 := cdppage.GetFrameTree()
-	if frameTree, err = action2.Do(cdp.WithExecutor(fs.ctx, fs.session)); err != nil {
-		return fmt.Errorf("getting page frame tree: %w", err)
-	} else if frameTree == nil {
-		// This can happen with very short scripts when we might not have enough
-		// time to initialize properly.
-		return fmt.Errorf("got a nil page frame tree")
+	frameTree, err := fs.getFrameTree()
+	if err != nil {
+		return err
 	}
 
 	if fs.isMainFrame() {
@@ -314,6 +413,38 @@ func (fs *FrameSession) initFrameTree() error {
 	return nil
 }
 
+// getFrameTree returns the frame tree of the session's target. The frame
+// tree is sometimes nil right after the target is created (e.g. on Windows,
+// or with very short scripts), so it is retried a few times with a backoff
+// before giving up.
+func (fs *FrameSession) getFrameTree() (*cdppage.FrameTree, error) {
+	const attempts = 5
+	backoff := 10 * time.Millisecond
+
+	for i := 1; ; i++ {
+		action := cdppage.GetFrameTree()
+		frameTree, err := action.Do(cdp.WithExecutor(fs.ctx, fs.session))
+		if err != nil {
+			return nil, fmt.Errorf("getting page frame tree: %w", err)
+		}
+		if frameTree != nil && frameTree.Frame != nil {
+			return frameTree, nil
+		}
+		if i == attempts {
+			return nil, fmt.Errorf("got a nil page frame tree after %d attempts", attempts)
+		}
+
+		fs.logger.Debugf("NewFrameSession:getFrameTree:retry",
+			"sid:%v tid:%v attempt:%d", fs.session.ID(), fs.targetID, i)
+		select {
+		case <-fs.ctx.Done():
+			return nil, fmt.Errorf("getting page frame tree: %w", fs.ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
 func (fs *FrameSession) initIsolatedWorld(name string) error {
 	fs.logger.Debugf("NewFrameSession:initIsolatedWorld",
 		"sid:%v tid:%v", fs.session.ID(), fs.targetID)
@@ -362,6 +493,52 @@ func (fs *FrameSession) initIsolatedWorld(name string) error {
 	return nil
 }
 
+// initLongTaskObserver observes the long tasks of the documents in the
+// session and reports them back through a binding. See onBindingCalled.
+func (fs *FrameSession) initLongTaskObserver() error {
+	action := cdpruntime.AddBinding(longTaskBindingName)
+	if err := action.Do(cdp.WithExecutor(fs.ctx, fs.session)); err != nil {
+		return fmt.Errorf("adding long task binding: %w", err)
+	}
+	action2 := cdppage.AddScriptToEvaluateOnNewDocument(longTaskObserverScript)
+	if _, err := action2.Do(cdp.WithExecutor(fs.ctx, fs.session)); err != nil {
+		return fmt.Errorf("adding long task observer script: %w", err)
+	}
+	return nil
+}
+
+// initLCPObserver observes the largest contentful paint of the top-level
+// documents in the session and reports it back through a binding.
+// See onBindingCalled.
+func (fs *FrameSession) initLCPObserver() error {
+	action := cdpruntime.AddBinding(lcpBindingName)
+	if err := action.Do(cdp.WithExecutor(fs.ctx, fs.session)); err != nil {
+		return fmt.Errorf("adding LCP binding: %w", err)
+	}
+	action2 := cdppage.AddScriptToEvaluateOnNewDocument(lcpObserverScript)
+	if _, err := action2.Do(cdp.WithExecutor(fs.ctx, fs.session)); err != nil {
+		return fmt.Errorf("adding LCP observer script: %w", err)
+	}
+	return nil
+}
+
+// initCLSObserver observes the layout shifts of the top-level documents
+// in the session from the utility world and reports them back through a
+// binding. See onBindingCalled.
+func (fs *FrameSession) initCLSObserver() error {
+	action := cdpruntime.AddBinding(clsBindingName).
+		WithExecutionContextName(utilityWorldName)
+	if err := action.Do(cdp.WithExecutor(fs.ctx, fs.session)); err != nil {
+		return fmt.Errorf("adding CLS binding: %w", err)
+	}
+	action2 := cdppage.AddScriptToEvaluateOnNewDocument(clsObserverScript).
+		WithWorldName(utilityWorldName)
+	if _, err := action2.Do(cdp.WithExecutor(fs.ctx, fs.session)); err != nil {
+		return fmt.Errorf("adding CLS observer script: %w", err)
+	}
+	return nil
+}
+
 func (fs *FrameSession) initOptions() error {
 	fs.logger.Debugf("NewFrameSession:initOptions",
 		"sid:%v tid:%v", fs.session.ID(), fs.targetID)
@@ -393,8 +570,12 @@ func (fs *FrameSession) initOptions() error {
 	if !opts.JavaScriptEnabled {
 		optActions = append(optActions, emulation.SetScriptExecutionDisabled(true))
 	}
-	if opts.UserAgent != "" || opts.Locale != "" {
-		optActions = append(optActions, emulation.SetUserAgentOverride(opts.UserAgent).WithAcceptLanguage(opts.Locale))
+	acceptLanguage := opts.AcceptLanguage
+	if acceptLanguage == "" {
+		acceptLanguage = opts.Locale
+	}
+	if opts.UserAgent != "" || acceptLanguage != "" {
+		optActions = append(optActions, emulation.SetUserAgentOverride(opts.UserAgent).WithAcceptLanguage(acceptLanguage))
 	}
 	if opts.Locale != "" {
 		if err := fs.emulateLocale(); err != nil {
@@ -434,6 +615,17 @@ func (fs *FrameSession) initOptions() error {
 	  for (const source of this._crPage._page._evaluateOnNewDocumentSources)
 	      promises.push(this._evaluateOnNewDocument(source, 'main'));*/
 
+	src, err := fs.manager.page.browserCtx.storageStateScript()
+	if err != nil {
+		return fmt.Errorf("preparing storage state script: %w", err)
+	}
+	if src != "" {
+		action := cdppage.AddScriptToEvaluateOnNewDocument(src)
+		if _, err := action.Do(cdp.WithExecutor(fs.ctx, fs.session)); err != nil {
+			return fmt.Errorf("adding storage state script: %w", err)
+		}
+	}
+
 	optActions = append(optActions, cdpruntime.RunIfWaitingForDebugger())
 
 	for _, action := range optActions {
@@ -461,6 +653,7 @@ func (fs *FrameSession) initRendererEvents() {
 		cdproto.EventPageJavascriptDialogOpening,
 		cdproto.EventPageLifecycleEvent,
 		cdproto.EventPageNavigatedWithinDocument,
+		cdproto.EventRuntimeBindingCalled,
 		cdproto.EventRuntimeConsoleAPICalled,
 		cdproto.EventRuntimeExceptionThrown,
 		cdproto.EventRuntimeExecutionContextCreated,
@@ -480,6 +673,9 @@ func (fs *FrameSession) handleFrameTree(frameTree *cdppage.FrameTree) {
 	fs.logger.Debugf("FrameSession:handleFrameTree",
 		"sid:%v tid:%v", fs.session.ID(), fs.targetID)
 
+	if frameTree == nil || frameTree.Frame == nil {
+		return
+	}
 	if frameTree.Frame.ParentID != "" {
 		fs.onFrameAttached(frameTree.Frame.ID, frameTree.Frame.ParentID)
 	}
@@ -500,12 +696,74 @@ func (fs *FrameSession) navigateFrame(frame *Frame, url, referrer string) (strin
 	action := cdppage.Navigate(url).WithReferrer(referrer).WithFrameID(cdp.FrameID(frame.ID()))
 	_, documentID, errorText, err := action.Do(cdp.WithExecutor(fs.ctx, fs.session))
 	if err != nil {
-		err = fmt.Errorf("%s at %q: %w", errorText, url, err)
+		return "", fmt.Errorf("%s at %q: %w", errorText, url, err)
+	}
+	if errorText != "" {
+		return "", newNavigationError(url, errorText)
+	}
+	return documentID.String(), nil
+}
+
+func (fs *FrameSession) onBindingCalled(event *cdpruntime.EventBindingCalled) {
+	fs.logger.Debugf("FrameSession:onBindingCalled",
+		"sid:%v tid:%v name:%q ectxid:%d",
+		fs.session.ID(), fs.targetID, event.Name, event.ExecutionContextID)
+
+	switch event.Name {
+	case longTaskBindingName, lcpBindingName, clsBindingName:
+	default:
+		return
+	}
+	fs.contextIDToContextMu.Lock()
+	execCtx, ok := fs.contextIDToContext[event.ExecutionContextID]
+	fs.contextIDToContextMu.Unlock()
+	if !ok || execCtx.Frame() == nil {
+		return
+	}
+
+	switch event.Name {
+	case longTaskBindingName:
+		var task LongTask
+		if err := json.Unmarshal([]byte(event.Payload), &task); err != nil {
+			fs.logger.Debugf("FrameSession:onBindingCalled",
+				"sid:%v tid:%v unmarshaling long task: %v", fs.session.ID(), fs.targetID, err)
+			return
+		}
+		execCtx.Frame().pushMetric(fs.k6Metrics.BrowserLongTask, task.Duration)
+		fs.page.emit(EventPageLongTask, &task)
+	case lcpBindingName:
+		var lcp struct {
+			StartTime float64 `json:"startTime"`
+		}
+		if err := json.Unmarshal([]byte(event.Payload), &lcp); err != nil {
+			fs.logger.Debugf("FrameSession:onBindingCalled",
+				"sid:%v tid:%v unmarshaling LCP: %v", fs.session.ID(), fs.targetID, err)
+			return
+		}
+		execCtx.Frame().setLargestContentfulPaint(lcp.StartTime)
+	case clsBindingName:
+		var shift struct {
+			Value     float64 `json:"value"`
+			StartTime float64 `json:"startTime"`
+		}
+		if err := json.Unmarshal([]byte(event.Payload), &shift); err != nil {
+			fs.logger.Debugf("FrameSession:onBindingCalled",
+				"sid:%v tid:%v unmarshaling layout shift: %v", fs.session.ID(), fs.targetID, err)
+			return
+		}
+		execCtx.Frame().addLayoutShift(shift.Value, shift.StartTime)
 	}
-	return documentID.String(), err
 }
 
 func (fs *FrameSession) onConsoleAPICalled(event *cdpruntime.EventConsoleAPICalled) {
+	// the console messages are always logged, and also forwarded to the
+	// page event handlers if there are any.
+	if fs.page.hasEventHandler(EventPageConsole) {
+		defer fs.emitConsoleMessage(event)
+	} else {
+		defer fs.releaseConsoleArgs(event.Args)
+	}
+
 	l := fs.serializer.
 		WithTime(event.Timestamp.Time()).
 		WithField("source", "browser-console-api")
@@ -537,8 +795,49 @@ func (fs *FrameSession) onConsoleAPICalled(event *cdpruntime.EventConsoleAPICall
 	}
 }
 
+// emitConsoleMessage emits the console API call as a console message
+// whose arguments are handles in the execution context of the call.
+// The arguments are released after the handlers have run, unless a
+// handler accessed them.
+func (fs *FrameSession) emitConsoleMessage(event *cdpruntime.EventConsoleAPICalled) {
+	fs.contextIDToContextMu.Lock()
+	execCtx, ok := fs.contextIDToContext[event.ExecutionContextID]
+	fs.contextIDToContextMu.Unlock()
+	if !ok {
+		fs.releaseConsoleArgs(event.Args)
+		return
+	}
+
+	args := make([]api.JSHandle, 0, len(event.Args))
+	for _, robj := range event.Args {
+		args = append(args, NewJSHandle(fs.ctx, fs.session, execCtx, execCtx.Frame(), robj, fs.logger))
+	}
+	msg := NewConsoleMessage(event, args)
+	fs.page.emitThen(EventPageConsole, msg, func() {
+		if msg.argsAccessed() {
+			return
+		}
+		go fs.releaseConsoleArgs(event.Args)
+	})
+}
+
+// releaseConsoleArgs releases the objects the browser retains for the
+// arguments of a console API call, as nothing can refer to them.
+func (fs *FrameSession) releaseConsoleArgs(args []*cdpruntime.RemoteObject) {
+	for _, robj := range args {
+		if robj.ObjectID == "" {
+			continue
+		}
+		action := cdpruntime.ReleaseObject(robj.ObjectID)
+		if err := action.Do(cdp.WithExecutor(fs.ctx, fs.session)); err != nil {
+			fs.logger.Debugf("FrameSession:releaseConsoleArgs",
+				"sid:%v tid:%v releasing object %q: %v", fs.session.ID(), fs.targetID, robj.ObjectID, err)
+		}
+	}
+}
+
 func (fs *FrameSession) onExceptionThrown(event *cdpruntime.EventExceptionThrown) {
-	fs.page.emit(EventPageError, event.ExceptionDetails)
+	fs.page.emit(EventPageError, newPageError(event.ExceptionDetails))
 }
 
 func (fs *FrameSession) onExecutionContextCreated(event *cdpruntime.EventExecutionContextCreated) {
@@ -621,7 +920,18 @@ func (fs *FrameSession) onFrameAttached(frameID cdp.FrameID, parentFrameID cdp.F
 		"sid:%v tid:%v fid:%v pfid:%v",
 		fs.session.ID(), fs.targetID, frameID, parentFrameID)
 
-	// TODO: add handling for cross-process frame transitioning
+	if cfs := fs.page.getFrameSession(frameID); cfs != nil && frameID != cdp.FrameID(fs.targetID) {
+		// This is a remote -> local frame transition: the frame moved back
+		// into the process of this session. Keep the Frame, and let this
+		// session attach the frame's children again.
+		atomic.StoreInt64(&cfs.swappedIn, 1)
+		// The frame or a whole subtree may be already gone, because some
+		// ancestor frame navigated.
+		if frame := fs.manager.getFrameByID(frameID); frame != nil {
+			fs.manager.removeChildFramesRecursively(frame)
+		}
+		return
+	}
 	fs.manager.frameAttached(frameID, parentFrameID)
 }
 
@@ -630,6 +940,20 @@ func (fs *FrameSession) onFrameDetached(frameID cdp.FrameID, reason cdppage.Fram
 		"sid:%v tid:%v fid:%v reason:%s",
 		fs.session.ID(), fs.targetID, frameID, reason)
 
+	if fs.page.getFrameSession(frameID) != nil {
+		// This is a local -> remote frame transition, which has been
+		// handled while attaching the frame to its new target.
+		return
+	}
+	if reason == cdppage.FrameDetachedReasonSwap {
+		// The frame is moving to another process: keep the Frame so that
+		// the new session can take it over, but drop its children since
+		// they will be attached again by the new session.
+		if frame := fs.manager.getFrameByID(frameID); frame != nil {
+			fs.manager.removeChildFramesRecursively(frame)
+		}
+		return
+	}
 	fs.manager.frameDetached(frameID)
 }
 
@@ -645,6 +969,7 @@ func (fs *FrameSession) onFrameNavigated(frame *cdp.Frame, initial bool) {
 		k6ext.Panic(fs.ctx, "handling frameNavigated event to %q: %w",
 			frame.URL+frame.URLFragment, err)
 	}
+	fs.manager.page.browserCtx.storageStateSeeded(frame.SecurityOrigin)
 }
 
 func (fs *FrameSession) onFrameRequestedNavigation(event *cdppage.EventFrameRequestedNavigation) {
@@ -696,6 +1021,14 @@ func (fs *FrameSession) onLogEntryAdded(event *cdplog.EventEntryAdded) {
 	default:
 		l.Debug(event.Entry.Text)
 	}
+
+	if event.Entry.Source == cdplog.EntrySourceSecurity {
+		fs.page.emit(EventPageSecurity, &SecurityMessage{
+			Level: event.Entry.Level.String(),
+			Text:  event.Entry.Text,
+			URL:   event.Entry.URL,
+		})
+	}
 }
 
 func (fs *FrameSession) onPageLifecycle(event *cdppage.EventLifecycleEvent) {
@@ -714,6 +1047,7 @@ func (fs *FrameSession) onPageLifecycle(event *cdppage.EventLifecycleEvent) {
 		return
 	case "load":
 		fs.manager.frameLifecycleEvent(event.FrameID, LifecycleEventLoad)
+		frame.emitDocumentResponseEnd()
 	case "DOMContentLoaded":
 		fs.manager.frameLifecycleEvent(event.FrameID, LifecycleEventDOMContentLoad)
 	}
@@ -729,6 +1063,37 @@ func (fs *FrameSession) onPageLifecycle(event *cdppage.EventLifecycleEvent) {
 	if m, ok := eventToMetric[event.Name]; ok {
 		frame.emitMetric(m, event.Timestamp.Time())
 	}
+	// the layout shifts are only observed in the top-level document.
+	if event.Name == "load" && frame == fs.manager.MainFrame() {
+		frame.pushMetric(fs.k6Metrics.BrowserCumulativeLayoutShift, frame.cumulativeLayoutShift())
+	}
+}
+
+// onJavascriptDialogOpening handles the beforeunload dialogs as set by
+// the beforeUnloadDialog context option. Other dialogs are left alone.
+func (fs *FrameSession) onJavascriptDialogOpening(event *cdppage.EventJavascriptDialogOpening) {
+	fs.logger.Debugf("FrameSession:onJavascriptDialogOpening",
+		"sid:%v tid:%v type:%q url:%q",
+		fs.session.ID(), fs.targetID, event.Type, event.URL)
+
+	if event.Type != cdppage.DialogTypeBeforeunload {
+		return
+	}
+	var accept bool
+	switch fs.page.browserCtx.opts.BeforeUnloadDialog {
+	case BeforeUnloadDialogAccept:
+		accept = true
+	case BeforeUnloadDialogDismiss:
+		accept = false
+	default:
+		return
+	}
+	action := cdppage.HandleJavaScriptDialog(accept)
+	if err := action.Do(cdp.WithExecutor(fs.ctx, fs.session)); err != nil {
+		fs.logger.Debugf("FrameSession:onJavascriptDialogOpening",
+			"sid:%v tid:%v handling beforeunload dialog: %v",
+			fs.session.ID(), fs.targetID, err)
+	}
 }
 
 func (fs *FrameSession) onPageNavigatedWithinDocument(event *cdppage.EventNavigatedWithinDocument) {
@@ -765,7 +1130,7 @@ func (fs *FrameSession) onAttachedToTarget(event *target.EventAttachedToTarget)
 	switch ti.Type {
 	case "iframe":
 		err = fs.attachIFrameToTarget(ti, sid)
-	case "worker":
+	case "worker", "service_worker":
 		err = fs.attachWorkerToTarget(ti, sid)
 	default:
 		// Just unblock (debugger continue) these targets and detach from them.
@@ -847,12 +1212,14 @@ func (fs *FrameSession) attachIFrameToTarget(ti *target.Info, sid target.Session
 
 // attachWorkerToTarget attaches a Worker target to a given session.
 func (fs *FrameSession) attachWorkerToTarget(ti *target.Info, sid target.SessionID) error {
-	w, err := NewWorker(fs.ctx, fs.page.browserCtx.getSession(sid), ti.TargetID, ti.URL)
+	w, err := NewWorker(
+		fs.ctx, fs.page.browserCtx.getSession(sid), ti.TargetID, ti.URL, fs.page.timeoutSettings, fs.logger,
+	)
 	if err != nil {
 		return fmt.Errorf("attaching worker target ID %v to session ID %v: %w",
 			ti.TargetID, sid, err)
 	}
-	fs.page.workers[sid] = w
+	fs.page.addWorker(sid, w)
 
 	return nil
 }
@@ -862,9 +1229,51 @@ func (fs *FrameSession) onDetachedFromTarget(event *target.EventDetachedFromTarg
 		"sid:%v tid:%v esid:%v",
 		fs.session.ID(), fs.targetID, event.SessionID)
 
+	if cfs := fs.childFrameSession(event.SessionID); cfs != nil {
+		fs.detachChildFrameSession(cfs)
+		return
+	}
 	fs.page.closeWorker(event.SessionID)
 }
 
+// childFrameSession returns the out-of-process frame session of the page
+// with the given session ID that has been attached by this session.
+func (fs *FrameSession) childFrameSession(sid target.SessionID) *FrameSession {
+	for _, cfs := range fs.page.allFrameSessions() {
+		if cfs.parent == fs && cfs.session.ID() == sid {
+			return cfs
+		}
+	}
+	return nil
+}
+
+// detachChildFrameSession removes the frame session of an out-of-process
+// frame whose target has gone away. The frame itself is detached unless it
+// has been swapped back into the process of this session.
+func (fs *FrameSession) detachChildFrameSession(cfs *FrameSession) {
+	fid := cdp.FrameID(cfs.targetID)
+	if atomic.LoadInt64(&cfs.swappedIn) == 1 {
+		fs.page.detachFrameSession(fid, cfs)
+		return
+	}
+
+	// The target may go away before the frame attached event of a remote ->
+	// local transition arrives. Do a roundtrip to this session to receive
+	// all the pending events before deciding to detach the frame.
+	go func() {
+		action := cdppage.Enable()
+		if err := action.Do(cdp.WithExecutor(fs.ctx, fs.session)); err != nil {
+			fs.logger.Debugf("FrameSession:detachChildFrameSession:return",
+				"sid:%v tid:%v fid:%v err:%v",
+				fs.session.ID(), fs.targetID, fid, err)
+		}
+		if atomic.LoadInt64(&cfs.swappedIn) == 0 {
+			fs.manager.frameDetached(fid)
+		}
+		fs.page.detachFrameSession(fid, cfs)
+	}()
+}
+
 func (fs *FrameSession) onTargetCrashed(event *inspector.EventTargetCrashed) {
 	fs.logger.Debugf("FrameSession:onTargetCrashed", "sid:%v tid:%v", fs.session.ID(), fs.targetID)
 
@@ -898,6 +1307,16 @@ func (fs *FrameSession) updateEmulateMedia(initial bool) error {
 		features = append(features, &emulation.MediaFeature{Name: "prefers-reduced-motion", Value: ""})
 	}
 
+	switch fs.page.contrast {
+	case ContrastMore, ContrastLess, ContrastCustom:
+		features = append(features, &emulation.MediaFeature{Name: "prefers-contrast", Value: string(fs.page.contrast)})
+	default:
+		features = append(features, &emulation.MediaFeature{Name: "prefers-contrast", Value: ""})
+	}
+
+	// an empty value falls back to the system setting.
+	features = append(features, &emulation.MediaFeature{Name: "forced-colors", Value: string(fs.page.forcedColors)})
+
 	action := emulation.SetEmulatedMedia().
 		WithMedia(string(fs.page.mediaType)).
 		WithFeatures(features)
@@ -926,8 +1345,12 @@ func (fs *FrameSession) updateExtraHTTPHeaders(initial bool) {
 func (fs *FrameSession) updateGeolocation(initial bool) error {
 	fs.logger.Debugf("NewFrameSession:updateGeolocation", "sid:%v tid:%v", fs.session.ID(), fs.targetID)
 
-	geolocation := fs.page.browserCtx.opts.Geolocation
-	if !initial || geolocation != nil {
+	geolocation := fs.page.geolocation
+	if geolocation == nil {
+		geolocation = fs.page.browserCtx.opts.Geolocation
+	}
+	switch {
+	case geolocation != nil:
 		action := emulation.SetGeolocationOverride().
 			WithLatitude(geolocation.Latitude).
 			WithLongitude(geolocation.Longitude).
@@ -935,6 +1358,12 @@ func (fs *FrameSession) updateGeolocation(initial bool) error {
 		if err := action.Do(cdp.WithExecutor(fs.ctx, fs.session)); err != nil {
 			return fmt.Errorf("overriding geolocation: %w", err)
 		}
+	case !initial:
+		// a nil geolocation at runtime means the override was cleared.
+		action := emulation.ClearGeolocationOverride()
+		if err := action.Do(cdp.WithExecutor(fs.ctx, fs.session)); err != nil {
+			return fmt.Errorf("clearing geolocation override: %w", err)
+		}
 	}
 	return nil
 }
@@ -986,16 +1415,16 @@ func (fs *FrameSession) updateViewport() error {
 	viewport := emulatedSize.Viewport
 	screen := emulatedSize.Screen
 
-	orientation := emulation.ScreenOrientation{
-		Angle: 0.0,
-		Type:  emulation.OrientationTypePortraitPrimary,
-	}
-	if viewport.Width > viewport.Height {
-		orientation.Angle = 90.0
-		orientation.Type = emulation.OrientationTypeLandscapePrimary
+	// without an explicit orientation, it's derived from the viewport.
+	orientation := fs.page.orientation
+	if orientation == "" {
+		orientation = ScreenOrientationPortraitPrimary
+		if viewport.Width > viewport.Height {
+			orientation = ScreenOrientationLandscapePrimary
+		}
 	}
 	action := emulation.SetDeviceMetricsOverride(viewport.Width, viewport.Height, opts.DeviceScaleFactor, opts.IsMobile).
-		WithScreenOrientation(&orientation).
+		WithScreenOrientation(orientation.toCDP()).
 		WithScreenWidth(screen.Width).
 		WithScreenHeight(screen.Height)
 	if err := action.Do(cdp.WithExecutor(fs.ctx, fs.session)); err != nil {
@@ -0,0 +1,103 @@
+/*
+ *
+ * xk6-browser - a browser automation extension for k6
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/xk6-browser/log"
+
+	"github.com/chromedp/cdproto/cdp"
+	cdppage "github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/target"
+	"github.com/mailru/easyjson"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// frameTreeSession is a session that returns an empty frame tree for the
+// first nilTrees calls of getFrameTree.
+type frameTreeSession struct {
+	session
+	nilTrees int
+	calls    int
+}
+
+func (s *frameTreeSession) Execute(
+	_ context.Context, method string, _ easyjson.Marshaler, res easyjson.Unmarshaler,
+) error {
+	if method != cdppage.CommandGetFrameTree {
+		return nil
+	}
+	s.calls++
+	if s.calls <= s.nilTrees {
+		return easyjson.Unmarshal([]byte(`{}`), res)
+	}
+	return easyjson.Unmarshal([]byte(`{"frameTree":{"frame":{"id":"1","loaderId":"1","url":"about:blank"}}}`), res)
+}
+
+func (s *frameTreeSession) ID() target.SessionID { return "1234" }
+
+func TestFrameSessionGetFrameTree(t *testing.T) {
+	t.Parallel()
+
+	newFrameSession := func(nilTrees int) (*FrameSession, *frameTreeSession) {
+		s := &frameTreeSession{nilTrees: nilTrees}
+		return &FrameSession{
+			ctx:      context.Background(),
+			session:  s,
+			targetID: "1",
+			logger:   log.NewNullLogger(),
+		}, s
+	}
+
+	t.Run("retries", func(t *testing.T) {
+		t.Parallel()
+
+		fs, s := newFrameSession(2)
+		tree, err := fs.getFrameTree()
+		require.NoError(t, err)
+		assert.Equal(t, cdp.FrameID("1"), tree.Frame.ID)
+		assert.Equal(t, 3, s.calls)
+	})
+
+	t.Run("gives_up", func(t *testing.T) {
+		t.Parallel()
+
+		fs, s := newFrameSession(10)
+		_, err := fs.getFrameTree()
+		assert.EqualError(t, err, "got a nil page frame tree after 5 attempts")
+		assert.Equal(t, 5, s.calls)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		fs, s := newFrameSession(10)
+		fs.ctx = ctx
+		_, err := fs.getFrameTree()
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 1, s.calls)
+	})
+}
@@ -2,6 +2,8 @@ package common
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -9,6 +11,7 @@ import (
 	"github.com/grafana/xk6-browser/log"
 
 	"github.com/chromedp/cdproto/cdp"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -110,3 +113,232 @@ func (e executionContextTestStub) eval(
 ) (res interface{}, err error) {
 	return e.evalFn(apiCtx, opts, js, args...)
 }
+
+func TestFrameNetworkIdleTimeout(t *testing.T) {
+	t.Parallel()
+
+	vu := k6test.NewVU(t)
+	log := log.NewNullLogger()
+
+	fm := NewFrameManager(vu.Context(), nil, nil, nil, log)
+	parent := NewFrame(vu.Context(), fm, nil, cdp.FrameID("1"), log)
+	child := NewFrame(vu.Context(), fm, parent, cdp.FrameID("2"), log)
+	other := NewFrame(vu.Context(), fm, nil, cdp.FrameID("3"), log)
+
+	parent.setGotoNetworkIdleTimeout(time.Second)
+	other.setGotoNetworkIdleTimeout(2 * time.Second)
+	require.Equal(t, time.Second, parent.networkIdleTimeout())
+	require.Equal(t, time.Second, child.networkIdleTimeout(), "should inherit the parent's navigation timeout")
+	require.Equal(t, 2*time.Second, other.networkIdleTimeout())
+
+	parent.setGotoNetworkIdleTimeout(0)
+	require.Equal(t, LifeCycleNetworkIdleTimeout, child.networkIdleTimeout())
+	require.Equal(t, 2*time.Second, other.networkIdleTimeout())
+}
+
+func TestFrameLayoutShiftSessionWindows(t *testing.T) {
+	t.Parallel()
+
+	vu := k6test.NewVU(t)
+	log := log.NewNullLogger()
+
+	fm := NewFrameManager(vu.Context(), nil, nil, nil, log)
+	frame := NewFrame(vu.Context(), fm, nil, cdp.FrameID("1"), log)
+
+	// the first window ends with a gap of more than a second.
+	frame.addLayoutShift(0.1, 0)
+	frame.addLayoutShift(0.1, 900)
+	assert.InDelta(t, 0.2, frame.cumulativeLayoutShift(), 1e-9)
+	frame.addLayoutShift(0.15, 2000)
+	assert.InDelta(t, 0.2, frame.cumulativeLayoutShift(), 1e-9, "should keep the largest window")
+
+	// the second window is capped at five seconds.
+	for start := 2500.0; start < 7000; start += 500 {
+		frame.addLayoutShift(0.05, start)
+	}
+	assert.InDelta(t, 0.6, frame.cumulativeLayoutShift(), 1e-9)
+
+	frame.resetLayoutShift()
+	assert.Equal(t, float64(0), frame.cumulativeLayoutShift())
+	frame.addLayoutShift(0.05, 7000)
+	assert.InDelta(t, 0.05, frame.cumulativeLayoutShift(), 1e-9, "should start a new window")
+}
+
+func TestFrameRecalculateLifecycleNestedFrames(t *testing.T) {
+	t.Parallel()
+
+	ctx, log := context.Background(), log.NewNullLogger()
+
+	page := &Page{BaseEventEmitter: NewBaseEventEmitter(ctx)}
+	fm := NewFrameManager(ctx, nil, page, NewTimeoutSettings(nil), log)
+	page.frameManager = fm
+	main := NewFrame(ctx, fm, nil, cdp.FrameID("1"), log)
+	child := NewFrame(ctx, fm, main, cdp.FrameID("2"), log)
+	grandchild := NewFrame(ctx, fm, child, cdp.FrameID("3"), log)
+	main.addChildFrame(child)
+	child.addChildFrame(grandchild)
+	fm.setMainFrame(main)
+
+	fire := func(f *Frame) {
+		f.lifecycleEventsMu.Lock()
+		f.lifecycleEvents[LifecycleEventLoad] = true
+		f.lifecycleEventsMu.Unlock()
+	}
+	frameLoaded := make(chan Event, 1)
+	main.on(ctx, []string{EventFrameAddLifecycle}, frameLoaded)
+	pageLoaded := make(chan Event, 1)
+	page.on(ctx, []string{EventPageLoad}, pageLoaded)
+
+	// the load event hasn't fired for the grandchild yet.
+	fire(main)
+	fire(child)
+	main.recalculateLifecycle()
+	require.False(t, main.hasSubtreeLifecycleEventFired(LifecycleEventLoad))
+	require.False(t, child.hasSubtreeLifecycleEventFired(LifecycleEventLoad))
+	require.False(t, grandchild.hasSubtreeLifecycleEventFired(LifecycleEventLoad))
+
+	fire(grandchild)
+	main.recalculateLifecycle()
+	require.True(t, main.hasSubtreeLifecycleEventFired(LifecycleEventLoad))
+	require.True(t, child.hasSubtreeLifecycleEventFired(LifecycleEventLoad))
+	require.True(t, grandchild.hasSubtreeLifecycleEventFired(LifecycleEventLoad))
+
+	for name, ch := range map[string]chan Event{
+		EventFrameAddLifecycle: frameLoaded,
+		EventPageLoad:          pageLoaded,
+	} {
+		select {
+		case <-ch:
+		case <-time.After(time.Second):
+			require.FailNowf(t, "event not emitted", "%s", name)
+		}
+	}
+}
+
+func TestFrameWaitForLoadStateIncludeSubframes(t *testing.T) {
+	t.Parallel()
+
+	ctx, log := context.Background(), log.NewNullLogger()
+
+	page := &Page{BaseEventEmitter: NewBaseEventEmitter(ctx)}
+	fm := NewFrameManager(ctx, nil, page, NewTimeoutSettings(nil), log)
+	page.frameManager = fm
+	main := NewFrame(ctx, fm, nil, cdp.FrameID("1"), log)
+	child := NewFrame(ctx, fm, main, cdp.FrameID("2"), log)
+	main.addChildFrame(child)
+	fm.setMainFrame(main)
+
+	fire := func(f *Frame) {
+		f.lifecycleEventsMu.Lock()
+		f.lifecycleEvents[LifecycleEventNetworkIdle] = true
+		f.lifecycleEventsMu.Unlock()
+	}
+	opts := func(includeSubframes bool, timeout time.Duration) *FrameWaitForLoadStateOptions {
+		return &FrameWaitForLoadStateOptions{IncludeSubframes: includeSubframes, Timeout: timeout}
+	}
+
+	// only the main frame is idle, the child frame is still loading.
+	fire(main)
+	main.recalculateLifecycle()
+	require.NoError(t, main.waitForLoadState(LifecycleEventNetworkIdle, opts(false, time.Second)))
+	err := main.waitForLoadState(LifecycleEventNetworkIdle, opts(true, 50*time.Millisecond))
+	require.ErrorIs(t, err, ErrTimedOut)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- main.waitForLoadState(LifecycleEventNetworkIdle, opts(true, 5*time.Second))
+	}()
+	time.Sleep(50 * time.Millisecond)
+	fire(child)
+	main.recalculateLifecycle()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "waitForLoadState did not return after the subtree became idle")
+	}
+	require.NoError(t, main.waitForLoadState(LifecycleEventNetworkIdle, opts(true, time.Second)))
+}
+
+func TestFrameNetworkIdleTimerRestart(t *testing.T) {
+	t.Parallel()
+
+	ctx, log := context.Background(), log.NewNullLogger()
+
+	page := &Page{BaseEventEmitter: NewBaseEventEmitter(ctx)}
+	fm := NewFrameManager(ctx, nil, page, NewTimeoutSettings(nil), log)
+	page.frameManager = fm
+	frame := NewFrame(ctx, fm, nil, cdp.FrameID("1"), log)
+	fm.frames[frame.id] = frame
+	fm.setMainFrame(frame)
+
+	const idle = 100 * time.Millisecond
+	frame.setGotoNetworkIdleTimeout(idle)
+	networkIdle := make(chan Event, 10)
+	frame.on(ctx, []string{EventFrameAddLifecycle}, networkIdle)
+
+	// the first navigation is followed quickly by a second one, which
+	// restarts the timer, so only the timer of the second one should fire.
+	frame.startNetworkIdleTimer()
+	time.Sleep(idle / 2)
+	var lastStart time.Time
+	for i := 0; i < 10; i++ {
+		frame.stopNetworkIdleTimer()
+		frame.startNetworkIdleTimer()
+		lastStart = time.Now()
+	}
+
+	select {
+	case ev := <-networkIdle:
+		require.Equal(t, LifecycleEventNetworkIdle, ev.data)
+		require.GreaterOrEqual(t, time.Since(lastStart), idle, "a stale timer fired")
+	case <-time.After(time.Second):
+		require.FailNow(t, "networkidle didn't fire")
+	}
+
+	// a stale timer shouldn't fire for the next document either.
+	frame.lifecycleEventsMu.Lock()
+	frame.lifecycleEvents = make(map[LifecycleEvent]bool)
+	frame.subtreeLifecycleEvents = make(map[LifecycleEvent]bool)
+	frame.lifecycleEventsMu.Unlock()
+	select {
+	case ev := <-networkIdle:
+		require.FailNowf(t, "unexpected lifecycle event", "%v", ev.data)
+	case <-time.After(3 * idle):
+	}
+}
+
+func TestRetryAction(t *testing.T) {
+	t.Parallel()
+
+	run := func(retries int64, errs ...error) (int, interface{}, error) {
+		var attempts int
+		act := retryAction(retries, func(_ context.Context, resultCh chan interface{}, errCh chan error) {
+			attempts++
+			if attempts <= len(errs) {
+				errCh <- errs[attempts-1]
+				return
+			}
+			resultCh <- "done"
+		})
+		res, err := callApiWithTimeout(context.Background(), act, 5*time.Second)
+		return attempts, res, err
+	}
+
+	attempts, res, err := run(2, ErrElementNotAttached, fmt.Errorf("checking hit target: %w", ErrElementIntercepted))
+	require.NoError(t, err)
+	assert.Equal(t, "done", res)
+	assert.Equal(t, 3, attempts)
+
+	attempts, _, err = run(1, ErrElementNotAttached, errors.New("Node is detached from document"))
+	require.Error(t, err)
+	assert.Equal(t, 2, attempts, "should stop after the retries")
+
+	attempts, _, err = run(3, errors.New("node is not an element"))
+	require.Error(t, err)
+	assert.Equal(t, 1, attempts, "should not retry an unrecoverable error")
+
+	attempts, _, err = run(0, ErrElementNotAttached)
+	require.ErrorIs(t, err, ErrElementNotAttached)
+	assert.Equal(t, 1, attempts)
+}
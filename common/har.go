@@ -0,0 +1,334 @@
+/*
+ *
+ * xk6-browser - a browser automation extension for k6
+ * Copyright (C) 2022 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+)
+
+// harVersion is the version of the HAR format the recorder writes.
+// See: http://www.softwareishard.com/blog/har-12-spec/
+const harVersion = "1.2"
+
+// HAR 1.2 document types.
+type (
+	harDocument struct {
+		Log harLog `json:"log"`
+	}
+	harLog struct {
+		Version string      `json:"version"`
+		Creator harCreator  `json:"creator"`
+		Pages   []struct{}  `json:"pages"`
+		Entries []*harEntry `json:"entries"`
+	}
+	harCreator struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	harEntry struct {
+		StartedDateTime time.Time   `json:"startedDateTime"`
+		Time            float64     `json:"time"`
+		Request         harRequest  `json:"request"`
+		Response        harResponse `json:"response"`
+		Cache           struct{}    `json:"cache"`
+		Timings         harTimings  `json:"timings"`
+		ServerIPAddress string      `json:"serverIPAddress,omitempty"`
+	}
+	harRequest struct {
+		Method      string         `json:"method"`
+		URL         string         `json:"url"`
+		HTTPVersion string         `json:"httpVersion"`
+		Cookies     []harNameValue `json:"cookies"`
+		Headers     []harNameValue `json:"headers"`
+		QueryString []harNameValue `json:"queryString"`
+		PostData    *harPostData   `json:"postData,omitempty"`
+		HeadersSize int64          `json:"headersSize"`
+		BodySize    int64          `json:"bodySize"`
+	}
+	harResponse struct {
+		Status      int64          `json:"status"`
+		StatusText  string         `json:"statusText"`
+		HTTPVersion string         `json:"httpVersion"`
+		Cookies     []harNameValue `json:"cookies"`
+		Headers     []harNameValue `json:"headers"`
+		Content     harContent     `json:"content"`
+		RedirectURL string         `json:"redirectURL"`
+		HeadersSize int64          `json:"headersSize"`
+		BodySize    int64          `json:"bodySize"`
+	}
+	harNameValue struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+	harPostData struct {
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text"`
+	}
+	harContent struct {
+		Size     int64  `json:"size"`
+		MimeType string `json:"mimeType"`
+	}
+	harTimings struct {
+		Blocked float64 `json:"blocked"`
+		DNS     float64 `json:"dns"`
+		Connect float64 `json:"connect"`
+		Send    float64 `json:"send"`
+		Wait    float64 `json:"wait"`
+		Receive float64 `json:"receive"`
+		SSL     float64 `json:"ssl"`
+	}
+)
+
+// harRecorder accumulates the requests of a browser context as HAR entries.
+// It's shared by the network managers of all the frame sessions in the context.
+type harRecorder struct {
+	path string
+
+	mu      sync.Mutex
+	entries []*harEntry
+
+	// flushMu serializes the writes of the HAR file, as it's flushed
+	// both when the context and when the browser closes.
+	flushMu sync.Mutex
+}
+
+func newHarRecorder(path string) *harRecorder {
+	return &harRecorder{path: path}
+}
+
+// record adds a HAR entry for the completed request. end is the monotonic
+// time the request finished or failed at.
+func (h *harRecorder) record(req *Request, end time.Time) {
+	if isInternalURL(req.url) {
+		return
+	}
+	entry := newHarEntry(req, end)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries = append(h.entries, entry)
+}
+
+// flush writes the recorded entries as a HAR document to the recorder's path.
+// It can be called more than once, each call overwrites the file with all
+// the entries recorded so far.
+func (h *harRecorder) flush() error {
+	h.flushMu.Lock()
+	defer h.flushMu.Unlock()
+
+	h.mu.Lock()
+	entries := make([]*harEntry, len(h.entries))
+	copy(entries, h.entries)
+	h.mu.Unlock()
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].StartedDateTime.Before(entries[j].StartedDateTime)
+	})
+	doc := harDocument{
+		Log: harLog{
+			Version: harVersion,
+			Creator: harCreator{Name: "xk6-browser"},
+			Pages:   []struct{}{},
+			Entries: entries,
+		},
+	}
+	buf, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling HAR: %w", err)
+	}
+	if dir := filepath.Dir(h.path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("creating HAR directory %q: %w", dir, err)
+		}
+	}
+	if err := ioutil.WriteFile(h.path, buf, 0o644); err != nil {
+		return fmt.Errorf("writing HAR file %q: %w", h.path, err)
+	}
+	return nil
+}
+
+func newHarEntry(req *Request, end time.Time) *harEntry {
+	entry := harEntry{
+		StartedDateTime: req.wallTime,
+		Time:            durationToMs(end.Sub(req.timestamp)),
+		Request: harRequest{
+			Method:      req.method,
+			URL:         req.URL(),
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []harNameValue{},
+			Headers:     toHarHeaders(req.headers),
+			QueryString: []harNameValue{},
+			HeadersSize: req.headersSize(),
+			BodySize:    int64(len(req.postData)),
+		},
+		Response: harResponse{
+			Cookies: []harNameValue{},
+			Headers: []harNameValue{},
+		},
+		Timings: harTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1},
+	}
+	for k, vs := range req.url.Query() {
+		for _, v := range vs {
+			entry.Request.QueryString = append(entry.Request.QueryString, harNameValue{Name: k, Value: v})
+		}
+	}
+	if req.postData != "" {
+		entry.Request.PostData = &harPostData{
+			MimeType: headerValue(req.headers, "Content-Type"),
+			Text:     req.postData,
+		}
+	}
+
+	resp := req.response
+	if resp == nil {
+		// the request failed before receiving a response.
+		entry.Timings.Wait = entry.Time
+		return &entry
+	}
+	if resp.protocol != "" {
+		entry.Request.HTTPVersion = resp.protocol
+	}
+	entry.Response.Status = resp.status
+	entry.Response.StatusText = resp.statusText
+	entry.Response.HTTPVersion = entry.Request.HTTPVersion
+	entry.Response.Headers = toHarHeaders(resp.headers)
+	entry.Response.RedirectURL = headerValue(resp.headers, "Location")
+	entry.Response.HeadersSize = resp.headersSize()
+	entry.Response.Content.MimeType = headerValue(resp.headers, "Content-Type")
+	if resp.remoteAddress != nil {
+		entry.ServerIPAddress = resp.remoteAddress.IPAddress
+	}
+	// the transfer size of the request includes the response headers.
+	if req.transferSize > 0 {
+		entry.Response.BodySize = maxInt64(req.transferSize-resp.headersLength, 0)
+	}
+	entry.Response.Content.Size = entry.Response.BodySize
+	resp.bodyMu.RLock()
+	if len(resp.body) > 0 {
+		entry.Response.Content.Size = int64(len(resp.body))
+	}
+	resp.bodyMu.RUnlock()
+
+	if t := resp.timing; t != nil {
+		entry.Timings = harTimingsFromResource(
+			t.DNSStart, t.DNSEnd, t.ConnectStart, t.ConnectEnd, t.SslStart, t.SslEnd,
+			t.SendStart, t.SendEnd, t.ReceiveHeadersEnd,
+		)
+		// the resource timings are relative to the request time, which is in
+		// seconds since the same epoch as the monotonic end time.
+		requestTime := cdp.MonotonicTimeEpoch.Add(time.Duration(t.RequestTime * float64(time.Second)))
+		responseEnd := durationToMs(end.Sub(requestTime))
+		entry.Timings.Receive = maxFloat(responseEnd-t.ReceiveHeadersEnd, 0)
+		entry.Time = sumHarTimings(entry.Timings)
+	}
+
+	return &entry
+}
+
+// harTimingsFromResource converts the CDP resource timings, which are
+// offsets in milliseconds from the request time, into HAR timing phases.
+func harTimingsFromResource(
+	dnsStart, dnsEnd, connectStart, connectEnd, sslStart, sslEnd,
+	sendStart, sendEnd, receiveHeadersEnd float64,
+) harTimings {
+	t := harTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1}
+
+	// the first phase that has started marks the end of the blocked phase.
+	for _, start := range []float64{dnsStart, connectStart, sendStart} {
+		if start >= 0 {
+			t.Blocked = start
+			break
+		}
+	}
+	if dnsStart >= 0 {
+		t.DNS = dnsEnd - dnsStart
+	}
+	if connectStart >= 0 {
+		t.Connect = connectEnd - connectStart
+	}
+	if sslStart >= 0 {
+		t.SSL = sslEnd - sslStart
+	}
+	t.Send = maxFloat(sendEnd-sendStart, 0)
+	t.Wait = maxFloat(receiveHeadersEnd-sendEnd, 0)
+
+	return t
+}
+
+// sumHarTimings returns the total time of the entry. SSL is already
+// included in the connect time, so it isn't added.
+func sumHarTimings(t harTimings) float64 {
+	var total float64
+	for _, v := range []float64{t.Blocked, t.DNS, t.Connect, t.Send, t.Wait, t.Receive} {
+		if v > 0 {
+			total += v
+		}
+	}
+	return total
+}
+
+func toHarHeaders(headers map[string][]string) []harNameValue {
+	hs := make([]harNameValue, 0, len(headers))
+	for n, vs := range headers {
+		for _, v := range vs {
+			hs = append(hs, harNameValue{Name: n, Value: v})
+		}
+	}
+	sort.SliceStable(hs, func(i, j int) bool { return hs[i].Name < hs[j].Name })
+	return hs
+}
+
+func headerValue(headers map[string][]string, name string) string {
+	for n, vs := range headers {
+		if strings.EqualFold(n, name) {
+			return strings.Join(vs, ", ")
+		}
+	}
+	return ""
+}
+
+func durationToMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func maxInt64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,54 @@
+/*
+ *
+ * xk6-browser - a browser automation extension for k6
+ * Copyright (C) 2022 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHarTimingsFromResource(t *testing.T) {
+	t.Parallel()
+
+	t.Run("new_connection", func(t *testing.T) {
+		t.Parallel()
+
+		// dnsStart, dnsEnd, connectStart, connectEnd, sslStart, sslEnd,
+		// sendStart, sendEnd, receiveHeadersEnd
+		got := harTimingsFromResource(1, 3, 3, 10, 5, 10, 11, 12, 20)
+		assert.Equal(t, harTimings{
+			Blocked: 1, DNS: 2, Connect: 7, SSL: 5, Send: 1, Wait: 8,
+		}, got)
+		got.Receive = 4
+		assert.Equal(t, 23.0, sumHarTimings(got))
+	})
+
+	t.Run("reused_connection", func(t *testing.T) {
+		t.Parallel()
+
+		got := harTimingsFromResource(-1, -1, -1, -1, -1, -1, 0.5, 1, 3)
+		assert.Equal(t, harTimings{
+			Blocked: 0.5, DNS: -1, Connect: -1, SSL: -1, Send: 0.5, Wait: 2,
+		}, got)
+		assert.Equal(t, 3.0, sumHarTimings(got))
+	})
+}
@@ -105,6 +105,9 @@ func convertArgument(
 	}
 }
 
+// callApiWithTimeout calls fn and waits for its result until the timeout,
+// or the deadline of ctx if it's earlier. A zero timeout disables the
+// timeout, but not the deadline of ctx.
 func callApiWithTimeout(ctx context.Context, fn func(context.Context, chan interface{}, chan error), timeout time.Duration) (interface{}, error) {
 	var result interface{}
 	var err error
@@ -133,6 +136,71 @@ func callApiWithTimeout(ctx context.Context, fn func(context.Context, chan inter
 	return result, err
 }
 
+// timeoutError returns ErrTimedOut after the timeout if err is due to a
+// deadline, or err otherwise.
+func timeoutError(err error, timeout time.Duration) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w after %s", ErrTimedOut, timeout)
+	}
+	return err
+}
+
+// timeoutUntil returns the time left before the deadline if it's shorter
+// than the timeout, so that a sequence of actions shares one budget. A zero
+// deadline keeps the timeout. Once the deadline has passed, the returned
+// timeout is a millisecond instead of zero, which disables it.
+func timeoutUntil(deadline time.Time, timeout time.Duration) time.Duration {
+	if deadline.IsZero() {
+		return timeout
+	}
+	d := time.Until(deadline)
+	if d < time.Millisecond {
+		return time.Millisecond
+	}
+	if timeout > 0 && timeout < d {
+		return timeout
+	}
+	return d
+}
+
+// remainingTimeout returns the time left before the context deadline if
+// it's shorter than the timeout. The steps of an action use it so that they
+// respect the time left for the action instead of resetting it.
+func remainingTimeout(ctx context.Context, timeout time.Duration) time.Duration {
+	deadline, _ := ctx.Deadline()
+	return timeoutUntil(deadline, timeout)
+}
+
+// injectedTimeout returns the timeout to pass to the waits of the injected
+// script, in milliseconds, or nil for a zero timeout, which the injected
+// script treats as no timeout.
+func injectedTimeout(timeout time.Duration) interface{} {
+	if timeout == 0 {
+		return nil
+	}
+	return timeout.Milliseconds()
+}
+
+// parseDeadline parses a deadline given either as a Date or as the number of
+// milliseconds since the Unix epoch, e.g. Date.now() + 5000.
+func parseDeadline(v goja.Value) (time.Time, error) {
+	ms := v.ToFloat()
+	if math.IsNaN(ms) || math.IsInf(ms, 0) || ms <= 0 {
+		return time.Time{}, fmt.Errorf(
+			"deadline must be a Date or a positive number of milliseconds since the Unix epoch, got: %v", v)
+	}
+	return time.UnixMilli(int64(ms)), nil
+}
+
+// parseRetries parses the retries option of the frame actions.
+func parseRetries(v goja.Value) (int64, error) {
+	retries := v.ToInteger()
+	if retries < 0 {
+		return 0, fmt.Errorf("retries must be a non-negative integer, got: %d", retries)
+	}
+	return retries, nil
+}
+
 func stringSliceContains(s []string, e string) bool {
 	for _, a := range s {
 		if a == e {
@@ -159,21 +227,26 @@ func createWaitForEventHandler(
 			case <-evCancelCtx.Done():
 				return
 			case ev := <-chEvHandler:
-				if stringSliceContains(events, ev.typ) {
-					if predicateFn != nil {
-						if predicateFn(ev.data) {
-							ch <- ev.data
-						}
-					} else {
-						ch <- nil
+				if !stringSliceContains(events, ev.typ) {
+					continue
+				}
+				var data interface{}
+				if predicateFn != nil {
+					if !predicateFn(ev.data) {
+						continue
 					}
-					close(ch)
-
-					// We wait for one matching event only,
-					// then remove event handler by cancelling context and stopping goroutine.
-					evCancelFn()
-					return
+					data = ev.data
 				}
+				select {
+				case ch <- data:
+				case <-evCancelCtx.Done():
+				}
+				close(ch)
+
+				// We wait for one matching event only,
+				// then remove event handler by cancelling context and stopping goroutine.
+				evCancelFn()
+				return
 			}
 		}
 	}()
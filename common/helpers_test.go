@@ -26,6 +26,7 @@ import (
 	"fmt"
 	"math"
 	"testing"
+	"time"
 
 	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/cdproto/runtime"
@@ -233,3 +234,46 @@ func TestConvertArgument(t *testing.T) {
 		require.Empty(t, arg.UnserializableValue)
 	})
 }
+
+func TestTimeoutUntil(t *testing.T) {
+	t.Parallel()
+
+	// no deadline keeps the timeout.
+	require.Equal(t, 30*time.Second, timeoutUntil(time.Time{}, 30*time.Second))
+	// an earlier deadline shortens the timeout.
+	d := timeoutUntil(time.Now().Add(time.Second), 30*time.Second)
+	require.LessOrEqual(t, d, time.Second)
+	require.Greater(t, d, 500*time.Millisecond)
+	// a later deadline keeps the timeout.
+	require.Equal(t, time.Second, timeoutUntil(time.Now().Add(time.Hour), time.Second))
+	// the deadline also applies when the timeout is disabled.
+	d = timeoutUntil(time.Now().Add(time.Second), 0)
+	require.LessOrEqual(t, d, time.Second)
+	require.Greater(t, d, 500*time.Millisecond)
+	// a passed deadline doesn't disable the timeout.
+	require.Equal(t, time.Millisecond, timeoutUntil(time.Now().Add(-time.Second), 0))
+}
+
+func TestParseDeadline(t *testing.T) {
+	t.Parallel()
+
+	rt := goja.New()
+	now := time.Now().Truncate(time.Millisecond)
+
+	d, err := parseDeadline(rt.ToValue(now.UnixMilli()))
+	require.NoError(t, err)
+	require.True(t, now.Equal(d))
+
+	v, err := rt.RunString(fmt.Sprintf("new Date(%d)", now.UnixMilli()))
+	require.NoError(t, err)
+	d, err = parseDeadline(v)
+	require.NoError(t, err)
+	require.True(t, now.Equal(d), "should parse a Date")
+
+	for _, v := range []goja.Value{
+		rt.ToValue(0), rt.ToValue(-1), rt.ToValue(math.NaN()), rt.ToValue(math.Inf(1)), rt.ToValue("soon"),
+	} {
+		_, err := parseDeadline(v)
+		require.Errorf(t, err, "should reject %v", v)
+	}
+}
@@ -44,16 +44,21 @@ const eventType = new Map([
   ["auxclick", "mouse"],
   ["click", "mouse"],
   ["dblclick", "mouse"],
+  ["contextmenu", "mouse"],
   ["mousedown", "mouse"],
-  ["mouseeenter", "mouse"],
+  ["mouseenter", "mouse"],
   ["mouseleave", "mouse"],
   ["mousemove", "mouse"],
   ["mouseout", "mouse"],
   ["mouseover", "mouse"],
   ["mouseup", "mouse"],
-  ["mouseleave", "mouse"],
   ["mousewheel", "mouse"],
 
+  ["wheel", "wheel"],
+
+  ["beforeinput", "input"],
+  ["input", "input"],
+
   ["keydown", "keyboard"],
   ["keyup", "keyboard"],
   ["keypress", "keyboard"],
@@ -77,6 +82,8 @@ const eventType = new Map([
 
   ["focus", "focus"],
   ["blur", "focus"],
+  ["focusin", "focus"],
+  ["focusout", "focus"],
 
   ["drag", "drag"],
   ["dragstart", "drag"],
@@ -90,6 +97,18 @@ const eventType = new Map([
 
 const continuePolling = Symbol("continuePolling");
 
+// toDataTransfer returns a DataTransfer with the data of the formats in the
+// data object, like { "text/plain": "text", "text/uri-list": "https://..." }.
+function toDataTransfer(data) {
+  const dataTransfer = new DataTransfer();
+  if (typeof data === "object" && data !== null) {
+    for (const [format, value] of Object.entries(data)) {
+      dataTransfer.setData(format, String(value));
+    }
+  }
+  return dataTransfer;
+}
+
 function isVisible(element) {
   if (!element.ownerDocument || !element.ownerDocument.defaultView) {
     return true;
@@ -102,6 +121,57 @@ function isVisible(element) {
   return rect.width > 0 && rect.height > 0;
 }
 
+// strictModeViolation returns the error of a strict selector that resolved
+// to more than one element. See errorFromDOMError.
+function strictModeViolation(selector, count) {
+  return `error:strictmodeviolation:${count}:${selector.selector}`;
+}
+
+// shallowInnerText approximates the innerText of the element, leaving out
+// the text of the elements nested more than maxDepth levels below it. The
+// hidden elements are skipped and the block elements go on separate lines.
+function shallowInnerText(element, maxDepth) {
+  const lines = [];
+  let line = "";
+  const flush = () => {
+    const text = line.replace(/\s+/g, " ").trim();
+    if (text) {
+      lines.push(text);
+    }
+    line = "";
+  };
+  const visit = (node, depth) => {
+    for (const child of node.childNodes) {
+      if (child.nodeType === 3 /*Node.TEXT_NODE*/) {
+        line += child.nodeValue;
+        continue;
+      }
+      if (child.nodeType !== 1 /*Node.ELEMENT_NODE*/ || depth >= maxDepth) {
+        continue;
+      }
+      if (child.nodeName === "BR") {
+        flush();
+        continue;
+      }
+      const style = child.ownerDocument.defaultView.getComputedStyle(child);
+      if (style.display === "none") {
+        continue;
+      }
+      const inline = style.display.startsWith("inline");
+      if (!inline) {
+        flush();
+      }
+      visit(child, depth + 1);
+      if (!inline) {
+        flush();
+      }
+    }
+  };
+  visit(element, 0);
+  flush();
+  return lines.join("\n");
+}
+
 function oneLine(s) {
   return s.replace(/\n/g, "↵").replace(/\t/g, "⇆");
 }
@@ -112,9 +182,526 @@ class CSSQueryEngine {
   }
 }
 
+class IDQueryEngine {
+  queryAll(root, selector) {
+    return root.querySelectorAll("#" + CSS.escape(selector));
+  }
+}
+
+// Tags whose text isn't rendered, so they can't be matched by text.
+const nonTextTags = new Set(["HEAD", "NOSCRIPT", "SCRIPT", "STYLE", "TEMPLATE"]);
+
+function normalizeWhiteSpace(s) {
+  return s.trim().replace(/\s+/g, " ");
+}
+
+// createTextMatcher returns a matcher of the text of an element. A quoted
+// selector matches the whole text, case-sensitively. Otherwise, it matches
+// a substring of the text, case-insensitively.
+function createTextMatcher(selector) {
+  const quoted =
+    selector.length > 1 &&
+    (selector[0] === '"' || selector[0] === "'") &&
+    selector[selector.length - 1] === selector[0];
+  if (quoted) {
+    const text = normalizeWhiteSpace(selector.slice(1, -1));
+    return (s) => s === text;
+  }
+  const text = normalizeWhiteSpace(selector).toLowerCase();
+  return (s) => s.toLowerCase().includes(text);
+}
+
 class TextQueryEngine {
+  // queryAll returns the innermost elements whose text matches the selector.
   queryAll(root, selector) {
-    return root.queryAll(selector);
+    const matches = createTextMatcher(selector);
+    const elementMatches = (element) =>
+      !nonTextTags.has(element.nodeName) &&
+      matches(normalizeWhiteSpace(element.textContent || ""));
+
+    const result = [];
+    for (const element of root.querySelectorAll("*")) {
+      if (!elementMatches(element)) {
+        continue;
+      }
+      if (![...element.children].some(elementMatches)) {
+        result.push(element);
+      }
+    }
+    return result;
+  }
+}
+
+// implicitRole returns the ARIA role that an element has without a role
+// attribute, or null if it has none.
+function implicitRole(element) {
+  switch (element.nodeName) {
+    case "A":
+    case "AREA":
+      return element.hasAttribute("href") ? "link" : null;
+    case "ARTICLE":
+      return "article";
+    case "ASIDE":
+      return "complementary";
+    case "BUTTON":
+      return "button";
+    case "DATALIST":
+      return "listbox";
+    case "DETAILS":
+    case "FIELDSET":
+      return "group";
+    case "DIALOG":
+      return "dialog";
+    case "FOOTER":
+      return element.closest("article, aside, main, nav, section")
+        ? null
+        : "contentinfo";
+    case "FORM":
+      return "form";
+    case "H1":
+    case "H2":
+    case "H3":
+    case "H4":
+    case "H5":
+    case "H6":
+      return "heading";
+    case "HEADER":
+      return element.closest("article, aside, main, nav, section")
+        ? null
+        : "banner";
+    case "HR":
+      return "separator";
+    case "IMG":
+      return element.getAttribute("alt") === "" ? "presentation" : "img";
+    case "INPUT":
+      return inputRole(element);
+    case "LI":
+      return "listitem";
+    case "MAIN":
+      return "main";
+    case "METER":
+      return "meter";
+    case "NAV":
+      return "navigation";
+    case "OL":
+    case "UL":
+      return "list";
+    case "OPTION":
+      return "option";
+    case "OUTPUT":
+      return "status";
+    case "PROGRESS":
+      return "progressbar";
+    case "SECTION":
+      return element.hasAttribute("aria-label") ||
+        element.hasAttribute("aria-labelledby")
+        ? "region"
+        : null;
+    case "SELECT":
+      return element.multiple || element.size > 1 ? "listbox" : "combobox";
+    case "TABLE":
+      return "table";
+    case "TBODY":
+    case "TFOOT":
+    case "THEAD":
+      return "rowgroup";
+    case "TD":
+      return "cell";
+    case "TEXTAREA":
+      return "textbox";
+    case "TH":
+      return element.getAttribute("scope") === "row" ? "rowheader" : "columnheader";
+    case "TR":
+      return "row";
+  }
+  return null;
+}
+
+function inputRole(input) {
+  switch (input.type) {
+    case "button":
+    case "image":
+    case "reset":
+    case "submit":
+      return "button";
+    case "checkbox":
+      return "checkbox";
+    case "radio":
+      return "radio";
+    case "range":
+      return "slider";
+    case "number":
+      return "spinbutton";
+    case "hidden":
+      return null;
+    case "search":
+      return input.hasAttribute("list") ? "combobox" : "searchbox";
+    case "email":
+    case "tel":
+    case "text":
+    case "url":
+      return input.hasAttribute("list") ? "combobox" : "textbox";
+  }
+  return "textbox";
+}
+
+// ariaRole returns the explicit role of an element if it has one, and its
+// implicit role otherwise.
+function ariaRole(element) {
+  const explicit = (element.getAttribute("role") || "").trim().split(/\s+/)[0];
+  return explicit || implicitRole(element);
+}
+
+// isHiddenForAria reports whether an element is excluded from the
+// accessibility tree, because it or one of its ancestors is hidden.
+function isHiddenForAria(element) {
+  const view = element.ownerDocument.defaultView;
+  const style = view.getComputedStyle(element);
+  if (style.visibility === "hidden" || style.visibility === "collapse") {
+    return true;
+  }
+  for (let e = element; e; e = e.parentElement) {
+    if (e.getAttribute("aria-hidden") === "true") {
+      return true;
+    }
+    if (view.getComputedStyle(e).display === "none") {
+      return true;
+    }
+  }
+  return false;
+}
+
+// Roles whose accessible name can be computed from their content.
+const nameFromContentRoles = new Set([
+  "button",
+  "cell",
+  "checkbox",
+  "columnheader",
+  "gridcell",
+  "heading",
+  "link",
+  "menuitem",
+  "menuitemcheckbox",
+  "menuitemradio",
+  "option",
+  "radio",
+  "row",
+  "rowheader",
+  "switch",
+  "tab",
+  "tooltip",
+  "treeitem",
+]);
+
+function pseudoContent(element, pseudo) {
+  const content = element.ownerDocument.defaultView.getComputedStyle(
+    element,
+    pseudo
+  ).content;
+  if (content.length > 1 && (content[0] === '"' || content[0] === "'")) {
+    return content.slice(1, -1);
+  }
+  return "";
+}
+
+// accessibleName computes the accessible name of an element, following the
+// steps of the accessible name algorithm: https://www.w3.org/TR/accname-1.2/
+function accessibleName(element) {
+  return normalizeWhiteSpace(textAlternative(element, new Set(), false, false));
+}
+
+function textAlternative(element, visited, inLabelledBy, inContent) {
+  if (visited.has(element)) {
+    return "";
+  }
+  visited.add(element);
+
+  // Step 2A: hidden elements have no name, unless they are referenced.
+  if (!inLabelledBy && isHiddenForAria(element)) {
+    return "";
+  }
+
+  // Step 2B: the elements referenced by aria-labelledby.
+  if (!inLabelledBy) {
+    const ids = (element.getAttribute("aria-labelledby") || "")
+      .split(/\s+/)
+      .filter(Boolean);
+    const labels = ids
+      .map((id) => element.ownerDocument.getElementById(id))
+      .filter(Boolean);
+    if (labels.length) {
+      return labels
+        .map((label) => textAlternative(label, visited, true, false))
+        .join(" ");
+    }
+  }
+
+  // Step 2C: embedded controls take their value when part of a name.
+  const role = ariaRole(element);
+  if (inContent || inLabelledBy) {
+    if (role === "textbox" || role === "searchbox") {
+      return element.value !== undefined
+        ? element.value
+        : element.textContent || "";
+    }
+    if (role === "combobox" || role === "listbox") {
+      if (element.nodeName === "SELECT") {
+        return [...element.selectedOptions].map((o) => o.text).join(" ");
+      }
+    }
+    if (
+      ["slider", "spinbutton", "progressbar", "meter"].includes(role) &&
+      element.value !== undefined
+    ) {
+      return String(element.value);
+    }
+  }
+
+  // Step 2D: aria-label.
+  const ariaLabel = (element.getAttribute("aria-label") || "").trim();
+  if (ariaLabel) {
+    return ariaLabel;
+  }
+
+  // Step 2E: the native text alternatives of the host language.
+  const native = nativeTextAlternative(element, visited);
+  if (native) {
+    return native;
+  }
+
+  // Step 2F: the name from the content of the element.
+  if (nameFromContentRoles.has(role) || inLabelledBy || inContent) {
+    const text = contentText(element, visited);
+    if (text.trim()) {
+      return text;
+    }
+  }
+
+  // Step 2I: the tooltip attribute.
+  return element.getAttribute("title") || "";
+}
+
+function nativeTextAlternative(element, visited) {
+  const labelsText = () =>
+    [...(element.labels || [])]
+      .map((label) => textAlternative(label, visited, true, false))
+      .join(" ");
+  switch (element.nodeName) {
+    case "INPUT": {
+      const type = element.type;
+      if (type === "button" || type === "submit" || type === "reset") {
+        if (element.hasAttribute("value")) {
+          return element.value;
+        }
+        return type === "submit" ? "Submit" : type === "reset" ? "Reset" : "";
+      }
+      if (type === "image") {
+        return element.getAttribute("alt") || element.getAttribute("value") || "";
+      }
+      return labelsText() || element.getAttribute("placeholder") || "";
+    }
+    case "SELECT":
+    case "TEXTAREA":
+    case "BUTTON":
+    case "METER":
+    case "OUTPUT":
+    case "PROGRESS":
+      return labelsText() || element.getAttribute("placeholder") || "";
+    case "AREA":
+    case "IMG":
+      return element.getAttribute("alt") || "";
+    case "FIELDSET": {
+      const legend = element.querySelector(":scope > legend");
+      return legend ? textAlternative(legend, visited, true, false) : "";
+    }
+    case "FIGURE": {
+      const caption = element.querySelector(":scope > figcaption");
+      return caption ? textAlternative(caption, visited, true, false) : "";
+    }
+    case "TABLE": {
+      const caption = element.querySelector(":scope > caption");
+      return caption ? textAlternative(caption, visited, true, false) : "";
+    }
+  }
+  return "";
+}
+
+function contentText(element, visited) {
+  const parts = [pseudoContent(element, "::before")];
+  for (const child of element.childNodes) {
+    if (child.nodeType === 3 /*Node.TEXT_NODE*/) {
+      parts.push(child.textContent);
+    } else if (child.nodeType === 1 /*Node.ELEMENT_NODE*/) {
+      const text = textAlternative(child, visited, false, true);
+      const display = child.ownerDocument.defaultView.getComputedStyle(child)
+        .display;
+      // block elements are separated by spaces from their siblings.
+      parts.push(display === "inline" ? text : ` ${text} `);
+    }
+  }
+  parts.push(pseudoContent(element, "::after"));
+  return parts.join("");
+}
+
+// parseRoleSelector parses the body of a role selector, such as
+// button[name="Messages" s] or button[name=/Messages \(\d+\)/i][pressed=true].
+// A name quoted as a JSON string matches a substring of the accessible name
+// case-insensitively, or the whole name if it's followed by s. A regular
+// expression is tested against the accessible name. The checked and pressed
+// states are true, false or mixed, and the expanded state is true or false.
+function parseRoleSelector(selector) {
+  const bracket = selector.indexOf("[");
+  const role = (bracket === -1 ? selector : selector.slice(0, bracket)).trim();
+  if (!role) {
+    throw new Error(`role selector "${selector}" has no role`);
+  }
+  const result = { role, name: null, states: {} };
+  if (bracket === -1) {
+    return result;
+  }
+  const malformed = () => new Error(`role selector "${selector}" is malformed`);
+  let rest = selector.slice(bracket);
+  while (rest.trim()) {
+    const m = /^\s*\[\s*([a-z]+)\s*=\s*/.exec(rest);
+    if (!m) {
+      throw malformed();
+    }
+    const attr = m[1];
+    let i = m[0].length;
+    switch (attr) {
+      case "name":
+        i = parseRoleName(rest, i, result, malformed);
+        break;
+      case "checked":
+      case "pressed":
+      case "expanded": {
+        const v = /^(true|false|mixed)\s*\]/.exec(rest.slice(i));
+        if (!v || (attr === "expanded" && v[1] === "mixed")) {
+          throw malformed();
+        }
+        result.states[attr] = v[1] === "mixed" ? "mixed" : v[1] === "true";
+        i += v[0].length;
+        break;
+      }
+      default:
+        throw new Error(
+          `role selector "${selector}" doesn't support the ${attr} attribute`
+        );
+    }
+    rest = rest.slice(i);
+  }
+  return result;
+}
+
+// parseRoleName parses the name attribute value of a role selector that
+// starts at index i of rest into result.name, and returns the index after
+// the closing bracket of the attribute.
+function parseRoleName(rest, i, result, malformed) {
+  if (rest[i] === '"') {
+    let j = i + 1;
+    while (j < rest.length && rest[j] !== '"') {
+      j += rest[j] === "\\" ? 2 : 1;
+    }
+    const value = JSON.parse(rest.slice(i, j + 1));
+    const flag = /^\s*([is])?\s*\]/.exec(rest.slice(j + 1));
+    if (!flag) {
+      throw malformed();
+    }
+    const text = normalizeWhiteSpace(value);
+    result.name =
+      flag[1] === "s"
+        ? (s) => s === text
+        : (s) => s.toLowerCase().includes(text.toLowerCase());
+    return j + 1 + flag[0].length;
+  }
+  if (rest[i] === "/") {
+    let j = i + 1;
+    let inClass = false;
+    for (; j < rest.length; j++) {
+      const c = rest[j];
+      if (c === "\\") {
+        j++;
+      } else if (c === "[") {
+        inClass = true;
+      } else if (c === "]") {
+        inClass = false;
+      } else if (c === "/" && !inClass) {
+        break;
+      }
+    }
+    const flags = /^([a-z]*)\s*\]/.exec(rest.slice(j + 1));
+    if (!flags) {
+      throw malformed();
+    }
+    const re = new RegExp(rest.slice(i + 1, j), flags[1]);
+    result.name = (s) => {
+      re.lastIndex = 0;
+      return re.test(s);
+    };
+    return j + 1 + flags[0].length;
+  }
+  throw malformed();
+}
+
+// ariaState returns the checked, pressed or expanded state of an element,
+// which is true, false, "mixed", or undefined if the element doesn't
+// support the state.
+function ariaState(element, state) {
+  const attr = element.getAttribute(`aria-${state}`);
+  switch (state) {
+    case "checked":
+      if (
+        element.nodeName === "INPUT" &&
+        ["checkbox", "radio"].includes(element.type)
+      ) {
+        if (element.type === "checkbox" && element.indeterminate) {
+          return "mixed";
+        }
+        return element.checked;
+      }
+      break;
+    case "expanded":
+      if (element.nodeName === "DETAILS") {
+        return element.open;
+      }
+      if (element.nodeName === "SUMMARY" && element.parentElement) {
+        return element.parentElement.open;
+      }
+      break;
+  }
+  if (attr === "true" || attr === "false") {
+    return attr === "true";
+  }
+  if (attr === "mixed" && state !== "expanded") {
+    return "mixed";
+  }
+  // without the attribute, an element isn't checked or pressed, and isn't
+  // expandable.
+  return state === "expanded" ? undefined : false;
+}
+
+class RoleQueryEngine {
+  // queryAll returns the elements that aren't hidden from assistive
+  // technologies, with the role, the accessible name, and the states of the
+  // selector.
+  queryAll(root, selector) {
+    const { role, name, states } = parseRoleSelector(selector);
+    const result = [];
+    for (const element of root.querySelectorAll("*")) {
+      if (ariaRole(element) !== role || isHiddenForAria(element)) {
+        continue;
+      }
+      if (name && !name(accessibleName(element))) {
+        continue;
+      }
+      if (
+        Object.keys(states).some((s) => ariaState(element, s) !== states[s])
+      ) {
+        continue;
+      }
+      result.push(element);
+    }
+    return result;
   }
 }
 
@@ -147,8 +734,12 @@ class InjectedScript {
   constructor() {
     this._replaceRafWithTimeout = false;
     this._stableRafCount = 10;
+    // _waits maps the IDs of the pending waits to the functions that cancel them.
+    this._waits = new Map();
     this._queryEngines = {
       css: new CSSQueryEngine(),
+      id: new IDQueryEngine(),
+      role: new RoleQueryEngine(),
       text: new TextQueryEngine(),
       xpath: new XPathQueryEngine(),
     };
@@ -176,7 +767,7 @@ class InjectedScript {
         if (typeof selector.capture === "number") {
           return "error:nthnocapture";
         }
-        const nth = part.body;
+        const nth = parseInt(part.body, 10);
         const set = new Set();
         for (const root of roots) {
           set.add(root.element);
@@ -374,11 +965,16 @@ class InjectedScript {
 
   dispatchEvent(node, type, eventInit) {
     let event;
+    // Events bubble, are cancelable and cross shadow DOM boundaries by
+    // default, unless eventInit says otherwise.
+    const init =
+      typeof eventInit === "object" && eventInit !== null ? eventInit : {};
+    const flag = (v) => (v === undefined ? true : !!v);
     eventInit = {
-      bubbles: true,
-      cancelable: true,
-      composed: true,
-      ...eventInit,
+      ...init,
+      bubbles: flag(init.bubbles),
+      cancelable: flag(init.cancelable),
+      composed: flag(init.composed),
     };
     switch (eventType.get(type)) {
       case "mouse":
@@ -396,7 +992,18 @@ class InjectedScript {
       case "focus":
         event = new FocusEvent(type, eventInit);
         break;
+      case "wheel":
+        event = new WheelEvent(type, eventInit);
+        break;
+      case "input":
+        event = new InputEvent(type, eventInit);
+        break;
       case "drag":
+        // the data transfer can be given as the data of its formats,
+        // e.g. { "text/plain": "text" }, since it can't be passed as is.
+        if (!(eventInit.dataTransfer instanceof DataTransfer)) {
+          eventInit.dataTransfer = toDataTransfer(eventInit.dataTransfer);
+        }
         event = new DragEvent(type, eventInit);
         break;
       default:
@@ -505,6 +1112,23 @@ class InjectedScript {
     return node.ownerDocument ? node.ownerDocument.documentElement : null;
   }
 
+  // innerText returns the inner text of the elements matching the selector
+  // within the node, or of the node itself without a selector, joined with
+  // new lines. A positive maxDepth limits the levels of nested elements
+  // whose text is included. See shallowInnerText.
+  innerText(node, selector, maxDepth) {
+    if (node.nodeType !== 1 /*Node.ELEMENT_NODE*/) {
+      throw new Error("node is not an element");
+    }
+    let elements = [node];
+    if (selector) {
+      elements = this.querySelectorAll(selector, node);
+    }
+    return elements
+      .map((e) => (maxDepth > 0 ? shallowInnerText(e, maxDepth) : e.innerText))
+      .join("\n");
+  }
+
   isVisible(element) {
     return isVisible(element);
   }
@@ -586,7 +1210,7 @@ class InjectedScript {
       new Map()
     );
     if (strict && result.length > 1) {
-      throw "error:strictmodeviolation";
+      throw strictModeViolation(selector, result.length);
     }
     if (result.length == 0) {
       return null;
@@ -611,6 +1235,30 @@ class InjectedScript {
     return [...set];
   }
 
+  setInputFiles(node, payloads) {
+    if (node.nodeType !== 1 /*Node.ELEMENT_NODE*/) {
+      return "error:notelement";
+    }
+    if (node.nodeName.toLowerCase() !== "input") {
+      return "error:notinput";
+    }
+    if ((node.getAttribute("type") || "").toLowerCase() !== "file") {
+      return "error:notfileinput";
+    }
+    if (payloads.length > 1 && !node.multiple) {
+      return "error:notmultiplefileinput";
+    }
+    const dt = new DataTransfer();
+    for (const p of payloads) {
+      const bytes = Uint8Array.from(atob(p.buffer), (c) => c.charCodeAt(0));
+      dt.items.add(new File([bytes], p.name, { type: p.mimeType }));
+    }
+    node.files = dt.files;
+    node.dispatchEvent(new Event("input", { bubbles: true }));
+    node.dispatchEvent(new Event("change", { bubbles: true }));
+    return "done";
+  }
+
   selectOptions(node, optionsToSelect) {
     const element = this._retarget(node, "follow-label");
     if (!element) {
@@ -703,20 +1351,47 @@ class InjectedScript {
   }
 
   async waitForPredicateFunction(predicateFn, polling, timeout, ...args) {
-    let timedOut = false;
+    return this._waitForPredicate(
+      () => predicateFn(...args) || continuePolling,
+      polling,
+      timeout
+    );
+  }
+
+  cancelWait(waitID) {
+    const cancel = this._waits.get(waitID);
+    if (cancel) {
+      cancel();
+    }
+  }
+
+  // _waitForPredicate polls the predicate until it returns something other
+  // than continuePolling, the timeout expires, or the wait with the waitID
+  // is cancelled.
+  async _waitForPredicate(predicate, polling, timeout, waitID) {
+    let stopReason = null;
     let timeoutPoll = null;
-    const predicate = () => {
-      return predicateFn(...args) || continuePolling;
+    const stop = (reason) => {
+      stopReason = reason;
+      if (timeoutPoll) timeoutPoll();
     };
-    if (timeout !== undefined || timeout !== null) {
-      setTimeout(() => {
-        timedOut = true;
-        if (timeoutPoll) timeoutPoll();
-      }, timeout);
+    let timer;
+    // a null timeout means there is no timeout. The callers pass it for a
+    // zero timeout, while any number, including zero, arms the timer.
+    if (timeout !== null) {
+      timer = setTimeout(() => stop(`timed out after ${timeout}ms`), timeout);
+    }
+    if (waitID !== undefined) {
+      this._waits.set(waitID, () => stop("wait cancelled"));
+    }
+    try {
+      if (polling === "raf") return await pollRaf();
+      if (polling === "mutation") return await pollMutation();
+      if (typeof polling === "number") return await pollInterval(polling);
+    } finally {
+      clearTimeout(timer);
+      this._waits.delete(waitID);
     }
-    if (polling === "raf") return await pollRaf();
-    if (polling === "mutation") return await pollMutation();
-    if (typeof polling === "number") return await pollInterval(polling);
 
     async function pollMutation() {
       const success = predicate();
@@ -728,11 +1403,19 @@ class InjectedScript {
         reject = rej;
       });
       const observer = new MutationObserver(async () => {
-        if (timedOut) {
+        if (stopReason) {
+          observer.disconnect();
+          reject(stopReason);
+          return;
+        }
+        let success;
+        try {
+          success = predicate();
+        } catch (e) {
           observer.disconnect();
-          reject(`timed out after ${timeout}ms`);
+          reject(e);
+          return;
         }
-        const success = predicate();
         if (success !== continuePolling) {
           observer.disconnect();
           resolve(success);
@@ -740,7 +1423,7 @@ class InjectedScript {
       });
       timeoutPoll = () => {
         observer.disconnect();
-        reject(`timed out after ${timeout}ms`);
+        reject(stopReason);
       };
       observer.observe(document, {
         childList: true,
@@ -760,11 +1443,17 @@ class InjectedScript {
       return result;
 
       async function onRaf() {
-        if (timedOut) {
-          reject(`timed out after ${timeout}ms`);
+        if (stopReason) {
+          reject(stopReason);
+          return;
+        }
+        let success;
+        try {
+          success = predicate();
+        } catch (e) {
+          reject(e);
           return;
         }
-        const success = predicate();
         if (success !== continuePolling) resolve(success);
         else requestAnimationFrame(onRaf);
       }
@@ -780,11 +1469,17 @@ class InjectedScript {
       return result;
 
       async function onTimeout() {
-        if (timedOut) {
-          reject(`timed out after ${timeout}ms`);
+        if (stopReason) {
+          reject(stopReason);
+          return;
+        }
+        let success;
+        try {
+          success = predicate();
+        } catch (e) {
+          reject(e);
           return;
         }
-        const success = predicate();
         if (success !== continuePolling) resolve(success);
         else setTimeout(onTimeout, pollInterval);
       }
@@ -864,39 +1559,134 @@ class InjectedScript {
     }
   }
 
-  waitForSelector(selector, root, strict, state, polling, timeout, ...args) {
+  waitForSelector(selector, root, strict, state, polling, timeout, waitID) {
     let lastElement;
     let previewNode = this.previewNode;
+    const isStable = this._stableChecker();
     const predicate = () => {
       const elements = this.querySelectorAll(selector, root || document);
       const element = elements[0];
       const visible = element ? isVisible(element) : false;
 
+      if (strict && elements.length > 1) {
+        throw strictModeViolation(selector, elements.length);
+      }
       if (lastElement !== element) {
         lastElement = element;
         if (!element) {
           console.log(`  selector did not resolve to any element`);
-        } else {
-          if (elements.length > 1) {
-            if (strict) {
-              throw "error:strictmodeviolation";
-            }
-          }
         }
       }
 
-      switch (state) {
-        case "attached":
-          return element ? element : continuePolling;
-        case "detached":
-          return !element ? undefined : continuePolling;
-        case "visible":
-          return visible ? element : continuePolling;
-        case "hidden":
-          return !visible ? undefined : continuePolling;
+      return this._elementInState(element, visible, state, isStable);
+    };
+
+    return this._waitForPredicate(predicate, polling, timeout, waitID);
+  }
+
+  waitForCount(selector, root, count, polling, timeout, waitID) {
+    const predicate = () => {
+      const elements = this.querySelectorAll(selector, root || document);
+      if (typeof elements === "string") {
+        return elements;
+      }
+      return elements.length === count || continuePolling;
+    };
+    return this._waitForPredicate(predicate, polling, timeout, waitID);
+  }
+
+  waitForAnySelector(selectors, root, strict, state, polling, timeout, waitID) {
+    const isStable = selectors.map(() => this._stableChecker());
+    const predicate = () => {
+      for (let index = 0; index < selectors.length; index++) {
+        const selector = selectors[index];
+        const elements = this.querySelectorAll(selector, root || document);
+        const element = elements[0];
+        const visible = element ? isVisible(element) : false;
+
+        if (strict && elements.length > 1) {
+          throw strictModeViolation(selector, elements.length);
+        }
+        const result = this._elementInState(
+          element,
+          visible,
+          state,
+          isStable[index]
+        );
+        if (result !== continuePolling) {
+          return { element: result, index };
+        }
       }
+      return continuePolling;
     };
 
-    return this.waitForPredicateFunction(predicate, polling, timeout, ...args);
+    return this._waitForPredicate(predicate, polling, timeout, waitID);
+  }
+
+  // _elementInState returns the element if it is in the given state, or in
+  // all of the given states if state is an array of states.
+  _elementInState(element, visible, state, isStable) {
+    if (Array.isArray(state)) {
+      if (!element) {
+        return continuePolling;
+      }
+      for (const s of state) {
+        if (
+          this._elementInState(element, visible, s, isStable) ===
+          continuePolling
+        ) {
+          return continuePolling;
+        }
+      }
+      return element;
+    }
+    switch (state) {
+      case "attached":
+        return element ? element : continuePolling;
+      case "detached":
+        return !element ? undefined : continuePolling;
+      case "visible":
+        return visible ? element : continuePolling;
+      case "hidden":
+        return !visible ? undefined : continuePolling;
+      case "editable":
+        return visible && this.checkElementState(element, "editable") === true
+          ? element
+          : continuePolling;
+      case "enabled":
+        return element && this.checkElementState(element, "enabled") === true
+          ? element
+          : continuePolling;
+      case "stable":
+        return visible && isStable(element) ? element : continuePolling;
+    }
+  }
+
+  // _stableChecker returns a function that reports whether an element kept
+  // the same bounding box for the last _stableRafCount times it was called.
+  _stableChecker() {
+    let lastElement;
+    let lastRect;
+    let samePositionCounter = 0;
+    return (element) => {
+      const clientRect = element.getBoundingClientRect();
+      const rect = {
+        x: clientRect.left,
+        y: clientRect.top,
+        width: clientRect.width,
+        height: clientRect.height,
+      };
+      const samePosition =
+        lastElement === element &&
+        lastRect &&
+        rect.x === lastRect.x &&
+        rect.y === lastRect.y &&
+        rect.width === lastRect.width &&
+        rect.height === lastRect.height;
+      samePositionCounter = samePosition ? samePositionCounter + 1 : 0;
+      lastElement = element;
+      lastRect = rect;
+      return samePositionCounter >= this._stableRafCount;
+    };
   }
 }
@@ -22,6 +22,7 @@ package common
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	"github.com/grafana/xk6-browser/api"
@@ -37,6 +38,7 @@ type jsHandle interface {
 	api.JSHandle
 	dispose() error
 	getProperties() (map[string]jsHandle, error)
+	getProperty(propertyName string) (jsHandle, error)
 }
 
 var _ jsHandle = &BaseJSHandle{}
@@ -166,9 +168,39 @@ func (h *BaseJSHandle) getProperties() (map[string]jsHandle, error) {
 	return props, nil
 }
 
-// GetProperty retreves a single property of the JS handle.
+// GetProperty retrieves a single property of the JS handle, without
+// fetching the other properties of the object.
 func (h *BaseJSHandle) GetProperty(propertyName string) api.JSHandle {
-	return nil
+	handle, err := h.getProperty(propertyName)
+	if err != nil {
+		k6ext.Panic(h.ctx, "getProperty %q: %w", propertyName, err)
+	}
+
+	return handle
+}
+
+// getProperty is like GetProperty, but does not panic.
+func (h *BaseJSHandle) getProperty(propertyName string) (jsHandle, error) {
+	if h.remoteObject.ObjectID == "" {
+		return nil, fmt.Errorf("JS handle of type %q is not an object", h.remoteObject.Type)
+	}
+	name, err := json.Marshal(propertyName)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling property name: %w", err)
+	}
+	act := runtime.CallFunctionOn(`function(name) { return this[name]; }`).
+		WithObjectID(h.remoteObject.ObjectID).
+		WithArguments([]*runtime.CallArgument{{Value: name}})
+	result, exceptionDetails, err := act.Do(cdp.WithExecutor(h.ctx, h.session))
+	if err != nil {
+		return nil, fmt.Errorf("getting property of element with ID %s: %w",
+			h.remoteObject.ObjectID, err)
+	}
+	if exceptionDetails != nil {
+		return nil, fmt.Errorf("%s", parseExceptionDetails(exceptionDetails))
+	}
+
+	return NewJSHandle(h.ctx, h.session, h.execCtx, h.execCtx.Frame(), result, h.logger), nil
 }
 
 // JSONValue returns a JSON version of this JS handle.
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/grafana/xk6-browser/api"
 	"github.com/grafana/xk6-browser/k6ext"
 	"github.com/grafana/xk6-browser/log"
 
@@ -30,6 +31,65 @@ func NewLocator(ctx context.Context, selector string, f *Frame, l *log.Logger) *
 	}
 }
 
+// All returns a locator for each of the elements that match the locator's
+// selector. Each returned locator targets its element with an nth= selector,
+// so that it is re-resolved on every action instead of holding a handle.
+func (l *Locator) All() []api.Locator {
+	l.log.Debugf("Locator:All", "fid:%s furl:%q sel:%q", l.frame.ID(), l.frame.URL(), l.selector)
+
+	locators, err := l.all()
+	if err != nil {
+		k6ext.Panic(l.ctx, "all %q: %w", l.selector, err)
+	}
+
+	return locators
+}
+
+func (l *Locator) all() ([]api.Locator, error) {
+	document, err := l.frame.document()
+	if err != nil {
+		return nil, fmt.Errorf("getting document: %w", err)
+	}
+	handles, err := document.queryAll(l.selector, document.evalWithScript)
+	if err != nil {
+		return nil, err
+	}
+	locators := make([]api.Locator, 0, len(handles))
+	for i, h := range handles {
+		// we only need the number of matching elements.
+		h.Dispose()
+		locators = append(locators, l.nth(i))
+	}
+
+	return locators, nil
+}
+
+// First returns a locator to the first matching element.
+func (l *Locator) First() api.Locator {
+	return l.nth(0)
+}
+
+// Last returns a locator to the last matching element.
+func (l *Locator) Last() api.Locator {
+	return l.nth(-1)
+}
+
+// Nth returns a locator to the n-th matching element. It's zero based.
+func (l *Locator) Nth(index int) api.Locator {
+	if index < 0 {
+		k6ext.Panic(l.ctx, "nth %q: index must be zero or positive, got %d", l.selector, index)
+	}
+	return l.nth(index)
+}
+
+// nth narrows the locator's selector to the n-th matching element,
+// or the last one if index is -1. The selector is re-resolved on every
+// action, just like the locator's.
+func (l *Locator) nth(index int) *Locator {
+	sel := fmt.Sprintf("%s >> nth=%d", l.selector, index)
+	return NewLocator(l.ctx, sel, l.frame, l.log)
+}
+
 // Click on an element using locator's selector with strict mode on.
 func (l *Locator) Click(opts goja.Value) {
 	l.log.Debugf("Locator:Click", "fid:%s furl:%q sel:%q opts:%+v", l.frame.ID(), l.frame.URL(), l.selector, opts)
@@ -616,6 +676,6 @@ func (l *Locator) WaitFor(opts goja.Value) {
 
 func (l *Locator) waitFor(opts *FrameWaitForSelectorOptions) error {
 	opts.Strict = true
-	_, err := l.frame.waitForSelector(l.selector, opts)
+	_, err := l.frame.waitForSelector(l.ctx, l.selector, opts)
 	return err
 }
@@ -59,7 +59,7 @@ type NetworkManager struct {
 	session      session
 	parent       *NetworkManager
 	frameManager *FrameManager
-	credentials  *Credentials
+	credentials  HTTPCredentials
 	resolver     k6netext.Resolver
 	vu           k6modules.VU
 
@@ -70,6 +70,16 @@ type NetworkManager struct {
 
 	attemptedAuth map[fetch.RequestID]bool
 
+	// transferSizes are the bytes received over the network for the
+	// responses of the last finished requests, by frame and URL.
+	transferSizes   map[transferSizeKey]int64
+	transferSizesMu sync.RWMutex
+
+	// webSockets are the open WebSockets observed by the page's
+	// websocket event handlers.
+	webSockets   map[network.RequestID]*WebSocket
+	webSocketsMu sync.Mutex
+
 	extraHTTPHeaders               map[string]string
 	offline                        bool
 	userCacheDisabled              bool
@@ -103,6 +113,8 @@ func NewNetworkManager(
 		reqIDToRequest:   make(map[network.RequestID]*Request),
 		attemptedAuth:    make(map[fetch.RequestID]bool),
 		extraHTTPHeaders: make(map[string]string),
+		transferSizes:    make(map[transferSizeKey]int64),
+		webSockets:       make(map[network.RequestID]*WebSocket),
 	}
 	m.initEvents()
 	if err := m.initDomains(); err != nil {
@@ -306,9 +318,9 @@ func (m *NetworkManager) emitResponseMetrics(resp *Response, req *Request) {
 func (m *NetworkManager) handleRequestRedirect(req *Request, redirectResponse *network.Response, timestamp *cdp.MonotonicTime) {
 	resp := NewHTTPResponse(m.ctx, req, redirectResponse, timestamp)
 	req.response = resp
-	req.redirectChain = append(req.redirectChain, req)
 
 	m.emitResponseMetrics(resp, req)
+	m.recordHar(req, timestamp.Time())
 	m.deleteRequestByID(req.requestID)
 
 	/*
@@ -345,6 +357,10 @@ func (m *NetworkManager) initEvents() {
 		cdproto.EventNetworkRequestWillBeSent,
 		cdproto.EventNetworkRequestServedFromCache,
 		cdproto.EventNetworkResponseReceived,
+		cdproto.EventNetworkWebSocketCreated,
+		cdproto.EventNetworkWebSocketFrameSent,
+		cdproto.EventNetworkWebSocketFrameReceived,
+		cdproto.EventNetworkWebSocketClosed,
 		cdproto.EventFetchRequestPaused,
 		cdproto.EventFetchAuthRequired,
 	}, chHandler)
@@ -376,6 +392,14 @@ func (m *NetworkManager) handleEvents(in <-chan Event) bool {
 			m.onRequestServedFromCache(ev)
 		case *network.EventResponseReceived:
 			m.onResponseReceived(ev)
+		case *network.EventWebSocketCreated:
+			m.onWebSocketCreated(ev)
+		case *network.EventWebSocketFrameSent:
+			m.onWebSocketFrameSent(ev)
+		case *network.EventWebSocketFrameReceived:
+			m.onWebSocketFrameReceived(ev)
+		case *network.EventWebSocketClosed:
+			m.onWebSocketClosed(ev)
 		case *fetch.EventRequestPaused:
 			m.onRequestPaused(ev)
 		case *fetch.EventAuthRequired:
@@ -393,6 +417,8 @@ func (m *NetworkManager) onLoadingFailed(event *network.EventLoadingFailed) {
 	}
 	req.setErrorText(event.ErrorText)
 	req.responseEndTiming = float64(event.Timestamp.Time().Unix()-req.timestamp.Unix()) * 1000
+	req.responseEndTime = event.Timestamp.Time()
+	m.recordHar(req, event.Timestamp.Time())
 	m.deleteRequestByID(event.RequestID)
 	m.frameManager.requestFailed(req, event.Canceled)
 }
@@ -419,25 +445,52 @@ func (m *NetworkManager) onLoadingFinished(event *network.EventLoadingFinished)
 		}
 	}
 	req.responseEndTiming = float64(event.Timestamp.Time().Unix()-req.timestamp.Unix()) * 1000
+	req.responseEndTime = event.Timestamp.Time()
+	req.transferSize = int64(event.EncodedDataLength)
 	// Skip data and blob URLs when emitting metrics, since they're internal to the browser.
 	if !isInternalURL(req.url) {
 		m.emitResponseMetrics(req.response, req)
+		key := transferSizeKey{url: req.url.String()}
+		if req.frame != nil {
+			key.frameID = req.frame.ID()
+		}
+		m.transferSizesMu.Lock()
+		m.transferSizes[key] = req.transferSize
+		m.transferSizesMu.Unlock()
 	}
+	m.recordHar(req, event.Timestamp.Time())
 	m.deleteRequestByID(event.RequestID)
 	m.frameManager.requestFinished(req)
 }
 
+// recordHar adds the completed request to the HAR of the browser context,
+// if the context records one.
+func (m *NetworkManager) recordHar(req *Request, end time.Time) {
+	if m.frameManager == nil || m.frameManager.page == nil {
+		return
+	}
+	if bctx := m.frameManager.page.browserCtx; bctx != nil && bctx.har != nil {
+		bctx.har.record(req, end)
+	}
+}
+
 func isInternalURL(u *url.URL) bool {
 	return u.Scheme == "data" || u.Scheme == "blob"
 }
 
 func (m *NetworkManager) onRequest(event *network.EventRequestWillBeSent, interceptionID string) {
-	var redirectChain []*Request = nil
+	var (
+		redirectChain  []*Request
+		redirectedFrom *Request
+	)
 	if event.RedirectResponse != nil {
-		req := m.requestFromID(event.RequestID)
-		if req != nil {
-			m.handleRequestRedirect(req, event.RedirectResponse, event.Timestamp)
-			redirectChain = req.redirectChain
+		redirectedFrom = m.requestFromID(event.RequestID)
+		if redirectedFrom != nil {
+			m.handleRequestRedirect(redirectedFrom, event.RedirectResponse, event.Timestamp)
+			// copy the chain so that the redirected request keeps its own.
+			redirectChain = make([]*Request, 0, len(redirectedFrom.redirectChain)+1)
+			redirectChain = append(redirectChain, redirectedFrom.redirectChain...)
+			redirectChain = append(redirectChain, redirectedFrom)
 		}
 	} else {
 		redirectChain = make([]*Request, 0)
@@ -461,11 +514,19 @@ func (m *NetworkManager) onRequest(event *network.EventRequestWillBeSent, interc
 		m.logger.Errorf("NetworkManager", "cannot create Request: %s", err)
 		return
 	}
+	if redirectedFrom != nil {
+		redirectedFrom.redirectedTo = req
+	}
 	// Skip data and blob URLs, since they're internal to the browser.
 	if isInternalURL(req.url) {
 		m.logger.Debugf("NetworkManager", "skipped request handling of %s URL", req.url.Scheme)
 		return
 	}
+	// the transfer sizes of the resources of the previous document are
+	// stale once the frame requests a new document.
+	if frame != nil && req.getDocumentID() != "" {
+		m.deleteTransferSizes(frame.ID())
+	}
 	m.reqsMu.Lock()
 	m.reqIDToRequest[event.RequestID] = req
 	m.reqsMu.Unlock()
@@ -493,6 +554,9 @@ func (m *NetworkManager) onRequestPaused(event *fetch.EventRequestPaused) {
 				return
 			}
 		}
+		if m.routeRequest(event) {
+			return
+		}
 		action := fetch.ContinueRequest(event.RequestID)
 		if err := action.Do(cdp.WithExecutor(m.ctx, m.session)); err != nil {
 			m.logger.Errorf("NetworkManager:onRequestPaused",
@@ -531,6 +595,45 @@ func (m *NetworkManager) onRequestPaused(event *fetch.EventRequestPaused) {
 	failErr = checkBlockedIPs(ip, state.Options.BlacklistIPs)
 }
 
+// routeRequest hands the paused request over to the page route handler
+// matching its URL. It returns false if there is no matching handler.
+func (m *NetworkManager) routeRequest(event *fetch.EventRequestPaused) bool {
+	if m.frameManager == nil {
+		return false
+	}
+	page := m.frameManager.page
+	if page == nil || page.routeHandler(event.Request.URL) == nil {
+		return false
+	}
+
+	req := m.requestFromID(network.RequestID(event.NetworkID))
+	if req == nil {
+		// The request is paused before Network.requestWillBeSent is received.
+		var (
+			now = time.Now()
+			ts  = cdp.MonotonicTime(now)
+			wt  = cdp.TimeSinceEpoch(now)
+			err error
+		)
+		ev := &network.EventRequestWillBeSent{
+			RequestID: network.RequestID(event.NetworkID),
+			Request:   event.Request,
+			FrameID:   event.FrameID,
+			Type:      event.ResourceType,
+			Timestamp: &ts,
+			WallTime:  &wt,
+		}
+		frame := m.frameManager.getFrameByID(event.FrameID)
+		if req, err = NewRequest(m.ctx, ev, frame, nil, string(event.RequestID), true); err != nil {
+			m.logger.Errorf("NetworkManager:routeRequest", "creating request: %s", err)
+			return false
+		}
+	}
+	page.enqueueRoute(NewRoute(m.ctx, m.session, event.RequestID, req, m.logger))
+
+	return true
+}
+
 func checkBlockedHosts(host string, blockedHosts *k6types.HostnameTrie) error {
 	if blockedHosts == nil {
 		return nil
@@ -557,14 +660,19 @@ func (m *NetworkManager) onAuthRequired(event *fetch.EventAuthRequired) {
 		res = fetch.AuthChallengeResponseResponseDefault
 		rid = event.RequestID
 
+		origin             string
 		username, password string
 	)
+	if event.AuthChallenge != nil {
+		origin = event.AuthChallenge.Origin
+	}
+	credentials := m.credentials.forOrigin(origin)
 
 	switch {
 	case m.attemptedAuth[rid]:
 		delete(m.attemptedAuth, rid)
 		res = fetch.AuthChallengeResponseResponseCancelAuth
-	case m.credentials != nil:
+	case credentials != nil:
 		// TODO: remove requests from attemptedAuth when:
 		//       - request is redirected
 		//       - loading finished
@@ -573,7 +681,7 @@ func (m *NetworkManager) onAuthRequired(event *fetch.EventAuthRequired) {
 		// The Fetch.AuthChallengeResponse docs mention username and password should only be set
 		// if the response is ProvideCredentials.
 		// See: https://chromedevtools.github.io/devtools-protocol/tot/Fetch/#type-AuthChallengeResponse
-		username, password = m.credentials.Username, m.credentials.Password
+		username, password = credentials.Username, credentials.Password
 	}
 	err := fetch.ContinueWithAuth(
 		rid,
@@ -607,6 +715,80 @@ func (m *NetworkManager) onResponseReceived(event *network.EventResponseReceived
 	m.frameManager.requestReceivedResponse(resp)
 }
 
+// onWebSocketCreated emits the WebSocket to the page's websocket event
+// handlers. Without handlers, the WebSocket isn't observed at all.
+func (m *NetworkManager) onWebSocketCreated(event *network.EventWebSocketCreated) {
+	if m.frameManager == nil || m.frameManager.page == nil {
+		return
+	}
+	p := m.frameManager.page
+	if !p.hasEventHandler(EventPageWebSocket) {
+		return
+	}
+	ws := NewWebSocket(m.ctx, p, event.RequestID, event.URL, m.logger)
+	m.webSocketsMu.Lock()
+	m.webSockets[event.RequestID] = ws
+	m.webSocketsMu.Unlock()
+
+	p.emit(EventPageWebSocket, ws)
+}
+
+func (m *NetworkManager) onWebSocketFrameSent(event *network.EventWebSocketFrameSent) {
+	if ws := m.webSocketFromID(event.RequestID); ws != nil {
+		ws.frameSent(event.Response)
+	}
+}
+
+func (m *NetworkManager) onWebSocketFrameReceived(event *network.EventWebSocketFrameReceived) {
+	if ws := m.webSocketFromID(event.RequestID); ws != nil {
+		ws.frameReceived(event.Response)
+	}
+}
+
+func (m *NetworkManager) onWebSocketClosed(event *network.EventWebSocketClosed) {
+	m.webSocketsMu.Lock()
+	ws := m.webSockets[event.RequestID]
+	delete(m.webSockets, event.RequestID)
+	m.webSocketsMu.Unlock()
+
+	if ws != nil {
+		ws.close()
+	}
+}
+
+func (m *NetworkManager) webSocketFromID(reqID network.RequestID) *WebSocket {
+	m.webSocketsMu.Lock()
+	defer m.webSocketsMu.Unlock()
+	return m.webSockets[reqID]
+}
+
+// transferSizeKey identifies the last finished request to a URL in a frame.
+type transferSizeKey struct {
+	frameID string
+	url     string
+}
+
+// transferSize returns the bytes received over the network for the response
+// of the last finished request to the URL in the frame. It returns false if
+// no such request has finished.
+func (m *NetworkManager) transferSize(frameID, url string) (int64, bool) {
+	m.transferSizesMu.RLock()
+	defer m.transferSizesMu.RUnlock()
+	size, ok := m.transferSizes[transferSizeKey{frameID: frameID, url: url}]
+	return size, ok
+}
+
+// deleteTransferSizes removes the transfer sizes recorded for the frame.
+func (m *NetworkManager) deleteTransferSizes(frameID string) {
+	m.transferSizesMu.Lock()
+	defer m.transferSizesMu.Unlock()
+	for k := range m.transferSizes {
+		if k.frameID == frameID {
+			delete(m.transferSizes, k)
+		}
+	}
+}
+
 func (m *NetworkManager) requestFromID(reqID network.RequestID) *Request {
 	m.reqsMu.RLock()
 	defer m.reqsMu.RUnlock()
@@ -664,7 +846,7 @@ func (m *NetworkManager) updateProtocolRequestInterception() error {
 }
 
 // Authenticate sets HTTP authentication credentials to use.
-func (m *NetworkManager) Authenticate(credentials *Credentials) {
+func (m *NetworkManager) Authenticate(credentials HTTPCredentials) {
 	m.credentials = credentials
 	if credentials != nil {
 		m.userReqInterceptionEnabled = true
@@ -689,6 +871,8 @@ func (m *NetworkManager) SetExtraHTTPHeaders(headers network.Headers) {
 }
 
 // SetOfflineMode toggles offline mode on/off.
+// The browser updates navigator.onLine and fires the online and offline
+// events on the windows of the session when the mode changes.
 func (m *NetworkManager) SetOfflineMode(offline bool) {
 	if m.offline == offline {
 		return
@@ -24,6 +24,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/url"
 	"strings"
 	"sync"
 	"time"
@@ -34,6 +35,7 @@ import (
 
 	k6modules "go.k6.io/k6/js/modules"
 
+	cdpbrowser "github.com/chromedp/cdproto/browser"
 	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/cdproto/dom"
 	"github.com/chromedp/cdproto/emulation"
@@ -50,9 +52,10 @@ var _ api.Page = &Page{}
 type Page struct {
 	BaseEventEmitter
 
-	Keyboard    *Keyboard    `js:"keyboard"`    // Public JS API
-	Mouse       *Mouse       `js:"mouse"`       // Public JS API
-	Touchscreen *Touchscreen `js:"touchscreen"` // Public JS API
+	Accessibility *Accessibility `js:"accessibility"` // Public JS API
+	Keyboard      *Keyboard      `js:"keyboard"`      // Public JS API
+	Mouse         *Mouse         `js:"mouse"`         // Public JS API
+	Touchscreen   *Touchscreen   `js:"touchscreen"`   // Public JS API
 
 	ctx context.Context
 
@@ -78,17 +81,38 @@ type Page struct {
 	emulatedSize     *EmulatedSize
 	mediaType        MediaType
 	colorScheme      ColorScheme
+	contrast         Contrast
+	forcedColors     ForcedColors
 	reducedMotion    ReducedMotion
+	orientation      ScreenOrientation
+	geolocation      *Geolocation
 	extraHTTPHeaders map[string]string
 
 	backgroundPage bool
 
 	mainFrameSession *FrameSession
-	// TODO: FrameSession changes by attachFrameSession (mutex?)
-	frameSessions map[cdp.FrameID]*FrameSession
-	workers       map[target.SessionID]*Worker
-	routes        []api.Route
-	vu            k6modules.VU
+	frameSessionsMu  sync.RWMutex
+	frameSessions    map[cdp.FrameID]*FrameSession
+	workersMu        sync.RWMutex
+	workers          map[target.SessionID]*Worker
+	vu               k6modules.VU
+
+	// routes are the handlers of the intercepted requests, see Route.
+	routesMu sync.RWMutex
+	routes   []*routeHandler
+	// eventHandlers are the JS handlers registered with On.
+	eventHandlersMu sync.RWMutex
+	eventHandlers   map[string][]pageEventHandler
+	// jsQueue holds the calls to the route and event handlers.
+	// The handlers can only be called from the VU goroutine, so the
+	// queue is drained either by the event loop when the script is idle
+	// (jsIdleCh) or by the blocking calls waiting on a navigation
+	// (jsBusyCh). See waitRouted.
+	jsQueueMu  sync.Mutex
+	jsQueue    []func() error
+	jsIdleCh   chan struct{}
+	jsBusyCh   chan struct{}
+	jsLoopStop chan struct{}
 
 	logger *log.Logger
 }
@@ -113,14 +137,18 @@ func NewPage(
 		backgroundPage:   bp,
 		mediaType:        MediaTypeScreen,
 		colorScheme:      bctx.opts.ColorScheme,
+		contrast:         bctx.opts.Contrast,
+		forcedColors:     bctx.opts.ForcedColors,
 		reducedMotion:    bctx.opts.ReducedMotion,
-		extraHTTPHeaders: bctx.opts.ExtraHTTPHeaders,
+		orientation:      bctx.opts.Orientation,
 		timeoutSettings:  NewTimeoutSettings(bctx.timeoutSettings),
 		Keyboard:         NewKeyboard(ctx, s),
 		jsEnabled:        true,
 		frameSessions:    make(map[cdp.FrameID]*FrameSession),
 		workers:          make(map[target.SessionID]*Worker),
-		routes:           make([]api.Route, 0),
+		eventHandlers:    make(map[string][]pageEventHandler),
+		jsIdleCh:         make(chan struct{}, 1),
+		jsBusyCh:         make(chan struct{}, 1),
 		vu:               k6ext.GetVU(ctx),
 		logger:           logger,
 	}
@@ -146,6 +174,7 @@ func NewPage(
 	p.frameSessions[cdp.FrameID(tid)] = p.mainFrameSession
 	p.Mouse = NewMouse(ctx, s, p.frameManager.MainFrame(), bctx.timeoutSettings, p.Keyboard)
 	p.Touchscreen = NewTouchscreen(ctx, s, p.Keyboard)
+	p.Accessibility = NewAccessibility(ctx, s)
 
 	action := target.SetAutoAttach(true, true).WithFlatten(true)
 	if err := action.Do(cdp.WithExecutor(p.ctx, p.session)); err != nil {
@@ -158,12 +187,25 @@ func NewPage(
 func (p *Page) closeWorker(sessionID target.SessionID) {
 	p.logger.Debugf("Page:closeWorker", "sid:%v", sessionID)
 
-	if worker, ok := p.workers[sessionID]; ok {
+	p.workersMu.Lock()
+	worker, ok := p.workers[sessionID]
+	delete(p.workers, sessionID)
+	p.workersMu.Unlock()
+
+	if ok {
 		worker.didClose()
-		delete(p.workers, sessionID)
+		p.emit(EventPageWorkerClose, worker)
 	}
 }
 
+func (p *Page) addWorker(sessionID target.SessionID, w *Worker) {
+	p.workersMu.Lock()
+	p.workers[sessionID] = w
+	p.workersMu.Unlock()
+
+	p.emit(EventPageWorker, w)
+}
+
 func (p *Page) defaultTimeout() time.Duration {
 	return time.Duration(p.timeoutSettings.timeout()) * time.Second
 }
@@ -177,7 +219,16 @@ func (p *Page) didClose() {
 	}
 	p.closedMu.Unlock()
 
-	p.emit(EventPageClose, p)
+	if f := p.frameManager.MainFrame(); f != nil {
+		f.flushLargestContentfulPaint()
+	}
+	// unlike the other events, the close handlers are queued before the
+	// event is emitted internally, so that Close, which waits for it, finds
+	// them queued. They're also queued before the loop is stopped, so that
+	// the loop calls them on its way out.
+	p.queueEventHandlers(EventPageClose, p, nil)
+	p.BaseEventEmitter.emit(EventPageClose, p)
+	p.stopJSLoop()
 }
 
 func (p *Page) didCrash() {
@@ -273,19 +324,383 @@ func (p *Page) getOwnerFrame(apiCtx context.Context, h *ElementHandle) cdp.Frame
 
 func (p *Page) attachFrameSession(fid cdp.FrameID, fs *FrameSession) {
 	p.logger.Debugf("Page:attachFrameSession", "sid:%v fid=%v", p.session.ID(), fid)
-	fs.page.frameSessions[fid] = fs
+	p.frameSessionsMu.Lock()
+	defer p.frameSessionsMu.Unlock()
+
+	p.frameSessions[fid] = fs
+}
+
+// detachFrameSession removes the frame session of the frame with fid, but
+// only if it is still fs. It is used when an out-of-process frame's target
+// goes away, which may happen after the frame has already been taken over
+// by a new session.
+func (p *Page) detachFrameSession(fid cdp.FrameID, fs *FrameSession) {
+	p.logger.Debugf("Page:detachFrameSession", "sid:%v fid=%v", p.session.ID(), fid)
+
+	p.frameSessionsMu.Lock()
+	defer p.frameSessionsMu.Unlock()
+
+	if p.frameSessions[fid] == fs {
+		delete(p.frameSessions, fid)
+	}
 }
 
 func (p *Page) getFrameSession(frameID cdp.FrameID) *FrameSession {
 	p.logger.Debugf("Page:getFrameSession", "sid:%v fid:%v", p.sessionID(), frameID)
 
+	p.frameSessionsMu.RLock()
+	defer p.frameSessionsMu.RUnlock()
+
 	return p.frameSessions[frameID]
 }
 
+// allFrameSessions returns a copy of the page's frame sessions so that they
+// can be iterated over while frames are attached to or detached from the page.
+func (p *Page) allFrameSessions() []*FrameSession {
+	p.frameSessionsMu.RLock()
+	defer p.frameSessionsMu.RUnlock()
+
+	sessions := make([]*FrameSession, 0, len(p.frameSessions))
+	for _, fs := range p.frameSessions {
+		sessions = append(sessions, fs)
+	}
+	return sessions
+}
+
 func (p *Page) hasRoutes() bool {
+	p.routesMu.RLock()
+	defer p.routesMu.RUnlock()
+
 	return len(p.routes) > 0
 }
 
+// routeHandler returns the last registered handler matching the URL.
+func (p *Page) routeHandler(url string) *routeHandler {
+	p.routesMu.RLock()
+	defer p.routesMu.RUnlock()
+
+	for i := len(p.routes) - 1; i >= 0; i-- {
+		if p.routes[i].matches(url) {
+			return p.routes[i]
+		}
+	}
+	return nil
+}
+
+// enqueueRoute queues the intercepted request to be handled by
+// a route handler on the VU goroutine.
+func (p *Page) enqueueRoute(r *Route) {
+	p.enqueueJS(func() error { return p.handleRoute(r) })
+}
+
+// enqueueJS queues fn to be called on the VU goroutine.
+func (p *Page) enqueueJS(fn func() error) {
+	p.jsQueueMu.Lock()
+	p.jsQueue = append(p.jsQueue, fn)
+	p.jsQueueMu.Unlock()
+
+	for _, ch := range []chan struct{}{p.jsIdleCh, p.jsBusyCh} {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// pageEventHandler is a JS handler registered with On.
+type pageEventHandler struct {
+	fn      goja.Callable
+	handler goja.Value
+}
+
+// jsPageEvents are the page events that can be handled from JS with On.
+var jsPageEvents = []string{
+	EventPageRequest,
+	EventPageResponse,
+	EventPageRequestFinished,
+	EventPageRequestFailed,
+	EventPageLongTask,
+	EventPageConsole,
+	EventPageError,
+	EventPageSecurity,
+	EventPageDownload,
+	EventPageWebSocket,
+	EventPageWorker,
+	EventPageWorkerClose,
+	EventPageClose,
+}
+
+// waitablePageEvents are the page events that can be waited for with
+// WaitForEvent.
+var waitablePageEvents = append([]string{
+	EventPageCrash,
+	EventPageDOMContentLoaded,
+	EventPageFrameAttached,
+	EventPageFrameDetached,
+	EventPageFrameNavigated,
+	EventPageLoad,
+}, jsPageEvents...)
+
+func isJSPageEvent(event string) bool {
+	return stringSliceContains(jsPageEvents, event)
+}
+
+// emit emits the event to the internal event handlers, and queues the
+// calls to the JS handlers registered for it with On. The calls are
+// queued here so that the handlers see the events in order.
+func (p *Page) emit(event string, data interface{}) {
+	p.emitThen(event, data, nil)
+}
+
+// emitThen emits the event and calls done, if it's not nil, after the
+// page event handlers have run. Without handlers, done is called right away.
+func (p *Page) emitThen(event string, data interface{}, done func()) {
+	p.BaseEventEmitter.emit(event, data)
+	p.queueEventHandlers(event, data, done)
+}
+
+// queueEventHandlers queues the calls to the JS handlers of the event,
+// and calls done, if it's not nil, after they have run.
+func (p *Page) queueEventHandlers(event string, data interface{}, done func()) {
+	p.eventHandlersMu.RLock()
+	handlers := p.eventHandlers[event]
+	p.eventHandlersMu.RUnlock()
+	if len(handlers) == 0 {
+		if done != nil {
+			done()
+		}
+		return
+	}
+	p.enqueueJS(func() error {
+		if done != nil {
+			defer done()
+		}
+		rt := p.vu.Runtime()
+		for _, h := range handlers {
+			if _, err := h.fn(goja.Undefined(), rt.ToValue(data)); err != nil {
+				return fmt.Errorf("%s event handler: %w", event, err)
+			}
+		}
+		return nil
+	})
+}
+
+func (p *Page) hasEventHandler(event string) bool {
+	p.eventHandlersMu.RLock()
+	defer p.eventHandlersMu.RUnlock()
+
+	return len(p.eventHandlers[event]) > 0
+}
+
+func (p *Page) hasEventHandlers() bool {
+	p.eventHandlersMu.RLock()
+	defer p.eventHandlersMu.RUnlock()
+
+	return len(p.eventHandlers) > 0
+}
+
+// runJS calls the queued route and event handlers.
+// It must only be called from the VU goroutine.
+func (p *Page) runJS() error {
+	p.jsQueueMu.Lock()
+	queue := p.jsQueue
+	p.jsQueue = nil
+	p.jsQueueMu.Unlock()
+
+	var err error
+	for _, fn := range queue {
+		if ferr := fn(); ferr != nil && err == nil {
+			err = ferr
+		}
+	}
+	return err
+}
+
+func (p *Page) handleRoute(r *Route) error {
+	rt := p.vu.Runtime()
+
+	var err error
+	if h := p.routeHandler(r.request.URL()); h != nil {
+		_, err = h.fn(goja.Undefined(), rt.ToValue(r), rt.ToValue(r.request))
+	}
+	// don't leave the request paused if the handler didn't handle it,
+	// or the route was removed in the meantime.
+	if !r.handled {
+		if cerr := r.continueRequest(NewRouteContinueOptions()); cerr != nil {
+			p.logger.Errorf("Page:handleRoute", "continuing request %q: %v", r.request.URL(), cerr)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("route handler of %q: %w", r.request.URL(), err)
+	}
+	return nil
+}
+
+// jsLoopIdleTimeout is how long the event loop is kept waiting for the
+// route and event handlers to be called while the script is idle. It's the
+// networkIdleTimeout of the browser context: once the network of the page
+// has been quiet for that long, no more requests are expected to be handled.
+func (p *Page) jsLoopIdleTimeout() time.Duration {
+	return p.frameManager.networkIdleTimeout()
+}
+
+// startJSLoop registers a callback on the event loop that calls the route
+// and event handlers while the script is idle. The callback re-registers
+// itself until the handlers are removed, the page is closed, or the VU
+// context is done. Since the event loop won't let the iteration end while
+// a callback is pending, the loop is also released once the script is idle
+// and no handlers were called for the jsLoopIdleTimeout. It's started again the
+// next time the handlers are needed on the VU goroutine.
+// It must only be called from the VU goroutine.
+func (p *Page) startJSLoop() {
+	p.jsQueueMu.Lock()
+	if p.jsLoopStop != nil {
+		p.jsQueueMu.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	p.jsLoopStop = stop
+	p.jsQueueMu.Unlock()
+
+	p.armJSLoop(stop)
+}
+
+func (p *Page) armJSLoop(stop chan struct{}) {
+	var (
+		cb   = p.vu.RegisterCallback()
+		done = p.vu.Context().Done()
+	)
+	go func() {
+		idle := time.NewTimer(p.jsLoopIdleTimeout())
+		defer idle.Stop()
+
+		select {
+		case <-p.jsIdleCh:
+			cb(func() error {
+				err := p.runJS()
+				p.rearmJSLoop(stop)
+				return err
+			})
+		case <-idle.C:
+			// the callback only runs once the script is idle, so keep
+			// the loop going only if there were handlers to call since.
+			cb(func() error {
+				if !p.hasQueuedJS() {
+					p.releaseJSLoop(stop)
+					return nil
+				}
+				err := p.runJS()
+				p.rearmJSLoop(stop)
+				return err
+			})
+		case <-stop:
+			// call the handlers queued before the loop was stopped.
+			cb(p.runJS)
+		case <-done:
+			p.releaseJSLoop(stop)
+			cb(func() error { return nil })
+		case <-p.ctx.Done():
+			p.releaseJSLoop(stop)
+			cb(func() error { return nil })
+		}
+	}()
+}
+
+// rearmJSLoop registers the loop callback again unless the loop was stopped.
+func (p *Page) rearmJSLoop(stop chan struct{}) {
+	select {
+	case <-stop:
+		return
+	default:
+	}
+	p.jsQueueMu.Lock()
+	running := p.jsLoopStop == stop
+	p.jsQueueMu.Unlock()
+	if running {
+		p.armJSLoop(stop)
+	}
+}
+
+// releaseJSLoop lets the loop end without stopping it, so that it can be
+// started again with startJSLoop.
+func (p *Page) releaseJSLoop(stop chan struct{}) {
+	p.jsQueueMu.Lock()
+	defer p.jsQueueMu.Unlock()
+
+	if p.jsLoopStop == stop {
+		p.jsLoopStop = nil
+	}
+}
+
+func (p *Page) stopJSLoop() {
+	p.jsQueueMu.Lock()
+	defer p.jsQueueMu.Unlock()
+
+	if p.jsLoopStop != nil {
+		close(p.jsLoopStop)
+		p.jsLoopStop = nil
+	}
+}
+
+func (p *Page) hasQueuedJS() bool {
+	p.jsQueueMu.Lock()
+	defer p.jsQueueMu.Unlock()
+
+	return len(p.jsQueue) > 0
+}
+
+// hasJSHandlers returns true if there are route or event handlers
+// that need the loop to be called.
+func (p *Page) hasJSHandlers() bool {
+	return p.hasRoutes() || p.hasEventHandlers()
+}
+
+// waitRouted waits for a value from ch while calling the route and event
+// handlers, since they can't run on the event loop while the VU goroutine
+// is blocked.
+//
+// Only the waits for navigations call the handlers this way. The requests
+// intercepted during the other blocking calls, such as a click or
+// waitForSelector, stay paused until the script waits for a navigation
+// or is idle.
+//
+// It waits for at most the timeout, or until the deadline of ctx if it's
+// earlier, so that the consecutive waits of a navigation share the
+// deadline of the navigation instead of each waiting for the timeout.
+func (p *Page) waitRouted(ctx context.Context, ch <-chan interface{}, timeout time.Duration) (interface{}, error) {
+	if p.hasJSHandlers() {
+		p.startJSLoop()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, fmt.Errorf("%w after %s", ErrTimedOut, timeout)
+			}
+			return nil, fmt.Errorf("%w", ctx.Err())
+		case v := <-ch:
+			return v, nil
+		case <-p.jsBusyCh:
+			if err := p.runJS(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func (p *Page) updateRequestInterception() error {
+	for _, fs := range p.allFrameSessions() {
+		if err := fs.updateRequestInterception(false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (p *Page) resetViewport() error {
 	p.logger.Debugf("Page:resetViewport", "sid:%v", p.sessionID())
 
@@ -318,7 +733,7 @@ func (p *Page) setViewportSize(viewportSize *Size) error {
 func (p *Page) updateExtraHTTPHeaders() {
 	p.logger.Debugf("Page:updateExtraHTTPHeaders", "sid:%v", p.sessionID())
 
-	for _, fs := range p.frameSessions {
+	for _, fs := range p.allFrameSessions() {
 		fs.updateExtraHTTPHeaders(false)
 	}
 }
@@ -326,7 +741,7 @@ func (p *Page) updateExtraHTTPHeaders() {
 func (p *Page) updateGeolocation() error {
 	p.logger.Debugf("Page:updateGeolocation", "sid:%v", p.sessionID())
 
-	for _, fs := range p.frameSessions {
+	for _, fs := range p.allFrameSessions() {
 		p.logger.Debugf("Page:updateGeolocation:frameSession",
 			"sid:%v tid:%v wid:%v",
 			p.sessionID(), fs.targetID, fs.windowID)
@@ -345,7 +760,7 @@ func (p *Page) updateGeolocation() error {
 func (p *Page) updateOffline() {
 	p.logger.Debugf("Page:updateOffline", "sid:%v", p.sessionID())
 
-	for _, fs := range p.frameSessions {
+	for _, fs := range p.allFrameSessions() {
 		fs.updateOffline(false)
 	}
 }
@@ -353,7 +768,7 @@ func (p *Page) updateOffline() {
 func (p *Page) updateHttpCredentials() {
 	p.logger.Debugf("Page:updateHttpCredentials", "sid:%v", p.sessionID())
 
-	for _, fs := range p.frameSessions {
+	for _, fs := range p.allFrameSessions() {
 		fs.updateHTTPCredentials(false)
 	}
 }
@@ -370,12 +785,19 @@ func (p *Page) AddInitScript(script goja.Value, arg goja.Value) {
 	k6ext.Panic(p.ctx, "Page.addInitScript(script, arg) has not been implemented yet")
 }
 
+// AddScriptTag adds a <script> tag to the main frame.
 func (p *Page) AddScriptTag(opts goja.Value) {
-	k6ext.Panic(p.ctx, "Page.addScriptTag(opts) has not been implemented yet")
+	p.logger.Debugf("Page:AddScriptTag", "sid:%v", p.sessionID())
+
+	p.MainFrame().AddScriptTag(opts)
 }
 
+// AddStyleTag adds a <style> or <link rel="stylesheet"> tag to the main
+// frame, e.g. to disable animations for stable screenshots.
 func (p *Page) AddStyleTag(opts goja.Value) {
-	k6ext.Panic(p.ctx, "Page.addStyleTag(opts) has not been implemented yet")
+	p.logger.Debugf("Page:AddStyleTag", "sid:%v", p.sessionID())
+
+	p.MainFrame().AddStyleTag(opts)
 }
 
 // BringToFront activates the browser tab for this page.
@@ -421,7 +843,28 @@ func (p *Page) Click(selector string, opts goja.Value) {
 func (p *Page) Close(opts goja.Value) {
 	p.logger.Debugf("Page:Close", "sid:%v", p.sessionID())
 
+	// subscribe before closing so that the close event isn't missed.
+	closed, evCancelFn := createWaitForEventHandler(p.ctx, p, []string{EventPageClose}, nil)
+	defer evCancelFn() // Remove event handler
+	wasClosed := p.IsClosed()
+
 	p.browserCtx.Close()
+
+	if !p.hasEventHandler(EventPageClose) {
+		return
+	}
+	if !wasClosed {
+		select {
+		case <-closed:
+		case <-time.After(p.defaultTimeout()):
+			k6ext.Panic(p.ctx, "waiting for the page to close: %w after %s", ErrTimedOut, p.defaultTimeout())
+		}
+	}
+	// call the close handlers right away, so that the teardown
+	// in them is done before the script continues.
+	if err := p.runJS(); err != nil {
+		k6ext.Panic(p.ctx, "%w", err)
+	}
 }
 
 // Content returns the HTML content of the page.
@@ -431,11 +874,24 @@ func (p *Page) Content() string {
 	return p.MainFrame().Content()
 }
 
+// DOMSnapshot returns a serialized snapshot of the DOM tree of the main frame.
+func (p *Page) DOMSnapshot() *api.DOMSnapshotNode {
+	p.logger.Debugf("Page:DOMSnapshot", "sid:%v", p.sessionID())
+
+	return p.MainFrame().DOMSnapshot()
+}
+
 // Context closes the page.
 func (p *Page) Context() api.BrowserContext {
 	return p.browserCtx
 }
 
+// hasTouch returns true if the browser context of the page emulates a
+// touchscreen.
+func (p *Page) hasTouch() bool {
+	return p.browserCtx != nil && p.browserCtx.opts != nil && p.browserCtx.opts.HasTouch
+}
+
 // Dblclick double clicks an element matching provided selector.
 func (p *Page) Dblclick(selector string, opts goja.Value) {
 	p.logger.Debugf("Page:Dblclick", "sid:%v selector:%s", p.sessionID(), selector)
@@ -453,10 +909,12 @@ func (p *Page) DragAndDrop(source string, target string, opts goja.Value) {
 	k6ext.Panic(p.ctx, "Page.DragAndDrop(source, target, opts) has not been implemented yet")
 }
 
+// EmulateMedia changes the emulated media type and media features of the
+// page, e.g. to switch to the dark color scheme in the middle of a test.
 func (p *Page) EmulateMedia(opts goja.Value) {
 	p.logger.Debugf("Page:EmulateMedia", "sid:%v", p.sessionID())
 
-	parsedOpts := NewPageEmulateMediaOptions(p.mediaType, p.colorScheme, p.reducedMotion)
+	parsedOpts := NewPageEmulateMediaOptions(p.mediaType, p.colorScheme, p.reducedMotion, p.contrast, p.forcedColors)
 	if err := parsedOpts.Parse(p.ctx, opts); err != nil {
 		k6ext.Panic(p.ctx, "parsing emulateMedia options: %w", err)
 	}
@@ -464,8 +922,10 @@ func (p *Page) EmulateMedia(opts goja.Value) {
 	p.mediaType = parsedOpts.Media
 	p.colorScheme = parsedOpts.ColorScheme
 	p.reducedMotion = parsedOpts.ReducedMotion
+	p.contrast = parsedOpts.Contrast
+	p.forcedColors = parsedOpts.ForcedColors
 
-	for _, fs := range p.frameSessions {
+	for _, fs := range p.allFrameSessions() {
 		if err := fs.updateEmulateMedia(false); err != nil {
 			k6ext.Panic(p.ctx, "emulating media: %w", err)
 		}
@@ -627,6 +1087,14 @@ func (p *Page) IsVisible(selector string, opts goja.Value) bool {
 	return p.MainFrame().IsVisible(selector, opts)
 }
 
+// GetByRole creates and returns a new locator for the elements with the
+// ARIA role that match the options (main frame).
+func (p *Page) GetByRole(role string, opts goja.Value) api.Locator {
+	p.logger.Debugf("Page:GetByRole", "sid:%s role:%q", p.sessionID(), role)
+
+	return p.MainFrame().GetByRole(role, opts)
+}
+
 // Locator creates and returns a new locator for this page (main frame).
 func (p *Page) Locator(selector string, opts goja.Value) api.Locator {
 	p.logger.Debugf("Page:Locator", "sid:%s sel: %q opts:%+v", p.sessionID(), selector, opts)
@@ -649,8 +1117,84 @@ func (p *Page) MainFrame() api.Frame {
 	return mf
 }
 
-// Opener returns the opener of the target.
+// Off removes the handler registered with On for the event. If the handler
+// isn't given, all the handlers of the event are removed.
+func (p *Page) Off(event string, handler goja.Value) {
+	p.logger.Debugf("Page:Off", "sid:%v event:%q", p.sessionID(), event)
+
+	p.eventHandlersMu.Lock()
+	handlers := make([]pageEventHandler, 0, len(p.eventHandlers[event]))
+	for _, h := range p.eventHandlers[event] {
+		if gojaValueExists(handler) && !h.handler.StrictEquals(handler) {
+			handlers = append(handlers, h)
+		}
+	}
+	if len(handlers) == 0 {
+		delete(p.eventHandlers, event)
+	} else {
+		p.eventHandlers[event] = handlers
+	}
+	p.eventHandlersMu.Unlock()
+
+	if !p.hasJSHandlers() {
+		p.stopJSLoop()
+	}
+}
+
+// On registers a handler to be called with the event data when the page
+// emits the event. The supported events are:
+//   - request: called with the Request when a request is issued.
+//   - response: called with the Response when a response is received.
+//   - requestfinished: called with the Request when a request finishes.
+//   - requestfailed: called with the Request when a request fails.
+//   - longtask: called with the LongTask when a task blocks the main
+//     thread of a frame for more than 50ms.
+//   - console: called with the ConsoleMessage when the page logs to the
+//     console. The messages are still logged by k6 as well.
+//   - pageerror: called with the PageError when an uncaught exception is
+//     thrown in the page.
+//   - security: called with the SecurityMessage when the browser logs a
+//     security warning or error, such as a mixed content warning.
+//   - download: called with the Download when the page starts a download.
+//     Downloads are only reported if the context accepts downloads.
+//   - websocket: called with the WebSocket when the page opens one. Its
+//     frames can be observed with the WebSocket's own On.
+//   - worker: called with the Worker when the page starts a worker.
+//   - workerclose: called with the Worker when it's terminated.
+//   - close: called with the Page when it's closed, either by Close or
+//     by the browser, e.g. when the page closes itself. The handlers of
+//     an explicit Close have run by the time it returns.
+//
+// The handlers run on the VU goroutine: while the script waits for
+// a navigation, or when it's idle. They're active until they're removed
+// with Off or the page is closed. While the script is idle, the event loop
+// waits for events for up to the networkIdleTimeout of the browser context
+// after the last handler call, so that the iteration can end if the script
+// is done.
+func (p *Page) On(event string, handler goja.Value) {
+	p.logger.Debugf("Page:On", "sid:%v event:%q", p.sessionID(), event)
+
+	if !isJSPageEvent(event) {
+		k6ext.Panic(p.ctx, "unknown page event: %q", event)
+	}
+	fn, ok := goja.AssertFunction(handler)
+	if !ok {
+		k6ext.Panic(p.ctx, "handler of page event %q must be a function", event)
+	}
+
+	p.eventHandlersMu.Lock()
+	p.eventHandlers[event] = append(p.eventHandlers[event], pageEventHandler{fn: fn, handler: handler})
+	p.eventHandlersMu.Unlock()
+
+	p.startJSLoop()
+}
+
+// Opener returns the page that opened this page as a popup, or nil if
+// this page isn't a popup or its opener is closed.
 func (p *Page) Opener() api.Page {
+	if p.opener == nil || p.opener.IsClosed() {
+		return nil
+	}
 	return p.opener
 }
 
@@ -702,11 +1246,11 @@ func (p *Page) Reload(opts goja.Value) api.Response {
 	}
 
 	var event *NavigationEvent
-	select {
-	case <-p.ctx.Done():
-	case <-time.After(parsedOpts.Timeout):
-		k6ext.Panic(p.ctx, "%w", ErrTimedOut)
-	case data := <-ch:
+	data, err := p.waitRouted(p.ctx, ch, parsedOpts.Timeout)
+	if err != nil {
+		k6ext.Panic(p.ctx, "reloading page: %w", err)
+	}
+	if data != nil {
 		event = data.(*NavigationEvent)
 	}
 
@@ -727,8 +1271,34 @@ func (p *Page) Reload(opts goja.Value) api.Response {
 	return resp
 }
 
-func (p *Page) Route(url goja.Value, handler goja.Callable) {
-	k6ext.Panic(p.ctx, "Page.route(url, handler) has not been implemented yet")
+// Route registers a handler for the requests matching the URL glob pattern
+// or RegExp. The handler is called with a Route and a Request, and can
+// continue, fulfill or abort the request. A request that isn't handled by
+// the handler is continued.
+//
+// The handlers run on the VU goroutine: while the script waits for
+// a navigation, or when it's idle. A request intercepted during another
+// blocking call, such as a click or waitForSelector, stays paused until
+// that call returns. While the script is idle, they're called as long as
+// the requests are no further apart than the networkIdleTimeout of the
+// browser context. Routes are active until they're removed with Unroute
+// or the page is closed.
+func (p *Page) Route(url goja.Value, handler goja.Value) {
+	p.logger.Debugf("Page:Route", "sid:%v url:%v", p.sessionID(), url)
+
+	h, err := newRouteHandler(p.vu.Runtime(), url, handler)
+	if err != nil {
+		k6ext.Panic(p.ctx, "registering route: %w", err)
+	}
+
+	p.routesMu.Lock()
+	p.routes = append(p.routes, h)
+	p.routesMu.Unlock()
+
+	p.startJSLoop()
+	if err := p.updateRequestInterception(); err != nil {
+		k6ext.Panic(p.ctx, "enabling request interception: %w", err)
+	}
 }
 
 // Screenshot will instruct Chrome to save a screenshot of the current page and save it to specified file.
@@ -780,9 +1350,61 @@ func (p *Page) SetExtraHTTPHeaders(headers map[string]string) {
 	p.updateExtraHTTPHeaders()
 }
 
+// SetGeolocation overrides the geo location of the page. The page's
+// geolocation takes precedence over the one of its browser context.
+// With the grantPermission option, the geolocation permission is also
+// granted to the origin of the page, so that the page can read it.
+func (p *Page) SetGeolocation(geolocation goja.Value, opts goja.Value) {
+	p.logger.Debugf("Page:SetGeolocation", "sid:%v", p.sessionID())
+
+	g := NewGeolocation()
+	if err := g.Parse(p.ctx, geolocation); err != nil {
+		k6ext.Panic(p.ctx, "parsing geo location: %w", err)
+	}
+	parsedOpts := NewPageSetGeolocationOptions()
+	if err := parsedOpts.Parse(p.ctx, opts); err != nil {
+		k6ext.Panic(p.ctx, "parsing setGeolocation options: %w", err)
+	}
+
+	if parsedOpts.GrantPermission {
+		if err := p.grantGeolocationPermission(); err != nil {
+			k6ext.Panic(p.ctx, "granting geolocation permission: %w", err)
+		}
+	}
+	p.geolocation = g
+	if err := p.updateGeolocation(); err != nil {
+		k6ext.Panic(p.ctx, "updating geo location: %w", err)
+	}
+
+	applySlowMo(p.ctx)
+}
+
+// grantGeolocationPermission grants the geolocation permission to the
+// origin of the page without changing the other permissions of the origin.
+func (p *Page) grantGeolocationPermission() error {
+	u, err := url.Parse(p.frameManager.MainFrame().URL())
+	if err != nil {
+		return fmt.Errorf("parsing page URL: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("page URL %q has no origin, navigate the page first", u)
+	}
+	origin := u.Scheme + "://" + u.Host
+
+	action := cdpbrowser.SetPermission(
+		&cdpbrowser.PermissionDescriptor{Name: "geolocation"},
+		cdpbrowser.PermissionSettingGranted,
+	).WithOrigin(origin).WithBrowserContextID(p.browserCtx.id)
+	if err := action.Do(cdp.WithExecutor(p.ctx, p.browserCtx.browser.conn)); err != nil {
+		return fmt.Errorf("origin %q: %w", origin, err)
+	}
+	return nil
+}
+
 func (p *Page) SetInputFiles(selector string, files goja.Value, opts goja.Value) {
-	k6ext.Panic(p.ctx, "Page.textContent(selector, opts) has not been implemented yet")
-	// TODO: needs slowMo
+	p.logger.Debugf("Page:SetInputFiles", "sid:%v selector:%s", p.sessionID(), selector)
+
+	p.MainFrame().SetInputFiles(selector, files, opts)
 }
 
 // SetViewportSize will update the viewport width and height.
@@ -799,6 +1421,27 @@ func (p *Page) SetViewportSize(viewportSize goja.Value) {
 	applySlowMo(p.ctx)
 }
 
+// SetOrientation emulates the screen orientation, one of portrait-primary,
+// portrait-secondary, landscape-primary, or landscape-secondary. It keeps
+// the viewport size, so pages can handle the change like a device rotation
+// with an orientationchange handler.
+func (p *Page) SetOrientation(orientation string) {
+	p.logger.Debugf("Page:SetOrientation", "sid:%v orientation:%s", p.sessionID(), orientation)
+
+	o, err := parseScreenOrientation(orientation)
+	if err != nil {
+		k6ext.Panic(p.ctx, "setting orientation: %w", err)
+	}
+	if p.emulatedSize == nil {
+		k6ext.Panic(p.ctx, "setting orientation: the page has no viewport to emulate")
+	}
+	p.orientation = o
+	if err := p.mainFrameSession.updateViewport(); err != nil {
+		k6ext.Panic(p.ctx, "setting orientation: %w", err)
+	}
+	applySlowMo(p.ctx)
+}
+
 func (p *Page) Tap(selector string, opts goja.Value) {
 	p.logger.Debugf("Page:SetViewportSize", "sid:%v selector:%s", p.sessionID(), selector)
 
@@ -824,8 +1467,34 @@ func (p *Page) Type(selector string, text string, opts goja.Value) {
 	p.MainFrame().Type(selector, text, opts)
 }
 
-func (p *Page) Unroute(url goja.Value, handler goja.Callable) {
-	k6ext.Panic(p.ctx, "Page.unroute(url, handler) has not been implemented yet")
+// Unroute removes the routes registered for the URL. If the handler is
+// given, only the routes with that handler are removed.
+func (p *Page) Unroute(url goja.Value, handler goja.Value) {
+	p.logger.Debugf("Page:Unroute", "sid:%v url:%v", p.sessionID(), url)
+
+	p.routesMu.Lock()
+	routes := make([]*routeHandler, 0, len(p.routes))
+	for _, h := range p.routes {
+		if !h.equals(url, handler) {
+			routes = append(routes, h)
+		}
+	}
+	p.routes = routes
+	p.routesMu.Unlock()
+
+	if len(routes) > 0 {
+		return
+	}
+	if !p.hasJSHandlers() {
+		p.stopJSLoop()
+	}
+	if err := p.updateRequestInterception(); err != nil {
+		k6ext.Panic(p.ctx, "disabling request interception: %w", err)
+	}
+	// continue the requests intercepted before the routes were removed.
+	if err := p.runJS(); err != nil {
+		k6ext.Panic(p.ctx, "%w", err)
+	}
 }
 
 // URL returns the location of the page.
@@ -851,10 +1520,44 @@ func (p *Page) ViewportSize() map[string]float64 {
 	}
 }
 
-// WaitForEvent waits for the specified event to trigger.
-func (p *Page) WaitForEvent(event string, optsOrPredicate goja.Value) interface{} {
-	k6ext.Panic(p.ctx, "Page.waitForEvent(event, optsOrPredicate) has not been implemented yet")
-	return nil
+// WaitForCount waits until the selector matches count elements in the
+// main frame.
+func (p *Page) WaitForCount(selector string, count int64, opts goja.Value) {
+	p.logger.Debugf("Page:WaitForCount", "sid:%v selector:%s count:%d", p.sessionID(), selector, count)
+
+	p.frameManager.MainFrame().WaitForCount(selector, count, opts)
+}
+
+// WaitForEvent returns a promise that resolves to the data of the first
+// event of the page that the predicate, if any, returns true for. The
+// optsOrPredicate is either the predicate function, or the options with
+// the predicate and timeout. The events are matched from the time of the
+// call, so it captures the events caused by an action that runs before
+// the promise is awaited.
+func (p *Page) WaitForEvent(event string, optsOrPredicate goja.Value) *goja.Promise {
+	p.logger.Debugf("Page:WaitForEvent", "sid:%v event:%q", p.sessionID(), event)
+
+	if !stringSliceContains(waitablePageEvents, event) {
+		k6ext.Panic(p.ctx, "unknown page event: %q", event)
+	}
+	parsedOpts := NewPageWaitForEventOptions(p.defaultTimeout())
+	if err := parsedOpts.Parse(p.ctx, optsOrPredicate); err != nil {
+		k6ext.Panic(p.ctx, "parsing waitForEvent options: %w", err)
+	}
+
+	var predicate func(data interface{}) (bool, error)
+	if parsedOpts.Predicate != nil {
+		rt := p.vu.Runtime()
+		predicate = func(data interface{}) (bool, error) {
+			v, err := parsedOpts.Predicate(goja.Undefined(), rt.ToValue(data))
+			if err != nil {
+				return false, err
+			}
+			return v.ToBoolean(), nil
+		}
+	}
+
+	return p.waitForEventPromise(event, parsedOpts.Timeout, nil, predicate)
 }
 
 // WaitForFunction waits for the given predicate to return a truthy value.
@@ -878,14 +1581,220 @@ func (p *Page) WaitForNavigation(opts goja.Value) api.Response {
 	return p.frameManager.MainFrame().WaitForNavigation(opts)
 }
 
-func (p *Page) WaitForRequest(urlOrPredicate, opts goja.Value) api.Request {
-	k6ext.Panic(p.ctx, "Page.waitForRequest(urlOrPredicate, opts) has not been implemented yet")
-	return nil
+// WaitForNetworkIdle waits until the number of inflight requests of the
+// page stays at or below maxInflight for idleTime. Unlike the networkidle
+// lifecycle event, it can be tuned for pages that never fully quiesce.
+func (p *Page) WaitForNetworkIdle(opts goja.Value) {
+	p.logger.Debugf("Page:WaitForNetworkIdle", "sid:%v", p.sessionID())
+
+	parsedOpts := NewPageWaitForNetworkIdleOptions(p.defaultTimeout())
+	if err := parsedOpts.Parse(p.ctx, opts); err != nil {
+		k6ext.Panic(p.ctx, "parsing waitForNetworkIdle options: %w", err)
+	}
+	if err := p.waitForNetworkIdle(parsedOpts); err != nil {
+		k6ext.Panic(p.ctx, "waiting for network idle: %w", err)
+	}
 }
 
-func (p *Page) WaitForResponse(urlOrPredicate, opts goja.Value) api.Response {
-	k6ext.Panic(p.ctx, "Page.waitForResponse(urlOrPredicate, opts) has not been implemented yet")
-	return nil
+// waitForNetworkIdle recounts the inflight requests whenever a request
+// starts or ends, and restarts the idle timer each time the count goes
+// above the maximum.
+func (p *Page) waitForNetworkIdle(opts *PageWaitForNetworkIdleOptions) error {
+	ctx, cancel := context.WithTimeout(p.ctx, opts.Timeout)
+	defer cancel()
+
+	ch := make(chan Event)
+	p.on(ctx, []string{
+		EventPageRequest,
+		EventPageRequestFinished,
+		EventPageRequestFailed,
+	}, ch)
+
+	var (
+		idleTimer *time.Timer
+		idle      <-chan time.Time
+	)
+	stopIdleTimer := func() {
+		if idleTimer != nil {
+			idleTimer.Stop()
+		}
+		idleTimer, idle = nil, nil
+	}
+	defer stopIdleTimer()
+
+	check := func() {
+		if int64(p.frameManager.inflightRequestsLen()) > opts.MaxInflight {
+			stopIdleTimer()
+			return
+		}
+		if idleTimer == nil {
+			idleTimer = time.NewTimer(opts.IdleTime)
+			idle = idleTimer.C
+		}
+	}
+	check()
+	for {
+		select {
+		case <-ch:
+			check()
+		case <-idle:
+			return nil
+		case <-ctx.Done():
+			return timeoutError(ctx.Err(), opts.Timeout)
+		}
+	}
+}
+
+// WaitForRequest returns a promise that resolves to the first request made
+// within the page whose URL matches urlOrPredicate.
+func (p *Page) WaitForRequest(urlOrPredicate, opts goja.Value) *goja.Promise {
+	p.logger.Debugf("Page:WaitForRequest", "sid:%v url:%v", p.sessionID(), urlOrPredicate)
+
+	parsedOpts := NewFrameWaitForRequestOptions(p.defaultTimeout())
+	if err := parsedOpts.Parse(p.ctx, opts); err != nil {
+		k6ext.Panic(p.ctx, "parsing waitForRequest options: %w", err)
+	}
+
+	return p.waitForNetworkEvent(nil, "waitForRequest", EventPageRequest, urlOrPredicate, parsedOpts.Timeout)
+}
+
+// WaitForResponse returns a promise that resolves to the first response
+// received within the page whose URL matches urlOrPredicate.
+func (p *Page) WaitForResponse(urlOrPredicate, opts goja.Value) *goja.Promise {
+	p.logger.Debugf("Page:WaitForResponse", "sid:%v url:%v", p.sessionID(), urlOrPredicate)
+
+	parsedOpts := NewFrameWaitForResponseOptions(p.defaultTimeout())
+	if err := parsedOpts.Parse(p.ctx, opts); err != nil {
+		k6ext.Panic(p.ctx, "parsing waitForResponse options: %w", err)
+	}
+
+	return p.waitForNetworkEvent(nil, "waitForResponse", EventPageResponse, urlOrPredicate, parsedOpts.Timeout)
+}
+
+// waitForNetworkEvent returns a promise that resolves to the first request
+// or response, depending on the event, whose URL matches urlOrPredicate. If
+// frame isn't nil, only the requests and responses within the frame match.
+func (p *Page) waitForNetworkEvent(
+	frame *Frame, method, event string, urlOrPredicate goja.Value, timeout time.Duration,
+) *goja.Promise {
+	var (
+		rt         = p.vu.Runtime()
+		predicate  func(data interface{}) (bool, error)
+		matchesURL = func(string) bool { return true }
+	)
+	if fn, ok := goja.AssertFunction(urlOrPredicate); ok {
+		predicate = func(data interface{}) (bool, error) {
+			v, err := fn(goja.Undefined(), rt.ToValue(networkEventURL(data)))
+			if err != nil {
+				return false, err
+			}
+			return v.ToBoolean(), nil
+		}
+	} else {
+		m, err := urlMatcher(rt, urlOrPredicate)
+		if err != nil {
+			k6ext.Panic(p.ctx, "%s: %w", method, err)
+		}
+		matchesURL = m
+	}
+	matches := func(data interface{}) bool {
+		var evFrame *Frame
+		switch data := data.(type) {
+		case *Request:
+			evFrame = data.getFrame()
+		case *Response:
+			evFrame = data.request.getFrame()
+		}
+		if frame != nil && evFrame != frame {
+			return false
+		}
+		return matchesURL(networkEventURL(data))
+	}
+
+	return p.waitForEventPromise(event, timeout, matches, predicate)
+}
+
+// networkEventURL returns the URL of the request or response.
+func networkEventURL(data interface{}) string {
+	switch data := data.(type) {
+	case *Request:
+		return data.URL()
+	case *Response:
+		return data.URL()
+	}
+	return ""
+}
+
+// waitForEventPromise subscribes to the page event and returns a promise
+// that resolves to the data of the first event that matches. The matches
+// function is called as the events arrive, and then the predicate, if it's
+// not nil, on the VU goroutine, one event at a time. So the predicate
+// functions of the scripts are only called once the script is idle.
+func (p *Page) waitForEventPromise(
+	event string, timeout time.Duration,
+	matches func(data interface{}) bool, predicate func(data interface{}) (bool, error),
+) *goja.Promise {
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(p.ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(p.ctx)
+	}
+	ch := make(chan Event)
+	p.on(ctx, []string{event}, ch)
+
+	// next returns the data of the next event that matches.
+	next := func() (interface{}, error) {
+		for {
+			select {
+			case <-ctx.Done():
+				return nil, timeoutError(ctx.Err(), timeout)
+			case ev := <-ch:
+				if matches == nil || matches(ev.data) {
+					return ev.data, nil
+				}
+			}
+		}
+	}
+
+	promise, resolve, reject := p.vu.Runtime().NewPromise()
+
+	var wait func()
+	wait = func() {
+		cb := p.vu.RegisterCallback()
+		go func() {
+			data, err := next()
+			cb(func() error {
+				ok := true
+				if err != nil {
+					err = fmt.Errorf("waiting for event %q: %w", event, err)
+				} else if predicate != nil {
+					if ok, err = predicate(data); err != nil {
+						err = fmt.Errorf("calling the predicate of event %q: %w", event, err)
+					}
+				}
+				if err == nil && !ok {
+					wait()
+					return nil
+				}
+
+				// the wait is over, so stop listening to the event.
+				defer cancel()
+				if err != nil {
+					reject(err)
+					return nil
+				}
+				resolve(data)
+				return nil
+			})
+		}()
+	}
+	wait()
+
+	return promise
 }
 
 // WaitForSelector waits for the given selector to match the waiting criteria.
@@ -906,6 +1815,9 @@ func (p *Page) WaitForTimeout(timeout int64) {
 
 // Workers returns all WebWorkers of page.
 func (p *Page) Workers() []api.Worker {
+	p.workersMu.RLock()
+	defer p.workersMu.RUnlock()
+
 	workers := make([]api.Worker, 0, len(p.workers))
 	for _, w := range p.workers {
 		workers = append(workers, w)
@@ -32,8 +32,18 @@ import (
 	"github.com/grafana/xk6-browser/k6ext"
 )
 
+// AccessibilitySnapshotOptions are options for Accessibility.snapshot.
+type AccessibilitySnapshotOptions struct {
+	InterestingOnly bool
+	Root            *ElementHandle
+}
+
+// PageEmulateMediaOptions are options for Page.emulateMedia.
+// An empty value means the system default.
 type PageEmulateMediaOptions struct {
 	ColorScheme   ColorScheme   `json:"colorScheme"`
+	Contrast      Contrast      `json:"contrast"`
+	ForcedColors  ForcedColors  `json:"forcedColors"`
 	Media         MediaType     `json:"media"`
 	ReducedMotion ReducedMotion `json:"reducedMotion"`
 }
@@ -43,35 +53,76 @@ type PageReloadOptions struct {
 	Timeout   time.Duration  `json:"timeout"`
 }
 
+type PageSetGeolocationOptions struct {
+	GrantPermission bool `json:"grantPermission"`
+}
+
 type PageScreenshotOptions struct {
-	Clip           *page.Viewport `json:"clip"`
-	Path           string         `json:"path"`
-	Format         ImageFormat    `json:"format"`
-	FullPage       bool           `json:"fullPage"`
-	OmitBackground bool           `json:"omitBackground"`
-	Quality        int64          `json:"quality"`
+	Clip              *page.Viewport `json:"clip"`
+	Path              string         `json:"path"`
+	Format            ImageFormat    `json:"format"`
+	FullPage          bool           `json:"fullPage"`
+	OmitBackground    bool           `json:"omitBackground"`
+	Quality           int64          `json:"quality"`
+	WaitForFonts      bool           `json:"waitForFonts"`
+	DisableAnimations bool           `json:"disableAnimations"`
 }
 
-func NewPageEmulateMediaOptions(defaultMedia MediaType, defaultColorScheme ColorScheme, defaultReducedMotion ReducedMotion) *PageEmulateMediaOptions {
+// PageWaitForEventOptions are options for Page.waitForEvent.
+type PageWaitForEventOptions struct {
+	Predicate goja.Callable `json:"-"`
+	Timeout   time.Duration `json:"timeout"`
+}
+
+// PageWaitForNetworkIdleOptions are options for Page.waitForNetworkIdle.
+type PageWaitForNetworkIdleOptions struct {
+	IdleTime    time.Duration `json:"idleTime"`
+	MaxInflight int64         `json:"maxInflight"`
+	Timeout     time.Duration `json:"timeout"`
+}
+
+func NewPageEmulateMediaOptions(
+	defaultMedia MediaType, defaultColorScheme ColorScheme, defaultReducedMotion ReducedMotion, defaultContrast Contrast,
+	defaultForcedColors ForcedColors,
+) *PageEmulateMediaOptions {
 	return &PageEmulateMediaOptions{
 		ColorScheme:   defaultColorScheme,
+		Contrast:      defaultContrast,
+		ForcedColors:  defaultForcedColors,
 		Media:         defaultMedia,
 		ReducedMotion: defaultReducedMotion,
 	}
 }
 
+// Parse parses the emulateMedia options. Omitted options keep their
+// current value, and null resets them to the system default.
 func (o *PageEmulateMediaOptions) Parse(ctx context.Context, opts goja.Value) error {
 	rt := k6ext.Runtime(ctx)
 	if opts != nil && !goja.IsUndefined(opts) && !goja.IsNull(opts) {
 		opts := opts.ToObject(rt)
 		for _, k := range opts.Keys() {
+			v := opts.Get(k)
+			var s string
+			if !goja.IsUndefined(v) && !goja.IsNull(v) {
+				s = v.String()
+			}
 			switch k {
 			case "colorScheme":
-				o.ColorScheme = ColorScheme(opts.Get(k).String())
+				o.ColorScheme = ColorScheme(s)
+			case "contrast":
+				o.Contrast = Contrast(s)
+			case "forcedColors":
+				switch f := ForcedColors(s); f {
+				case ForcedColorsActive, ForcedColorsNone, "":
+					o.ForcedColors = f
+				default:
+					return fmt.Errorf("forcedColors must be either %q, %q or null, got %q",
+						ForcedColorsActive, ForcedColorsNone, s)
+				}
 			case "media":
-				o.Media = MediaType(opts.Get(k).String())
+				o.Media = MediaType(s)
 			case "reducedMotion":
-				o.ReducedMotion = ReducedMotion(opts.Get(k).String())
+				o.ReducedMotion = ReducedMotion(s)
 			}
 		}
 	}
@@ -106,6 +157,25 @@ func (o *PageReloadOptions) Parse(ctx context.Context, opts goja.Value) error {
 	return nil
 }
 
+func NewPageSetGeolocationOptions() *PageSetGeolocationOptions {
+	return &PageSetGeolocationOptions{
+		GrantPermission: false,
+	}
+}
+
+func (o *PageSetGeolocationOptions) Parse(ctx context.Context, opts goja.Value) error {
+	rt := k6ext.Runtime(ctx)
+	if opts != nil && !goja.IsUndefined(opts) && !goja.IsNull(opts) {
+		opts := opts.ToObject(rt)
+		for _, k := range opts.Keys() {
+			if k == "grantPermission" {
+				o.GrantPermission = opts.Get(k).ToBoolean()
+			}
+		}
+	}
+	return nil
+}
+
 func NewPageScreenshotOptions() *PageScreenshotOptions {
 	return &PageScreenshotOptions{
 		Clip:           nil,
@@ -135,6 +205,8 @@ func (o *PageScreenshotOptions) Parse(ctx context.Context, opts goja.Value) erro
 						Scale:  1,
 					}
 				}
+			case "disableAnimations":
+				o.DisableAnimations = opts.Get(k).ToBoolean()
 			case "fullPage":
 				o.FullPage = opts.Get(k).ToBoolean()
 			case "omitBackground":
@@ -143,6 +215,8 @@ func (o *PageScreenshotOptions) Parse(ctx context.Context, opts goja.Value) erro
 				o.Path = opts.Get(k).String()
 			case "quality":
 				o.Quality = opts.Get(k).ToInteger()
+			case "waitForFonts":
+				o.WaitForFonts = opts.Get(k).ToBoolean()
 			case "type":
 				if f, ok := imageFormatToID[opts.Get(k).String()]; ok {
 					o.Format = f
@@ -161,3 +235,106 @@ func (o *PageScreenshotOptions) Parse(ctx context.Context, opts goja.Value) erro
 
 	return nil
 }
+
+// NewPageWaitForEventOptions returns the default waitForEvent options.
+func NewPageWaitForEventOptions(defaultTimeout time.Duration) *PageWaitForEventOptions {
+	return &PageWaitForEventOptions{
+		Timeout: defaultTimeout,
+	}
+}
+
+// Parse parses the waitForEvent options, which are either a predicate
+// function, or an object with the predicate and timeout.
+func (o *PageWaitForEventOptions) Parse(ctx context.Context, optsOrPredicate goja.Value) error {
+	if !gojaValueExists(optsOrPredicate) {
+		return nil
+	}
+	if fn, ok := goja.AssertFunction(optsOrPredicate); ok {
+		o.Predicate = fn
+		return nil
+	}
+	opts := optsOrPredicate.ToObject(k6ext.Runtime(ctx))
+	for _, k := range opts.Keys() {
+		switch k {
+		case "predicate":
+			fn, ok := goja.AssertFunction(opts.Get(k))
+			if !ok {
+				return fmt.Errorf("predicate must be a function, got %s", opts.Get(k).ExportType())
+			}
+			o.Predicate = fn
+		case "timeout":
+			o.Timeout = time.Duration(opts.Get(k).ToInteger()) * time.Millisecond
+		}
+	}
+	return nil
+}
+
+// NewPageWaitForNetworkIdleOptions returns the default waitForNetworkIdle
+// options, which match the rule of the networkidle lifecycle event.
+func NewPageWaitForNetworkIdleOptions(defaultTimeout time.Duration) *PageWaitForNetworkIdleOptions {
+	return &PageWaitForNetworkIdleOptions{
+		IdleTime:    LifeCycleNetworkIdleTimeout,
+		MaxInflight: 0,
+		Timeout:     defaultTimeout,
+	}
+}
+
+// Parse parses the waitForNetworkIdle options.
+func (o *PageWaitForNetworkIdleOptions) Parse(ctx context.Context, opts goja.Value) error {
+	rt := k6ext.Runtime(ctx)
+	if opts != nil && !goja.IsUndefined(opts) && !goja.IsNull(opts) {
+		opts := opts.ToObject(rt)
+		for _, k := range opts.Keys() {
+			switch k {
+			case "idleTime":
+				ms := opts.Get(k).ToInteger()
+				if ms < 0 {
+					return fmt.Errorf("idleTime must be a positive number, got %d", ms)
+				}
+				o.IdleTime = time.Duration(ms) * time.Millisecond
+			case "maxInflight":
+				n := opts.Get(k).ToInteger()
+				if n < 0 {
+					return fmt.Errorf("maxInflight must be a positive number, got %d", n)
+				}
+				o.MaxInflight = n
+			case "timeout":
+				o.Timeout = time.Duration(opts.Get(k).ToInteger()) * time.Millisecond
+			}
+		}
+	}
+	return nil
+}
+
+// NewAccessibilitySnapshotOptions returns the default snapshot options,
+// which leave out the nodes that aren't interesting.
+func NewAccessibilitySnapshotOptions() *AccessibilitySnapshotOptions {
+	return &AccessibilitySnapshotOptions{
+		InterestingOnly: true,
+	}
+}
+
+// Parse parses the accessibility snapshot options.
+func (o *AccessibilitySnapshotOptions) Parse(ctx context.Context, opts goja.Value) error {
+	rt := k6ext.Runtime(ctx)
+	if opts != nil && !goja.IsUndefined(opts) && !goja.IsNull(opts) {
+		opts := opts.ToObject(rt)
+		for _, k := range opts.Keys() {
+			switch k {
+			case "interestingOnly":
+				o.InterestingOnly = opts.Get(k).ToBoolean()
+			case "root":
+				v := opts.Get(k)
+				if !gojaValueExists(v) {
+					continue
+				}
+				root, ok := v.Export().(*ElementHandle)
+				if !ok {
+					return fmt.Errorf("root must be an ElementHandle, got %T", v.Export())
+				}
+				o.Root = root
+			}
+		}
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package common
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -32,3 +33,18 @@ func TestPageLocator(t *testing.T) {
 
 	// other behavior will be tested via integration tests
 }
+
+func TestPageWaitRoutedDeadline(t *testing.T) {
+	t.Parallel()
+
+	// the deadline of the context is earlier than the timeout, e.g. when
+	// a navigation already waited for part of its timeout.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	p := &Page{}
+	start := time.Now()
+	_, err := p.waitRouted(ctx, make(chan interface{}), time.Minute)
+	assert.ErrorIs(t, err, ErrTimedOut)
+	assert.Less(t, time.Since(start), 5*time.Second, "should stop at the deadline of the context")
+}
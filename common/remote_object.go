@@ -26,7 +26,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
-	"strconv"
+	"math/big"
 	"strings"
 
 	"github.com/grafana/xk6-browser/k6ext"
@@ -84,7 +84,7 @@ func parseRemoteObjectValue(t cdpruntime.Type, val string, op *cdpruntime.Object
 	case cdpruntime.TypeAccessor:
 		return "accessor", nil
 	case cdpruntime.TypeBigint:
-		n, err := strconv.ParseInt(strings.Replace(val, "n", "", -1), 10, 64)
+		n, err := parseBigInt(cdpruntime.UnserializableValue(val))
 		if err != nil {
 			return nil, BigIntParseError{err}
 		}
@@ -137,6 +137,14 @@ func parseRemoteObject(obj *cdpruntime.RemoteObject) (interface{}, error) {
 		return parseRemoteObjectValue(obj.Type, string(obj.Value), obj.Preview)
 	}
 
+	if obj.Type == cdpruntime.TypeBigint {
+		n, err := parseBigInt(obj.UnserializableValue)
+		if err != nil {
+			return nil, err
+		}
+		return n, nil
+	}
+
 	switch obj.UnserializableValue.String() {
 	case "-0": // To handle +0 divided by negative number
 		return math.Float64frombits(0 | (1 << 63)), nil
@@ -151,6 +159,34 @@ func parseRemoteObject(obj *cdpruntime.RemoteObject) (interface{}, error) {
 	return nil, UnserializableValueError{obj.UnserializableValue}
 }
 
+// parseBigInt returns the decimal digits of a BigInt, like "123" for the
+// unserializable value "123n". BigInts are returned as strings since they
+// can exceed the precision of a JS number, e.g. 64-bit IDs.
+func parseBigInt(v cdpruntime.UnserializableValue) (string, error) {
+	var n big.Int
+	if _, ok := n.SetString(strings.TrimSuffix(v.String(), "n"), 10); !ok {
+		return "", UnserializableValueError{v}
+	}
+	return n.String(), nil
+}
+
+// nonSerializableValueType returns a description of the remote object's type
+// if it was returned by value but couldn't be serialized by the browser.
+func nonSerializableValueType(robj *cdpruntime.RemoteObject) (string, bool) {
+	switch {
+	case robj.Type == cdpruntime.TypeFunction:
+		return robj.Type.String(), true
+	case robj.Type == cdpruntime.TypeSymbol:
+		return robj.Type.String(), true
+	case robj.Type == cdpruntime.TypeObject && robj.Subtype == cdpruntime.SubtypeNode:
+		if robj.ClassName != "" {
+			return robj.ClassName, true
+		}
+		return robj.Subtype.String(), true
+	}
+	return "", false
+}
+
 func valueFromRemoteObject(ctx context.Context, robj *cdpruntime.RemoteObject) (goja.Value, error) {
 	val, err := parseRemoteObject(robj)
 	if val == "undefined" {
@@ -64,6 +64,19 @@ func TestValueFromRemoteObject(t *testing.T) {
 		assert.ErrorIs(t, UnserializableValueError{unserializableValue}, err)
 	})
 
+	t.Run("bigint values", func(t *testing.T) {
+		vu := k6test.NewVU(t)
+		for _, v := range []string{"0", "100", "-9007199254740993", "18446744073709551616"} {
+			remoteObject := &runtime.RemoteObject{
+				Type:                "bigint",
+				UnserializableValue: runtime.UnserializableValue(v + "n"),
+			}
+			arg, err := valueFromRemoteObject(vu.Context(), remoteObject)
+			require.NoError(t, err)
+			assert.Equal(t, v, arg.Export())
+		}
+	})
+
 	t.Run("float64 unserializable values", func(t *testing.T) {
 		vu := k6test.NewVU(t)
 		unserializableValues := []struct {
@@ -167,6 +180,60 @@ func TestValueFromRemoteObject(t *testing.T) {
 	})
 }
 
+func TestNonSerializableValueType(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		robj   *runtime.RemoteObject
+		wantOK bool
+		want   string
+	}{
+		{
+			name:   "function",
+			robj:   &runtime.RemoteObject{Type: runtime.TypeFunction},
+			wantOK: true,
+			want:   "function",
+		},
+		{
+			name: "node",
+			robj: &runtime.RemoteObject{
+				Type: runtime.TypeObject, Subtype: runtime.SubtypeNode, ClassName: "HTMLDivElement",
+			},
+			wantOK: true,
+			want:   "HTMLDivElement",
+		},
+		{
+			name:   "node_without_class_name",
+			robj:   &runtime.RemoteObject{Type: runtime.TypeObject, Subtype: runtime.SubtypeNode},
+			wantOK: true,
+			want:   "node",
+		},
+		{
+			name: "plain_object",
+			robj: &runtime.RemoteObject{Type: runtime.TypeObject, Value: []byte(`{"a":1}`)},
+		},
+		{
+			name: "number",
+			robj: &runtime.RemoteObject{Type: runtime.TypeNumber, Value: []byte(`1`)},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			typ, ok := nonSerializableValueType(tt.robj)
+			require.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, typ)
+		})
+	}
+
+	err := NonSerializableValueError{Type: "function"}
+	assert.ErrorIs(t, err, NonSerializableValueError{})
+	assert.Contains(t, err.Error(), "function")
+}
+
 func TestParseRemoteObject(t *testing.T) {
 	t.Parallel()
 
@@ -183,6 +250,7 @@ func TestParseRemoteObject(t *testing.T) {
 				Properties: []*runtime.PropertyPreview{
 					{Name: "accessor", Type: runtime.TypeAccessor, Value: ""},
 					{Name: "bigint", Type: runtime.TypeBigint, Value: "100n"},
+					{Name: "bigbigint", Type: runtime.TypeBigint, Value: "18446744073709551616n"},
 					{Name: "bool", Type: runtime.TypeBoolean, Value: "true"},
 					{Name: "fn", Type: runtime.TypeFunction, Value: ""},
 					{Name: "num", Type: runtime.TypeNumber, Value: "1"},
@@ -192,14 +260,15 @@ func TestParseRemoteObject(t *testing.T) {
 				},
 			},
 			expected: map[string]interface{}{
-				"accessor": "accessor",
-				"bigint":   int64(100),
-				"bool":     true,
-				"fn":       "function()",
-				"num":      float64(1),
-				"str":      "string",
-				"strquot":  "quoted string",
-				"sym":      "Symbol()",
+				"accessor":  "accessor",
+				"bigint":    "100",
+				"bigbigint": "18446744073709551616",
+				"bool":      true,
+				"fn":        "function()",
+				"num":       float64(1),
+				"str":       "string",
+				"strquot":   "quoted string",
+				"sym":       "Symbol()",
 			},
 		},
 		{
@@ -47,6 +47,7 @@ type Request struct {
 	frame               *Frame
 	response            *Response
 	redirectChain       []*Request
+	redirectedTo        *Request
 	requestID           network.RequestID
 	documentID          string
 	url                 *url.URL
@@ -62,6 +63,8 @@ type Request struct {
 	timestamp           time.Time
 	wallTime            time.Time
 	responseEndTiming   float64
+	responseEndTime     time.Time
+	transferSize        int64
 	vu                  k6modules.VU
 }
 
@@ -224,14 +227,22 @@ func (r *Request) PostDataJSON() string {
 	return ""
 }
 
+// RedirectedFrom returns the request that was redirected to this one,
+// or nil if this request isn't the result of a redirect.
 func (r *Request) RedirectedFrom() api.Request {
-	k6ext.Panic(r.ctx, "Request.redirectedFrom() has not been implemented yet")
-	return nil
+	if len(r.redirectChain) == 0 {
+		return nil
+	}
+	return r.redirectChain[len(r.redirectChain)-1]
 }
 
+// RedirectedTo returns the request that this one was redirected to,
+// or nil if the server didn't redirect it.
 func (r *Request) RedirectedTo() api.Request {
-	k6ext.Panic(r.ctx, "Request.redirectedTo() has not been implemented yet")
-	return nil
+	if r.redirectedTo == nil {
+		return nil
+	}
+	return r.redirectedTo
 }
 
 // ResourceType returns the request resource type.
@@ -253,6 +264,10 @@ func (r *Request) Size() api.HTTPMessageSize {
 
 func (r *Request) Timing() goja.Value {
 	rt := r.vu.Runtime()
+	if r.response == nil || r.response.timing == nil {
+		// the timing is only known once the response is received.
+		return goja.Null()
+	}
 	timing := r.response.timing
 	return rt.ToValue(&ResourceTiming{
 		StartTime:             (timing.RequestTime - float64(r.timestamp.Unix()) + float64(r.wallTime.Unix())) * 1000,
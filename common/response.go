@@ -23,6 +23,7 @@ package common
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -34,6 +35,7 @@ import (
 
 	k6modules "go.k6.io/k6/js/modules"
 
+	"github.com/chromedp/cdproto"
 	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/cdproto/network"
 	"github.com/dop251/goja"
@@ -78,6 +80,8 @@ type Response struct {
 	timestamp         time.Time
 	responseTime      time.Time
 	timing            *network.ResourceTiming
+	headersLength     int64
+	navigationTiming  *api.NavigationTiming
 	vu                k6modules.VU
 
 	cachedJSON interface{}
@@ -107,6 +111,7 @@ func NewHTTPResponse(ctx context.Context, req *Request, resp *network.Response,
 		timestamp:         timestamp.Time(),
 		responseTime:      time.Time{},
 		timing:            resp.Timing,
+		headersLength:     int64(resp.EncodedDataLength),
 		vu:                vu,
 	}
 
@@ -149,8 +154,15 @@ func (r *Response) fetchBody() error {
 	if cached() {
 		return nil
 	}
+	if r.status >= 300 && r.status <= 399 {
+		return errors.New("response body is unavailable for redirect responses")
+	}
 	action := network.GetResponseBody(r.request.requestID)
 	body, err := action.Do(cdp.WithExecutor(r.ctx, r.request.frame.manager.session))
+	var cdpe *cdproto.Error
+	if errors.As(err, &cdpe) && isEvictedBodyCDPError(cdpe) {
+		return ErrResponseBodyUnavailable
+	}
 	if err != nil {
 		return fmt.Errorf("fetching response body: %w", err)
 	}
@@ -160,6 +172,13 @@ func (r *Response) fetchBody() error {
 	return nil
 }
 
+// isEvictedBodyCDPError returns true if the browser no longer holds the
+// response body, e.g. after it was evicted from the network buffer.
+func isEvictedBodyCDPError(err *cdproto.Error) bool {
+	return strings.Contains(err.Message, "No resource with given identifier found") ||
+		strings.Contains(err.Message, "No data found for resource with given identifier")
+}
+
 func (r *Response) headersSize() int64 {
 	size := 4 // 4 = 2 spaces + 2 line breaks (HTTP/1.1 200 OK\r\n)
 	size += 8 // httpVersion
@@ -183,9 +202,6 @@ func (r *Response) AllHeaders() map[string]string {
 
 // Body returns the response body as a binary buffer.
 func (r *Response) Body() goja.ArrayBuffer {
-	if r.status >= 300 && r.status <= 399 {
-		k6ext.Panic(r.ctx, "Response body is unavailable for redirect responses")
-	}
 	if err := r.fetchBody(); err != nil {
 		k6ext.Panic(r.ctx, "getting response body: %w", err)
 	}
@@ -300,6 +316,36 @@ func (r *Response) Ok() bool {
 	return false
 }
 
+// NavigationTiming returns the timing of the navigation that resulted in
+// this response, or null if the response isn't the result of a navigation
+// started by Frame.goto.
+func (r *Response) NavigationTiming() goja.Value {
+	rt := r.vu.Runtime()
+	return rt.ToValue(r.navigationTiming)
+}
+
+// setNavigationTiming records the timing of the navigation that resulted
+// in this response.
+func (r *Response) setNavigationTiming(start, end time.Time) {
+	r.navigationTiming = &api.NavigationTiming{
+		StartTime: float64(start.UnixNano()) / float64(time.Millisecond),
+		EndTime:   float64(end.UnixNano()) / float64(time.Millisecond),
+		Duration:  float64(end.Sub(start)) / float64(time.Millisecond),
+	}
+}
+
+// RedirectChain returns the URLs of the requests that were redirected to
+// this response, in order, followed by the URL of the response itself.
+func (r *Response) RedirectChain() []string {
+	var urls []string
+	if r.request != nil {
+		for _, req := range r.request.redirectChain {
+			urls = append(urls, req.URL())
+		}
+	}
+	return append(urls, r.url)
+}
+
 // Request returns the request that led to this response.
 func (r *Response) Request() api.Request {
 	return r.request
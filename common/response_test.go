@@ -0,0 +1,87 @@
+/*
+ *
+ * xk6-browser - a browser automation extension for k6
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package common
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/chromedp/cdproto"
+	"github.com/chromedp/cdproto/network"
+	"github.com/mailru/easyjson"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// errSession is a session that fails all the CDP calls with err.
+type errSession struct {
+	session
+	err   error
+	calls int
+}
+
+func (s *errSession) Execute(context.Context, string, easyjson.Marshaler, easyjson.Unmarshaler) error {
+	s.calls++
+	return s.err
+}
+
+func TestResponseFetchBody(t *testing.T) {
+	t.Parallel()
+
+	newResponse := func(status int64, err error) (*Response, *errSession) {
+		s := &errSession{err: err}
+		req := &Request{
+			requestID: network.RequestID("1234"),
+			frame:     &Frame{manager: &FrameManager{session: s}},
+		}
+		return &Response{ctx: context.Background(), request: req, status: status}, s
+	}
+
+	t.Run("evicted", func(t *testing.T) {
+		t.Parallel()
+
+		for _, msg := range []string{
+			"No resource with given identifier found",
+			"No data found for resource with given identifier",
+		} {
+			r, _ := newResponse(200, &cdproto.Error{Code: -32000, Message: msg})
+			assert.ErrorIs(t, r.fetchBody(), ErrResponseBodyUnavailable, msg)
+		}
+	})
+
+	t.Run("other_error", func(t *testing.T) {
+		t.Parallel()
+
+		r, _ := newResponse(200, &cdproto.Error{Code: -32000, Message: "Target closed"})
+		err := r.fetchBody()
+		require.Error(t, err)
+		assert.False(t, errors.Is(err, ErrResponseBodyUnavailable))
+	})
+
+	t.Run("redirect", func(t *testing.T) {
+		t.Parallel()
+
+		r, s := newResponse(302, nil)
+		require.Error(t, r.fetchBody())
+		assert.Zero(t, s.calls, "should not fetch the body of a redirect")
+	})
+}
@@ -0,0 +1,338 @@
+/*
+ *
+ * xk6-browser - a browser automation extension for k6
+ * Copyright (C) 2022 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package common
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/xk6-browser/api"
+	"github.com/grafana/xk6-browser/k6ext"
+	"github.com/grafana/xk6-browser/log"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/dop251/goja"
+)
+
+// Ensure Route implements the api.Route interface.
+var _ api.Route = &Route{}
+
+// errRouteHandled is returned when a route is continued, fulfilled or aborted twice.
+var errRouteHandled = errors.New("route is already handled")
+
+// Route is an intercepted request that waits to be continued, fulfilled or
+// aborted by a route handler.
+type Route struct {
+	ctx       context.Context
+	logger    *log.Logger
+	session   session
+	requestID fetch.RequestID
+	request   *Request
+	handled   bool
+}
+
+// NewRoute creates a new route for the paused request.
+func NewRoute(
+	ctx context.Context, s session, requestID fetch.RequestID, req *Request, l *log.Logger,
+) *Route {
+	return &Route{
+		ctx:       ctx,
+		logger:    l,
+		session:   s,
+		requestID: requestID,
+		request:   req,
+	}
+}
+
+var errorReasons = map[string]network.ErrorReason{
+	"aborted":              network.ErrorReasonAborted,
+	"accessdenied":         network.ErrorReasonAccessDenied,
+	"addressunreachable":   network.ErrorReasonAddressUnreachable,
+	"blockedbyclient":      network.ErrorReasonBlockedByClient,
+	"blockedbyresponse":    network.ErrorReasonBlockedByResponse,
+	"connectionaborted":    network.ErrorReasonConnectionAborted,
+	"connectionclosed":     network.ErrorReasonConnectionClosed,
+	"connectionfailed":     network.ErrorReasonConnectionFailed,
+	"connectionrefused":    network.ErrorReasonConnectionRefused,
+	"connectionreset":      network.ErrorReasonConnectionReset,
+	"internetdisconnected": network.ErrorReasonInternetDisconnected,
+	"namenotresolved":      network.ErrorReasonNameNotResolved,
+	"timedout":             network.ErrorReasonTimedOut,
+	"failed":               network.ErrorReasonFailed,
+}
+
+// Abort fails the request with the given error code, "failed" by default.
+func (r *Route) Abort(errorCode string) {
+	r.logger.Debugf("Route:Abort", "rid:%s url:%q code:%q", r.requestID, r.request.URL(), errorCode)
+
+	if err := r.abort(errorCode); err != nil {
+		k6ext.Panic(r.ctx, "aborting request %q: %w", r.request.URL(), err)
+	}
+}
+
+func (r *Route) abort(errorCode string) error {
+	if r.handled {
+		return errRouteHandled
+	}
+	if errorCode == "" {
+		errorCode = "failed"
+	}
+	reason, ok := errorReasons[strings.ToLower(errorCode)]
+	if !ok {
+		return fmt.Errorf("unknown error code %q", errorCode)
+	}
+	r.handled = true
+
+	action := fetch.FailRequest(r.requestID, reason)
+	if err := action.Do(cdp.WithExecutor(r.ctx, r.session)); err != nil {
+		return fmt.Errorf("failing request: %w", err)
+	}
+	return nil
+}
+
+// Continue sends the request to the network with optional overrides.
+func (r *Route) Continue(opts goja.Value) {
+	r.logger.Debugf("Route:Continue", "rid:%s url:%q", r.requestID, r.request.URL())
+
+	copts := NewRouteContinueOptions()
+	if err := copts.Parse(r.ctx, opts); err != nil {
+		k6ext.Panic(r.ctx, "parsing continue options: %w", err)
+	}
+	if err := r.continueRequest(copts); err != nil {
+		k6ext.Panic(r.ctx, "continuing request %q: %w", r.request.URL(), err)
+	}
+}
+
+func (r *Route) continueRequest(opts *RouteContinueOptions) error {
+	if r.handled {
+		return errRouteHandled
+	}
+	r.handled = true
+
+	action := fetch.ContinueRequest(r.requestID)
+	if opts.URL != "" {
+		action = action.WithURL(opts.URL)
+	}
+	if opts.Method != "" {
+		action = action.WithMethod(opts.Method)
+	}
+	if len(opts.Headers) > 0 {
+		action = action.WithHeaders(toHeaderEntries(opts.Headers))
+	}
+	if opts.PostData != "" {
+		action = action.WithPostData(base64.StdEncoding.EncodeToString([]byte(opts.PostData)))
+	}
+	if err := action.Do(cdp.WithExecutor(r.ctx, r.session)); err != nil {
+		return fmt.Errorf("continuing request: %w", err)
+	}
+	return nil
+}
+
+// Fulfill responds to the request with the given response
+// without sending it to the network.
+func (r *Route) Fulfill(opts goja.Value) {
+	r.logger.Debugf("Route:Fulfill", "rid:%s url:%q", r.requestID, r.request.URL())
+
+	fopts := NewRouteFulfillOptions()
+	if err := fopts.Parse(r.ctx, opts); err != nil {
+		k6ext.Panic(r.ctx, "parsing fulfill options: %w", err)
+	}
+	if err := r.fulfill(fopts); err != nil {
+		k6ext.Panic(r.ctx, "fulfilling request %q: %w", r.request.URL(), err)
+	}
+}
+
+func (r *Route) fulfill(opts *RouteFulfillOptions) error {
+	if r.handled {
+		return errRouteHandled
+	}
+	r.handled = true
+
+	action := fetch.FulfillRequest(r.requestID, opts.Status).
+		WithResponseHeaders(toHeaderEntries(fulfillHeaders(opts))).
+		WithBody(base64.StdEncoding.EncodeToString([]byte(opts.Body)))
+	if text := http.StatusText(int(opts.Status)); text != "" {
+		action = action.WithResponsePhrase(text)
+	}
+	if err := action.Do(cdp.WithExecutor(r.ctx, r.session)); err != nil {
+		return fmt.Errorf("fulfilling request: %w", err)
+	}
+	return nil
+}
+
+// fulfillHeaders returns the response headers of a fulfilled request.
+// The header names are case-insensitive, so the contentType option replaces
+// a Content-Type header of any case, and an explicit Content-Length header
+// is kept as is.
+func fulfillHeaders(opts *RouteFulfillOptions) map[string]string {
+	headers := make(map[string]string, len(opts.Headers)+2)
+	var hasContentLength bool
+	for k, v := range opts.Headers {
+		switch {
+		case strings.EqualFold(k, "Content-Type") && opts.ContentType != "":
+			continue
+		case strings.EqualFold(k, "Content-Length"):
+			hasContentLength = true
+		}
+		headers[k] = v
+	}
+	if opts.ContentType != "" {
+		headers["Content-Type"] = opts.ContentType
+	}
+	if !hasContentLength {
+		headers["Content-Length"] = strconv.Itoa(len(opts.Body))
+	}
+	return headers
+}
+
+// Request returns the intercepted request.
+func (r *Route) Request() api.Request {
+	return r.request
+}
+
+func toHeaderEntries(headers map[string]string) []*fetch.HeaderEntry {
+	entries := make([]*fetch.HeaderEntry, 0, len(headers))
+	for k, v := range headers {
+		entries = append(entries, &fetch.HeaderEntry{Name: k, Value: v})
+	}
+	return entries
+}
+
+// routeHandler is a handler registered with Page.Route that
+// handles the requests with a matching URL.
+type routeHandler struct {
+	url     goja.Value
+	handler goja.Value
+	fn      goja.Callable
+	matches func(url string) bool
+}
+
+func newRouteHandler(rt *goja.Runtime, url, handler goja.Value) (*routeHandler, error) {
+	fn, ok := goja.AssertFunction(handler)
+	if !ok {
+		return nil, errors.New("handler must be a function")
+	}
+	matches, err := urlMatcher(rt, url)
+	if err != nil {
+		return nil, err
+	}
+	return &routeHandler{
+		url:     url,
+		handler: handler,
+		fn:      fn,
+		matches: matches,
+	}, nil
+}
+
+// equals returns true if the handler was registered with the given URL and,
+// if it's given, the handler function.
+func (h *routeHandler) equals(url, handler goja.Value) bool {
+	if !h.url.StrictEquals(url) && h.url.String() != url.String() {
+		return false
+	}
+	return !gojaValueExists(handler) || h.handler.StrictEquals(handler)
+}
+
+// urlMatcher returns a function that matches URLs against the given
+// glob pattern string or RegExp.
+func urlMatcher(rt *goja.Runtime, url goja.Value) (func(string) bool, error) {
+	if !gojaValueExists(url) {
+		return nil, errors.New("url must be a glob pattern or a RegExp")
+	}
+	if obj, ok := url.(*goja.Object); ok && obj.ClassName() == "RegExp" {
+		expr := obj.Get("source").String()
+		if strings.Contains(obj.Get("flags").String(), "i") {
+			expr = "(?i)" + expr
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("compiling RegExp %s: %w", url, err)
+		}
+		return re.MatchString, nil
+	}
+	re, err := regexp.Compile(globToRegexp(url.String()))
+	if err != nil {
+		return nil, fmt.Errorf("compiling glob %q: %w", url, err)
+	}
+	return re.MatchString, nil
+}
+
+// globToRegexp converts a URL glob pattern to a regular expression, where
+// ** matches any characters including /, * matches any characters except /,
+// ? matches a single character and {a,b} matches any of the alternatives.
+// Based on: https://github.com/microsoft/playwright/blob/main/packages/playwright-core/src/utils/glob.ts
+func globToRegexp(glob string) string {
+	var (
+		tokens  strings.Builder
+		inGroup bool
+	)
+	tokens.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case c == '\\' && i+1 < len(glob):
+			i++
+			tokens.WriteString(regexp.QuoteMeta(string(glob[i])))
+		case c == '*':
+			var before, after byte
+			if i > 0 {
+				before = glob[i-1]
+			}
+			stars := 1
+			for i+1 < len(glob) && glob[i+1] == '*' {
+				stars++
+				i++
+			}
+			if i+1 < len(glob) {
+				after = glob[i+1]
+			}
+			deep := stars > 1 && (before == '/' || before == 0) && (after == '/' || after == 0)
+			if deep {
+				tokens.WriteString("((?:[^/]*(?:/|$))*)")
+				i++ // skip the slash after the **
+			} else {
+				tokens.WriteString("([^/]*)")
+			}
+		case c == '?':
+			tokens.WriteString(".")
+		case c == '{':
+			inGroup = true
+			tokens.WriteString("(")
+		case c == '}':
+			inGroup = false
+			tokens.WriteString(")")
+		case c == ',' && inGroup:
+			tokens.WriteString("|")
+		default:
+			tokens.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	tokens.WriteString("$")
+	return tokens.String()
+}
@@ -0,0 +1,112 @@
+/*
+ *
+ * xk6-browser - a browser automation extension for k6
+ * Copyright (C) 2022 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package common
+
+import (
+	"context"
+
+	"github.com/grafana/xk6-browser/k6ext"
+
+	"github.com/dop251/goja"
+)
+
+// RouteContinueOptions are the request overrides of Route.Continue.
+type RouteContinueOptions struct {
+	URL      string            `json:"url"`
+	Method   string            `json:"method"`
+	Headers  map[string]string `json:"headers"`
+	PostData string            `json:"postData"`
+}
+
+// RouteFulfillOptions describe the response of Route.Fulfill.
+type RouteFulfillOptions struct {
+	Status      int64             `json:"status"`
+	Headers     map[string]string `json:"headers"`
+	ContentType string            `json:"contentType"`
+	Body        string            `json:"body"`
+}
+
+// NewRouteContinueOptions returns the default Route.Continue options.
+func NewRouteContinueOptions() *RouteContinueOptions {
+	return &RouteContinueOptions{}
+}
+
+// Parse parses the Route.Continue options from a goja value.
+func (o *RouteContinueOptions) Parse(ctx context.Context, opts goja.Value) error {
+	rt := k6ext.Runtime(ctx)
+	if opts != nil && !goja.IsUndefined(opts) && !goja.IsNull(opts) {
+		opts := opts.ToObject(rt)
+		for _, k := range opts.Keys() {
+			switch k {
+			case "url":
+				o.URL = opts.Get(k).String()
+			case "method":
+				o.Method = opts.Get(k).String()
+			case "headers":
+				o.Headers = parseStringMap(rt, opts.Get(k))
+			case "postData":
+				o.PostData = opts.Get(k).String()
+			}
+		}
+	}
+	return nil
+}
+
+// NewRouteFulfillOptions returns the default Route.Fulfill options.
+func NewRouteFulfillOptions() *RouteFulfillOptions {
+	return &RouteFulfillOptions{
+		Status:  200,
+		Headers: make(map[string]string),
+	}
+}
+
+// Parse parses the Route.Fulfill options from a goja value.
+func (o *RouteFulfillOptions) Parse(ctx context.Context, opts goja.Value) error {
+	rt := k6ext.Runtime(ctx)
+	if opts != nil && !goja.IsUndefined(opts) && !goja.IsNull(opts) {
+		opts := opts.ToObject(rt)
+		for _, k := range opts.Keys() {
+			switch k {
+			case "status":
+				o.Status = opts.Get(k).ToInteger()
+			case "headers":
+				o.Headers = parseStringMap(rt, opts.Get(k))
+			case "contentType":
+				o.ContentType = opts.Get(k).String()
+			case "body":
+				o.Body = opts.Get(k).String()
+			}
+		}
+	}
+	return nil
+}
+
+func parseStringMap(rt *goja.Runtime, v goja.Value) map[string]string {
+	m := make(map[string]string)
+	if !gojaValueExists(v) {
+		return m
+	}
+	obj := v.ToObject(rt)
+	for _, k := range obj.Keys() {
+		m[k] = obj.Get(k).String()
+	}
+	return m
+}
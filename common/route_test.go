@@ -0,0 +1,114 @@
+/*
+ *
+ * xk6-browser - a browser automation extension for k6
+ * Copyright (C) 2022 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package common
+
+import (
+	"testing"
+
+	"github.com/dop251/goja"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLMatcher(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pattern string
+		url     string
+		want    bool
+	}{
+		{"**/*.js", "https://localhost:8080/foo.js", true},
+		{"**/*.js", "https://localhost:8080/foo.css", false},
+		{"https://localhost:8080/api/*", "https://localhost:8080/api/users", true},
+		{"https://localhost:8080/api/*", "https://localhost:8080/api/users/1", false},
+		{"https://localhost:8080/api/**", "https://localhost:8080/api/users/1", true},
+		{"**/*.{png,jpg}", "https://localhost:8080/a/b.jpg", true},
+		{"**/*.{png,jpg}", "https://localhost:8080/a/b.gif", false},
+		{"**/image?.png", "https://localhost:8080/image1.png", true},
+		{"https://localhost:8080/search?q=1", "https://localhost:8080/searchXq=1", true},
+		{"**/a+b(c).html", "https://localhost:8080/a+b(c).html", true},
+	}
+	rt := goja.New()
+	for _, tt := range tests {
+		matches, err := urlMatcher(rt, rt.ToValue(tt.pattern))
+		require.NoError(t, err)
+		assert.Equalf(t, tt.want, matches(tt.url), "%q should match %q: %t", tt.pattern, tt.url, tt.want)
+	}
+
+	t.Run("regexp", func(t *testing.T) {
+		t.Parallel()
+
+		rt := goja.New()
+		re, err := rt.RunString(`/API\/users\/\d+$/i`)
+		require.NoError(t, err)
+		matches, err := urlMatcher(rt, re)
+		require.NoError(t, err)
+		assert.True(t, matches("https://localhost:8080/api/users/42"))
+		assert.False(t, matches("https://localhost:8080/api/users/42/posts"))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+
+		rt := goja.New()
+		_, err := urlMatcher(rt, goja.Undefined())
+		assert.Error(t, err)
+	})
+}
+
+func TestFulfillHeaders(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		opts *RouteFulfillOptions
+		want map[string]string
+	}{
+		{
+			name: "defaults",
+			opts: &RouteFulfillOptions{Body: "hello"},
+			want: map[string]string{"Content-Length": "5"},
+		},
+		{
+			name: "content_type",
+			opts: &RouteFulfillOptions{
+				Headers:     map[string]string{"content-type": "text/plain", "X-Id": "1"},
+				ContentType: "application/json",
+				Body:        "{}",
+			},
+			want: map[string]string{"Content-Type": "application/json", "X-Id": "1", "Content-Length": "2"},
+		},
+		{
+			name: "content_type_header",
+			opts: &RouteFulfillOptions{Headers: map[string]string{"content-type": "text/plain"}},
+			want: map[string]string{"content-type": "text/plain", "Content-Length": "0"},
+		},
+		{
+			name: "content_length",
+			opts: &RouteFulfillOptions{Headers: map[string]string{"content-length": "10"}, Body: "hello"},
+			want: map[string]string{"content-length": "10"},
+		},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, fulfillHeaders(tt.opts), tt.name)
+	}
+}
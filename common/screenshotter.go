@@ -29,6 +29,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/cdproto/emulation"
@@ -210,8 +211,26 @@ func (s *screenshotter) screenshot(
 	return &buf, nil
 }
 
-func (s *screenshotter) screenshotElement(h *ElementHandle, opts *ElementHandleScreenshotOptions) (*[]byte, error) {
+func (s *screenshotter) screenshotElement(
+	h *ElementHandle, opts *ElementHandleScreenshotOptions,
+) (_ *[]byte, rerr error) {
 	format := opts.Format
+	if opts.WaitForFonts {
+		if err := s.waitForFonts(h.frame, opts.Timeout); err != nil {
+			return nil, err
+		}
+	}
+	if opts.DisableAnimations {
+		enable, err := s.disableAnimations(h.frame, opts.Timeout)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			if err := enable(); err != nil && rerr == nil {
+				rerr = err
+			}
+		}()
+	}
 	viewportSize, originalViewportSize, err := s.originalViewportSize(h.frame.page)
 	if err != nil {
 		return nil, fmt.Errorf("getting original viewport size: %w", err)
@@ -281,8 +300,24 @@ func (s *screenshotter) screenshotElement(h *ElementHandle, opts *ElementHandleS
 	return buf, nil
 }
 
-func (s *screenshotter) screenshotPage(p *Page, opts *PageScreenshotOptions) (*[]byte, error) {
+func (s *screenshotter) screenshotPage(p *Page, opts *PageScreenshotOptions) (_ *[]byte, rerr error) {
 	format := opts.Format
+	if opts.WaitForFonts {
+		if err := s.waitForFonts(p.frameManager.MainFrame(), p.defaultTimeout()); err != nil {
+			return nil, err
+		}
+	}
+	if opts.DisableAnimations {
+		enable, err := s.disableAnimations(p.frameManager.MainFrame(), p.defaultTimeout())
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			if err := enable(); err != nil && rerr == nil {
+				rerr = err
+			}
+		}()
+	}
 
 	// Infer file format by path
 	if opts.Path != "" && opts.Format != "png" && opts.Format != "jpeg" {
@@ -361,6 +396,49 @@ func (s *screenshotter) screenshotPage(p *Page, opts *PageScreenshotOptions) (*[
 	return s.screenshot(p.session, nil, viewportRect, format, opts.OmitBackground, opts.Quality, opts.Path)
 }
 
+// waitForFonts waits until the fonts of the frame's document are loaded,
+// so that the screenshot doesn't capture the fallback fonts.
+func (s *screenshotter) waitForFonts(f *Frame, timeout time.Duration) error {
+	if _, err := f.evaluateWithTimeout(timeout, `async () => { await document.fonts.ready; }`); err != nil {
+		return fmt.Errorf("waiting for fonts: %w", err)
+	}
+	return nil
+}
+
+// disableAnimationsCSS stops the CSS animations and transitions of a page
+// at their end, so that screenshots don't capture them halfway.
+const disableAnimationsCSS = `*, *::before, *::after {
+	animation-delay: 0s !important;
+	animation-duration: 0s !important;
+	transition-delay: 0s !important;
+	transition-duration: 0s !important;
+}`
+
+// disableAnimations adds a stylesheet that disables the CSS animations and
+// transitions of the frame. The returned function removes it again.
+func (s *screenshotter) disableAnimations(f *Frame, timeout time.Duration) (func() error, error) {
+	addFn := `async (css) => {
+		const style = document.createElement('style');
+		style.setAttribute('data-k6-browser-disable-animations', '');
+		style.textContent = css;
+		(document.head || document.documentElement).appendChild(style);
+		await new Promise(resolve => requestAnimationFrame(() => resolve()));
+	}`
+	removeFn := `() => {
+		document.querySelectorAll('style[data-k6-browser-disable-animations]').forEach(s => s.remove());
+	}`
+
+	if _, err := f.evaluateWithTimeout(timeout, addFn, disableAnimationsCSS); err != nil {
+		return nil, fmt.Errorf("disabling animations: %w", err)
+	}
+	return func() error {
+		if _, err := f.evaluateWithTimeout(timeout, removeFn); err != nil {
+			return fmt.Errorf("enabling animations: %w", err)
+		}
+		return nil
+	}, nil
+}
+
 func (s *screenshotter) trimClipToSize(clip *Rect, size *Size) (*Rect, error) {
 	p1 := Position{
 		X: math.Max(0, math.Min(clip.X, size.Width)),
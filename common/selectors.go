@@ -45,6 +45,13 @@ import (
 // Matches `name:body`, a query engine name and selector for that engine.
 var reQueryEngine *regexp.Regexp = regexp.MustCompile(`^[a-zA-Z_0-9-+:*]+$`)
 
+// queryEngines are the names of the selector engines. nth and visible
+// aren't engines but filter the elements matched by the previous part.
+var queryEngines = []string{"css", "id", "nth", "role", "text", "visible", "xpath"}
+
+// Matches an ARIA role, such as button or menuitemcheckbox.
+var reRole *regexp.Regexp = regexp.MustCompile(`^[a-z]+$`)
+
 // Matches start of XPath query.
 var reXPathSelector *regexp.Regexp = regexp.MustCompile(`^\(*//`)
 
@@ -116,6 +123,11 @@ func (s *Selector) parse() error {
 			capture = true
 			name = name[1:]
 		}
+		// a part that isn't prefixed with a known engine is a CSS selector,
+		// such as one with an attribute selector, e.g. a[href=x].
+		if !stringSliceContains(queryEngines, name) {
+			return &SelectorPart{Name: "css", Body: part}, false
+		}
 
 		return &SelectorPart{Name: name, Body: body}, capture
 	}
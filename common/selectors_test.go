@@ -0,0 +1,60 @@
+/*
+ *
+ * xk6-browser - a browser automation extension for k6
+ * Copyright (C) 2022 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSelector(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		selector string
+		parts    []*SelectorPart
+	}{
+		{"button", []*SelectorPart{{Name: "css", Body: "button"}}},
+		{"a[href=x]", []*SelectorPart{{Name: "css", Body: "a[href=x]"}}},
+		{"text=Submit", []*SelectorPart{{Name: "text", Body: "Submit"}}},
+		{`"Submit"`, []*SelectorPart{{Name: "text", Body: `"Submit"`}}},
+		{"xpath=//button", []*SelectorPart{{Name: "xpath", Body: "//button"}}},
+		{"//button", []*SelectorPart{{Name: "xpath", Body: "//button"}}},
+		{"id=submit", []*SelectorPart{{Name: "id", Body: "submit"}}},
+		{"foo=bar", []*SelectorPart{{Name: "css", Body: "foo=bar"}}},
+		{"form >> text=Submit", []*SelectorPart{
+			{Name: "css", Body: "form"},
+			{Name: "text", Body: "Submit"},
+		}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.selector, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := NewSelector(tt.selector)
+			require.NoError(t, err)
+			assert.Equal(t, tt.parts, s.Parts)
+		})
+	}
+}
@@ -122,6 +122,7 @@ func (s *Session) readLoop() {
 				s.logger.Debugf("Session:readLoop:<-s.readCh", "sid:%v tid:%v cannot unmarshal: %v", s.id, s.targetID, err)
 				continue
 			}
+			s.traceCDP("<-", msg)
 			s.emit(string(msg.Method), ev)
 		case <-s.done:
 			s.logger.Debugf("Session:readLoop:<-s.done", "sid:%v tid:%v", s.id, s.targetID)
@@ -188,6 +189,7 @@ func (s *Session) Execute(ctx context.Context, method string, params easyjson.Ma
 		Method:    cdproto.MethodType(method),
 		Params:    buf,
 	}
+	s.traceCDP("->", msg)
 	return s.conn.send(contextWithDoneChan(ctx, s.done), msg, ch, res)
 }
 
@@ -233,9 +235,25 @@ func (s *Session) ExecuteWithoutExpectationOnReply(ctx context.Context, method s
 		Method:    cdproto.MethodType(method),
 		Params:    buf,
 	}
+	s.traceCDP("->", msg)
 	return s.conn.send(contextWithDoneChan(ctx, s.done), msg, nil, res)
 }
 
+// traceCDP logs the method and the truncated params of a CDP command sent,
+// or an event received, by the session when CDP tracing is enabled.
+func (s *Session) traceCDP(direction string, msg *cdproto.Message) {
+	if !s.logger.CDPTraceMode() || msg.Method == "" {
+		return
+	}
+	const maxParamsLen = 256
+
+	params := string(msg.Params)
+	if len(params) > maxParamsLen {
+		params = params[:maxParamsLen] + "..."
+	}
+	s.logger.Infof("Session:cdp", "sid:%v tid:%v %s %s %s", s.id, s.targetID, direction, msg.Method, params)
+}
+
 // Done returns a channel that is closed when this session is closed.
 func (s *Session) Done() <-chan struct{} {
 	return s.done
@@ -24,6 +24,7 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/grafana/xk6-browser/log"
@@ -35,6 +36,7 @@ import (
 	"github.com/chromedp/cdproto/target"
 	"github.com/gorilla/websocket"
 	"github.com/mailru/easyjson"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -128,3 +130,25 @@ func TestSessionCreateSession(t *testing.T) {
 		}
 	})
 }
+
+func TestSessionTraceCDP(t *testing.T) {
+	t.Parallel()
+
+	logger, hook := logrustest.NewNullLogger()
+	s := &Session{id: "sid", targetID: "tid", logger: log.New(logger, false, nil)}
+	msg := &cdproto.Message{
+		Method: cdproto.MethodType(cdproto.CommandPageNavigate),
+		Params: easyjson.RawMessage(`{"url":"` + strings.Repeat("x", 300) + `"}`),
+	}
+
+	s.traceCDP("->", msg)
+	assert.Empty(t, hook.AllEntries(), "should not log without CDP tracing")
+
+	s.logger.TraceCDP()
+	s.traceCDP("->", msg)
+	s.traceCDP("<-", &cdproto.Message{ID: 1})
+	entries := hook.AllEntries()
+	require.Len(t, entries, 1, "should not log results without a method")
+	assert.Contains(t, entries[0].Message, `sid:sid tid:tid -> Page.navigate {"url":"xxx`)
+	assert.True(t, strings.HasSuffix(entries[0].Message, "x..."))
+}
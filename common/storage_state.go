@@ -0,0 +1,248 @@
+/*
+ *
+ * xk6-browser - a browser automation extension for k6
+ * Copyright (C) 2022 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/grafana/xk6-browser/api"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/storage"
+	"github.com/dop251/goja"
+)
+
+// originStorageScript returns the local storage of the frame's origin, or
+// null if the frame has no origin with a local storage, e.g. about:blank.
+const originStorageScript = `() => {
+	try {
+		if (location.origin === "null" || localStorage.length === 0) {
+			return null;
+		}
+		const localStorageItems = [];
+		for (let i = 0; i < localStorage.length; i++) {
+			const name = localStorage.key(i);
+			localStorageItems.push({ name, value: localStorage.getItem(name) });
+		}
+		return { origin: location.origin, localStorage: localStorageItems };
+	} catch (e) {
+		return null;
+	}
+}`
+
+// parseStorageState parses the storageState option of a browser context.
+// It's either the path to a file saved by BrowserContext.storageState,
+// or the value returned by it.
+func parseStorageState(v goja.Value) (*api.StorageState, error) {
+	if !gojaValueExists(v) {
+		return nil, nil //nolint:nilnil
+	}
+	var (
+		data []byte
+		err  error
+	)
+	switch e := v.Export().(type) {
+	case *api.StorageState:
+		return e, nil
+	case string:
+		if data, err = os.ReadFile(e); err != nil { //nolint:gosec
+			return nil, fmt.Errorf("reading %q: %w", e, err)
+		}
+	default:
+		if data, err = json.Marshal(e); err != nil {
+			return nil, fmt.Errorf("%w", err)
+		}
+	}
+	var state api.StorageState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+	return &state, nil
+}
+
+// saveStorageState saves the storage state to the path as JSON.
+func saveStorageState(state *api.StorageState, path string) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { //nolint:gomnd
+		return fmt.Errorf("creating the directory of %q: %w", path, err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil { //nolint:gomnd
+		return fmt.Errorf("writing %q: %w", path, err)
+	}
+	return nil
+}
+
+// setStorageState adds the cookies of the storage state to the browser
+// context, and keeps its local storage to set it when the pages first load
+// each of its origins. See storageStateScript.
+func (b *BrowserContext) setStorageState(state *api.StorageState) error {
+	if len(state.Cookies) > 0 {
+		params := make([]*network.CookieParam, 0, len(state.Cookies))
+		for _, c := range state.Cookies {
+			params = append(params, toCookieParam(c))
+		}
+		action := storage.SetCookies(params).WithBrowserContextID(b.id)
+		if err := action.Do(cdp.WithExecutor(b.ctx, b.browser.conn)); err != nil {
+			return fmt.Errorf("adding cookies: %w", err)
+		}
+	}
+	if len(state.Origins) == 0 {
+		return nil
+	}
+	b.storageStateMu.Lock()
+	defer b.storageStateMu.Unlock()
+	b.storageStateOrigins = make(map[string][]*api.StorageEntry, len(state.Origins))
+	for _, o := range state.Origins {
+		b.storageStateOrigins[o.Origin] = append(b.storageStateOrigins[o.Origin], o.LocalStorage...)
+	}
+
+	return nil
+}
+
+// storageStateSeededKey is the session storage item that marks the origins
+// whose local storage has been set from the storage state in a page.
+const storageStateSeededKey = "__xk6_browser_storage_state_seeded"
+
+// storageStateScript returns the script that sets the local storage of the
+// storage state when a page first loads one of its origins, or an empty
+// string if there's none left to set. Each origin is only set once, so a
+// page that clears its local storage doesn't get it back on a reload: the
+// script marks the origin in the session storage of the page, and the new
+// pages leave out the origins that any page has loaded.
+func (b *BrowserContext) storageStateScript() (string, error) {
+	b.storageStateMu.Lock()
+	defer b.storageStateMu.Unlock()
+
+	if len(b.storageStateOrigins) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(b.storageStateOrigins)
+	if err != nil {
+		return "", fmt.Errorf("%w", err)
+	}
+	// an origin whose local storage has items has already been set in
+	// another page, and its items are kept.
+	return fmt.Sprintf(`((origins, seededKey) => {
+		const items = origins[location.origin];
+		if (!items || sessionStorage.getItem(seededKey) || localStorage.length > 0) {
+			return;
+		}
+		sessionStorage.setItem(seededKey, "true");
+		for (const { name, value } of items) {
+			localStorage.setItem(name, value);
+		}
+	})(%s, %q);`, data, storageStateSeededKey), nil
+}
+
+// storageStateSeeded marks the local storage of the origin as set, since a
+// page has loaded it, so the new pages don't set it again.
+func (b *BrowserContext) storageStateSeeded(origin string) {
+	b.storageStateMu.Lock()
+	defer b.storageStateMu.Unlock()
+
+	delete(b.storageStateOrigins, origin)
+}
+
+// originStorages returns the local storage of the origins of the frames of
+// the open pages. The storage of the origins without open frames can't be
+// read.
+func (b *BrowserContext) originStorages() []*api.OriginStorage {
+	var (
+		origins []*api.OriginStorage
+		seen    = make(map[string]bool)
+	)
+	for _, p := range b.getPages() {
+		for _, f := range p.frameManager.Frames() {
+			o, err := f.(*Frame).originStorage()
+			if err != nil {
+				b.logger.Debugf("BrowserContext:originStorages", "bctxid:%v furl:%q err:%v", b.id, f.URL(), err)
+				continue
+			}
+			if o == nil || seen[o.Origin] {
+				continue
+			}
+			seen[o.Origin] = true
+			origins = append(origins, o)
+		}
+	}
+	return origins
+}
+
+// originStorage returns the local storage of the frame's origin, or nil if
+// the frame has none.
+func (f *Frame) originStorage() (*api.OriginStorage, error) {
+	ctx, cancel := context.WithTimeout(f.ctx, f.defaultTimeout())
+	defer cancel()
+
+	rt := f.vu.Runtime()
+	opts := evalOptions{forceCallable: true, returnByValue: true}
+	result, err := f.evaluate(ctx, utilityWorld, opts, rt.ToValue(originStorageScript))
+	if err != nil {
+		return nil, err
+	}
+	gv, ok := result.(goja.Value)
+	if !ok || !gojaValueExists(gv) {
+		return nil, nil //nolint:nilnil
+	}
+	data, err := json.Marshal(gv.Export())
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+	var o api.OriginStorage
+	if err := json.Unmarshal(data, &o); err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+	if o.Origin == "" {
+		return nil, errors.New("missing origin")
+	}
+	return &o, nil
+}
+
+// toCookieParam converts a cookie of a storage state to a CDP cookie.
+func toCookieParam(c *api.Cookie) *network.CookieParam {
+	p := network.CookieParam{
+		Name:     c.Name,
+		Value:    c.Value,
+		URL:      c.URL,
+		Domain:   c.Domain,
+		Path:     c.Path,
+		HTTPOnly: c.HTTPOnly,
+		Secure:   c.Secure,
+	}
+	// a negative expiry means a session cookie.
+	if c.Expires >= 0 {
+		p.Expires = cookieExpires(c.Expires)
+	}
+	switch ss := network.CookieSameSite(c.SameSite); ss {
+	case network.CookieSameSiteStrict, network.CookieSameSiteLax, network.CookieSameSiteNone:
+		p.SameSite = ss
+	}
+	return &p
+}
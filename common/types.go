@@ -23,15 +23,24 @@ package common
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"math"
+	"mime"
+	"net/url"
+	"path/filepath"
+	"reflect"
 	"sort"
 	"strings"
 
 	"github.com/grafana/xk6-browser/api"
 	"github.com/grafana/xk6-browser/k6ext"
 
+	"github.com/chromedp/cdproto/emulation"
+	cdpruntime "github.com/chromedp/cdproto/runtime"
 	"github.com/dop251/goja"
 )
 
@@ -81,12 +90,68 @@ func (c *ColorScheme) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// Contrast represents a browser contrast preference.
+type Contrast string
+
+// Valid contrast preferences.
+const (
+	ContrastMore         Contrast = "more"
+	ContrastLess         Contrast = "less"
+	ContrastCustom       Contrast = "custom"
+	ContrastNoPreference Contrast = "no-preference"
+)
+
+func (c Contrast) String() string {
+	return contrastToString[c]
+}
+
+var contrastToString = map[Contrast]string{
+	ContrastMore:         "more",
+	ContrastLess:         "less",
+	ContrastCustom:       "custom",
+	ContrastNoPreference: "no-preference",
+}
+
+var contrastToID = map[string]Contrast{
+	"more":          ContrastMore,
+	"less":          ContrastLess,
+	"custom":        ContrastCustom,
+	"no-preference": ContrastNoPreference,
+}
+
+// MarshalJSON marshals the enum as a quoted JSON string.
+func (c Contrast) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBufferString(`"`)
+	buffer.WriteString(contrastToString[c])
+	buffer.WriteString(`"`)
+	return buffer.Bytes(), nil
+}
+
+// UnmarshalJSON unmarshals a quoted JSON string to the enum value.
+func (c *Contrast) UnmarshalJSON(b []byte) error {
+	var j string
+	err := json.Unmarshal(b, &j)
+	if err != nil {
+		return err
+	}
+	// Note that if the string cannot be found then it will be set to the zero value.
+	*c = contrastToID[j]
+	return nil
+}
+
 // Credentials holds HTTP authentication credentials.
+// Credentials without an origin are used for any origin.
 type Credentials struct {
 	Username string `js:"username"`
 	Password string `js:"password"`
+	Origin   string `js:"origin"`
 }
 
+// HTTPCredentials holds HTTP authentication credentials by origin.
+// The credentials of the empty origin are used for the origins that
+// don't have their own credentials.
+type HTTPCredentials map[string]*Credentials
+
 // DOMElementState represents a DOM element state.
 type DOMElementState int
 
@@ -96,6 +161,9 @@ const (
 	DOMElementStateDetached
 	DOMElementStateVisible
 	DOMElementStateHidden
+	DOMElementStateEditable
+	DOMElementStateEnabled
+	DOMElementStateStable
 )
 
 func (s DOMElementState) String() string {
@@ -107,6 +175,9 @@ var domElementStateToString = map[DOMElementState]string{
 	DOMElementStateDetached: "detached",
 	DOMElementStateVisible:  "visible",
 	DOMElementStateHidden:   "hidden",
+	DOMElementStateEditable: "editable",
+	DOMElementStateEnabled:  "enabled",
+	DOMElementStateStable:   "stable",
 }
 
 var domElementStateToID = map[string]DOMElementState{
@@ -114,6 +185,9 @@ var domElementStateToID = map[string]DOMElementState{
 	"detached": DOMElementStateDetached,
 	"visible":  DOMElementStateVisible,
 	"hidden":   DOMElementStateHidden,
+	"editable": DOMElementStateEditable,
+	"enabled":  DOMElementStateEnabled,
+	"stable":   DOMElementStateStable,
 }
 
 // MarshalJSON marshals the enum as a quoted JSON string.
@@ -237,6 +311,84 @@ func (f *ImageFormat) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// InputFile is a file to set to a file input element.
+// Buffer is the base64 encoded content of the file.
+type InputFile struct {
+	Name     string `json:"name"`
+	MimeType string `json:"mimeType"`
+	Buffer   string `json:"buffer"`
+}
+
+// parseInputFiles parses a file or an array of files to set to a file input.
+// A file is either a path to read the file from, or an object with the name,
+// mimeType, and the base64 encoded buffer of the file.
+func parseInputFiles(ctx context.Context, files goja.Value) ([]*InputFile, error) {
+	if !gojaValueExists(files) {
+		return nil, nil
+	}
+	rt := k6ext.Runtime(ctx)
+	var items []goja.Value
+	if obj, ok := files.(*goja.Object); ok && obj.ClassName() == "Array" {
+		for _, k := range obj.Keys() {
+			items = append(items, obj.Get(k))
+		}
+	} else {
+		items = []goja.Value{files}
+	}
+
+	parsed := make([]*InputFile, 0, len(items))
+	for i, item := range items {
+		f, err := parseInputFile(rt, item)
+		if err != nil {
+			return nil, fmt.Errorf("files[%d]: %w", i, err)
+		}
+		parsed = append(parsed, f)
+	}
+	return parsed, nil
+}
+
+func parseInputFile(rt *goja.Runtime, v goja.Value) (*InputFile, error) {
+	obj, ok := v.(*goja.Object)
+	if !ok {
+		path := v.String()
+		b, err := ioutil.ReadFile(path) //nolint:gosec
+		if err != nil {
+			return nil, fmt.Errorf("reading file: %w", err)
+		}
+		return &InputFile{
+			Name:     filepath.Base(path),
+			MimeType: mime.TypeByExtension(filepath.Ext(path)),
+			Buffer:   base64.StdEncoding.EncodeToString(b),
+		}, nil
+	}
+
+	f := InputFile{}
+	for _, k := range obj.Keys() {
+		switch k {
+		case "name":
+			f.Name = obj.Get(k).String()
+		case "mimeType":
+			f.MimeType = obj.Get(k).String()
+		case "buffer":
+			switch b := obj.Get(k).Export().(type) {
+			case goja.ArrayBuffer:
+				f.Buffer = base64.StdEncoding.EncodeToString(b.Bytes())
+			case string:
+				if _, err := base64.StdEncoding.DecodeString(b); err != nil {
+					return nil, fmt.Errorf("decoding base64 buffer: %w", err)
+				}
+				f.Buffer = b
+			default:
+				return nil, fmt.Errorf("buffer must be a base64 string or an ArrayBuffer, got %T", b)
+			}
+		}
+	}
+	if f.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	return &f, nil
+}
+
 type LifecycleEvent int
 
 const (
@@ -322,6 +474,24 @@ func (l *LifecycleEvent) UnmarshalText(text []byte) error {
 	return nil
 }
 
+// LongTask is a task that blocked the main thread of a page for 50ms or more.
+// StartTime and Duration are in milliseconds, relative to the time origin of
+// the document.
+type LongTask struct {
+	Name      string  `json:"name" js:"name"`
+	StartTime float64 `json:"startTime" js:"startTime"`
+	Duration  float64 `json:"duration" js:"duration"`
+}
+
+// SecurityMessage is a security warning or error that the browser logged
+// for a page, such as a mixed content warning of an HTTPS page that loads
+// insecure subresources.
+type SecurityMessage struct {
+	Level string `json:"level" js:"level"`
+	Text  string `json:"text" js:"text"`
+	URL   string `json:"url" js:"url"`
+}
+
 type MediaType string
 
 const (
@@ -329,6 +499,102 @@ const (
 	MediaTypePrint  MediaType = "print"
 )
 
+// ForcedColors represents a browser forced colors mode.
+type ForcedColors string
+
+// Valid forced colors modes.
+const (
+	ForcedColorsActive ForcedColors = "active"
+	ForcedColorsNone   ForcedColors = "none"
+)
+
+// ScreenOrientation represents an emulated screen orientation.
+type ScreenOrientation string
+
+// Valid screen orientations.
+const (
+	ScreenOrientationPortraitPrimary    ScreenOrientation = "portrait-primary"
+	ScreenOrientationPortraitSecondary  ScreenOrientation = "portrait-secondary"
+	ScreenOrientationLandscapePrimary   ScreenOrientation = "landscape-primary"
+	ScreenOrientationLandscapeSecondary ScreenOrientation = "landscape-secondary"
+)
+
+// parseScreenOrientation returns the screen orientation of s, or an error
+// if s isn't one of the valid orientations.
+func parseScreenOrientation(s string) (ScreenOrientation, error) {
+	switch o := ScreenOrientation(s); o {
+	case ScreenOrientationPortraitPrimary, ScreenOrientationPortraitSecondary,
+		ScreenOrientationLandscapePrimary, ScreenOrientationLandscapeSecondary:
+		return o, nil
+	}
+	return "", fmt.Errorf("invalid screen orientation %q, must be one of: "+
+		"portrait-primary, portrait-secondary, landscape-primary, landscape-secondary", s)
+}
+
+// toCDP returns the CDP screen orientation with the angle of the
+// orientation, or nil if o isn't valid.
+func (o ScreenOrientation) toCDP() *emulation.ScreenOrientation {
+	switch o {
+	case ScreenOrientationPortraitPrimary:
+		return &emulation.ScreenOrientation{Type: emulation.OrientationTypePortraitPrimary, Angle: 0}
+	case ScreenOrientationLandscapePrimary:
+		return &emulation.ScreenOrientation{Type: emulation.OrientationTypeLandscapePrimary, Angle: 90}
+	case ScreenOrientationPortraitSecondary:
+		return &emulation.ScreenOrientation{Type: emulation.OrientationTypePortraitSecondary, Angle: 180}
+	case ScreenOrientationLandscapeSecondary:
+		return &emulation.ScreenOrientation{Type: emulation.OrientationTypeLandscapeSecondary, Angle: 270}
+	}
+	return nil
+}
+
+// PageError is an uncaught exception thrown in a page.
+type PageError struct {
+	Name    string `json:"name" js:"name"`
+	Message string `json:"message" js:"message"`
+	Stack   string `json:"stack" js:"stack"`
+}
+
+// newPageError extracts the error name, message, and stack from the
+// details of an exception thrown in a page.
+func newPageError(details *cdpruntime.ExceptionDetails) *PageError {
+	exc := details.Exception
+	if exc == nil {
+		return &PageError{Message: details.Text}
+	}
+	// a thrown value that isn't an Error, e.g. throw "boom".
+	if exc.Subtype != cdpruntime.SubtypeError {
+		if v, err := parseRemoteObject(exc); err == nil && v != nil {
+			return &PageError{Message: fmt.Sprint(v)}
+		}
+		if exc.Description != "" {
+			return &PageError{Message: exc.Description}
+		}
+		return &PageError{Message: details.Text}
+	}
+
+	// the description of an Error is its stack, which starts
+	// with the "name: message" line, e.g. "TypeError: x is undefined".
+	perr := PageError{
+		Name:  exc.ClassName,
+		Stack: exc.Description,
+	}
+	if exc.Preview != nil {
+		for _, p := range exc.Preview.Properties {
+			switch p.Name {
+			case "name":
+				perr.Name = p.Value
+			case "message":
+				perr.Message = p.Value
+			}
+		}
+	}
+	if perr.Message == "" {
+		header := strings.SplitN(exc.Description, "\n", 2)[0]
+		perr.Message = strings.TrimPrefix(strings.TrimPrefix(header, perr.Name), ": ")
+	}
+	return &perr
+}
+
 type PollingType int
 
 const (
@@ -578,8 +844,63 @@ func (c *Credentials) Parse(ctx context.Context, credentials goja.Value) error {
 				c.Username = credentials.Get(k).String()
 			case "password":
 				c.Password = credentials.Get(k).String()
+			case "origin":
+				origin, err := normalizeOrigin(credentials.Get(k).String())
+				if err != nil {
+					return err
+				}
+				c.Origin = origin
 			}
 		}
 	}
 	return nil
 }
+
+// NewHTTPCredentials returns an empty set of HTTP credentials.
+func NewHTTPCredentials() HTTPCredentials {
+	return HTTPCredentials{}
+}
+
+// Parse parses either a single credentials object or an array of them.
+func (c HTTPCredentials) Parse(ctx context.Context, credentials goja.Value) error {
+	if credentials == nil || goja.IsUndefined(credentials) || goja.IsNull(credentials) {
+		return nil
+	}
+	rt := k6ext.Runtime(ctx)
+	items := []goja.Value{credentials}
+	if credentials.ExportType().Kind() == reflect.Slice {
+		if err := rt.ExportTo(credentials, &items); err != nil {
+			return fmt.Errorf("parsing HTTP credentials: %w", err)
+		}
+	}
+	for i, item := range items {
+		cr := NewCredentials()
+		if err := cr.Parse(ctx, item); err != nil {
+			return fmt.Errorf("parsing HTTP credentials[%d]: %w", i, err)
+		}
+		c[cr.Origin] = cr
+	}
+	return nil
+}
+
+// forOrigin returns the credentials of the origin, or the credentials
+// without an origin if the origin doesn't have its own credentials.
+func (c HTTPCredentials) forOrigin(origin string) *Credentials {
+	if cr, ok := c[strings.ToLower(origin)]; ok {
+		return cr
+	}
+	return c[""]
+}
+
+// normalizeOrigin returns the scheme://host[:port] origin of the given
+// origin or URL.
+func normalizeOrigin(origin string) (string, error) {
+	if origin == "" {
+		return "", nil
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("invalid origin %q", origin)
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host), nil
+}
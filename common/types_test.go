@@ -3,6 +3,7 @@ package common
 import (
 	"testing"
 
+	"github.com/chromedp/cdproto/runtime"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -129,3 +130,104 @@ func TestLifecycleEventUnmarshalText(t *testing.T) {
 				`must be one of: load, domcontentloaded, networkidle`)
 	})
 }
+
+func TestNewPageError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		details *runtime.ExceptionDetails
+		want    *PageError
+	}{
+		{
+			name:    "no_exception",
+			details: &runtime.ExceptionDetails{Text: "Uncaught"},
+			want:    &PageError{Message: "Uncaught"},
+		},
+		{
+			name: "thrown_value",
+			details: &runtime.ExceptionDetails{
+				Text:      "Uncaught",
+				Exception: &runtime.RemoteObject{Type: runtime.TypeString, Value: []byte(`"boom"`)},
+			},
+			want: &PageError{Message: "boom"},
+		},
+		{
+			name: "error_description",
+			details: &runtime.ExceptionDetails{
+				Exception: &runtime.RemoteObject{
+					Type:        runtime.TypeObject,
+					Subtype:     runtime.SubtypeError,
+					ClassName:   "TypeError",
+					Description: "TypeError: x is undefined\n    at <anonymous>:1:1",
+				},
+			},
+			want: &PageError{
+				Name:    "TypeError",
+				Message: "x is undefined",
+				Stack:   "TypeError: x is undefined\n    at <anonymous>:1:1",
+			},
+		},
+		{
+			name: "error_preview",
+			details: &runtime.ExceptionDetails{
+				Exception: &runtime.RemoteObject{
+					Type:        runtime.TypeObject,
+					Subtype:     runtime.SubtypeError,
+					ClassName:   "Error",
+					Description: "CustomError: multi: part\n    at <anonymous>:1:1",
+					Preview: &runtime.ObjectPreview{
+						Properties: []*runtime.PropertyPreview{
+							{Name: "name", Type: runtime.TypeString, Value: "CustomError"},
+							{Name: "message", Type: runtime.TypeString, Value: "multi: part"},
+						},
+					},
+				},
+			},
+			want: &PageError{
+				Name:    "CustomError",
+				Message: "multi: part",
+				Stack:   "CustomError: multi: part\n    at <anonymous>:1:1",
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, newPageError(tt.details))
+		})
+	}
+}
+
+func TestHTTPCredentialsForOrigin(t *testing.T) {
+	t.Parallel()
+
+	def := &Credentials{Username: "default"}
+	other := &Credentials{Username: "other", Origin: "https://other.example:8443"}
+	creds := HTTPCredentials{"": def, other.Origin: other}
+
+	assert.Same(t, other, creds.forOrigin("https://other.example:8443"))
+	assert.Same(t, other, creds.forOrigin("HTTPS://OTHER.example:8443"))
+	assert.Same(t, def, creds.forOrigin("https://other.example"))
+	assert.Same(t, def, creds.forOrigin(""))
+	assert.Nil(t, HTTPCredentials{other.Origin: other}.forOrigin("http://example.com"))
+	assert.Nil(t, HTTPCredentials(nil).forOrigin("http://example.com"))
+}
+
+func TestNormalizeOrigin(t *testing.T) {
+	t.Parallel()
+
+	for in, want := range map[string]string{
+		"":                             "",
+		"https://Example.com":          "https://example.com",
+		"http://example.com:8080/path": "http://example.com:8080",
+	} {
+		got, err := normalizeOrigin(in)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+	_, err := normalizeOrigin("example.com")
+	assert.Error(t, err)
+}
@@ -0,0 +1,180 @@
+/*
+ *
+ * xk6-browser - a browser automation extension for k6
+ * Copyright (C) 2022 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package common
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sync"
+
+	"github.com/grafana/xk6-browser/api"
+	"github.com/grafana/xk6-browser/k6ext"
+	"github.com/grafana/xk6-browser/log"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/dop251/goja"
+)
+
+// Ensure WebSocket implements the api.WebSocket interface.
+var _ api.WebSocket = &WebSocket{}
+
+// The WebSocket events that can be handled from JS with WebSocket.On.
+const (
+	EventWebSocketFrameSent     string = "framesent"
+	EventWebSocketFrameReceived string = "framereceived"
+	EventWebSocketClose         string = "close"
+)
+
+// webSocketOpcodeText is the opcode of the text frames. The other data
+// frames are binary.
+const webSocketOpcodeText = 1
+
+// WebSocket is a WebSocket opened by a page. Its frames are observed
+// through the Network domain, and can't be modified.
+type WebSocket struct {
+	ctx       context.Context
+	logger    *log.Logger
+	page      *Page
+	requestID network.RequestID
+	url       string
+
+	closedMu sync.RWMutex
+	closed   bool
+
+	// handlers are the JS handlers registered with On. They're only
+	// accessed from the VU goroutine.
+	handlers map[string][]goja.Callable
+}
+
+// NewWebSocket creates a new WebSocket opened by the page.
+func NewWebSocket(
+	ctx context.Context, p *Page, requestID network.RequestID, url string, l *log.Logger,
+) *WebSocket {
+	return &WebSocket{
+		ctx:       ctx,
+		logger:    l,
+		page:      p,
+		requestID: requestID,
+		url:       url,
+		handlers:  make(map[string][]goja.Callable),
+	}
+}
+
+// IsClosed returns true if the WebSocket is closed.
+func (ws *WebSocket) IsClosed() bool {
+	ws.closedMu.RLock()
+	defer ws.closedMu.RUnlock()
+
+	return ws.closed
+}
+
+// On registers a handler for the framesent, framereceived or close events.
+// The frame handlers are called with an object with the payload of the
+// frame, a string for the text frames and an ArrayBuffer for the binary
+// ones, and whether the frame is binary.
+func (ws *WebSocket) On(event string, handler goja.Value) {
+	ws.logger.Debugf("WebSocket:On", "url:%q event:%q", ws.url, event)
+
+	switch event {
+	case EventWebSocketFrameSent, EventWebSocketFrameReceived, EventWebSocketClose:
+	default:
+		k6ext.Panic(ws.ctx, "unknown websocket event: %q", event)
+	}
+	fn, ok := goja.AssertFunction(handler)
+	if !ok {
+		k6ext.Panic(ws.ctx, "handler of websocket event %q must be a function", event)
+	}
+	ws.handlers[event] = append(ws.handlers[event], fn)
+}
+
+// URL returns the URL of the WebSocket.
+func (ws *WebSocket) URL() string {
+	return ws.url
+}
+
+// frameSent emits the frame sent by the page.
+func (ws *WebSocket) frameSent(frame *network.WebSocketFrame) {
+	ws.emitFrame(EventWebSocketFrameSent, frame)
+}
+
+// frameReceived emits the frame received by the page.
+func (ws *WebSocket) frameReceived(frame *network.WebSocketFrame) {
+	ws.emitFrame(EventWebSocketFrameReceived, frame)
+}
+
+// close marks the WebSocket as closed and emits the close event.
+func (ws *WebSocket) close() {
+	ws.closedMu.Lock()
+	ws.closed = true
+	ws.closedMu.Unlock()
+
+	ws.emit(EventWebSocketClose, func(rt *goja.Runtime) (goja.Value, error) {
+		return rt.ToValue(ws), nil
+	})
+}
+
+func (ws *WebSocket) emitFrame(event string, frame *network.WebSocketFrame) {
+	if frame == nil {
+		return
+	}
+	ws.emit(event, func(rt *goja.Runtime) (goja.Value, error) {
+		if int(frame.Opcode) == webSocketOpcodeText {
+			return rt.ToValue(map[string]interface{}{
+				"payload": frame.PayloadData,
+				"binary":  false,
+			}), nil
+		}
+		// the payload of the binary frames is base64 encoded.
+		payload, err := base64.StdEncoding.DecodeString(frame.PayloadData)
+		if err != nil {
+			return nil, fmt.Errorf("decoding binary frame: %w", err)
+		}
+		return rt.ToValue(map[string]interface{}{
+			"payload": rt.NewArrayBuffer(payload),
+			"binary":  true,
+		}), nil
+	})
+}
+
+// emit queues the calls to the handlers of the event on the page's JS
+// queue. The handlers are looked up once the calls run, so that the ones
+// registered by the page's websocket event handler, which runs first, see
+// the frames that arrived in the meantime.
+func (ws *WebSocket) emit(event string, data func(*goja.Runtime) (goja.Value, error)) {
+	ws.page.enqueueJS(func() error {
+		handlers := ws.handlers[event]
+		if len(handlers) == 0 {
+			return nil
+		}
+		rt := ws.page.vu.Runtime()
+		v, err := data(rt)
+		if err != nil {
+			return fmt.Errorf("websocket %s event: %w", event, err)
+		}
+		for _, fn := range handlers {
+			if _, err := fn(goja.Undefined(), v); err != nil {
+				return fmt.Errorf("websocket %s event handler: %w", event, err)
+			}
+		}
+		return nil
+	})
+}
@@ -22,12 +22,18 @@ package common
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/grafana/xk6-browser/api"
+	"github.com/grafana/xk6-browser/k6ext"
+	"github.com/grafana/xk6-browser/log"
 
+	"github.com/chromedp/cdproto"
 	"github.com/chromedp/cdproto/cdp"
-	"github.com/chromedp/cdproto/log"
+	cdplog "github.com/chromedp/cdproto/log"
 	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/cdproto/runtime"
 	"github.com/chromedp/cdproto/target"
@@ -42,36 +48,95 @@ type Worker struct {
 	BaseEventEmitter
 
 	ctx     context.Context
+	cancel  context.CancelFunc
 	session session
+	logger  *log.Logger
+
+	timeoutSettings *TimeoutSettings
 
 	targetID target.ID
 	url      string
+
+	executionContextMu sync.RWMutex
+	executionContext   *ExecutionContext
+	// executionContextReady is closed once the worker has an execution
+	// context, and replaced when the execution context is destroyed.
+	executionContextReady chan struct{}
 }
 
-// NewWorker creates a new page viewport.
-func NewWorker(ctx context.Context, s session, id target.ID, url string) (*Worker, error) {
+// NewWorker creates a new web worker with the target of the session.
+func NewWorker(
+	ctx context.Context, s session, id target.ID, url string, ts *TimeoutSettings, l *log.Logger,
+) (*Worker, error) {
+	ctx, cancel := context.WithCancel(ctx)
 	w := Worker{
-		BaseEventEmitter: NewBaseEventEmitter(ctx),
-		ctx:              ctx,
-		session:          s,
-		targetID:         id,
-		url:              url,
+		BaseEventEmitter:      NewBaseEventEmitter(ctx),
+		ctx:                   ctx,
+		cancel:                cancel,
+		session:               s,
+		logger:                l,
+		timeoutSettings:       ts,
+		targetID:              id,
+		url:                   url,
+		executionContextReady: make(chan struct{}),
 	}
 	if err := w.initEvents(); err != nil {
+		cancel()
 		return nil, err
 	}
 
 	return &w, nil
 }
 
+// didClose is called when the worker target is detached. It drops the
+// execution context, so that the evaluations in the worker fail.
 func (w *Worker) didClose() {
 	w.emit(EventWorkerClose, w)
+	w.setExecutionContext(nil)
+	w.cancel()
 }
 
 func (w *Worker) initEvents() error {
+	// subscribe before enabling the runtime domain, which reports the
+	// existing execution context right away.
+	events := []string{
+		cdproto.EventRuntimeExecutionContextCreated,
+		cdproto.EventRuntimeExecutionContextDestroyed,
+		cdproto.EventRuntimeExecutionContextsCleared,
+	}
+	ch := make(chan Event)
+	w.session.on(w.ctx, events, ch)
+	go func() {
+		for {
+			select {
+			case <-w.ctx.Done():
+				return
+			case <-w.session.Done():
+				return
+			case event := <-ch:
+				switch ev := event.data.(type) {
+				case *runtime.EventExecutionContextCreated:
+					// workers don't have frames, so the handles that the
+					// execution context creates are never element handles.
+					w.setExecutionContext(NewExecutionContext(w.ctx, w.session, nil, ev.Context.ID, w.logger))
+				case *runtime.EventExecutionContextDestroyed:
+					w.executionContextMu.RLock()
+					current := w.executionContext != nil && w.executionContext.ID() == ev.ExecutionContextID
+					w.executionContextMu.RUnlock()
+					if current {
+						w.setExecutionContext(nil)
+					}
+				case *runtime.EventExecutionContextsCleared:
+					w.setExecutionContext(nil)
+				}
+			}
+		}
+	}()
+
 	actions := []Action{
-		log.Enable(),
+		cdplog.Enable(),
 		network.Enable(),
+		runtime.Enable(),
 		runtime.RunIfWaitingForDebugger(),
 	}
 	for _, action := range actions {
@@ -82,16 +147,78 @@ func (w *Worker) initEvents() error {
 	return nil
 }
 
+func (w *Worker) setExecutionContext(ec *ExecutionContext) {
+	w.executionContextMu.Lock()
+	defer w.executionContextMu.Unlock()
+
+	w.executionContext = ec
+	select {
+	case <-w.executionContextReady:
+		if ec == nil {
+			w.executionContextReady = make(chan struct{})
+		}
+	default:
+		if ec != nil {
+			close(w.executionContextReady)
+		}
+	}
+}
+
+// waitForExecutionContext returns the execution context of the worker,
+// waiting for it until the default timeout.
+func (w *Worker) waitForExecutionContext() (*ExecutionContext, error) {
+	timeout := time.Duration(w.timeoutSettings.timeout()) * time.Second
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		w.executionContextMu.RLock()
+		ec, ready := w.executionContext, w.executionContextReady
+		w.executionContextMu.RUnlock()
+		if ec != nil {
+			return ec, nil
+		}
+
+		select {
+		case <-ready:
+		case <-w.ctx.Done():
+			return nil, errors.New("worker is closed")
+		case <-timer.C:
+			return nil, fmt.Errorf("%w after %s waiting for the execution context", ErrTimedOut, timeout)
+		}
+	}
+}
+
 // Evaluate evaluates a page function in the context of the web worker.
 func (w *Worker) Evaluate(pageFunc goja.Value, args ...goja.Value) interface{} {
-	// TODO: implement
-	return nil
+	w.logger.Debugf("Worker:Evaluate", "sid:%v tid:%v wurl:%q", w.session.ID(), w.targetID, w.url)
+
+	ec, err := w.waitForExecutionContext()
+	if err != nil {
+		k6ext.Panic(w.ctx, "evaluating JS in worker: %w", err)
+	}
+	result, err := ec.Eval(w.ctx, pageFunc, args...)
+	if err != nil {
+		k6ext.Panic(w.ctx, "evaluating JS in worker: %w", err)
+	}
+
+	return result
 }
 
 // EvaluateHandle evaluates a page function in the context of the web worker and returns a JS handle.
 func (w *Worker) EvaluateHandle(pageFunc goja.Value, args ...goja.Value) api.JSHandle {
-	// TODO: implement
-	return nil
+	w.logger.Debugf("Worker:EvaluateHandle", "sid:%v tid:%v wurl:%q", w.session.ID(), w.targetID, w.url)
+
+	ec, err := w.waitForExecutionContext()
+	if err != nil {
+		k6ext.Panic(w.ctx, "evaluating handle in worker: %w", err)
+	}
+	handle, err := ec.EvalHandle(w.ctx, pageFunc, args...)
+	if err != nil {
+		k6ext.Panic(w.ctx, "evaluating handle in worker: %w", err)
+	}
+
+	return handle
 }
 
 // URL returns the URL of the web worker.
@@ -4,11 +4,16 @@ import k6metrics "go.k6.io/k6/metrics"
 
 // CustomMetrics are the custom k6 metrics used by xk6-browser.
 type CustomMetrics struct {
-	BrowserDOMContentLoaded     *k6metrics.Metric
-	BrowserFirstPaint           *k6metrics.Metric
-	BrowserFirstContentfulPaint *k6metrics.Metric
-	BrowserFirstMeaningfulPaint *k6metrics.Metric
-	BrowserLoaded               *k6metrics.Metric
+	BrowserDOMContentLoaded       *k6metrics.Metric
+	BrowserFirstPaint             *k6metrics.Metric
+	BrowserCumulativeLayoutShift  *k6metrics.Metric
+	BrowserFirstContentfulPaint   *k6metrics.Metric
+	BrowserFirstMeaningfulPaint   *k6metrics.Metric
+	BrowserLoaded                 *k6metrics.Metric
+	BrowserDocumentResponseEnd    *k6metrics.Metric
+	BrowserLongTask               *k6metrics.Metric
+	BrowserLargestContentfulPaint *k6metrics.Metric
+	BrowserTimeToFirstByte        *k6metrics.Metric
 }
 
 // RegisterCustomMetrics creates and registers our custom metrics with the k6
@@ -19,11 +24,21 @@ func RegisterCustomMetrics(registry *k6metrics.Registry) *CustomMetrics {
 			"browser_dom_content_loaded", k6metrics.Trend, k6metrics.Time),
 		BrowserFirstPaint: registry.MustNewMetric(
 			"browser_first_paint", k6metrics.Trend, k6metrics.Time),
+		BrowserCumulativeLayoutShift: registry.MustNewMetric(
+			"browser_cumulative_layout_shift", k6metrics.Trend),
 		BrowserFirstContentfulPaint: registry.MustNewMetric(
 			"browser_first_contentful_paint", k6metrics.Trend, k6metrics.Time),
 		BrowserFirstMeaningfulPaint: registry.MustNewMetric(
 			"browser_first_meaningful_paint", k6metrics.Trend, k6metrics.Time),
 		BrowserLoaded: registry.MustNewMetric(
 			"browser_loaded", k6metrics.Trend, k6metrics.Time),
+		BrowserDocumentResponseEnd: registry.MustNewMetric(
+			"browser_document_response_end", k6metrics.Trend, k6metrics.Time),
+		BrowserLongTask: registry.MustNewMetric(
+			"browser_long_task", k6metrics.Trend, k6metrics.Time),
+		BrowserLargestContentfulPaint: registry.MustNewMetric(
+			"browser_largest_contentful_paint", k6metrics.Trend, k6metrics.Time),
+		BrowserTimeToFirstByte: registry.MustNewMetric(
+			"browser_time_to_first_byte", k6metrics.Trend, k6metrics.Time),
 	}
 }
@@ -41,6 +41,7 @@ type Logger struct {
 	lastLogCall    int64
 	debugOverride  bool
 	categoryFilter *regexp.Regexp
+	cdpTrace       bool
 }
 
 // NewNullLogger will create a logger where log lines will
@@ -145,6 +146,16 @@ func (l *Logger) DebugMode() bool {
 	return l.GetLevel() >= logrus.DebugLevel
 }
 
+// TraceCDP enables logging every CDP command and event.
+func (l *Logger) TraceCDP() {
+	l.cdpTrace = true
+}
+
+// CDPTraceMode returns true if every CDP command and event should be logged.
+func (l *Logger) CDPTraceMode() bool {
+	return l != nil && l.cdpTrace
+}
+
 // ReportCaller adds source file and function names to the log entries.
 func (l *Logger) ReportCaller() {
 	caller := func() func(*runtime.Frame) (string, string) {
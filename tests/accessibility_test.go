@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/grafana/xk6-browser/common"
+
+	"github.com/dop251/goja"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessibilitySnapshot(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+	p.SetContent(`
+		<h1>Title</h1>
+		<div id="form">
+			<button>Go</button>
+			<input aria-label="Name" value="Ada">
+			<input type="checkbox" aria-label="Agree" checked>
+		</div>
+		<div hidden><button id="hidden">Hidden</button></div>
+	`, nil)
+	cp, ok := p.(*common.Page)
+	require.True(t, ok)
+	a := cp.Accessibility
+
+	snapshot := func(opts map[string]interface{}) map[string]interface{} {
+		v := a.Snapshot(tb.toGojaValue(opts))
+		if goja.IsNull(v) {
+			return nil
+		}
+		node, ok := v.Export().(map[string]interface{})
+		require.True(t, ok)
+		return node
+	}
+
+	t.Run("interesting_only", func(t *testing.T) {
+		root := snapshot(nil)
+		require.NotNil(t, root)
+		assert.Equal(t, "WebArea", root["role"])
+		assert.Equal(t, []map[string]interface{}{
+			{"role": "heading", "name": "Title", "level": float64(1)},
+			{"role": "button", "name": "Go"},
+			{"role": "textbox", "name": "Name", "value": "Ada"},
+			{"role": "checkbox", "name": "Agree", "checked": true},
+		}, root["children"])
+	})
+	t.Run("all", func(t *testing.T) {
+		root := snapshot(map[string]interface{}{"interestingOnly": false})
+		require.NotNil(t, root)
+
+		// the text of the button is only in the full tree.
+		var hasText func(node map[string]interface{}) bool
+		hasText = func(node map[string]interface{}) bool {
+			if node["role"] == "text" && node["name"] == "Go" {
+				return true
+			}
+			children, _ := node["children"].([]map[string]interface{})
+			for _, c := range children {
+				if hasText(c) {
+					return true
+				}
+			}
+			return false
+		}
+		assert.True(t, hasText(root))
+	})
+	t.Run("root", func(t *testing.T) {
+		root := snapshot(map[string]interface{}{"root": p.Query("button")})
+		assert.Equal(t, map[string]interface{}{"role": "button", "name": "Go"}, root)
+
+		assert.Nil(t, snapshot(map[string]interface{}{"root": p.Query("#hidden")}),
+			"should be null if the root isn't in the tree")
+		assert.Nil(t, snapshot(map[string]interface{}{"root": p.Query("#form")}),
+			"should be null if the root isn't interesting")
+
+		assert.NotNil(t, snapshot(map[string]interface{}{"root": p.Query("#form"), "interestingOnly": false}),
+			"should serialize any root with all the nodes")
+	})
+}
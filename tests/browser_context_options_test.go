@@ -38,6 +38,7 @@ func TestBrowserContextOptionsDefaultValues(t *testing.T) {
 	assert.False(t, opts.AcceptDownloads)
 	assert.False(t, opts.BypassCSP)
 	assert.Equal(t, common.ColorSchemeLight, opts.ColorScheme)
+	assert.Equal(t, common.ContrastNoPreference, opts.Contrast)
 	assert.Equal(t, 1.0, opts.DeviceScaleFactor)
 	assert.Empty(t, opts.ExtraHTTPHeaders)
 	assert.Nil(t, opts.Geolocation)
@@ -104,3 +105,29 @@ func TestBrowserContextOptionsExtraHTTPHeaders(t *testing.T) {
 	require.NotEmpty(t, h)
 	assert.Equal(t, "Some-Value", h[0])
 }
+
+func TestBrowserContextOptionsAcceptLanguage(t *testing.T) {
+	tb := newTestBrowser(t, withHTTPServer())
+	bctx := tb.NewContext(tb.toGojaValue(struct {
+		AcceptLanguage string `js:"acceptLanguage"`
+		Locale         string `js:"locale"`
+	}{
+		AcceptLanguage: "de-DE,de;q=0.9",
+		Locale:         "fr-FR",
+	}))
+	t.Cleanup(bctx.Close)
+
+	p := bctx.NewPage()
+	resp := p.Goto(tb.URL("/get"), nil)
+
+	require.NotNil(t, resp)
+	var body struct{ Headers map[string][]string }
+	err := json.Unmarshal(resp.Body().Bytes(), &body)
+	require.NoError(t, err)
+	h := body.Headers["Accept-Language"]
+	require.NotEmpty(t, h)
+	assert.Equal(t, "de-DE,de;q=0.9", h[0])
+
+	locale := p.Evaluate(tb.toGojaValue(`() => Intl.DateTimeFormat().resolvedOptions().locale`))
+	assert.Equal(t, "fr-FR", tb.asGojaValue(locale).String())
+}
@@ -0,0 +1,513 @@
+/*
+ *
+ * xk6-browser - a browser automation extension for k6
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/xk6-browser/api"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBrowserContextGeolocation(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withFileServer())
+	bctx := tb.NewContext(nil)
+	t.Cleanup(bctx.Close)
+	bctx.GrantPermissions([]string{"geolocation"}, nil)
+	p := bctx.NewPage()
+	require.NotNil(t, p.Goto(tb.staticURL("empty.html"), nil))
+
+	position := func() string {
+		v := p.Evaluate(tb.toGojaValue(`() => new Promise(resolve => {
+			navigator.geolocation.getCurrentPosition(
+				pos => resolve(pos.coords.latitude + ',' + pos.coords.longitude),
+				() => resolve('unavailable'),
+				{ timeout: 1000 },
+			);
+		})`))
+		return tb.asGojaValue(v).String()
+	}
+
+	bctx.SetGeolocation(tb.toGojaValue(map[string]float64{
+		"latitude":  59.95,
+		"longitude": 30.31667,
+	}))
+	assert.Equal(t, "59.95,30.31667", position())
+
+	// clearing must not dereference the now nil geolocation.
+	require.NotPanics(t, bctx.ClearGeolocation)
+	assert.Equal(t, "unavailable", position(), "should not report the cleared position")
+	// clearing twice is harmless.
+	require.NotPanics(t, bctx.ClearGeolocation)
+}
+
+func TestBrowserContextSetGeolocation(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withFileServer())
+	bctx := tb.NewContext(nil)
+	t.Cleanup(bctx.Close)
+	bctx.GrantPermissions([]string{"geolocation"}, nil)
+	p := bctx.NewPage()
+	require.NotNil(t, p.Goto(tb.staticURL("empty.html"), nil))
+
+	position := func() map[string]interface{} {
+		v := p.Evaluate(tb.toGojaValue(`() => new Promise(resolve => {
+			navigator.geolocation.getCurrentPosition(pos => resolve({
+				latitude: pos.coords.latitude,
+				longitude: pos.coords.longitude,
+			}));
+		})`))
+		pos, ok := tb.asGojaValue(v).Export().(map[string]interface{})
+		require.True(t, ok)
+		return pos
+	}
+
+	bctx.SetGeolocation(tb.toGojaValue(map[string]float64{"latitude": 10, "longitude": 20}))
+	assert.Equal(t, map[string]interface{}{"latitude": float64(10), "longitude": float64(20)}, position())
+
+	bctx.SetGeolocation(tb.toGojaValue(map[string]float64{"latitude": -30, "longitude": 40}))
+	assert.Equal(t, map[string]interface{}{"latitude": float64(-30), "longitude": float64(40)}, position())
+
+	for _, invalid := range []map[string]float64{
+		{"latitude": 91, "longitude": 0},
+		{"latitude": 0, "longitude": -181},
+		{"latitude": 0, "longitude": 0, "accuracy": -1},
+	} {
+		assert.Panics(t, func() { bctx.SetGeolocation(tb.toGojaValue(invalid)) })
+	}
+}
+
+func TestBrowserContextBearerToken(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	tb.withHandler("/auth", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, r.Header.Get("Authorization"))
+	})
+	bctx := tb.NewContext(tb.toGojaValue(map[string]interface{}{
+		"extraHTTPHeaders": map[string]string{"authorization": "Basic xyz"},
+	}))
+	t.Cleanup(bctx.Close)
+	p := bctx.NewPage()
+
+	authHeader := func() string {
+		require.NotNil(t, p.Goto(tb.URL("/auth"), nil))
+		return p.InnerText("body", nil)
+	}
+
+	assert.Equal(t, "Basic xyz", authHeader())
+
+	bctx.SetBearerToken("token1")
+	assert.Equal(t, "Bearer token1", authHeader(), "should replace the header case-insensitively")
+
+	bctx.SetBearerToken("token2")
+	assert.Equal(t, "Bearer token2", authHeader())
+
+	bctx.ClearBearerToken()
+	assert.Equal(t, "", authHeader())
+}
+
+func TestBrowserContextRecordHar(t *testing.T) {
+	t.Parallel()
+
+	const body = "<p>recorded</p>"
+
+	type harEntry struct {
+		Request struct {
+			Method      string
+			URL         string
+			QueryString []struct{ Name, Value string }
+		}
+		Response struct {
+			Status   int
+			BodySize int64
+			Content  struct {
+				Size     int64
+				MimeType string
+			}
+		}
+		Time float64
+	}
+	readHar := func(t *testing.T, path string) []harEntry {
+		t.Helper()
+
+		buf, err := os.ReadFile(path) //nolint:gosec
+		require.NoError(t, err)
+
+		var har struct {
+			Log struct {
+				Version string
+				Entries []harEntry
+			}
+		}
+		require.NoError(t, json.Unmarshal(buf, &har))
+		assert.Equal(t, "1.2", har.Log.Version)
+		require.NotEmpty(t, har.Log.Entries)
+
+		return har.Log.Entries
+	}
+
+	t.Run("context_close", func(t *testing.T) {
+		t.Parallel()
+
+		tb := newTestBrowser(t, withHTTPServer())
+		tb.withHandler("/har", func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = fmt.Fprint(w, body)
+		})
+
+		path := filepath.Join(t.TempDir(), "out", "context.har")
+		bctx := tb.NewContext(tb.toGojaValue(map[string]interface{}{
+			"recordHar": map[string]string{"path": path},
+		}))
+		p := bctx.NewPage()
+		require.NotNil(t, p.Goto(tb.URL("/har?q=1"), nil))
+		bctx.Close()
+
+		entry := readHar(t, path)[0]
+		assert.Equal(t, "GET", entry.Request.Method)
+		assert.Equal(t, tb.URL("/har?q=1"), entry.Request.URL)
+		assert.Equal(t, []struct{ Name, Value string }{{"q", "1"}}, entry.Request.QueryString)
+		assert.Equal(t, http.StatusOK, entry.Response.Status)
+		assert.Equal(t, "text/html", entry.Response.Content.MimeType)
+		assert.Equal(t, int64(len(body)), entry.Response.BodySize)
+		assert.Equal(t, int64(len(body)), entry.Response.Content.Size)
+		assert.GreaterOrEqual(t, entry.Time, 0.0)
+	})
+
+	t.Run("browser_close", func(t *testing.T) {
+		t.Parallel()
+
+		tb := newTestBrowser(t, withHTTPServer(), withSkipClose())
+		tb.withHandler("/har", func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = fmt.Fprint(w, body)
+		})
+
+		path := filepath.Join(t.TempDir(), "browser.har")
+		p := tb.NewPage(tb.toGojaValue(map[string]interface{}{
+			"recordHar": map[string]string{"path": path},
+		}))
+		require.NotNil(t, p.Goto(tb.URL("/har"), nil))
+		// the context is not closed, the HAR should be
+		// exported when the browser closes.
+		tb.Close()
+
+		entry := readHar(t, path)[0]
+		assert.Equal(t, tb.URL("/har"), entry.Request.URL)
+		assert.Equal(t, int64(len(body)), entry.Response.BodySize)
+	})
+}
+
+func TestBrowserContextSetOfflineEvents(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withFileServer())
+	bctx := tb.NewContext(nil)
+	t.Cleanup(bctx.Close)
+	p := bctx.NewPage()
+	require.NotNil(t, p.Goto(tb.staticURL("empty.html"), nil))
+
+	p.Evaluate(tb.toGojaValue(`() => {
+		window.connectivityEvents = [];
+		window.addEventListener('offline', () => connectivityEvents.push('offline:' + navigator.onLine));
+		window.addEventListener('online', () => connectivityEvents.push('online:' + navigator.onLine));
+	}`))
+	// the events are dispatched asynchronously, so wait for them to arrive.
+	events := func(n int) string {
+		return tb.asGojaValue(p.Evaluate(tb.toGojaValue(`async (n) => {
+			for (let i = 0; i < 100 && connectivityEvents.length < n; i++) {
+				await new Promise(r => setTimeout(r, 10));
+			}
+			return connectivityEvents.join(',');
+		}`), tb.toGojaValue(n))).String()
+	}
+
+	bctx.SetOffline(true)
+	assert.Equal(t, "offline:false", events(1))
+
+	bctx.SetOffline(false)
+	assert.Equal(t, "offline:false,online:true", events(2))
+}
+
+func TestBrowserContextDownloads(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	tb.withHandler("/page", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `<a href="/file">download</a>`)
+	})
+	tb.withHandler("/file", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Content-Disposition", `attachment; filename="report.txt"`)
+		_, _ = fmt.Fprint(w, "downloaded content")
+	})
+
+	bctx := tb.NewContext(tb.toGojaValue(map[string]interface{}{
+		"acceptDownloads": true,
+	}))
+	p := bctx.NewPage()
+	require.NotNil(t, p.Goto(tb.URL("/page"), nil))
+
+	var (
+		downloads []api.Download
+		savedPath = filepath.Join(t.TempDir(), "saved.txt")
+	)
+	require.NoError(t, tb.runtime().Set("onDownload", func(d api.Download) {
+		downloads = append(downloads, d)
+		d.SaveAs(savedPath, nil)
+	}))
+	onDownload, err := tb.runtime().RunString(`d => onDownload(d)`)
+	require.NoError(t, err)
+	err = tb.vu.Loop.Start(func() error {
+		p.On("download", onDownload)
+		p.Click("a", nil)
+		p.WaitForTimeout(500)
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.Len(t, downloads, 1)
+	d := downloads[0]
+	assert.Equal(t, "report.txt", d.SuggestedFilename())
+	assert.Equal(t, tb.URL("/file"), d.URL())
+	assert.Empty(t, d.Failure(nil))
+
+	path := d.Path(nil)
+	buf, err := os.ReadFile(path) //nolint:gosec
+	require.NoError(t, err)
+	assert.Equal(t, "downloaded content", string(buf))
+	buf, err = os.ReadFile(savedPath) //nolint:gosec
+	require.NoError(t, err)
+	assert.Equal(t, "downloaded content", string(buf))
+
+	// the downloads are removed with the context.
+	bctx.Close()
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err), "download should be removed when the context closes")
+}
+
+func TestBrowserContextDownloadTimeout(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	tb.withHandler("/page", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `<a href="/file">download</a>`)
+	})
+	// the download doesn't finish until the test is done.
+	done := make(chan struct{})
+	t.Cleanup(func() { close(done) })
+	tb.withHandler("/file", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Content-Disposition", `attachment; filename="slow.txt"`)
+		_, _ = fmt.Fprint(w, "partial")
+		w.(http.Flusher).Flush()
+		<-done
+	})
+
+	bctx := tb.NewContext(tb.toGojaValue(map[string]interface{}{
+		"acceptDownloads": true,
+	}))
+	p := bctx.NewPage()
+	require.NotNil(t, p.Goto(tb.URL("/page"), nil))
+
+	var downloads []api.Download
+	require.NoError(t, tb.runtime().Set("onDownload", func(d api.Download) {
+		downloads = append(downloads, d)
+	}))
+	onDownload, err := tb.runtime().RunString(`d => onDownload(d)`)
+	require.NoError(t, err)
+	err = tb.vu.Loop.Start(func() error {
+		p.On("download", onDownload)
+		p.Click("a", nil)
+		p.WaitForTimeout(500)
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.Len(t, downloads, 1)
+	timeout := tb.toGojaValue(map[string]interface{}{"timeout": 100})
+	assert.Panics(t, func() { downloads[0].Path(timeout) }, "should time out")
+	assert.Panics(t, func() { downloads[0].Failure(timeout) }, "should time out")
+}
+
+func TestBrowserContextCookies(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	tb.withHandler("/cookies", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, r.Header.Get("Cookie"))
+	})
+	bctx := tb.NewContext(nil)
+	t.Cleanup(bctx.Close)
+	p := bctx.NewPage()
+
+	bctx.AddCookies(tb.toGojaValue([]map[string]interface{}{
+		{"name": "a", "value": "1", "url": tb.URL("/")},
+		{"name": "b", "value": "2", "domain": "example.com", "path": "/"},
+	}))
+
+	require.NotNil(t, p.Goto(tb.URL("/cookies"), nil))
+	assert.Equal(t, "a=1", p.InnerText("body", nil), "should send the cookies to the pages")
+	assert.Equal(t, "a=1", p.Evaluate(tb.toGojaValue(`() => document.cookie`)))
+
+	cookies := bctx.Cookies()
+	require.Len(t, cookies, 2)
+	cookies = bctx.Cookies(tb.URL("/"))
+	require.Len(t, cookies, 1)
+	assert.Equal(t, &api.Cookie{
+		Name:    "a",
+		Value:   "1",
+		Domain:  "127.0.0.1",
+		Path:    "/",
+		Expires: -1,
+	}, cookies[0])
+
+	bctx.ClearCookies()
+	assert.Empty(t, bctx.Cookies())
+
+	assert.Panics(t, func() {
+		bctx.AddCookies(tb.toGojaValue([]map[string]interface{}{{"name": "c", "value": "3"}}))
+	}, "should require either a url or a domain and path")
+}
+
+func TestBrowserContextBeforeUnloadDialog(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	tb.withHandler("/dirty", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `<input id="name">
+			<script>window.onbeforeunload = e => { e.preventDefault(); e.returnValue = ""; };</script>`)
+	})
+	tb.withHandler("/next", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, "next")
+	})
+	bctx := tb.NewContext(tb.toGojaValue(map[string]interface{}{
+		"beforeUnloadDialog": "accept",
+	}))
+	t.Cleanup(bctx.Close)
+	p := bctx.NewPage()
+
+	require.NotNil(t, p.Goto(tb.URL("/dirty"), nil))
+	// the browser only prompts after the user interacted with the page.
+	p.Type("#name", "unsaved", nil)
+	resp := p.Goto(tb.URL("/next"), tb.toGojaValue(map[string]interface{}{"timeout": 5000}))
+	require.NotNil(t, resp, "should navigate away after accepting the beforeunload dialog")
+	assert.Equal(t, "next", p.InnerText("body", nil))
+}
+
+func TestBrowserContextGrantPermissions(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withFileServer())
+	bctx := tb.NewContext(nil)
+	t.Cleanup(bctx.Close)
+	p := bctx.NewPage()
+	require.NotNil(t, p.Goto(tb.staticURL("empty.html"), nil))
+
+	state := func(name string) string {
+		v := p.Evaluate(tb.toGojaValue(`name => navigator.permissions.query({ name }).then(r => r.state)`),
+			tb.toGojaValue(name))
+		return tb.asGojaValue(v).String()
+	}
+	require.Equal(t, "prompt", state("geolocation"))
+
+	bctx.GrantPermissions([]string{"geolocation"}, tb.toGojaValue(map[string]string{
+		"origin": "http://other.example.com",
+	}))
+	assert.Equal(t, "prompt", state("geolocation"), "should only grant to the origin")
+
+	bctx.GrantPermissions([]string{"geolocation", "notifications"}, nil)
+	assert.Equal(t, "granted", state("geolocation"))
+	assert.Equal(t, "granted", state("notifications"))
+
+	bctx.ClearPermissions()
+	assert.Equal(t, "prompt", state("geolocation"))
+
+	assert.Panics(t, func() { bctx.GrantPermissions([]string{"teleport"}, nil) })
+}
+
+func TestBrowserContextStorageState(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	tb.withHandler("/doc", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `<html><body>doc</body></html>`)
+	})
+
+	// the first collaborator signs in and starts a draft.
+	bctx := tb.NewContext(nil)
+	t.Cleanup(bctx.Close)
+	p := bctx.NewPage()
+	require.NotNil(t, p.Goto(tb.URL("/doc"), nil))
+	p.Evaluate(tb.toGojaValue(`() => {
+		document.cookie = "session=abc; path=/";
+		localStorage.setItem("draft", "hello");
+	}`))
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	state := bctx.StorageState(tb.toGojaValue(map[string]interface{}{"path": path}))
+	require.Len(t, state.Cookies, 1)
+	assert.Equal(t, "session", state.Cookies[0].Name)
+	assert.Equal(t, "abc", state.Cookies[0].Value)
+	require.Len(t, state.Origins, 1)
+	assert.Equal(t, []*api.StorageEntry{{Name: "draft", Value: "hello"}}, state.Origins[0].LocalStorage)
+
+	storage := func(p api.Page) string {
+		v := p.Evaluate(tb.toGojaValue(`() => document.cookie + "|" + localStorage.getItem("draft")`))
+		return tb.asGojaValue(v).String()
+	}
+
+	// the second collaborator starts from the same state, either from the
+	// returned state or from the saved file.
+	for name, opt := range map[string]interface{}{
+		"state": state,
+		"path":  path,
+	} {
+		bctx2 := tb.NewContext(tb.toGojaValue(map[string]interface{}{"storageState": opt}))
+		p2 := bctx2.NewPage()
+		require.NotNil(t, p2.Goto(tb.URL("/doc"), nil))
+		assert.Equal(t, "session=abc|hello", storage(p2), name)
+
+		// the local storage is only set once, and then it's up to the page.
+		p2.Evaluate(tb.toGojaValue(`() => localStorage.setItem("draft", "edited")`))
+		require.NotNil(t, p2.Reload(nil))
+		assert.Equal(t, "session=abc|edited", storage(p2), name)
+
+		// nor is it set again once the page clears it.
+		p2.Evaluate(tb.toGojaValue(`() => localStorage.clear()`))
+		require.NotNil(t, p2.Reload(nil))
+		assert.Equal(t, "session=abc|null", storage(p2), name)
+		p3 := bctx2.NewPage()
+		require.NotNil(t, p3.Goto(tb.URL("/doc"), nil))
+		assert.Equal(t, "session=abc|null", storage(p3), name)
+		bctx2.Close()
+	}
+}
@@ -267,6 +267,35 @@ func TestElementHandleIsChecked(t *testing.T) {
 	element.Dispose()
 }
 
+func TestElementHandleCheckVerifiesState(t *testing.T) {
+	t.Parallel()
+
+	t.Run("async", func(t *testing.T) {
+		t.Parallel()
+
+		p := newTestBrowser(t).NewPage(nil)
+		p.SetContent(`<input type="checkbox" onclick="
+			event.preventDefault();
+			setTimeout(() => { this.checked = true; }, 100);
+		">`, nil)
+		element := p.Query("input")
+		element.Check(nil)
+		assert.True(t, element.IsChecked(), "should wait for the state to change")
+	})
+	t.Run("swallowed", func(t *testing.T) {
+		t.Parallel()
+
+		tb := newTestBrowser(t)
+		p := tb.NewPage(nil)
+		p.SetContent(`<input type="checkbox" onclick="event.preventDefault()">`, nil)
+		element := p.Query("input")
+		assert.Panics(t, func() {
+			element.Check(tb.toGojaValue(map[string]interface{}{"timeout": 500}))
+		}, "should throw when the click doesn't change the state")
+		assert.False(t, element.IsChecked())
+	})
+}
+
 func TestElementHandleQueryAll(t *testing.T) {
 	const (
 		wantLiLen = 2
@@ -338,6 +367,37 @@ func TestElementHandleScreenshot(t *testing.T) {
 	assert.Equal(t, uint32(0), b)
 }
 
+func TestElementHandleScreenshotCanvas(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+	p.SetContent(`
+		<style>body { margin: 0; }</style>
+		<canvas width="100" height="50"></canvas>
+		<script>
+			const ctx = document.querySelector('canvas').getContext('2d');
+			ctx.fillStyle = 'red';
+			ctx.fillRect(0, 0, 50, 50);
+			ctx.fillStyle = 'blue';
+			ctx.fillRect(50, 0, 50, 50);
+		</script>
+	`, nil)
+
+	buf := p.Query("canvas").Screenshot(nil)
+
+	img, err := png.Decode(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, 100, img.Bounds().Dx())
+	assert.Equal(t, 50, img.Bounds().Dy())
+	r, _, b, _ := img.At(25, 25).RGBA()
+	assert.Equal(t, uint32(255), r>>8)
+	assert.Equal(t, uint32(0), b)
+	r, _, b, _ = img.At(75, 25).RGBA()
+	assert.Equal(t, uint32(0), r)
+	assert.Equal(t, uint32(255), b>>8)
+}
+
 func TestElementHandleWaitForSelector(t *testing.T) {
 	tb := newTestBrowser(t)
 	p := tb.NewPage(nil)
@@ -363,3 +423,30 @@ func TestElementHandleWaitForSelector(t *testing.T) {
 
 	element.Dispose()
 }
+
+func TestElementHandleShadowRoot(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+	p.SetContent(`
+		<div id="open"></div>
+		<div id="closed"></div>
+		<div id="none"></div>
+		<script>
+			document.getElementById('open').attachShadow({ mode: 'open' }).innerHTML =
+				'<span class="inner">open content</span>';
+			document.getElementById('closed').attachShadow({ mode: 'closed' }).innerHTML =
+				'<span class="inner">closed content</span>';
+		</script>
+	`, nil)
+
+	root := p.Query("#open").ShadowRoot()
+	require.NotNil(t, root)
+	inner := root.Query(".inner")
+	require.NotNil(t, inner)
+	assert.Equal(t, "open content", inner.TextContent())
+
+	assert.Nil(t, p.Query("#closed").ShadowRoot())
+	assert.Nil(t, p.Query("#none").ShadowRoot())
+}
@@ -0,0 +1,1046 @@
+/*
+ *
+ * xk6-browser - a browser automation extension for k6
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package tests
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/xk6-browser/api"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrameWaitForExecutionContext(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withFileServer())
+	p := tb.NewPage(nil)
+	require.NotNil(t, p.Goto(tb.staticURL("empty.html"), nil))
+
+	frame := tb.attachFrame(p, "frame1", tb.staticURL("empty.html"))
+	require.NotNil(t, frame)
+
+	var log []string
+	require.NoError(t, tb.runtime().Set("log", func(s string) { log = append(log, s) }))
+	require.NoError(t, tb.runtime().Set("frame", frame))
+
+	err := tb.vu.Loop.Start(func() error {
+		_, err := tb.runtime().RunString(`
+			frame.waitForExecutionContext({ timeout: 1000 }).then(f => {
+				log('ok: ' + f.evaluate(() => 1 + 1));
+			}, err => {
+				log('err: ' + err);
+			});`)
+		if err != nil {
+			return fmt.Errorf("%w", err)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ok: 2"}, log)
+}
+
+func TestFrameGotoWaitUntil(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	tb.withHandler("/page", func(w http.ResponseWriter, _ *http.Request) {
+		// the request starts after the load event, so only
+		// networkidle waits for it to finish.
+		_, _ = fmt.Fprint(w, `<script>
+			window.addEventListener('load', () => {
+				setTimeout(() => fetch('/slow').then(() => window.fetched = true), 0);
+			});
+		</script>`)
+	})
+	tb.withHandler("/slow", func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		_, _ = fmt.Fprint(w, "ok")
+	})
+
+	for waitUntil, wantFetched := range map[string]bool{
+		"load":        false,
+		"networkidle": true,
+	} {
+		p := tb.NewPage(nil)
+		f := p.MainFrame()
+		require.NotNil(t, f.Goto(tb.URL("/page"), tb.toGojaValue(map[string]string{"waitUntil": waitUntil})))
+		fetched := f.Evaluate(tb.toGojaValue(`() => window.fetched === true`))
+		assert.Equalf(t, wantFetched, tb.asGojaBool(fetched), "waitUntil: %s", waitUntil)
+	}
+}
+
+func TestFrameTypeRespectMaxLength(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+	p.SetContent(`
+		<input id="a" maxlength="5" value="ab">
+		<textarea id="b" maxlength="3"></textarea>
+		<input id="c">
+		<script>
+			window.keys = 0;
+			document.addEventListener('keydown', () => window.keys++);
+		</script>
+	`, nil)
+	f := p.MainFrame()
+
+	typ := func(selector, text string, respectMaxLength bool) (string, int64) {
+		p.Evaluate(tb.toGojaValue(`() => window.keys = 0`))
+		f.Type(selector, text, tb.toGojaValue(map[string]bool{"respectMaxLength": respectMaxLength}))
+		return f.InputValue(selector, nil), tb.asGojaValue(p.Evaluate(tb.toGojaValue(`() => window.keys`))).ToInteger()
+	}
+
+	value, keys := typ("#a", "cdefg", true)
+	assert.Equal(t, "abcde", value)
+	assert.Equal(t, int64(3), keys, "should stop typing at the maxlength")
+
+	value, keys = typ("#b", "ab\U0001F600c", true)
+	assert.Equal(t, "ab", value, "an emoji takes two of the characters of maxlength")
+	assert.Equal(t, int64(2), keys)
+
+	value, keys = typ("#c", "unlimited", true)
+	assert.Equal(t, "unlimited", value)
+	assert.Equal(t, int64(9), keys)
+
+	p.Fill("#a", "", nil)
+	value, keys = typ("#a", "1234567", false)
+	assert.Equal(t, "12345", value)
+	assert.Equal(t, int64(7), keys, "should type all the keys by default")
+}
+
+func TestFrameWaitForImages(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	tb.withHandler("/gallery", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `<div id="gallery"><img src="/slow.png"><img src="/slow.png?2"></div>`)
+	})
+	tb.withHandler("/slow.png", func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		w.Header().Set("Content-Type", "image/png")
+		_ = png.Encode(w, image.NewRGBA(image.Rect(0, 0, 10, 10)))
+	})
+	p := tb.NewPage(nil)
+	require.NotNil(t, p.Goto(tb.URL("/gallery"), tb.toGojaValue(map[string]string{"waitUntil": "domcontentloaded"})))
+
+	var log []string
+	require.NoError(t, tb.runtime().Set("log", func(s string) { log = append(log, s) }))
+	require.NoError(t, tb.runtime().Set("frame", p.MainFrame()))
+
+	err := tb.vu.Loop.Start(func() error {
+		_, err := tb.runtime().RunString(`
+			frame.waitForImages("#gallery", { timeout: 5000 }).then(() => {
+				log('ok: ' + frame.evaluate(() =>
+					[...document.images].every(img => img.complete && img.naturalWidth > 0)));
+			}, err => {
+				log('err: ' + err);
+			});
+			frame.waitForImages("#gallery", { timeout: 100 }).then(() => {
+				log('should time out');
+			}, () => {
+				log('timed out');
+			});`)
+		if err != nil {
+			return fmt.Errorf("%w", err)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"ok: true", "timed out"}, log)
+}
+
+func TestFrameWaitForRequestResponse(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	tb.withHandler("/api/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, r.URL.Path)
+	})
+	p := tb.NewPage(nil)
+	p.SetContent(`<button onclick="
+		fetch('/api/cart').then(() => fetch('/api/checkout'))
+	">checkout</button>`, nil)
+
+	var log []string
+	require.NoError(t, tb.runtime().Set("log", func(s string) { log = append(log, s) }))
+	require.NoError(t, tb.runtime().Set("frame", p.MainFrame()))
+
+	err := tb.vu.Loop.Start(func() error {
+		_, err := tb.runtime().RunString(`
+			frame.waitForRequest('**/api/*').then(req => {
+				log('request: ' + req.url().replace(/^.*\/api/, '/api'));
+			}, err => {
+				log('err: ' + err);
+			});
+			frame.waitForResponse(/checkout$/).then(res => {
+				log('regexp: ' + res.status());
+			}, err => {
+				log('err: ' + err);
+			});
+			frame.waitForResponse(url => url.includes('/api/checkout')).then(res => {
+				log('predicate: ' + res.url().replace(/^.*\/api/, '/api'));
+			}, err => {
+				log('err: ' + err);
+			});
+			frame.waitForResponse('**/nothing', { timeout: 500 }).then(() => {
+				log('should time out');
+			}, err => {
+				log('timed out: ' + String(err).includes('timed out'));
+			});
+			frame.click('button');`)
+		if err != nil {
+			return fmt.Errorf("%w", err)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"request: /api/cart",
+		"regexp: 200",
+		"predicate: /api/checkout",
+		"timed out: true",
+	}, log)
+}
+
+func TestFrameScreenshot(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withFileServer())
+	p := tb.NewPage(nil)
+	require.NotNil(t, p.Goto(tb.staticURL("empty.html"), nil))
+
+	frame := tb.attachFrame(p, "frame1", tb.staticURL("empty.html"))
+	require.NotNil(t, frame)
+	p.Evaluate(tb.toGojaValue(`() => {
+		const frame = document.getElementById('frame1');
+		frame.style.border = '0';
+		frame.style.width = '200px';
+		frame.style.height = '100px';
+	}`))
+
+	buf := frame.Screenshot(nil)
+	img, err := png.Decode(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, 200, img.Bounds().Dx())
+	assert.Equal(t, 100, img.Bounds().Dy())
+
+	p.Evaluate(tb.toGojaValue(`() => document.getElementById('frame1').remove()`))
+	require.Eventually(t, frame.IsDetached, time.Second, 10*time.Millisecond)
+	assert.Panics(t, func() { frame.Screenshot(nil) })
+}
+
+func TestFrameSelectText(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+	p.SetContent(`
+		<input value="input text">
+		<textarea>textarea text</textarea>
+		<p id="para">paragraph <b>text</b></p>
+		<span>one</span><span>two</span>
+	`, nil)
+	f := p.MainFrame()
+
+	selected := func() string {
+		v := p.Evaluate(tb.toGojaValue(`() => {
+			const el = document.activeElement;
+			if (el && (el.tagName === "INPUT" || el.tagName === "TEXTAREA")) {
+				return el.value.substring(el.selectionStart, el.selectionEnd);
+			}
+			return window.getSelection().toString();
+		}`))
+		return tb.asGojaValue(v).String()
+	}
+
+	f.SelectText("input", nil)
+	assert.Equal(t, "input text", selected())
+	f.SelectText("textarea", nil)
+	assert.Equal(t, "textarea text", selected())
+	f.SelectText("#para", nil)
+	assert.Equal(t, "paragraph text", selected())
+
+	assert.Panics(t, func() {
+		f.SelectText("span", tb.toGojaValue(map[string]interface{}{"strict": true}))
+	}, "should fail in strict mode if the selector matches many elements")
+	assert.Panics(t, func() {
+		f.SelectText("#missing", tb.toGojaValue(map[string]interface{}{"timeout": 100}))
+	}, "should time out if the element isn't attached")
+}
+
+func TestFrameQuerySettled(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withFileServer())
+	p := tb.NewPage(nil)
+	require.NotNil(t, p.Goto(tb.staticURL("empty.html"), nil))
+	p.Evaluate(tb.toGojaValue(`() => document.body.innerHTML = '<div id="app">home</div>'`))
+	f := p.MainFrame()
+
+	// the query doesn't wait without a same-document navigation.
+	assert.Nil(t, f.QuerySettled("#next"))
+
+	// a client-side route change that mounts the new route a tick later.
+	p.Evaluate(tb.toGojaValue(`() => {
+		history.pushState({}, "", "#/next");
+		setTimeout(() => {
+			document.getElementById("app").innerHTML = '<p id="next">next</p>';
+		}, 50);
+	}`))
+	require.Eventually(t, func() bool {
+		return strings.HasSuffix(f.URL(), "#/next")
+	}, time.Second, 5*time.Millisecond)
+
+	assert.NotNil(t, f.QuerySettled("#next"))
+}
+
+func TestFrameHighlight(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+	p.SetContent(`<ul><li>one</li><li>two</li></ul>`, nil)
+	f := p.MainFrame()
+
+	overlays := func() int64 {
+		v := p.Evaluate(tb.toGojaValue(`() => {
+			const overlay = document.querySelector("[data-k6-browser-highlight]");
+			return overlay ? overlay.children.length : -1;
+		}`))
+		return tb.asGojaValue(v).ToInteger()
+	}
+	layout := func() string {
+		v := p.Evaluate(tb.toGojaValue(`() => JSON.stringify(document.querySelector("ul").getBoundingClientRect())`))
+		return tb.asGojaValue(v).String()
+	}
+	before := layout()
+
+	f.Highlight("li")
+	if defaultLaunchOpts().Headless {
+		assert.Equal(t, int64(-1), overlays(), "should not highlight in headless mode")
+	} else {
+		assert.Equal(t, int64(2), overlays())
+	}
+	assert.Equal(t, before, layout(), "should not change the layout")
+
+	f.Highlight("#missing")
+	assert.Equal(t, int64(-1), overlays(), "should not highlight if nothing matches")
+
+	f.Highlight("li")
+	f.ClearHighlight()
+	assert.Equal(t, int64(-1), overlays())
+}
+
+func TestFrameDispatchEventInit(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+	p.SetContent(`
+		<div id="parent"><button id="btn">click</button></div>
+		<script>
+			window.events = [];
+			document.getElementById('parent').addEventListener('custom', e => {
+				e.preventDefault();
+				window.events.push('parent:' + e.defaultPrevented);
+			});
+		</script>
+	`, nil)
+	f := p.MainFrame()
+
+	f.DispatchEvent("#btn", "custom", nil, nil)
+	f.DispatchEvent("#btn", "custom", tb.toGojaValue(map[string]interface{}{"cancelable": false}), nil)
+	f.DispatchEvent("#btn", "custom", tb.toGojaValue(map[string]interface{}{"bubbles": false}), nil)
+
+	events := p.Evaluate(tb.toGojaValue(`() => window.events.join(',')`))
+	assert.Equal(t, "parent:true,parent:false", tb.asGojaValue(events).String())
+}
+
+func TestFrameDispatchEventOptions(t *testing.T) {
+	t.Parallel()
+
+	content := `
+		<button>a</button>
+		<button>b</button>
+		<script>
+			window.events = [];
+			document.querySelectorAll('button').forEach(b =>
+				b.addEventListener('custom', () => window.events.push(b.textContent)));
+		</script>
+	`
+
+	t.Run("non_strict", func(t *testing.T) {
+		t.Parallel()
+
+		tb := newTestBrowser(t)
+		p := tb.NewPage(nil)
+		p.SetContent(content, nil)
+		p.MainFrame().DispatchEvent("button", "custom", nil, tb.toGojaValue(map[string]interface{}{
+			"clickCount": 2,
+		}))
+
+		events := p.Evaluate(tb.toGojaValue(`() => window.events.join(',')`))
+		assert.Equal(t, "a", tb.asGojaValue(events).String())
+	})
+	t.Run("strict", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			assertPanicErrorContains(t, recover(), `strict mode violation: "button" resolved to 2 elements`)
+		}()
+
+		tb := newTestBrowser(t)
+		p := tb.NewPage(nil)
+		p.SetContent(content, nil)
+		p.MainFrame().DispatchEvent("button", "custom", nil, tb.toGojaValue(map[string]interface{}{
+			"strict": true,
+		}))
+	})
+	t.Run("timeout", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			assertPanicErrorContains(t, recover(), "timed out")
+		}()
+
+		tb := newTestBrowser(t)
+		p := tb.NewPage(nil)
+		p.SetContent(content, nil)
+		p.MainFrame().DispatchEvent("#missing", "custom", nil, tb.toGojaValue(map[string]interface{}{
+			"timeout": 100,
+		}))
+	})
+}
+
+func TestFrameDispatchEventTypes(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+	p.SetContent(`
+		<div id="target">drop here</div>
+		<script>
+			window.events = [];
+			const target = document.getElementById('target');
+			for (const type of ['click', 'keydown', 'wheel', 'drop', 'custom']) {
+				target.addEventListener(type, e => {
+					let detail = '';
+					switch (type) {
+					case 'click': detail = e.clientX; break;
+					case 'keydown': detail = e.key; break;
+					case 'wheel': detail = e.deltaY; break;
+					case 'drop': detail = e.dataTransfer.getData('text/plain'); break;
+					}
+					window.events.push(type + ':' + e.constructor.name + ':' + detail);
+				});
+			}
+		</script>
+	`, nil)
+	f := p.MainFrame()
+
+	f.DispatchEvent("#target", "click", tb.toGojaValue(map[string]interface{}{"clientX": 10}), nil)
+	f.DispatchEvent("#target", "keydown", tb.toGojaValue(map[string]interface{}{"key": "Enter"}), nil)
+	f.DispatchEvent("#target", "wheel", tb.toGojaValue(map[string]interface{}{"deltaY": 100}), nil)
+	f.DispatchEvent("#target", "drop", tb.toGojaValue(map[string]interface{}{
+		"dataTransfer": map[string]string{"text/plain": "dropped"},
+	}), nil)
+	f.DispatchEvent("#target", "custom", nil, nil)
+
+	events := p.Evaluate(tb.toGojaValue(`() => window.events`))
+	var got []string
+	require.NoError(t, tb.runtime().ExportTo(tb.asGojaValue(events), &got))
+	assert.Equal(t, []string{
+		"click:MouseEvent:10",
+		"keydown:KeyboardEvent:Enter",
+		"wheel:WheelEvent:100",
+		"drop:DragEvent:dropped",
+		"custom:Event:",
+	}, got)
+}
+
+func TestFrameEvalOnSelector(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+	p.SetContent(`<div id="el" data-value="42">text</div>`, nil)
+	f := p.MainFrame()
+
+	got := f.EvalOnSelector("#el",
+		tb.toGojaValue(`(el, suffix) => el.dataset.value + suffix`),
+		tb.toGojaValue("!"))
+	assert.Equal(t, "42!", tb.asGojaValue(got).String())
+
+	assert.Panics(t, func() {
+		f.EvalOnSelector("#missing", tb.toGojaValue(`el => el.id`))
+	}, "should throw if no element matches")
+}
+
+func TestFrameEvalOnSelectorInvalidSelector(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		assertPanicErrorContains(t, recover(), `evaluating on selector "#el[":`)
+	}()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+	p.SetContent(`<div id="el"></div>`, nil)
+	p.MainFrame().EvalOnSelector("#el[", tb.toGojaValue(`el => el.id`))
+	t.Error("did not panic")
+}
+
+func TestFrameEvalOnSelectorAll(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+	p.SetContent(`<ul><li>a</li><li>b</li><li>c</li></ul>`, nil)
+	f := p.MainFrame()
+
+	got := f.EvalOnSelectorAll("li",
+		tb.toGojaValue(`(els, sep) => els.map(e => e.textContent).join(sep)`),
+		tb.toGojaValue("-"))
+	assert.Equal(t, "a-b-c", tb.asGojaValue(got).String())
+
+	got = f.EvalOnSelectorAll("p", tb.toGojaValue(`els => els.length`))
+	assert.Equal(t, int64(0), tb.asGojaValue(got).ToInteger())
+}
+
+func TestFrameEvalOnSelectorAllInvalidSelector(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		assertPanicErrorContains(t, recover(), `evaluating on all elements of selector "li[":`)
+	}()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+	p.SetContent(`<ul><li>a</li></ul>`, nil)
+	p.MainFrame().EvalOnSelectorAll("li[", tb.toGojaValue(`els => els.length`))
+	t.Error("did not panic")
+}
+
+func TestFrameWaitForSelectorEditable(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+	p.SetContent(`<input id="first" disabled><input id="second" readonly>`, nil)
+	p.Evaluate(tb.toGojaValue(`() => setTimeout(() => {
+		document.getElementById('first').disabled = false;
+		document.getElementById('second').readOnly = false;
+	}, 100)`))
+	f := p.MainFrame()
+
+	el := f.WaitForSelector("#first", tb.toGojaValue(struct {
+		State   string `js:"state"`
+		Timeout int64  `js:"timeout"`
+	}{State: "editable", Timeout: 1000}))
+	require.NotNil(t, el, "expected the element to become editable")
+	assert.True(t, el.IsEditable())
+
+	// fill should wait for the field to become editable.
+	f.Fill("#second", "hello", nil)
+	assert.Equal(t, "hello", f.InputValue("#second", nil))
+}
+
+func TestFrameWaitForSelectorStates(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+	p.SetContent(`<button id="btn" disabled style="display: none">click</button>`, nil)
+	p.Evaluate(tb.toGojaValue(`() => {
+		const btn = document.getElementById('btn');
+		setTimeout(() => btn.style.display = 'block', 50);
+		setTimeout(() => btn.disabled = false, 150);
+	}`))
+	f := p.MainFrame()
+
+	el := f.WaitForSelector("#btn", tb.toGojaValue(map[string]interface{}{
+		"state":   []string{"visible", "enabled", "stable"},
+		"timeout": 1000,
+	}))
+	require.NotNil(t, el, "expected the element to become visible and enabled")
+	assert.True(t, el.IsVisible())
+	assert.True(t, el.IsEnabled(), "should wait for all the states at the same time")
+}
+
+func TestFrameWaitForAnySelector(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+	p.SetContent(`<div id="root"></div>`, nil)
+	p.Evaluate(tb.toGojaValue(`() => setTimeout(() => {
+		const div = document.createElement('div');
+		div.className = 'variant-b';
+		div.textContent = 'B';
+		document.getElementById('root').appendChild(div);
+	}, 100)`))
+	f := p.MainFrame()
+
+	match := f.WaitForAnySelector([]string{".variant-a", ".variant-b"}, tb.toGojaValue(struct {
+		Timeout int64 `js:"timeout"`
+	}{Timeout: 1000}))
+	require.NotNil(t, match)
+	assert.Equal(t, ".variant-b", match.Selector)
+	require.NotNil(t, match.Handle)
+	assert.Equal(t, "B", match.Handle.TextContent())
+
+	match = f.WaitForAnySelector([]string{".variant-b", ".variant-c"}, tb.toGojaValue(struct {
+		State string `js:"state"`
+	}{State: "detached"}))
+	assert.Equal(t, ".variant-c", match.Selector)
+	assert.Nil(t, match.Handle)
+}
+
+func TestFrameFillWaitsForEditable(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+	p.SetContent(`<input id="a" readonly><input id="b" readonly>`, nil)
+	p.Evaluate(tb.toGojaValue(`() => setTimeout(() => {
+		document.getElementById('a').removeAttribute('readonly');
+	}, 200)`))
+	f := p.MainFrame()
+
+	// a zero timeout disables the timeout instead of giving up immediately.
+	f.Fill("#a", "without timeout", tb.toGojaValue(map[string]interface{}{"timeout": 0}))
+	assert.Equal(t, "without timeout", f.InputValue("#a", nil))
+
+	p.Evaluate(tb.toGojaValue(`() => setTimeout(() => {
+		document.getElementById('b').removeAttribute('readonly');
+	}, 200)`))
+	f.Fill("#b", "with timeout", tb.toGojaValue(map[string]interface{}{"timeout": 2000}))
+	assert.Equal(t, "with timeout", f.InputValue("#b", nil))
+}
+
+func TestFrameWaitForSelectorZeroTimeout(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+	p.Evaluate(tb.toGojaValue(`() => setTimeout(() => {
+		document.body.innerHTML = '<div id="late"></div>';
+	}, 200)`))
+	f := p.MainFrame()
+
+	// a zero timeout disables the timeout of the wait in the page.
+	require.NotNil(t, f.WaitForSelector("#late", tb.toGojaValue(map[string]interface{}{"timeout": 0})))
+
+	// any other timeout is armed, however short it is.
+	assert.Panics(t, func() {
+		f.WaitForSelector("#missing", tb.toGojaValue(map[string]interface{}{"timeout": 1}))
+	})
+}
+
+func TestFrameResourceTransferSize(t *testing.T) {
+	t.Parallel()
+
+	body := bytes.Repeat([]byte("x"), 4096)
+	tb := newTestBrowser(t, withHTTPServer())
+	tb.withHandler("/page", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `<img id="loaded" src="/img"><img id="empty"><link id="unloaded" href="/unloaded">`)
+	})
+	tb.withHandler("/img", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(body)
+	})
+	tb.withHandler("/other", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `<link id="stale" href="/img">`)
+	})
+	p := tb.NewPage(nil)
+	require.NotNil(t, p.Goto(tb.URL("/page"), tb.toGojaValue(map[string]string{"waitUntil": "load"})))
+	f := p.MainFrame()
+
+	assert.Greater(t, f.ResourceTransferSize("#loaded"), int64(len(body)),
+		"should include the body and the headers")
+	assert.Equal(t, int64(-1), f.ResourceTransferSize("#empty"), "should be unknown without a resource")
+	assert.Equal(t, int64(-1), f.ResourceTransferSize("#unloaded"), "should be unknown until loaded")
+
+	// the link doesn't load the image again on the next page.
+	require.NotNil(t, p.Goto(tb.URL("/other"), tb.toGojaValue(map[string]string{"waitUntil": "load"})))
+	assert.Equal(t, int64(-1), f.ResourceTransferSize("#stale"),
+		"should forget the resources of the previous document")
+}
+
+func TestFrameEvaluateTop(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	crossOriginURL := strings.Replace(tb.URL("/frame"), "127.0.0.1", "localhost", 1)
+	tb.withHandler("/page", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprintf(w, `
+			<script>window.shared = { from: 'top' };</script>
+			<iframe id="same" src="/frame"></iframe>
+			<iframe id="cross" src=%q></iframe>`, crossOriginURL)
+	})
+	tb.withHandler("/frame", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `<script>window.shared = { from: 'frame' };</script>`)
+	})
+	p := tb.NewPage(nil)
+	require.NotNil(t, p.Goto(tb.URL("/page"), tb.toGojaValue(map[string]string{"waitUntil": "load"})))
+	require.Len(t, p.Frames(), 3)
+
+	var same, cross api.Frame
+	for _, f := range p.Frames()[1:] {
+		if f.URL() == crossOriginURL {
+			cross = f
+		} else {
+			same = f
+		}
+	}
+	require.NotNil(t, same)
+	require.NotNil(t, cross)
+
+	fn := tb.toGojaValue(`suffix => window.shared.from + suffix`)
+	assert.Equal(t, "frame!", tb.asGojaValue(same.Evaluate(fn, tb.toGojaValue("!"))).String())
+	assert.Equal(t, "top!", tb.asGojaValue(same.EvaluateTop(fn, tb.toGojaValue("!"))).String())
+	assert.Equal(t, "top!", tb.asGojaValue(p.MainFrame().EvaluateTop(fn, tb.toGojaValue("!"))).String())
+
+	defer func() {
+		assertPanicErrorContains(t, recover(), "is not same-origin with the top frame")
+	}()
+	cross.EvaluateTop(fn, tb.toGojaValue("!"))
+	t.Error("did not panic")
+}
+
+func TestFrameCrossOriginIFrame(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	// 127.0.0.1 and localhost are different sites, so the iframe is
+	// loaded out of process.
+	frameURL := strings.Replace(tb.URL("/frame"), "127.0.0.1", "localhost", 1)
+	tb.withHandler("/page", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprintf(w, `<iframe id="frame" src=%q></iframe>`, frameURL)
+	})
+	tb.withHandler("/frame", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `<button onclick="this.innerText = 'clicked'">click</button>`)
+	})
+	p := tb.NewPage(nil)
+	require.NotNil(t, p.Goto(tb.URL("/page"), tb.toGojaValue(map[string]string{"waitUntil": "load"})))
+
+	frames := p.Frames()
+	require.Len(t, frames, 2)
+	f := frames[1]
+	require.Equal(t, frameURL, f.URL())
+
+	f.Click("button", nil)
+	assert.Equal(t, "clicked", f.InnerText("button", nil))
+
+	// navigating to the page's origin moves the frame back into the
+	// process of the page, which should keep the same frame.
+	require.NotNil(t, f.Goto(tb.URL("/frame"), tb.toGojaValue(map[string]string{"waitUntil": "load"})))
+	require.Len(t, p.Frames(), 2)
+	f.Click("button", nil)
+	assert.Equal(t, "clicked", f.InnerText("button", nil))
+}
+
+func TestFrameAllTexts(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+	p.SetContent(`
+		<ul>
+			<li>one</li>
+			<li>two<span style="display: none"> hidden</span></li>
+			<li>three <b>bold</b></li>
+		</ul>
+	`, nil)
+	f := p.MainFrame()
+
+	assert.Equal(t, []string{"one", "two hidden", "three bold"}, f.AllTextContents("li"))
+	// the inner text leaves out the hidden text.
+	assert.Equal(t, []string{"one", "two", "three bold"}, f.AllInnerTexts("li"))
+
+	assert.Equal(t, []string{}, f.AllTextContents("#missing"))
+	assert.Equal(t, []string{}, f.AllInnerTexts("#missing"))
+}
+
+func TestFrameCount(t *testing.T) {
+	t.Parallel()
+
+	p := newTestBrowser(t).NewPage(nil)
+	p.SetContent(`
+		<ul>
+			<li>one</li>
+			<li>two</li>
+			<li>three</li>
+		</ul>
+	`, nil)
+	f := p.MainFrame()
+
+	assert.Equal(t, int64(3), f.Count("li"))
+	assert.Equal(t, int64(1), f.Count("text=two"))
+	assert.Equal(t, int64(0), f.Count("ol"))
+}
+
+func TestFrameActionsShareDeadline(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+	p.SetContent(`<button>click</button>`, nil)
+	f := p.MainFrame()
+
+	start := time.Now()
+	opts := tb.toGojaValue(map[string]interface{}{
+		"deadline": start.Add(500 * time.Millisecond).UnixMilli(),
+		"timeout":  10000,
+	})
+	require.NotNil(t, f.WaitForSelector("button", opts))
+	assert.Panics(t, func() { f.Click("#missing", opts) })
+	assert.Less(t, time.Since(start), 5*time.Second, "should stop at the shared deadline")
+}
+
+func TestFrameActionCancelsPageWait(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+	// the page counts the polls for the missing element and drops the
+	// long timers, so only a cancellation from the action can stop the
+	// wait in the page.
+	p.SetContent(`<script>
+		window.polls = 0;
+		const origQuerySelectorAll = Document.prototype.querySelectorAll;
+		Document.prototype.querySelectorAll = function(selector) {
+			if (selector === '#missing') window.polls++;
+			return origQuerySelectorAll.call(this, selector);
+		};
+		const origSetTimeout = window.setTimeout;
+		window.setTimeout = (fn, ms, ...args) => ms >= 100 ? 0 : origSetTimeout(fn, ms, ...args);
+	</script>`, nil)
+	f := p.MainFrame()
+
+	assert.Panics(t, func() {
+		f.Click("#missing", tb.toGojaValue(map[string]interface{}{"timeout": 300}))
+	})
+	polls := func() int64 {
+		return tb.asGojaValue(f.Evaluate(tb.toGojaValue(`() => window.polls`))).ToInteger()
+	}
+	n := polls()
+	require.Greater(t, n, int64(0), "should poll for the element")
+	time.Sleep(300 * time.Millisecond)
+	assert.Equal(t, n, polls(), "should stop polling once the action times out")
+}
+
+func TestFrameActionRetries(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+	f := p.MainFrame()
+
+	// an overlay intercepts the clicks on the button until it's removed.
+	setContent := func() {
+		p.SetContent(`
+			<button onclick="window.clicked = true">click</button>
+			<div id="overlay" style="position: fixed; inset: 0"></div>
+			<script>setTimeout(() => document.getElementById('overlay').remove(), 300)</script>
+		`, nil)
+	}
+
+	setContent()
+	assert.Panics(t, func() { f.Click("button", nil) }, "should fail without retries")
+
+	setContent()
+	f.Click("button", tb.toGojaValue(map[string]interface{}{"retries": 5}))
+	assert.True(t, tb.asGojaValue(f.Evaluate(tb.toGojaValue(`() => window.clicked`))).ToBoolean())
+
+	assert.Panics(t, func() {
+		f.Click("button", tb.toGojaValue(map[string]interface{}{"retries": -1}))
+	}, "should reject negative retries")
+
+	// the input is replaced, which detaches it, until it's stable.
+	p.SetContent(`
+		<input>
+		<script>
+			let n = 0;
+			const id = setInterval(() => {
+				document.querySelector('input').replaceWith(document.createElement('input'));
+				if (++n === 5) clearInterval(id);
+			}, 50);
+		</script>
+	`, nil)
+	f.Press("input", "a", tb.toGojaValue(map[string]interface{}{"retries": 10}))
+	assert.Panics(t, func() {
+		f.Press("input", "a", tb.toGojaValue(map[string]interface{}{"retries": -1}))
+	}, "should reject negative retries")
+	assert.Panics(t, func() {
+		f.Type("input", "a", tb.toGojaValue(map[string]interface{}{"retries": -1}))
+	}, "should reject negative retries")
+	assert.Panics(t, func() {
+		f.DispatchEvent("input", "click", nil, tb.toGojaValue(map[string]interface{}{"retries": -1}))
+	}, "should reject negative retries")
+}
+
+func TestFrameInnerTextScoped(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+	p.SetContent(`
+		<section id="report">
+			Summary <b>total</b>
+			<div class="label">Revenue <span>100</span></div>
+			<div class="label">Costs <span>40</span></div>
+			<div><div><p>deeply nested</p></div></div>
+			<div style="display: none">hidden</div>
+		</section>
+	`, nil)
+	f := p.MainFrame()
+
+	innerText := func(opts map[string]interface{}) string {
+		return f.InnerText("#report", tb.toGojaValue(opts))
+	}
+	assert.Equal(t, "Revenue 100\nCosts 40", innerText(map[string]interface{}{"within": ".label"}))
+	assert.Equal(t,
+		"Summary total\nRevenue\nCosts",
+		innerText(map[string]interface{}{"maxDepth": 1}),
+	)
+	assert.Equal(t,
+		"Summary total\nRevenue 100\nCosts 40",
+		innerText(map[string]interface{}{"maxDepth": 2}),
+	)
+	assert.Contains(t, innerText(nil), "deeply nested")
+	assert.Empty(t, innerText(map[string]interface{}{"within": ".missing"}))
+
+	assert.Panics(t, func() { innerText(map[string]interface{}{"maxDepth": -1}) })
+}
+
+func TestFrameWaitForCount(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+	p.SetContent(`
+		<div class="spinner"></div>
+		<div class="spinner"></div>
+		<script>
+			setTimeout(() => document.querySelectorAll('.spinner')[0].remove(), 100);
+			setTimeout(() => document.querySelectorAll('.spinner')[0].remove(), 300);
+		</script>
+	`, nil)
+	f := p.MainFrame()
+
+	f.WaitForCount(".spinner", 1, nil)
+	f.WaitForCount(".spinner", 0, nil)
+	assert.Equal(t, int64(0), f.Count(".spinner"))
+
+	start := time.Now()
+	assert.Panics(t, func() {
+		f.WaitForCount("div", 3, tb.toGojaValue(map[string]interface{}{"timeout": 200}))
+	})
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Panics(t, func() { f.WaitForCount("div", -1, nil) })
+}
+
+func TestFrameTapRequiresTouch(t *testing.T) {
+	t.Parallel()
+
+	content := `<button ontouchstart="window.tapped = true">tap</button>`
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			assertPanicErrorContains(t, recover(), "set hasTouch: true in the browser context options")
+		}()
+
+		tb := newTestBrowser(t)
+		p := tb.NewPage(nil)
+		p.SetContent(content, nil)
+		p.MainFrame().Tap("button", nil)
+	})
+	t.Run("enabled", func(t *testing.T) {
+		t.Parallel()
+
+		tb := newTestBrowser(t)
+		p := tb.NewPage(tb.toGojaValue(map[string]interface{}{"hasTouch": true}))
+		p.SetContent(content, nil)
+		p.MainFrame().Tap("button", nil)
+
+		tapped := p.Evaluate(tb.toGojaValue(`() => window.tapped === true`))
+		assert.True(t, tb.asGojaBool(tapped))
+	})
+}
+
+func TestFrameInputValueElementTypes(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+	p.SetContent(`
+		<input id="input" value="input value">
+		<textarea id="textarea">textarea value</textarea>
+		<select id="select">
+			<option value="a">A</option>
+			<option value="b" selected>B</option>
+		</select>
+		<select id="multi" multiple>
+			<option value="a" selected>A</option>
+			<option value="b">B</option>
+			<option value="c" selected>C</option>
+		</select>
+		<select id="multi-none" multiple><option value="a">A</option></select>
+		<select id="multi-comma" multiple>
+			<option value="a,b" selected>A, B</option>
+			<option value="c" selected>C</option>
+		</select>
+		<div id="editable" contenteditable="true">editable <b>text</b></div>
+		<div id="plain">plain</div>
+	`, nil)
+	f := p.MainFrame()
+
+	for sel, want := range map[string]string{
+		"#input":      "input value",
+		"#textarea":   "textarea value",
+		"#select":     "b",
+		"#multi":      "a,c",
+		"#multi-none": "",
+		// option values with commas can't be told apart once joined.
+		"#multi-comma": "a,b,c",
+		"#editable":    "editable text",
+	} {
+		assert.Equal(t, want, f.InputValue(sel, nil), sel)
+	}
+
+	defer func() {
+		assertPanicErrorContains(t, recover(), "Node is not an <input>, <textarea>, <select> or contenteditable element")
+	}()
+	f.InputValue("#plain", nil)
+}
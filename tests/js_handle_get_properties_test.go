@@ -24,6 +24,7 @@ import (
 	_ "embed"
 	"testing"
 
+	"github.com/dop251/goja"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -45,3 +46,23 @@ func TestJSHandleGetProperties(t *testing.T) {
 	value := props["prop1"].JSONValue().String()
 	assert.Equal(t, value, "one", `expected property value of "one", got %q`, value)
 }
+
+func TestJSHandleGetProperty(t *testing.T) {
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+
+	handle := p.EvaluateHandle(tb.toGojaValue(`
+	() => {
+		return {
+			prop1: "one",
+			nested: { prop2: "two" }
+		};
+	}
+	`))
+
+	value := handle.GetProperty("prop1").JSONValue().String()
+	assert.Equal(t, "one", value)
+	nested := handle.GetProperty("nested").GetProperty("prop2").JSONValue().String()
+	assert.Equal(t, "two", nested)
+	assert.True(t, goja.IsUndefined(handle.GetProperty("missing").JSONValue()))
+}
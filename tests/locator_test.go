@@ -20,10 +20,34 @@ import (
 func TestLocator(t *testing.T) {
 	t.Parallel()
 
+	// tap needs touch emulation, the other actions don't mind it.
+	withTouch := func(tb *testBrowser) goja.Value {
+		return tb.toGojaValue(map[string]interface{}{"hasTouch": true})
+	}
+
 	tests := []struct {
 		name string
 		do   func(*testBrowser, api.Page)
 	}{
+		{
+			"All", func(tb *testBrowser, p api.Page) {
+				ls := p.Locator("div > span", nil).All()
+				require.Len(t, ls, 2)
+				assert.Equal(t, "hello", ls[0].TextContent(nil))
+				assert.Equal(t, "bye", ls[1].TextContent(nil))
+				require.Empty(t, p.Locator("#does-not-exist", nil).All())
+			},
+		},
+		{
+			"FirstLastNth", func(tb *testBrowser, p api.Page) {
+				l := p.Locator("div > span", nil)
+				assert.Equal(t, "hello", l.First().TextContent(nil))
+				assert.Equal(t, "bye", l.Last().TextContent(nil))
+				assert.Equal(t, "hello", l.Nth(0).TextContent(nil))
+				assert.Equal(t, "bye", l.Nth(1).TextContent(nil))
+				assert.Equal(t, "bye", l.Last().First().TextContent(nil), "should narrow the previous match")
+			},
+		},
 		{
 			"Check", func(tb *testBrowser, p api.Page) {
 				t.Run("check", func(t *testing.T) {
@@ -183,7 +207,7 @@ func TestLocator(t *testing.T) {
 			t.Parallel()
 
 			tb := newTestBrowser(t, withFileServer())
-			p := tb.NewPage(nil)
+			p := tb.NewPage(withTouch(tb))
 			require.NotNil(t, p.Goto(tb.staticURL("/locators.html"), nil))
 			tt.do(tb, p)
 		})
@@ -258,14 +282,14 @@ func TestLocator(t *testing.T) {
 			t.Parallel()
 
 			tb := newTestBrowser(t)
-			p := tb.NewPage(nil)
+			p := tb.NewPage(withTouch(tb))
 			p.SetContent("<html></html>", nil)
 			assert.Panics(t, func() { tt.do(p.Locator("NOTEXIST", nil), tb) })
 		})
 	}
 
 	tb := newTestBrowser(t, withFileServer())
-	p := tb.NewPage(nil)
+	p := tb.NewPage(withTouch(tb))
 	require.NotNil(t, p.Goto(tb.staticURL("/locators.html"), nil))
 	for _, tt := range sanityTests {
 		t.Run("strict/"+tt.name, func(t *testing.T) {
@@ -371,3 +395,85 @@ func TestLocatorElementState(t *testing.T) {
 		})
 	}
 }
+
+func TestLocatorGetByRole(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+	p.SetContent(`
+		<button>Messages (3)</button>
+		<button aria-label="Close dialog">x</button>
+		<span id="lbl">Send now</span>
+		<div role="button" aria-labelledby="lbl">→</div>
+		<button><img alt="Search"> the site</button>
+		<button>Say "hi" >> [now]</button>
+		<button style="display: none">Messages (hidden)</button>
+		<label>Email <input type="email" value="a@b.c"></label>
+		<a href="/">Home</a>
+	`, nil)
+
+	re, err := tb.runtime().RunString(`/Messages \(\d+\)/`)
+	require.NoError(t, err)
+	name := func(v interface{}) goja.Value {
+		return tb.toGojaValue(map[string]interface{}{"name": v})
+	}
+
+	assert.Equal(t, "Messages (3)", p.GetByRole("button", name(re)).TextContent(nil))
+	assert.Equal(t, "x", p.GetByRole("button", name("close dialog")).TextContent(nil))
+	assert.Equal(t, "→", p.GetByRole("button", name("Send now")).TextContent(nil))
+	assert.Equal(t, " the site", p.GetByRole("button", name("Search the site")).TextContent(nil))
+	assert.Equal(t, "a@b.c", p.GetByRole("textbox", name("Email")).InputValue(nil))
+	assert.Equal(t, "Home", p.GetByRole("link", nil).TextContent(nil))
+
+	// quotes, ] and >> in a RegExp name don't break the selector.
+	quoted, err := tb.runtime().RunString(`/"hi" >> \[now]$/`)
+	require.NoError(t, err)
+	assert.Equal(t, `Say "hi" >> [now]`, p.GetByRole("button", name(quoted)).TextContent(nil))
+
+	// the hidden button isn't in the accessibility tree.
+	assert.Len(t, p.GetByRole("button", name("messages")).All(), 1)
+	assert.Len(t, p.GetByRole("button", nil).All(), 5)
+	// exact matches the whole name case-sensitively.
+	assert.Empty(t, p.GetByRole("button", tb.toGojaValue(map[string]interface{}{
+		"name": "close", "exact": true,
+	})).All())
+	assert.Len(t, p.GetByRole("button", tb.toGojaValue(map[string]interface{}{
+		"name": "Close dialog", "exact": true,
+	})).All(), 1)
+}
+
+func TestLocatorGetByRoleStates(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+	p.SetContent(`
+		<input type="checkbox" aria-label="Agree" checked>
+		<input type="checkbox" aria-label="Subscribe">
+		<div role="checkbox" aria-label="Partial" aria-checked="mixed"></div>
+		<button aria-label="Bold" aria-pressed="true">B</button>
+		<button aria-label="Italic" aria-pressed="false">I</button>
+		<button aria-label="Menu" aria-expanded="true">≡</button>
+	`, nil)
+
+	names := func(role string, opts map[string]interface{}) []string {
+		var got []string
+		for _, l := range p.GetByRole(role, tb.toGojaValue(opts)).All() {
+			got = append(got, l.GetAttribute("aria-label", nil).String())
+		}
+		return got
+	}
+
+	assert.Equal(t, []string{"Agree"}, names("checkbox", map[string]interface{}{"checked": true}))
+	// a mixed checkbox is neither checked nor unchecked.
+	assert.Equal(t, []string{"Subscribe"}, names("checkbox", map[string]interface{}{"checked": false}))
+	assert.Equal(t, []string{"Bold"}, names("button", map[string]interface{}{"pressed": true}))
+	assert.Equal(t, []string{"Menu"}, names("button", map[string]interface{}{"expanded": true}))
+	assert.Equal(t, []string{"Italic"}, names("button", map[string]interface{}{
+		"name": "it", "pressed": false,
+	}))
+
+	mixed := p.Locator(`role=checkbox[checked=mixed]`, nil)
+	assert.Equal(t, "Partial", mixed.GetAttribute("aria-label", nil).String())
+}
@@ -23,6 +23,7 @@ package tests
 import (
 	"fmt"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/grafana/xk6-browser/api"
@@ -127,3 +128,55 @@ func TestBasicAuth(t *testing.T) {
 		assert.Equal(t, http.StatusUnauthorized, int(resp.Status()))
 	})
 }
+
+func TestBasicAuthByOrigin(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	// 127.0.0.1 and localhost are different origins of the same server.
+	url1 := tb.URL("/basic-auth/user1/pass1")
+	url2 := strings.Replace(tb.URL("/basic-auth/user2/pass2"), "127.0.0.1", "localhost", 1)
+
+	bctx := tb.NewContext(tb.toGojaValue(map[string]interface{}{
+		"httpCredentials": []map[string]string{
+			{"username": "user1", "password": "pass1", "origin": url1},
+			{"username": "user2", "password": "pass2"},
+		},
+	}))
+	t.Cleanup(bctx.Close)
+	p := bctx.NewPage()
+
+	status := func(u string) int {
+		resp := p.Goto(u, tb.toGojaValue(map[string]string{"waitUntil": "load"}))
+		require.NotNil(t, resp)
+		return int(resp.Status())
+	}
+	assert.Equal(t, http.StatusOK, status(url1))
+	assert.Equal(t, http.StatusOK, status(url2),
+		"should fall back to the credentials without an origin")
+	assert.Equal(t, http.StatusUnauthorized, status(tb.URL("/basic-auth/user2/pass2")),
+		"should prefer the credentials of the origin")
+}
+
+func TestSetHTTPCredentialsKeepsOtherOrigins(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	url1 := tb.URL("/basic-auth/user1/pass1")
+	url2 := strings.Replace(tb.URL("/basic-auth/user2/pass2"), "127.0.0.1", "localhost", 1)
+
+	bctx := tb.NewContext(tb.toGojaValue(map[string]interface{}{
+		"httpCredentials": map[string]string{"username": "user1", "password": "pass1", "origin": url1},
+	}))
+	t.Cleanup(bctx.Close)
+	bctx.SetHTTPCredentials(tb.toGojaValue(map[string]string{"username": "user2", "password": "pass2"}))
+	p := bctx.NewPage()
+
+	status := func(u string) int {
+		resp := p.Goto(u, tb.toGojaValue(map[string]string{"waitUntil": "load"}))
+		require.NotNil(t, resp)
+		return int(resp.Status())
+	}
+	assert.Equal(t, http.StatusOK, status(url1), "should keep the credentials of the origin")
+	assert.Equal(t, http.StatusOK, status(url2))
+}
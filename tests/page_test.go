@@ -24,13 +24,24 @@ import (
 	"bytes"
 	"context"
 	_ "embed"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/grafana/xk6-browser/api"
+	"github.com/grafana/xk6-browser/common"
 
 	"github.com/dop251/goja"
+	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -39,6 +50,7 @@ type emulateMediaOpts struct {
 	Media         string `js:"media"`
 	ColorScheme   string `js:"colorScheme"`
 	ReducedMotion string `js:"reducedMotion"`
+	Contrast      string `js:"contrast"`
 }
 
 type jsFrameBaseOpts struct {
@@ -56,6 +68,7 @@ func TestPageEmulateMedia(t *testing.T) {
 		Media:         "print",
 		ColorScheme:   "dark",
 		ReducedMotion: "reduce",
+		Contrast:      "more",
 	}))
 
 	result := p.Evaluate(tb.toGojaValue("() => matchMedia('print').matches"))
@@ -72,6 +85,165 @@ func TestPageEmulateMedia(t *testing.T) {
 	res, ok = result.(goja.Value)
 	require.True(t, ok)
 	assert.True(t, res.ToBoolean(), "expected reduced motion setting to be 'reduce'")
+
+	result = p.Evaluate(tb.toGojaValue("() => matchMedia('(prefers-contrast: more)').matches"))
+	res, ok = result.(goja.Value)
+	require.True(t, ok)
+	assert.True(t, res.ToBoolean(), "expected contrast setting to be 'more'")
+}
+
+func TestPageSetOrientation(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(tb.toGojaValue(map[string]interface{}{
+		"orientation": "portrait-secondary",
+	}))
+
+	orientation := func() string {
+		v := p.Evaluate(tb.toGojaValue(`() => screen.orientation.type + ':' + screen.orientation.angle`))
+		return tb.asGojaValue(v).String()
+	}
+	assert.Equal(t, "portrait-secondary:180", orientation(), "should use the context option")
+
+	p.Evaluate(tb.toGojaValue(`() => {
+		window.changes = 0;
+		screen.orientation.addEventListener('change', () => window.changes++);
+	}`))
+	p.SetOrientation("landscape-primary")
+	assert.Equal(t, "landscape-primary:90", orientation())
+
+	changed := p.Evaluate(tb.toGojaValue(`() => new Promise(resolve => {
+		const done = () => resolve(window.changes > 0);
+		window.changes > 0 ? done() : setTimeout(done, 1000);
+	})`))
+	assert.True(t, tb.asGojaBool(changed), "should fire the orientation change event")
+
+	assert.Panics(t, func() { p.SetOrientation("sideways") })
+}
+
+func TestPageEmulateMediaSwitch(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+
+	matches := func(query string) bool {
+		v := p.Evaluate(tb.toGojaValue(`q => matchMedia(q).matches`), tb.toGojaValue(query))
+		return tb.asGojaValue(v).ToBoolean()
+	}
+
+	p.EmulateMedia(tb.toGojaValue(map[string]interface{}{
+		"colorScheme":  "dark",
+		"forcedColors": "active",
+	}))
+	assert.True(t, matches("(prefers-color-scheme: dark)"))
+	assert.True(t, matches("(forced-colors: active)"))
+
+	p.EmulateMedia(tb.toGojaValue(map[string]interface{}{"colorScheme": "light"}))
+	assert.True(t, matches("(prefers-color-scheme: light)"))
+	assert.True(t, matches("(forced-colors: active)"), "should keep the omitted features")
+
+	p.EmulateMedia(tb.toGojaValue(map[string]interface{}{"forcedColors": nil}))
+	assert.True(t, matches("(forced-colors: none)"), "should reset to the system default")
+
+	assert.Panics(t, func() {
+		p.EmulateMedia(tb.toGojaValue(map[string]interface{}{"forcedColors": "sometimes"}))
+	})
+}
+
+func TestPageEmulateMediaFromContextOptions(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	bctx := tb.NewContext(tb.toGojaValue(map[string]interface{}{
+		"contrast":     "less",
+		"forcedColors": "active",
+	}))
+	t.Cleanup(bctx.Close)
+	p := bctx.NewPage()
+
+	for _, query := range []string{"(prefers-contrast: less)", "(forced-colors: active)"} {
+		v := p.Evaluate(tb.toGojaValue(`q => matchMedia(q).matches`), tb.toGojaValue(query))
+		assert.True(t, tb.asGojaValue(v).ToBoolean(), query)
+	}
+}
+
+func TestPageEmulateMediaPrint(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+	p.SetContent(`
+		<style>
+			.print-only { display: none; }
+			@media print {
+				.print-only { display: block; }
+				.screen-only { display: none; }
+			}
+		</style>
+		<div class="screen-only">screen</div>
+		<div class="print-only">print</div>
+	`, nil)
+	require.False(t, p.IsVisible(".print-only", nil))
+
+	p.EmulateMedia(tb.toGojaValue(map[string]interface{}{"media": "print"}))
+	assert.True(t, p.IsVisible(".print-only", nil), "should apply the print styles")
+	assert.False(t, p.IsVisible(".screen-only", nil))
+	// a hidden element can't be captured, so this also checks that the
+	// screenshots reflect the print styles.
+	assert.NotEmpty(t, p.Query(".print-only").Screenshot(nil).Bytes())
+
+	p.EmulateMedia(tb.toGojaValue(map[string]interface{}{"media": nil}))
+	assert.False(t, p.IsVisible(".print-only", nil), "should reset to the screen styles")
+}
+
+func TestPageAddStyleTag(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+	p.SetContent(`<div id="box" style="transition: opacity 1s">box</div>`, nil)
+
+	style := func(prop string) string {
+		v := p.Evaluate(tb.toGojaValue(`prop => getComputedStyle(document.getElementById('box'))[prop]`),
+			tb.toGojaValue(prop))
+		return tb.asGojaValue(v).String()
+	}
+
+	p.AddStyleTag(tb.toGojaValue(map[string]string{
+		"content": `* { transition: none !important; }`,
+	}))
+	assert.Equal(t, "0s", style("transitionDuration"))
+
+	path := filepath.Join(t.TempDir(), "style.css")
+	require.NoError(t, os.WriteFile(path, []byte(`#box { color: rgb(255, 0, 0); }`), 0o600))
+	p.AddStyleTag(tb.toGojaValue(map[string]string{"path": path}))
+	assert.Equal(t, "rgb(255, 0, 0)", style("color"))
+
+	assert.Panics(t, func() { p.AddStyleTag(nil) }, "should require url, path or content")
+}
+
+func TestPageAddScriptTag(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	tb.withHandler("/script.js", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/javascript")
+		_, _ = fmt.Fprint(w, `window.fromURL = 'url';`)
+	})
+	p := tb.NewPage(nil)
+	require.NotNil(t, p.Goto(tb.URL("/"), nil))
+
+	p.AddScriptTag(tb.toGojaValue(map[string]string{"content": `window.fromContent = 'content';`}))
+	p.AddScriptTag(tb.toGojaValue(map[string]string{"url": tb.URL("/script.js")}))
+
+	assert.Equal(t, "content", tb.asGojaValue(p.Evaluate(tb.toGojaValue(`() => window.fromContent`))).String())
+	assert.Equal(t, "url", tb.asGojaValue(p.Evaluate(tb.toGojaValue(`() => window.fromURL`))).String())
+
+	assert.Panics(t, func() {
+		p.AddScriptTag(tb.toGojaValue(map[string]string{"url": tb.URL("/missing.js")}))
+	}, "should throw when the script can't be loaded")
 }
 
 func TestPageContent(t *testing.T) {
@@ -106,6 +278,49 @@ func TestPageEvaluate(t *testing.T) {
 		assert.Equal(t, "test", gotVal.Export())
 	})
 
+	t.Run("ok/large_string", func(t *testing.T) {
+		t.Parallel()
+
+		tb := newTestBrowser(t)
+		p := tb.NewPage(nil)
+
+		// a canvas full of noise has a data URL of a few megabytes.
+		got := p.Evaluate(tb.toGojaValue(`() => {
+			const canvas = document.createElement('canvas');
+			canvas.width = canvas.height = 1000;
+			const ctx = canvas.getContext('2d');
+			const img = ctx.createImageData(1000, 1000);
+			for (let i = 0; i < img.data.length; i++) {
+				img.data[i] = Math.random() * 256;
+			}
+			ctx.putImageData(img, 0, 0);
+			window.dataURL = canvas.toDataURL();
+			return window.dataURL;
+		}`))
+		dataURL := tb.asGojaValue(got).String()
+
+		wantLen := p.Evaluate(tb.toGojaValue(`() => window.dataURL.length`))
+		wantEnd := p.Evaluate(tb.toGojaValue(`() => window.dataURL.slice(-64)`))
+		require.Greater(t, len(dataURL), 1<<20)
+		assert.Equal(t, tb.asGojaValue(wantLen).ToInteger(), int64(len(dataURL)))
+		assert.Equal(t, tb.asGojaValue(wantEnd).String(), dataURL[len(dataURL)-64:])
+		assert.True(t, strings.HasPrefix(dataURL, "data:image/png;base64,"))
+	})
+
+	t.Run("ok/bigint", func(t *testing.T) {
+		t.Parallel()
+
+		tb := newTestBrowser(t)
+		p := tb.NewPage(nil)
+
+		// the ID is above Number.MAX_SAFE_INTEGER, so it would lose precision as a number.
+		got := p.Evaluate(tb.toGojaValue(`() => 9007199254740993n`))
+		assert.Equal(t, "9007199254740993", tb.asGojaValue(got).Export())
+
+		got = p.Evaluate(tb.toGojaValue(`() => -(2n ** 64n)`))
+		assert.Equal(t, "-18446744073709551616", tb.asGojaValue(got).Export())
+	})
+
 	t.Run("err", func(t *testing.T) {
 		t.Parallel()
 
@@ -122,6 +337,18 @@ func TestPageEvaluate(t *testing.T) {
 				"evaluating JS: SyntaxError: Unexpected token ')'",
 			},
 			{"undef", "undef", "evaluating JS: ReferenceError: undef is not defined"},
+			{
+				"window", `() => window`,
+				"page function returned a non-serializable value of type object",
+			},
+			{
+				"function", `() => () => 1`,
+				"page function returned a non-serializable value of type function",
+			},
+			{
+				"node", `() => document.body`,
+				"page function returned a non-serializable value of type HTMLBodyElement",
+			},
 		}
 
 		for _, tc := range testCases {
@@ -155,6 +382,29 @@ func TestPageGoto(t *testing.T) {
 	assert.Equal(t, url, r.URL(), `expected URL to be %q, result of navigation was %q`, url, r.URL())
 }
 
+func TestPageGotoReferer(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	p := tb.NewPage(nil)
+
+	resp := p.Goto(tb.URL("/get"), tb.toGojaValue(struct {
+		Referer string `js:"referer"`
+	}{Referer: "http://example.com/"}))
+	require.NotNil(t, resp)
+	var body struct{ Headers map[string][]string }
+	require.NoError(t, json.Unmarshal(resp.Body().Bytes(), &body))
+	assert.Equal(t, []string{"http://example.com/"}, body.Headers["Referer"])
+
+	defer func() {
+		assertPanicErrorContains(t, recover(), "referer must be an absolute URL")
+	}()
+	p.Goto(tb.URL("/get"), tb.toGojaValue(struct {
+		Referer string `js:"referer"`
+	}{Referer: "example.com/from"}))
+	t.Error("did not panic")
+}
+
 func TestPageGotoDataURI(t *testing.T) {
 	p := newTestBrowser(t).NewPage(nil)
 
@@ -425,6 +675,65 @@ func TestPageScreenshotFullpage(t *testing.T) {
 	assert.Greater(t, b, uint32(128))
 }
 
+func TestPageScreenshotWaitForFonts(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	tb.withHandler("/font", func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+		w.WriteHeader(http.StatusNotFound)
+	})
+	p := tb.NewPage(nil)
+	p.SetContent(fmt.Sprintf(`
+		<style>
+			@font-face { font-family: slow; src: url(%q); }
+			body { font-family: slow; }
+		</style>
+		<body>text</body>
+	`, tb.URL("/font")), nil)
+
+	p.Screenshot(tb.toGojaValue(map[string]interface{}{"waitForFonts": true}))
+
+	status := p.Evaluate(tb.toGojaValue(`() => document.fonts.status`))
+	assert.Equal(t, "loaded", tb.asGojaValue(status).String())
+}
+
+func TestPageScreenshotDisableAnimations(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+	p.SetContent(`
+		<style>
+			@keyframes toblue { from { background: red; } to { background: blue; } }
+			body { margin: 0; }
+			div { width: 100px; height: 100px; animation: toblue 100s linear forwards; }
+		</style>
+		<div></div>
+	`, nil)
+
+	buf := p.Screenshot(tb.toGojaValue(map[string]interface{}{"disableAnimations": true}))
+
+	img, err := png.Decode(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	r, _, b, _ := img.At(50, 50).RGBA()
+	assert.Less(t, r, uint32(128), "animation should be at its end")
+	assert.Greater(t, b, uint32(128), "animation should be at its end")
+
+	n := p.Evaluate(tb.toGojaValue(
+		`() => document.querySelectorAll('style[data-k6-browser-disable-animations]').length`))
+	assert.Equal(t, int64(0), tb.asGojaValue(n).ToInteger(), "should remove the stylesheet")
+
+	// a zero timeout uses the default timeout instead of timing out.
+	assert.NotPanics(t, func() {
+		p.Query("div").Screenshot(tb.toGojaValue(map[string]interface{}{
+			"disableAnimations": true,
+			"waitForFonts":      true,
+			"timeout":           0,
+		}))
+	})
+}
+
 func TestPageTitle(t *testing.T) {
 	p := newTestBrowser(t).NewPage(nil)
 	p.SetContent(`<html><head><title>Some title</title></head></html>`, nil)
@@ -452,6 +761,121 @@ func TestPageSetExtraHTTPHeaders(t *testing.T) {
 	assert.Equal(t, "Some-Value", h[0])
 }
 
+func TestPageGotoResponseBody(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	tb.withHandler("/json", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"name":"xk6-browser","tags":["a","b"]}`)
+	})
+	p := tb.NewPage(nil)
+
+	resp := p.Goto(tb.URL("/json"), nil)
+	require.NotNil(t, resp)
+
+	want := `{"name":"xk6-browser","tags":["a","b"]}`
+	assert.Equal(t, want, string(resp.Body().Bytes()))
+	assert.Equal(t, want, resp.Text())
+
+	body, ok := resp.JSON().Export().(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "xk6-browser", body["name"])
+	assert.Equal(t, []interface{}{"a", "b"}, body["tags"])
+}
+
+func TestPageGotoRedirect(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	tb.withHandler("/login", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/home", http.StatusFound)
+	})
+	tb.withHandler("/home", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, "home")
+	})
+	tb.withHandler("/no-content", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	p := tb.NewPage(nil)
+
+	resp := p.Goto(tb.URL("/login"), nil)
+	require.NotNil(t, resp)
+	assert.Equal(t, tb.URL("/home"), resp.URL())
+	assert.Equal(t, int64(http.StatusOK), resp.Status())
+	assert.Equal(t, "home", p.InnerText("body", nil))
+
+	// an aborted navigation without a following navigation still fails.
+	assert.Panics(t, func() { p.Goto(tb.URL("/no-content"), nil) })
+
+	// a link clicked while the navigation is pending aborts it with
+	// net::ERR_ABORTED, and Goto resolves with the navigation of the link.
+	cp, ok := p.(*common.Page)
+	require.True(t, ok)
+	tb.withHandler("/link", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `<a href="/home" style="position: fixed; inset: 0">home</a>`)
+	})
+	tb.withHandler("/slow", func(w http.ResponseWriter, r *http.Request) {
+		cp.Mouse.Click(10, 10, nil)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+		_, _ = fmt.Fprint(w, "slow")
+	})
+	require.NotNil(t, p.Goto(tb.URL("/link"), nil))
+	resp = p.Goto(tb.URL("/slow"), nil)
+	require.NotNil(t, resp)
+	assert.Equal(t, tb.URL("/home"), resp.URL())
+	assert.Equal(t, "home", p.InnerText("body", nil))
+}
+
+func TestPageGotoRedirectChainAndTiming(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	tb.withHandler("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/callback", http.StatusFound)
+	})
+	tb.withHandler("/callback", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/home", http.StatusSeeOther)
+	})
+	tb.withHandler("/home", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, "home")
+	})
+	p := tb.NewPage(nil)
+
+	before := time.Now()
+	resp := p.Goto(tb.URL("/authorize"), nil)
+	after := time.Now()
+	require.NotNil(t, resp)
+
+	assert.Equal(t, []string{
+		tb.URL("/authorize"),
+		tb.URL("/callback"),
+		tb.URL("/home"),
+	}, resp.RedirectChain())
+
+	req := resp.Request()
+	require.NotNil(t, req.RedirectedFrom())
+	assert.Equal(t, tb.URL("/callback"), req.RedirectedFrom().URL())
+	assert.Nil(t, req.RedirectedTo())
+	first := req.RedirectedFrom().RedirectedFrom()
+	require.NotNil(t, first)
+	assert.Equal(t, tb.URL("/authorize"), first.URL())
+	assert.Nil(t, first.RedirectedFrom())
+	assert.Equal(t, tb.URL("/callback"), first.RedirectedTo().URL())
+
+	timing, ok := resp.NavigationTiming().Export().(*api.NavigationTiming)
+	require.True(t, ok)
+	assert.GreaterOrEqual(t, timing.StartTime, float64(before.UnixNano())/float64(time.Millisecond))
+	assert.LessOrEqual(t, timing.EndTime, float64(after.UnixNano())/float64(time.Millisecond))
+	assert.InDelta(t, timing.EndTime-timing.StartTime, timing.Duration, 1)
+
+	// responses that aren't the result of a navigation have no timing.
+	assert.True(t, goja.IsNull(first.Response().NavigationTiming()))
+}
+
 func TestPageWaitForFunction(t *testing.T) {
 	t.Parallel()
 
@@ -641,6 +1065,92 @@ func TestPageWaitForFunction(t *testing.T) {
 		assert.Contains(t, log, "ok: Hello")
 	})
 
+	t.Run("ok_func_poll_interval_cadence", func(t *testing.T) {
+		t.Parallel()
+
+		tb := newTestBrowser(t)
+		p := tb.NewPage(nil)
+		require.NoError(t, tb.runtime().Set("page", p))
+		var log []string
+		require.NoError(t, tb.runtime().Set("log", func(s string) { log = append(log, s) }))
+
+		// the predicate becomes true on its fifth call, after four intervals.
+		_, err := tb.runtime().RunString(`fn = () => {
+			window._calls = (window._calls || 0) + 1;
+			if (window._calls === 1) window._start = Date.now();
+			window._elapsed = Date.now() - window._start;
+			return window._calls >= 5;
+		}`)
+		require.NoError(t, err)
+
+		err = tb.vu.Loop.Start(func() error {
+			if _, err := tb.runtime().RunString(fmt.Sprintf(script, "fn",
+				"{ polling: 'interval', interval: 100, timeout: 5000 }", "null")); err != nil {
+				return fmt.Errorf("%w", err)
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"ok: null"}, log)
+		calls := p.Evaluate(tb.toGojaValue(`() => window._calls`))
+		assert.Equal(t, int64(5), tb.asGojaValue(calls).ToInteger())
+		elapsed := p.Evaluate(tb.toGojaValue(`() => window._elapsed`))
+		assert.GreaterOrEqual(t, tb.asGojaValue(elapsed).ToInteger(), int64(400), "should wait for the interval between calls")
+	})
+
+	t.Run("err_func_poll_interval_timeout", func(t *testing.T) {
+		t.Parallel()
+
+		tb := newTestBrowser(t)
+		p := tb.NewPage(nil)
+		require.NoError(t, tb.runtime().Set("page", p))
+		var log []string
+		require.NoError(t, tb.runtime().Set("log", func(s string) { log = append(log, s) }))
+
+		_, err := tb.runtime().RunString(`fn = () => {
+			window._calls = (window._calls || 0) + 1;
+			return false;
+		}`)
+		require.NoError(t, err)
+
+		err = tb.vu.Loop.Start(func() error {
+			if _, err := tb.runtime().RunString(fmt.Sprintf(script, "fn",
+				"{ polling: 200, timeout: 500 }", "null")); err != nil {
+				return fmt.Errorf("%w", err)
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		require.Len(t, log, 1)
+		assert.Contains(t, log[0], "timed out after 500ms")
+		// the predicate is called right away and then every 200ms.
+		calls := tb.asGojaValue(p.Evaluate(tb.toGojaValue(`() => window._calls`))).ToInteger()
+		assert.GreaterOrEqual(t, calls, int64(2))
+		assert.LessOrEqual(t, calls, int64(4))
+	})
+
+	t.Run("err_func_timeout_last_value", func(t *testing.T) {
+		t.Parallel()
+
+		tb := newTestBrowser(t)
+		p := tb.NewPage(nil)
+		require.NoError(t, tb.runtime().Set("page", p))
+		var log []string
+		require.NoError(t, tb.runtime().Set("log", func(s string) { log = append(log, s) }))
+
+		err := tb.vu.Loop.Start(func() error {
+			if _, err := tb.runtime().RunString(fmt.Sprintf(script,
+				"() => document.querySelectorAll('li').length",
+				"{ polling: 100, timeout: 300 }", "null")); err != nil {
+				return fmt.Errorf("%w", err)
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		require.Len(t, log, 1)
+		assert.Contains(t, log[0], "timed out after 300ms, the predicate last returned: 0")
+	})
+
 	t.Run("ok_func_poll_mutation", func(t *testing.T) {
 		t.Parallel()
 
@@ -675,44 +1185,1038 @@ func TestPageWaitForFunction(t *testing.T) {
 	})
 }
 
-func TestPageWaitForLoadState(t *testing.T) {
+func TestPageRoute(t *testing.T) {
 	t.Parallel()
 
-	// TODO: Add happy path tests once WaitForLoadState is not racy.
+	tb := newTestBrowser(t, withHTTPServer())
+	tb.withHandler("/page", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, "original")
+	})
+	p := tb.NewPage(nil)
 
-	t.Run("err_wrong_event", func(t *testing.T) {
-		t.Parallel()
+	jsFunc := func(src string) goja.Value {
+		v, err := tb.runtime().RunString(src)
+		require.NoError(t, err)
+		return v
+	}
 
-		defer func() {
-			assertPanicErrorContains(t, recover(),
-				`invalid lifecycle event: "none"; `+
-					`must be one of: load, domcontentloaded, networkidle`)
-		}()
+	t.Run("fulfill", func(t *testing.T) {
+		p.Route(tb.toGojaValue("**/page"), jsFunc(`(route, request) => route.fulfill({
+			status: 200, contentType: 'text/html', body: '<p>' + request.method() + ' mocked</p>',
+		})`))
+		require.NotNil(t, p.Goto(tb.URL("/page"), nil))
+		assert.Equal(t, "GET mocked", p.InnerText("p", nil))
+		p.Unroute(tb.toGojaValue("**/page"), nil)
+	})
 
-		p := newTestBrowser(t).NewPage(nil)
-		p.WaitForLoadState("none", nil)
-		t.Error("did not panic")
+	t.Run("continue", func(t *testing.T) {
+		p.Route(jsFunc(`/\/page$/`), jsFunc(`route => route.continue()`))
+		require.NotNil(t, p.Goto(tb.URL("/page"), nil))
+		assert.Equal(t, "original", p.InnerText("body", nil))
+		p.Unroute(jsFunc(`/\/page$/`), nil)
 	})
-}
 
-// See: The issue #187 for details.
-func TestPageWaitForNavigationShouldNotPanic(t *testing.T) {
-	ctx, cancel := context.WithCancel(context.Background())
-	p := newTestBrowser(t, withContext(ctx)).NewPage(nil)
-	go cancel()
-	<-ctx.Done()
-	require.NotPanics(t, func() { p.WaitForNavigation(nil) })
+	t.Run("abort", func(t *testing.T) {
+		p.Route(tb.toGojaValue("**/page"), jsFunc(`route => route.abort('connectionrefused')`))
+		assert.Panics(t, func() { p.Goto(tb.URL("/page"), nil) })
+		p.Unroute(tb.toGojaValue("**/page"), nil)
+	})
+
+	t.Run("unroute", func(t *testing.T) {
+		require.NotNil(t, p.Goto(tb.URL("/page"), nil))
+		assert.Equal(t, "original", p.InnerText("body", nil))
+	})
 }
 
-func assertPanicErrorContains(t *testing.T, err interface{}, expErrMsg string) {
-	t.Helper()
+func TestPageRouteDuringBlockingCall(t *testing.T) {
+	t.Parallel()
 
-	require.NotNil(t, err)
-	require.IsType(t, &goja.Object{}, err)
-	gotObj, _ := err.(*goja.Object)
-	got := gotObj.Export()
-	expErr := errors.New(expErrMsg)
-	gotErr, ok := got.(error)
+	tb := newTestBrowser(t, withHTTPServer())
+	p := tb.NewPage(nil)
+
+	handler, err := tb.runtime().RunString(`route => route.fulfill({ body: 'mocked' })`)
+	require.NoError(t, err)
+	err = tb.vu.Loop.Start(func() error {
+		p.Route(tb.toGojaValue("**/api"), handler)
+		p.SetContent(`<button onclick="
+			fetch('/api').then(r => r.text()).then(t => document.body.insertAdjacentHTML('beforeend', '<p>' + t + '</p>'))
+		">fetch</button>`, nil)
+		p.Click("button", nil)
+		// the route handler can't be called until the script waits
+		// for a navigation or is idle.
+		assert.Panics(t, func() {
+			p.WaitForSelector("p", tb.toGojaValue(map[string]interface{}{"timeout": 500}))
+		})
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.NotNil(t, p.WaitForSelector("p", nil))
+	assert.Equal(t, "mocked", p.InnerText("p", nil))
+}
+
+func TestPageOnRequestResponse(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	tb.withHandler("/page", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `<iframe src="/frame"></iframe><script>fetch('/api', {method: 'POST', body: 'data'})</script>`)
+	})
+	tb.withHandler("/frame", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, "frame")
+	})
+	tb.withHandler("/api", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-Api", "1")
+		_, _ = fmt.Fprint(w, "ok")
+	})
+	p := tb.NewPage(nil)
+
+	var (
+		requests  []string
+		responses []string
+	)
+	require.NoError(t, tb.runtime().Set("logRequest", func(s string) { requests = append(requests, s) }))
+	require.NoError(t, tb.runtime().Set("logResponse", func(s string) { responses = append(responses, s) }))
+	handlers, err := tb.runtime().RunString(`[
+		req => logRequest(req.method() + ' ' + new URL(req.url()).pathname + ' ' + req.resourceType() + ' ' + (req.postData() || '')),
+		res => logResponse(res.status() + ' ' + new URL(res.url()).pathname + ' ' + (res.headers()['x-api'] || '')),
+	]`)
+	require.NoError(t, err)
+	fns := handlers.ToObject(tb.runtime())
+	onRequest := fns.Get("0")
+	onResponse := fns.Get("1")
+	err = tb.vu.Loop.Start(func() error {
+		p.On("request", onRequest)
+		p.On("response", onResponse)
+		p.Goto(tb.URL("/page"), tb.toGojaValue(map[string]string{"waitUntil": "networkidle"}))
+		// the handlers of the events that arrived after the
+		// navigation are called once the page is closed.
+		p.Close(nil)
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, requests, "GET /page Document ")
+	assert.Contains(t, requests, "GET /frame Document ")
+	assert.Contains(t, requests, "POST /api Fetch data")
+	assert.Contains(t, responses, "200 /page ")
+	assert.Contains(t, responses, "200 /frame ")
+	assert.Contains(t, responses, "200 /api 1")
+
+	assert.Panics(t, func() { p.On("unknown", onRequest) })
+}
+
+func TestPageOnWithoutClose(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	tb.withHandler("/page", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, "page")
+	})
+	p := tb.NewPage(nil)
+
+	var requests, removed int
+	require.NoError(t, tb.runtime().Set("countRequest", func() { requests++ }))
+	require.NoError(t, tb.runtime().Set("countRemoved", func() { removed++ }))
+	onRequest, err := tb.runtime().RunString(`() => countRequest()`)
+	require.NoError(t, err)
+	onRemoved, err := tb.runtime().RunString(`() => countRemoved()`)
+	require.NoError(t, err)
+
+	done := make(chan error)
+	go func() {
+		done <- tb.vu.Loop.Start(func() error {
+			p.On("request", onRequest)
+			p.On("request", onRemoved)
+			p.Off("request", onRemoved)
+			p.Goto(tb.URL("/page"), nil)
+			// the page is not closed, the iteration should
+			// end once the event loop is idle.
+			return nil
+		})
+	}()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("event loop didn't end while the page was open")
+	}
+	assert.NotZero(t, requests)
+	assert.Zero(t, removed)
+
+	// the loop ends right away once all the handlers are removed.
+	err = tb.vu.Loop.Start(func() error {
+		p.Off("request", nil)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestPageOnLongTask(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	tb.withHandler("/page", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `<script>
+			setTimeout(() => {
+				const end = performance.now() + 150;
+				while (performance.now() < end) {}
+			}, 0);
+		</script>`)
+	})
+	p := tb.NewPage(nil)
+
+	var durations []float64
+	require.NoError(t, tb.runtime().Set("logLongTask", func(d float64) { durations = append(durations, d) }))
+	onLongTask, err := tb.runtime().RunString(`task => logLongTask(task.duration)`)
+	require.NoError(t, err)
+	err = tb.vu.Loop.Start(func() error {
+		p.On("longtask", onLongTask)
+		p.Goto(tb.URL("/page"), nil)
+		p.WaitForTimeout(500)
+		p.Close(nil)
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.NotEmpty(t, durations)
+	assert.GreaterOrEqual(t, durations[0], float64(100))
+}
+
+func TestPageOnConsole(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+
+	var msgs []string
+	require.NoError(t, tb.runtime().Set("logMessage", func(m string) { msgs = append(msgs, m) }))
+	onConsole, err := tb.runtime().RunString(`msg => logMessage(
+		msg.type() + '|' + msg.text() + '|' + msg.args()[1].jsonValue() + '|' + msg.location().lineNumber
+	)`)
+	require.NoError(t, err)
+	err = tb.vu.Loop.Start(func() error {
+		p.On("console", onConsole)
+		p.Evaluate(tb.toGojaValue(`() => {
+			console.warn('hello', 42);
+		}`))
+		p.WaitForTimeout(100)
+		p.Close(nil)
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "warning|hello 42|42|1", msgs[0])
+}
+
+func TestPageOnPageError(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+
+	var errs []string
+	require.NoError(t, tb.runtime().Set("logError", func(m string) { errs = append(errs, m) }))
+	onPageError, err := tb.runtime().RunString(`err => logError(
+		err.name + '|' + err.message + '|' + err.stack.includes('throwLater')
+	)`)
+	require.NoError(t, err)
+	err = tb.vu.Loop.Start(func() error {
+		p.On("pageerror", onPageError)
+		p.Evaluate(tb.toGojaValue(`() => {
+			setTimeout(function throwLater() { throw new TypeError('bad input'); }, 0);
+		}`))
+		p.WaitForTimeout(100)
+		p.Close(nil)
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.Len(t, errs, 1)
+	assert.Equal(t, "TypeError|bad input|true", errs[0])
+}
+
+func TestPageSelectorEngines(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+	p.SetContent(`
+		<form>
+			<p>Fill in the form and <b>Submit</b> it</p>
+			<button id="submit">  Submit  </button>
+			<button id="cancel" onclick="window.clicked = this.id">Cancel</button>
+		</form>
+	`, nil)
+
+	opts := tb.toGojaValue(map[string]interface{}{"timeout": 1000})
+	for _, sel := range []string{
+		"text=submit", `text="Submit"`, "xpath=//button[1]", "id=submit", "form >> text=Submit",
+	} {
+		el := p.WaitForSelector(sel, opts)
+		require.NotNil(t, el, sel)
+		assert.Contains(t, el.TextContent(), "Submit", sel)
+	}
+	// only the innermost elements match the text.
+	assert.Equal(t, "P", tb.asGojaValue(p.Query("text=Submit it").Evaluate(
+		tb.toGojaValue(`el => el.nodeName`))).String())
+	assert.Len(t, p.QueryAll("text=Submit"), 2)
+
+	p.Click(`text="Cancel"`, nil)
+	clicked := p.Evaluate(tb.toGojaValue(`() => window.clicked`))
+	assert.Equal(t, "cancel", tb.asGojaValue(clicked).String())
+}
+
+func TestPageSetInputFiles(t *testing.T) {
+	t.Parallel()
+
+	const content = `
+		<input id="single" type="file" onchange="window.changed = true">
+		<input id="text" type="text">
+	`
+	file := map[string]interface{}{
+		"name":     "data.csv",
+		"mimeType": "text/csv",
+		"buffer":   base64.StdEncoding.EncodeToString([]byte("a,b\n1,2\n")),
+	}
+
+	t.Run("buffer", func(t *testing.T) {
+		t.Parallel()
+
+		tb := newTestBrowser(t)
+		p := tb.NewPage(nil)
+		p.SetContent(content, nil)
+		p.SetInputFiles("#single", tb.toGojaValue(file), nil)
+
+		got := p.Evaluate(tb.toGojaValue(`() => {
+			const f = document.getElementById('single').files[0];
+			return [f.name, f.type, f.size, window.changed].join(' ');
+		}`))
+		assert.Equal(t, "data.csv text/csv 8 true", tb.asGojaValue(got).String())
+	})
+	t.Run("path", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "notes.txt")
+		require.NoError(t, os.WriteFile(path, []byte("hello"), 0o600))
+
+		tb := newTestBrowser(t)
+		p := tb.NewPage(nil)
+		p.SetContent(content, nil)
+		p.SetInputFiles("#single", tb.toGojaValue(path), nil)
+
+		got := p.Evaluate(tb.toGojaValue(`async () => {
+			const f = document.getElementById('single').files[0];
+			return [f.name, f.type.split(';')[0], await f.text()].join(' ');
+		}`))
+		assert.Equal(t, "notes.txt text/plain hello", tb.asGojaValue(got).String())
+	})
+	t.Run("array_buffer", func(t *testing.T) {
+		t.Parallel()
+
+		tb := newTestBrowser(t)
+		p := tb.NewPage(nil)
+		p.SetContent(content, nil)
+		p.SetInputFiles("#single", tb.toGojaValue(map[string]interface{}{
+			"name":     "data.bin",
+			"mimeType": "application/octet-stream",
+			"buffer":   tb.runtime().NewArrayBuffer([]byte{0, 1, 2, 255}),
+		}), nil)
+
+		got := p.Evaluate(tb.toGojaValue(`async () => {
+			const f = document.getElementById('single').files[0];
+			const bytes = new Uint8Array(await f.arrayBuffer());
+			return [f.name, f.type, bytes.join(',')].join(' ');
+		}`))
+		assert.Equal(t, "data.bin application/octet-stream 0,1,2,255", tb.asGojaValue(got).String())
+	})
+	t.Run("err_multiple_files", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			assertPanicErrorContains(t, recover(), "non-multiple file input can only accept single file")
+		}()
+
+		tb := newTestBrowser(t)
+		p := tb.NewPage(nil)
+		p.SetContent(content, nil)
+		p.SetInputFiles("#single", tb.toGojaValue([]interface{}{file, file}), nil)
+	})
+	t.Run("err_not_file_input", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			assertPanicErrorContains(t, recover(), "node is not an input[type=file] element")
+		}()
+
+		tb := newTestBrowser(t)
+		p := tb.NewPage(nil)
+		p.SetContent(content, nil)
+		p.SetInputFiles("#text", tb.toGojaValue(file), nil)
+	})
+}
+
+func TestPageStrictMode(t *testing.T) {
+	t.Parallel()
+
+	const content = `
+		<button onclick="window.clicked = 'first'">a</button>
+		<button onclick="window.clicked = 'second'">b</button>
+		<button onclick="window.clicked = 'third'">c</button>
+	`
+
+	t.Run("non_strict", func(t *testing.T) {
+		t.Parallel()
+
+		tb := newTestBrowser(t)
+		p := tb.NewPage(nil)
+		p.SetContent(content, nil)
+		p.Click("button", nil)
+
+		clicked := p.Evaluate(tb.toGojaValue(`() => window.clicked`))
+		assert.Equal(t, "first", tb.asGojaValue(clicked).String())
+	})
+	t.Run("strict", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			assertPanicErrorContains(t, recover(), `strict mode violation: "button" resolved to 3 elements`)
+		}()
+
+		tb := newTestBrowser(t)
+		p := tb.NewPage(nil)
+		p.SetContent(content, nil)
+		p.Click("button", tb.toGojaValue(map[string]interface{}{"strict": true}))
+	})
+}
+
+func TestPageWaitForSelector(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+	p.SetContent(`<div id="root"></div>`, nil)
+	p.Evaluate(tb.toGojaValue(`() => setTimeout(() => {
+		const div = document.createElement('div');
+		div.id = 'late';
+		div.textContent = 'appeared';
+		document.getElementById('root').appendChild(div);
+	}, 100)`))
+
+	el := p.WaitForSelector("#late", tb.toGojaValue(struct {
+		Timeout int64 `js:"timeout"`
+	}{Timeout: 1000}))
+	require.NotNil(t, el, "expected element to have been found after wait")
+	// the handle must be usable in the main world like one from frame.waitForSelector.
+	v := p.Evaluate(tb.toGojaValue(`el => el.textContent`), tb.toGojaValue(el))
+	assert.Equal(t, "appeared", tb.asGojaValue(v).String())
+}
+
+func TestPageWaitForLoadState(t *testing.T) {
+	t.Parallel()
+
+	// TODO: Add happy path tests once WaitForLoadState is not racy.
+
+	t.Run("err_wrong_event", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			assertPanicErrorContains(t, recover(),
+				`invalid lifecycle event: "none"; `+
+					`must be one of: load, domcontentloaded, networkidle`)
+		}()
+
+		p := newTestBrowser(t).NewPage(nil)
+		p.WaitForLoadState("none", nil)
+		t.Error("did not panic")
+	})
+}
+
+// See: The issue #187 for details.
+func TestPageWaitForNavigationShouldNotPanic(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := newTestBrowser(t, withContext(ctx)).NewPage(nil)
+	go cancel()
+	<-ctx.Done()
+	require.NotPanics(t, func() { p.WaitForNavigation(nil) })
+}
+
+func assertPanicErrorContains(t *testing.T, err interface{}, expErrMsg string) {
+	t.Helper()
+
+	require.NotNil(t, err)
+	require.IsType(t, &goja.Object{}, err)
+	gotObj, _ := err.(*goja.Object)
+	got := gotObj.Export()
+	expErr := errors.New(expErrMsg)
+	gotErr, ok := got.(error)
 	require.True(t, ok)
 	assert.Contains(t, gotErr.Error(), expErr.Error())
 }
+
+func TestPageDOMSnapshot(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+	p.SetContent(`<html><head></head><body><div id="a" class="b">hello <!-- comment --><i>world</i></div></body></html>`, nil)
+
+	want := &api.DOMSnapshotNode{
+		Tag: "html",
+		Children: []*api.DOMSnapshotNode{
+			{Tag: "head"},
+			{
+				Tag: "body",
+				Children: []*api.DOMSnapshotNode{
+					{
+						Tag:        "div",
+						Attributes: map[string]string{"id": "a", "class": "b"},
+						Children: []*api.DOMSnapshotNode{
+							{Text: "hello"},
+							{Tag: "i", Children: []*api.DOMSnapshotNode{{Text: "world"}}},
+						},
+					},
+				},
+			},
+		},
+	}
+	assert.Equal(t, want, p.DOMSnapshot())
+}
+
+func TestPageSetGeolocation(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withFileServer())
+	p := tb.NewPage(nil)
+	require.NotNil(t, p.Goto(tb.staticURL("empty.html"), nil))
+
+	p.SetGeolocation(
+		tb.toGojaValue(map[string]float64{"latitude": 10, "longitude": 20}),
+		tb.toGojaValue(map[string]bool{"grantPermission": true}),
+	)
+	v := p.Evaluate(tb.toGojaValue(`() => new Promise((resolve, reject) => {
+		navigator.geolocation.getCurrentPosition(
+			pos => resolve({latitude: pos.coords.latitude, longitude: pos.coords.longitude}),
+			err => reject(err.message),
+		);
+	})`))
+	pos, ok := tb.asGojaValue(v).Export().(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, map[string]interface{}{"latitude": float64(10), "longitude": float64(20)}, pos)
+}
+
+func TestPageLargestContentfulPaintMetric(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	tb.withHandler("/lcp", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `<h1>The largest contentful paint</h1>`)
+	})
+	tb.withHandler("/blank", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `<html><body></body></html>`)
+	})
+	p := tb.NewPage(nil)
+
+	require.NotNil(t, p.Goto(tb.URL("/lcp"), tb.toGojaValue(map[string]string{"waitUntil": "load"})))
+	p.WaitForTimeout(200)
+	// the LCP is final once the page leaves the document.
+	require.NotNil(t, p.Goto(tb.URL("/blank"), tb.toGojaValue(map[string]string{"waitUntil": "load"})))
+	p.WaitForTimeout(200)
+	p.Close(nil)
+
+	var urls []string
+	for _, s := range tb.samples() {
+		if s.Metric.Name != "browser_largest_contentful_paint" {
+			continue
+		}
+		assert.Greater(t, s.Value, float64(0))
+		url, _ := s.Tags.Get("url")
+		urls = append(urls, url)
+	}
+	assert.Equal(t, []string{tb.URL("/lcp")}, urls,
+		"should emit once for the page with contentful paint, and not for the blank page")
+}
+
+func TestPageCumulativeLayoutShiftMetric(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	tb.withHandler("/cls", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `
+			<p>Some text that shifts down</p>
+			<img src="/slow">
+			<script>
+				setTimeout(() => {
+					const div = document.createElement('div');
+					div.style.height = '200px';
+					document.body.prepend(div);
+				}, 100);
+			</script>
+		`)
+	})
+	tb.withHandler("/slow", func(w http.ResponseWriter, _ *http.Request) {
+		// delays the load event until after the layout shift.
+		time.Sleep(500 * time.Millisecond)
+		w.WriteHeader(http.StatusNotFound)
+	})
+	p := tb.NewPage(nil)
+
+	require.NotNil(t, p.Goto(tb.URL("/cls"), tb.toGojaValue(map[string]string{"waitUntil": "load"})))
+	// a route change within the document doesn't report the CLS again.
+	p.Evaluate(tb.toGojaValue(`() => history.pushState({}, "", "/route")`))
+	p.WaitForTimeout(200)
+
+	var (
+		values []float64
+		urls   []string
+	)
+	for _, s := range tb.samples() {
+		if s.Metric.Name != "browser_cumulative_layout_shift" {
+			continue
+		}
+		values = append(values, s.Value)
+		url, _ := s.Tags.Get("url")
+		urls = append(urls, url)
+	}
+	assert.Equal(t, []string{tb.URL("/cls")}, urls)
+	require.Len(t, values, 1)
+	assert.Greater(t, values[0], float64(0))
+}
+
+func TestPageTimeToFirstByteMetric(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	tb.withHandler("/slow", func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		_, _ = fmt.Fprint(w, `<html><body>slow</body></html>`)
+	})
+	p := tb.NewPage(nil)
+
+	require.NotNil(t, p.Goto(tb.URL("/slow"), tb.toGojaValue(map[string]string{"waitUntil": "load"})))
+	// navigations without a request have no TTFB.
+	p.SetContent(`<html><body>no request</body></html>`, nil)
+
+	var (
+		values []float64
+		urls   []string
+	)
+	for _, s := range tb.samples() {
+		if s.Metric.Name != "browser_time_to_first_byte" {
+			continue
+		}
+		values = append(values, s.Value)
+		url, _ := s.Tags.Get("url")
+		urls = append(urls, url)
+	}
+	assert.Equal(t, []string{tb.URL("/slow")}, urls)
+	require.Len(t, values, 1)
+	assert.GreaterOrEqual(t, values[0], float64(200))
+}
+
+func TestPageDocumentResponseEndMetric(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	tb.withHandler("/doc", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `<html><body>`)
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+		time.Sleep(200 * time.Millisecond)
+		_, _ = fmt.Fprint(w, `</body></html>`)
+	})
+	p := tb.NewPage(nil)
+	require.NotNil(t, p.Goto(tb.URL("/doc"), tb.toGojaValue(map[string]string{"waitUntil": "load"})))
+	p.WaitForTimeout(100)
+
+	values := map[string][]float64{}
+	for _, s := range tb.samples() {
+		if url, _ := s.Tags.Get("url"); url != tb.URL("/doc") {
+			continue
+		}
+		values[s.Metric.Name] = append(values[s.Metric.Name], s.Value)
+	}
+	require.Len(t, values["browser_document_response_end"], 1)
+	require.Len(t, values["browser_loaded"], 1)
+	assert.Greater(t, values["browser_document_response_end"][0], float64(0),
+		"the document should be committed before its response ends")
+	assert.LessOrEqual(t, values["browser_document_response_end"][0], values["browser_loaded"][0],
+		"the document response should end before the page loads")
+}
+
+func TestPageOnWebSocket(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	tb.withHandler("/ws", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close() //nolint:errcheck
+		for {
+			typ, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(typ, msg); err != nil {
+				return
+			}
+		}
+	})
+	tb.withHandler("/page", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `<script>
+			const ws = new WebSocket("ws://" + location.host + "/ws");
+			let received = 0;
+			ws.onopen = () => {
+				ws.send("hello");
+				ws.send(new Uint8Array([1, 2, 3]));
+			};
+			ws.onmessage = () => {
+				if (++received == 2) {
+					ws.close();
+				}
+			};
+		</script>`)
+	})
+	p := tb.NewPage(nil)
+
+	var log []string
+	require.NoError(t, tb.runtime().Set("log", func(s string) { log = append(log, s) }))
+	onWebSocket, err := tb.runtime().RunString(`ws => {
+		log('open ' + new URL(ws.url()).pathname);
+		const payload = f => f.binary ? 'binary:' + new Uint8Array(f.payload).join(',') : f.payload;
+		ws.on('framesent', f => log('sent ' + payload(f)));
+		ws.on('framereceived', f => log('received ' + payload(f)));
+		ws.on('close', () => log('close ' + ws.isClosed()));
+	}`)
+	require.NoError(t, err)
+	err = tb.vu.Loop.Start(func() error {
+		p.On("websocket", onWebSocket)
+		p.Goto(tb.URL("/page"), nil)
+		p.WaitForTimeout(500)
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"open /ws",
+		"sent hello",
+		"sent binary:1,2,3",
+		"received hello",
+		"received binary:1,2,3",
+		"close true",
+	}, log)
+}
+
+func TestPageWaitForNetworkIdle(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	done := make(chan struct{})
+	t.Cleanup(func() { close(done) })
+	tb.withHandler("/hanging", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	})
+	tb.withHandler("/", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintf(w, `<script>fetch(%q)</script>`, tb.URL("/hanging"))
+	})
+	p := tb.NewPage(nil)
+	require.NotNil(t, p.Goto(tb.URL("/"), nil))
+
+	// the hanging request never finishes, so the page is never fully idle.
+	assert.Panics(t, func() {
+		p.WaitForNetworkIdle(tb.toGojaValue(map[string]interface{}{
+			"idleTime": 100,
+			"timeout":  500,
+		}))
+	})
+	assert.NotPanics(t, func() {
+		p.WaitForNetworkIdle(tb.toGojaValue(map[string]interface{}{
+			"idleTime":    100,
+			"maxInflight": 1,
+			"timeout":     1000,
+		}))
+	})
+}
+
+func TestPageOpener(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	tb.withHandler("/opener", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `<script>
+			window.received = "";
+			addEventListener("message", e => { window.received = e.data; });
+		</script>`)
+	})
+	tb.withHandler("/popup", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `<script>window.opener.postMessage("token", "*");</script>`)
+	})
+	bctx := tb.NewContext(nil)
+	t.Cleanup(bctx.Close)
+	p := bctx.NewPage()
+	require.NotNil(t, p.Goto(tb.URL("/opener"), nil))
+	assert.Nil(t, p.Opener(), "should not have an opener")
+
+	p.Evaluate(tb.toGojaValue(`url => { window.open(url); }`), tb.toGojaValue(tb.URL("/popup")))
+
+	var popup api.Page
+	for start := time.Now(); popup == nil && time.Since(start) < 5*time.Second; {
+		for _, pp := range bctx.Pages() {
+			if pp != p {
+				popup = pp
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	require.NotNil(t, popup, "should open the popup")
+	assert.Equal(t, p, popup.Opener())
+
+	var received string
+	for start := time.Now(); received == "" && time.Since(start) < 5*time.Second; {
+		received = tb.asGojaValue(p.Evaluate(tb.toGojaValue(`() => window.received`))).String()
+		time.Sleep(50 * time.Millisecond)
+	}
+	assert.Equal(t, "token", received, "the opener should receive the popup's message")
+}
+
+func TestPageClickAllowTargetClose(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	tb.withHandler("/popup", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `<button onclick="window.close()">close</button>`)
+	})
+	bctx := tb.NewContext(nil)
+	t.Cleanup(bctx.Close)
+	p := bctx.NewPage()
+	p.Evaluate(tb.toGojaValue(`url => { window.open(url); }`), tb.toGojaValue(tb.URL("/popup")))
+
+	var popup api.Page
+	for start := time.Now(); popup == nil && time.Since(start) < 5*time.Second; {
+		for _, pp := range bctx.Pages() {
+			if pp != p {
+				popup = pp
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	require.NotNil(t, popup, "should open the popup")
+	require.NotNil(t, popup.WaitForSelector("button", nil))
+
+	// the click closes the page, which the script expects.
+	assert.NotPanics(t, func() {
+		popup.Click("button", tb.toGojaValue(map[string]interface{}{"allowTargetClose": true}))
+	})
+}
+
+func TestPageWaitForEvent(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+
+	var log []string
+	require.NoError(t, tb.runtime().Set("log", func(s string) { log = append(log, s) }))
+	require.NoError(t, tb.runtime().Set("page", p))
+
+	err := tb.vu.Loop.Start(func() error {
+		_, err := tb.runtime().RunString(`
+			page.waitForEvent('console', msg => msg.text() === 'second').then(msg => {
+				log('predicate: ' + msg.text());
+			}, err => {
+				log('err: ' + err);
+			});
+			page.waitForEvent('console', { timeout: 5000 }).then(msg => {
+				log('any: ' + ['first', 'second'].includes(msg.text()));
+			}, err => {
+				log('err: ' + err);
+			});
+			page.waitForEvent('pageerror', { timeout: 300 }).then(() => {
+				log('should time out');
+			}, err => {
+				log('timed out: ' + String(err).includes('waiting for event "pageerror": timed out'));
+			});
+			page.evaluate(() => {
+				console.log('first');
+				console.log('second');
+			});`)
+		if err != nil {
+			return fmt.Errorf("%w", err)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"any: true",
+		"predicate: second",
+		"timed out: true",
+	}, log)
+
+	assert.Panics(t, func() { p.WaitForEvent("nonexistent", nil) })
+}
+
+func TestPageWorkers(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+	p.Evaluate(tb.toGojaValue(`() => {
+		const src = 'self.answer = 42; self.onmessage = () => self.close();';
+		window.worker = new Worker(URL.createObjectURL(new Blob([src])));
+	}`))
+
+	waitForWorkers := func(n int) []api.Worker {
+		var workers []api.Worker
+		for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(50 * time.Millisecond) {
+			if workers = p.Workers(); len(workers) == n {
+				break
+			}
+		}
+		require.Len(t, workers, n)
+		return workers
+	}
+	w := waitForWorkers(1)[0]
+
+	assert.True(t, strings.HasPrefix(w.URL(), "blob:"), "unexpected worker URL: %q", w.URL())
+	got := w.Evaluate(tb.toGojaValue(`n => self.answer + n`), tb.toGojaValue(1))
+	assert.Equal(t, int64(43), tb.asGojaValue(got).ToInteger())
+
+	h := w.EvaluateHandle(tb.toGojaValue(`() => ({ answer: self.answer })`))
+	require.NotNil(t, h)
+	assert.Nil(t, h.AsElement())
+	assert.Equal(t, int64(42), tb.asGojaValue(h.GetProperty("answer").JSONValue()).ToInteger())
+
+	// the worker closes itself, which detaches its target.
+	p.Evaluate(tb.toGojaValue(`() => window.worker.postMessage('close')`))
+	waitForWorkers(0)
+	assert.Panics(t, func() { w.Evaluate(tb.toGojaValue(`() => self.answer`)) })
+}
+
+func TestPageOnSecurity(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		// the insecure script is blocked before it's requested,
+		// so its host doesn't have to resolve.
+		_, _ = fmt.Fprint(w, `<script src="http://insecure.test/script.js"></script>`)
+	}))
+	t.Cleanup(srv.Close)
+
+	tb := newTestBrowser(t)
+	bctx := tb.NewContext(tb.toGojaValue(map[string]interface{}{"ignoreHTTPSErrors": true}))
+	p := bctx.NewPage()
+
+	var msgs []string
+	require.NoError(t, tb.runtime().Set("logMessage", func(m string) { msgs = append(msgs, m) }))
+	onSecurity, err := tb.runtime().RunString(`msg => logMessage(msg.level + '|' + msg.text)`)
+	require.NoError(t, err)
+	err = tb.vu.Loop.Start(func() error {
+		p.On("security", onSecurity)
+		p.Goto(srv.URL, nil)
+		p.WaitForTimeout(100)
+		p.Close(nil)
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.NotEmpty(t, msgs)
+	assert.Contains(t, msgs[0], "Mixed Content")
+	assert.Contains(t, msgs[0], "http://insecure.test/script.js")
+}
+
+func TestPageOnClose(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+
+	var events []string
+	require.NoError(t, tb.runtime().Set("logEvent", func(e string) { events = append(events, e) }))
+	onClose, err := tb.runtime().RunString(`p => logEvent('close|' + p.isClosed())`)
+	require.NoError(t, err)
+	err = tb.vu.Loop.Start(func() error {
+		p.On("close", onClose)
+		p.Close(nil)
+		// the handler must have run by the time close returns.
+		assert.Equal(t, []string{"close|true"}, events)
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"close|true"}, events, "the handler should run once")
+}
+
+func TestPageOnWorker(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+
+	var events []string
+	require.NoError(t, tb.runtime().Set("logEvent", func(e string) { events = append(events, e) }))
+	onWorker, err := tb.runtime().RunString(`w => logEvent('worker|' + w.url().startsWith('blob:'))`)
+	require.NoError(t, err)
+	onWorkerClose, err := tb.runtime().RunString(`w => logEvent('workerclose|' + w.url().startsWith('blob:'))`)
+	require.NoError(t, err)
+	err = tb.vu.Loop.Start(func() error {
+		p.On("worker", onWorker)
+		p.On("workerclose", onWorkerClose)
+		p.Evaluate(tb.toGojaValue(`() => {
+			const src = 'self.onmessage = () => self.close();';
+			window.worker = new Worker(URL.createObjectURL(new Blob([src])));
+		}`))
+		p.WaitForTimeout(500)
+		p.Evaluate(tb.toGojaValue(`() => window.worker.postMessage('close')`))
+		p.WaitForTimeout(500)
+		p.Close(nil)
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"worker|true", "workerclose|true"}, events)
+}
+
+func TestPageOnServiceWorker(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	tb.withHandler("/sw.js", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/javascript")
+		_, _ = fmt.Fprint(w, `self.answer = 42;`)
+	})
+	p := tb.NewPage(nil)
+	require.NotNil(t, p.Goto(tb.URL("/"), nil))
+
+	var urls []string
+	require.NoError(t, tb.runtime().Set("logWorker", func(url string) { urls = append(urls, url) }))
+	onWorker, err := tb.runtime().RunString(`w => logWorker(w.url())`)
+	require.NoError(t, err)
+	err = tb.vu.Loop.Start(func() error {
+		p.On("worker", onWorker)
+		p.Evaluate(tb.toGojaValue(`async () => {
+			await navigator.serviceWorker.register('/sw.js');
+			await navigator.serviceWorker.ready;
+		}`))
+		p.WaitForTimeout(500)
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []string{tb.URL("/sw.js")}, urls)
+	var sw api.Worker
+	for _, w := range p.Workers() {
+		if w.URL() == tb.URL("/sw.js") {
+			sw = w
+		}
+	}
+	require.NotNil(t, sw, "service worker should be reported by Workers")
+	got := sw.Evaluate(tb.toGojaValue(`() => self.answer`))
+	assert.Equal(t, int64(42), tb.asGojaValue(got).ToInteger())
+}
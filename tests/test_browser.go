@@ -249,6 +249,20 @@ func (b *testBrowser) asGojaBool(v interface{}) bool {
 	return gv.ToBoolean()
 }
 
+// samples returns the metric samples that the browser has pushed since the
+// last call.
+func (b *testBrowser) samples() []k6metrics.Sample {
+	var samples []k6metrics.Sample
+	for {
+		select {
+		case sc := <-b.vu.StateField.Samples:
+			samples = append(samples, sc.GetSamples()...)
+		default:
+			return samples
+		}
+	}
+}
+
 // launchOptions provides a way to customize browser type
 // launch options in tests.
 type launchOptions struct {